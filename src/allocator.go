@@ -0,0 +1,101 @@
+package main
+
+import "sort"
+
+// span is a half-open address range [start, end). 64-bit bounds let a block
+// end exactly at 2^32 without overflowing.
+type span struct {
+	start, end uint64
+}
+
+// allocator hands out aligned power-of-two blocks from a parent range using
+// first fit. With subnets requested largest first this packs blocks
+// contiguously from the start of the parent, leaving holes only where a
+// placement constraint rejected a candidate.
+type allocator struct {
+	parent span
+	used   []span // sorted by start, non-overlapping
+}
+
+func newAllocator(start uint32, prefix int) *allocator {
+	size := uint64(1) << (32 - prefix)
+	return &allocator{parent: span{uint64(start), uint64(start) + size}}
+}
+
+// allocate finds the first aligned block of size addresses for which accept
+// returns true and marks it used. accept may be nil.
+func (a *allocator) allocate(size uint64, accept func(start uint64) bool) (uint64, bool) {
+	candidate := alignUp(a.parent.start, size)
+	for candidate+size <= a.parent.end {
+		if blocker, ok := a.overlap(span{candidate, candidate + size}); ok {
+			candidate = alignUp(blocker.end, size)
+			continue
+		}
+		if accept != nil && !accept(candidate) {
+			candidate += size
+			continue
+		}
+		a.reserve(span{candidate, candidate + size})
+		return candidate, true
+	}
+	return 0, false
+}
+
+// reserve marks s as used.
+func (a *allocator) reserve(s span) {
+	i := sort.Search(len(a.used), func(i int) bool { return a.used[i].start >= s.start })
+	a.used = append(a.used, span{})
+	copy(a.used[i+1:], a.used[i:])
+	a.used[i] = s
+}
+
+// overlap returns a used span intersecting s, if any.
+func (a *allocator) overlap(s span) (span, bool) {
+	for _, u := range a.used {
+		if u.start < s.end && s.start < u.end {
+			return u, true
+		}
+		if u.start >= s.end {
+			break
+		}
+	}
+	return span{}, false
+}
+
+// free returns the unallocated gaps of the parent in ascending order.
+func (a *allocator) free() []span {
+	var gaps []span
+	cursor := a.parent.start
+	for _, u := range a.used {
+		if u.start > cursor {
+			gaps = append(gaps, span{cursor, u.start})
+		}
+		if u.end > cursor {
+			cursor = u.end
+		}
+	}
+	if cursor < a.parent.end {
+		gaps = append(gaps, span{cursor, a.parent.end})
+	}
+	return gaps
+}
+
+func alignUp(n, size uint64) uint64 {
+	return (n + size - 1) / size * size
+}
+
+// candidates returns the starts of up to n free aligned blocks of size
+// addresses in ascending order, without reserving them.
+func (a *allocator) candidates(size uint64, n int) []uint64 {
+	var starts []uint64
+	candidate := alignUp(a.parent.start, size)
+	for candidate+size <= a.parent.end && len(starts) < n {
+		if blocker, ok := a.overlap(span{candidate, candidate + size}); ok {
+			candidate = alignUp(blocker.end, size)
+			continue
+		}
+		starts = append(starts, candidate)
+		candidate += size
+	}
+	return starts
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Annotation is review metadata for one subnet, kept in a sidecar file so
+// the source config does not have to change when a plan is reviewed.
+type Annotation struct {
+	Note     string `json:"note,omitempty"`
+	Approval string `json:"approval,omitempty"`
+	Reviewer string `json:"reviewer,omitempty"`
+}
+
+// loadAnnotations reads a sidecar file mapping subnet names to annotations.
+func loadAnnotations(path string) (map[string]Annotation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading annotations file: %v", err)
+	}
+	var annotations map[string]Annotation
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("error parsing annotations file %s (expected {\"<subnet name>\": {\"note\": ..., \"approval\": ...}}): %v", path, err)
+	}
+	return annotations, nil
+}
+
+// staleAnnotations lists annotated subnet names that are not in the plan.
+func staleAnnotations(annotations map[string]Annotation, results []SubnetResult) []string {
+	planned := make(map[string]bool)
+	for _, r := range results {
+		planned[r.Name] = true
+	}
+	var stale []string
+	for name := range annotations {
+		if !planned[name] {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// writeMarkdownAnnotations appends a review notes section for the annotated
+// subnets, in plan order.
+func writeMarkdownAnnotations(sb *strings.Builder, results []SubnetResult, annotations map[string]Annotation) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if _, ok := annotations[r.Name]; ok && !seen[r.Name] {
+			seen[r.Name] = true
+			names = append(names, r.Name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	cell := func(s string) string { return strings.ReplaceAll(s, "|", "\\|") }
+	sb.WriteString("\n## Review Notes\n\n")
+	sb.WriteString("| Subnet | Note | Approval | Reviewer |\n")
+	sb.WriteString("|--------|------|----------|----------|\n")
+	for _, name := range names {
+		a := annotations[name]
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", cell(name), cell(a.Note), cell(a.Approval), cell(a.Reviewer)))
+	}
+}
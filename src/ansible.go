@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var ansibleInvalidGroupChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// ansibleGroupName converts a subnet name into a valid Ansible group name
+// (letters, digits and underscores, not starting with a digit).
+func ansibleGroupName(name string) string {
+	g := ansibleInvalidGroupChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_")
+	g = strings.Trim(g, "_")
+	if g == "" || (g[0] >= '0' && g[0] <= '9') {
+		g = "subnet_" + g
+	}
+	return g
+}
+
+// yamlString quotes s as a YAML double-quoted scalar.
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// ansibleGroup is one planned subnet rendered as an inventory group.
+type ansibleGroup struct {
+	name    string
+	network SubnetResult
+	gateway string
+	hosts   []SubnetResult
+}
+
+// ExportAnsible writes a YAML inventory with one group per subnet. Every
+// single-address assignment becomes a host with ansible_host set to its IP;
+// assignment names used in more than one subnet are qualified with the
+// subnet name so host names stay unique. Assignments with a generated FQDN
+// use it as their host name.
+func ExportAnsible(results []SubnetResult, path string) error {
+	var groups []*ansibleGroup
+	bySubnet := make(map[string]*ansibleGroup)
+	groupNames := make(map[string]int)
+	hostNames := make(map[string]int)
+	for _, r := range results {
+		if isFreeSpaceRow(r) || r.Category == "Summary" {
+			continue
+		}
+		key := r.Parent + "|" + r.Subnet + "|" + r.Name
+		g, ok := bySubnet[key]
+		if !ok {
+			name := ansibleGroupName(r.Name)
+			groupNames[name]++
+			if n := groupNames[name]; n > 1 {
+				name = fmt.Sprintf("%s_%d", name, n)
+			}
+			g = &ansibleGroup{name: name}
+			bySubnet[key] = g
+			groups = append(groups, g)
+		}
+		switch {
+		case r.Category == "Network":
+			g.network = r
+		case isAssignedCategory(r.Category) && !strings.Contains(r.IP, " - "):
+			g.hosts = append(g.hosts, r)
+			hostNames[r.Label]++
+			if strings.EqualFold(r.Label, "Gateway") {
+				g.gateway = r.IP
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Ansible inventory generated by IPSubnetPlanner\n")
+	sb.WriteString("all:\n")
+	sb.WriteString("  children:\n")
+	for _, g := range groups {
+		sb.WriteString(fmt.Sprintf("    %s:\n", g.name))
+		sb.WriteString("      vars:\n")
+		sb.WriteString(fmt.Sprintf("        subnet: %s\n", yamlString(g.network.Subnet)))
+		sb.WriteString(fmt.Sprintf("        prefix: %d\n", g.network.Prefix))
+		sb.WriteString(fmt.Sprintf("        netmask: %s\n", yamlString(g.network.Mask)))
+		if g.network.VLAN > 0 {
+			sb.WriteString(fmt.Sprintf("        vlan: %d\n", g.network.VLAN))
+		}
+		if g.gateway != "" {
+			sb.WriteString(fmt.Sprintf("        gateway: %s\n", yamlString(g.gateway)))
+		}
+		if len(g.hosts) == 0 {
+			continue
+		}
+		sb.WriteString("      hosts:\n")
+		for _, h := range g.hosts {
+			host := h.Label
+			if h.FQDN != "" {
+				host = h.FQDN
+			} else if hostNames[h.Label] > 1 {
+				host = h.Name + "-" + h.Label
+			}
+			sb.WriteString(fmt.Sprintf("        %s:\n", yamlString(host)))
+			sb.WriteString(fmt.Sprintf("          ansible_host: %s\n", yamlString(h.IP)))
+		}
+	}
+
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// azTag places a subnet in an AWS availability zone. Its value is a zone
+// name (us-east-1a), a zone ID (use1-az1), or an index into the zones the
+// region offers (0, 1, ...), so one plan works in every region.
+const azTag = "az"
+
+// awsMinPrefix and awsMaxPrefix bound the CIDR blocks of VPCs and subnets.
+const (
+	awsMinPrefix = 16
+	awsMaxPrefix = 28
+)
+
+var (
+	awsZoneID       = regexp.MustCompile(`^[a-z]{2,5}[0-9]-az[0-9]+$`)
+	awsInvalidIDRun = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// awsVPC is a parent network with its subnets, ready to be written.
+type awsVPC struct {
+	cidr    string
+	id      string // Terraform resource name
+	name    string // Name tag
+	subnets []awsSubnet
+}
+
+// awsSubnet is one planned subnet as an AWS subnet.
+type awsSubnet struct {
+	id     string // Terraform resource name
+	cfnID  string // CloudFormation logical ID
+	cidr   string
+	zone   string // availability zone name
+	zoneID string // availability zone ID
+	index  int    // index into the region's zones, or -1
+	tags   map[string]string
+}
+
+// awsResourceID turns a subnet name into a Terraform resource name of
+// lowercase letters, digits and '_', starting with "subnet_".
+func awsResourceID(name string) string {
+	id := strings.Trim(awsInvalidIDRun.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	return "subnet_" + id
+}
+
+// buildAWSVPCs groups the plan into VPCs, one per parent network, with a
+// subnet per planned subnet.
+func buildAWSVPCs(results []SubnetResult) ([]awsVPC, error) {
+	var vpcs []awsVPC
+	index := make(map[string]int)
+	used := make(map[string]bool) // Terraform names and CloudFormation IDs
+	for _, r := range results {
+		if r.Category != "Network" {
+			continue
+		}
+		i, ok := index[r.Parent]
+		if !ok {
+			if prefix := parentPrefix(r.Parent); prefix < awsMinPrefix || prefix > awsMaxPrefix {
+				return nil, fmt.Errorf("parent network %s is outside the AWS VPC sizes /%d to /%d", r.Parent, awsMinPrefix, awsMaxPrefix)
+			}
+			i = len(vpcs)
+			index[r.Parent] = i
+			name := "vpc-" + strings.NewReplacer(".", "-", "/", "-").Replace(r.Parent)
+			vpcs = append(vpcs, awsVPC{cidr: r.Parent, id: strings.ReplaceAll(name, "-", "_"), name: name})
+		}
+		if r.Prefix > awsMaxPrefix {
+			return nil, fmt.Errorf("subnet %s (/%d) is smaller than the AWS minimum of /%d", r.Name, r.Prefix, awsMaxPrefix)
+		}
+		s := awsSubnet{id: awsUniqueID(awsResourceID(r.Name), "_", used), cidr: r.Subnet, index: -1, tags: map[string]string{"Name": r.Name}}
+		s.cfnID = awsUniqueID(cfnLogicalID(s.id), "x", used)
+		for k, v := range r.Tags {
+			if k != azTag {
+				s.tags[k] = v
+			}
+		}
+		if az := strings.TrimSpace(r.Tags[azTag]); az != "" {
+			if n, err := strconv.Atoi(az); err == nil && n >= 0 {
+				s.index = n
+			} else if awsZoneID.MatchString(az) {
+				s.zoneID = az
+			} else {
+				s.zone = az
+			}
+		}
+		vpcs[i].subnets = append(vpcs[i].subnets, s)
+	}
+	return vpcs, nil
+}
+
+// awsUniqueID returns id, or id with the lowest free number appended after
+// sep, and marks the result as used.
+func awsUniqueID(id, sep string, used map[string]bool) string {
+	unique := id
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s%s%d", id, sep, n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// parentPrefix returns the prefix length of a CIDR, or -1.
+func parentPrefix(cidr string) int {
+	_, prefix, ok := strings.Cut(cidr, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.Atoi(prefix)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// ExportAWS writes the plan as AWS resources: a VPC per parent network and
+// a subnet per planned subnet, in the availability zone of its "az" tag.
+// format is terraform or cloudformation (a JSON template); by default .tf
+// files get Terraform and others CloudFormation.
+func ExportAWS(results []SubnetResult, path, format string) error {
+	if format == "" {
+		format = "cloudformation"
+		if strings.EqualFold(filepath.Ext(path), ".tf") {
+			format = "terraform"
+		}
+	}
+	vpcs, err := buildAWSVPCs(results)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch format {
+	case "terraform":
+		data = []byte(renderTerraform(vpcs))
+	case "cloudformation":
+		if data, err = renderCloudFormation(vpcs); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown AWS format %q (use terraform or cloudformation)", format)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// renderTerraform writes aws_vpc and aws_subnet resources. Zone indexes
+// read the region's zones from the aws_availability_zones data source.
+func renderTerraform(vpcs []awsVPC) string {
+	var sb strings.Builder
+	sb.WriteString("# AWS VPCs generated by IPSubnetPlanner\n")
+	if awsUsesZoneIndex(vpcs) {
+		sb.WriteString("\ndata \"aws_availability_zones\" \"available\" {\n  state = \"available\"\n}\n")
+	}
+	writeTags := func(tags map[string]string) {
+		sb.WriteString("\n  tags = {\n")
+		for _, k := range sortedKeys(tags) {
+			sb.WriteString(fmt.Sprintf("    %q = %q\n", k, tags[k]))
+		}
+		sb.WriteString("  }\n")
+	}
+	for _, v := range vpcs {
+		sb.WriteString(fmt.Sprintf("\nresource \"aws_vpc\" \"%s\" {\n", v.id))
+		sb.WriteString(fmt.Sprintf("  cidr_block           = %q\n", v.cidr))
+		sb.WriteString("  enable_dns_support   = true\n")
+		sb.WriteString("  enable_dns_hostnames = true\n")
+		writeTags(map[string]string{"Name": v.name})
+		sb.WriteString("}\n")
+		for _, s := range v.subnets {
+			sb.WriteString(fmt.Sprintf("\nresource \"aws_subnet\" \"%s\" {\n", s.id))
+			sb.WriteString(fmt.Sprintf("  vpc_id     = aws_vpc.%s.id\n", v.id))
+			sb.WriteString(fmt.Sprintf("  cidr_block = %q\n", s.cidr))
+			switch {
+			case s.zone != "":
+				sb.WriteString(fmt.Sprintf("  availability_zone = %q\n", s.zone))
+			case s.zoneID != "":
+				sb.WriteString(fmt.Sprintf("  availability_zone_id = %q\n", s.zoneID))
+			case s.index >= 0:
+				sb.WriteString(fmt.Sprintf("  availability_zone = data.aws_availability_zones.available.names[%d]\n", s.index))
+			}
+			writeTags(s.tags)
+			sb.WriteString("}\n")
+		}
+	}
+	return sb.String()
+}
+
+// awsUsesZoneIndex reports whether any subnet picks its zone by index.
+func awsUsesZoneIndex(vpcs []awsVPC) bool {
+	for _, v := range vpcs {
+		for _, s := range v.subnets {
+			if s.index >= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cfnTag is a tag of a CloudFormation resource.
+type cfnTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// cfnResource is a resource of a CloudFormation template.
+type cfnResource struct {
+	Type       string                 `json:"Type"`
+	Properties map[string]interface{} `json:"Properties"`
+}
+
+// cfnTags lists tags in key order.
+func cfnTags(tags map[string]string) []cfnTag {
+	list := make([]cfnTag, 0, len(tags))
+	for _, k := range sortedKeys(tags) {
+		list = append(list, cfnTag{Key: k, Value: tags[k]})
+	}
+	return list
+}
+
+// cfnLogicalID turns a resource name into a CloudFormation logical ID,
+// which must be alphanumeric: subnet_app_tier becomes SubnetAppTier.
+func cfnLogicalID(id string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(id, "_") {
+		if part != "" {
+			sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return sb.String()
+}
+
+// renderCloudFormation writes a JSON template of AWS::EC2::VPC and
+// AWS::EC2::Subnet resources, with their IDs as outputs. Zone indexes
+// select from Fn::GetAZs.
+func renderCloudFormation(vpcs []awsVPC) ([]byte, error) {
+	resources := make(map[string]cfnResource)
+	outputs := make(map[string]interface{})
+	for i, v := range vpcs {
+		vpcID := fmt.Sprintf("VPC%d", i)
+		resources[vpcID] = cfnResource{Type: "AWS::EC2::VPC", Properties: map[string]interface{}{
+			"CidrBlock":          v.cidr,
+			"EnableDnsSupport":   true,
+			"EnableDnsHostnames": true,
+			"Tags":               cfnTags(map[string]string{"Name": v.name}),
+		}}
+		outputs[vpcID] = map[string]interface{}{"Value": map[string]string{"Ref": vpcID}}
+		for _, s := range v.subnets {
+			id := s.cfnID
+			if _, ok := resources[id]; ok {
+				return nil, fmt.Errorf("duplicate CloudFormation logical ID %s", id)
+			}
+			props := map[string]interface{}{
+				"VpcId":     map[string]string{"Ref": vpcID},
+				"CidrBlock": s.cidr,
+				"Tags":      cfnTags(s.tags),
+			}
+			switch {
+			case s.zone != "":
+				props["AvailabilityZone"] = s.zone
+			case s.zoneID != "":
+				props["AvailabilityZoneId"] = s.zoneID
+			case s.index >= 0:
+				props["AvailabilityZone"] = map[string]interface{}{"Fn::Select": []interface{}{s.index, map[string]string{"Fn::GetAZs": ""}}}
+			}
+			resources[id] = cfnResource{Type: "AWS::EC2::Subnet", Properties: props}
+			outputs[id] = map[string]interface{}{"Value": map[string]string{"Ref": id}}
+		}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Description":              "AWS VPCs generated by IPSubnetPlanner",
+		"Resources":                resources,
+		"Outputs":                  outputs,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CloudFormation template: %v", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// azureAPIVersion is the Microsoft.Network API version used in generated templates.
+const azureAPIVersion = "2023-09-01"
+
+var azureInvalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// azureSubnetName converts a subnet name into one Azure (and NSG rules that
+// reference it) accepts: 1-80 characters of letters, digits, '_', '.', '-',
+// starting with an alphanumeric and ending with an alphanumeric or '_'.
+func azureSubnetName(name string) string {
+	n := azureInvalidNameChars.ReplaceAllString(strings.TrimSpace(name), "-")
+	n = strings.TrimLeft(n, "_.-")
+	if len(n) > 80 {
+		n = n[:80]
+	}
+	n = strings.TrimRight(n, ".-")
+	if n == "" {
+		n = "subnet"
+	}
+	return n
+}
+
+// ExportBicep writes an Azure Bicep template with one virtual network per
+// parent network and one subnet per planned subnet. Subnet delegations are
+// taken from the "delegations" field of the configuration.
+func ExportBicep(results []SubnetResult, networks []Network, path string) error {
+	delegations := make(map[string][]string)
+	for _, n := range networks {
+		for _, s := range n.Subnets {
+			delegations[s.Name] = s.Delegations
+		}
+	}
+
+	var parents []string
+	subnets := make(map[string][]SubnetResult)
+	for _, r := range results {
+		if r.Category != "Network" {
+			continue
+		}
+		if r.Prefix > 29 {
+			return fmt.Errorf("subnet %s (/%d) is smaller than the Azure minimum of /29", r.Name, r.Prefix)
+		}
+		if _, ok := subnets[r.Parent]; !ok {
+			parents = append(parents, r.Parent)
+		}
+		subnets[r.Parent] = append(subnets[r.Parent], r)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Azure virtual networks generated by IPSubnetPlanner\n")
+	sb.WriteString("param location string = resourceGroup().location\n")
+
+	for i, parent := range parents {
+		vnetName := "vnet-" + strings.NewReplacer(".", "-", "/", "-").Replace(parent)
+		sb.WriteString(fmt.Sprintf("\nresource vnet%d 'Microsoft.Network/virtualNetworks@%s' = {\n", i, azureAPIVersion))
+		sb.WriteString(fmt.Sprintf("  name: '%s'\n", vnetName))
+		sb.WriteString("  location: location\n")
+		sb.WriteString("  properties: {\n")
+		sb.WriteString("    addressSpace: {\n")
+		sb.WriteString(fmt.Sprintf("      addressPrefixes: [\n        '%s'\n      ]\n", parent))
+		sb.WriteString("    }\n")
+		sb.WriteString("    subnets: [\n")
+
+		used := make(map[string]int)
+		for _, r := range subnets[parent] {
+			name := azureSubnetName(r.Name)
+			if used[strings.ToLower(name)]++; used[strings.ToLower(name)] > 1 {
+				name = fmt.Sprintf("%s-%d", name, used[strings.ToLower(name)])
+			}
+			sb.WriteString("      {\n")
+			sb.WriteString(fmt.Sprintf("        name: '%s'\n", name))
+			sb.WriteString("        properties: {\n")
+			sb.WriteString(fmt.Sprintf("          addressPrefix: '%s'\n", r.Subnet))
+			if d := delegations[r.Name]; len(d) > 0 {
+				sb.WriteString("          delegations: [\n")
+				for j, service := range d {
+					sb.WriteString("            {\n")
+					sb.WriteString(fmt.Sprintf("              name: 'delegation-%d'\n", j))
+					sb.WriteString(fmt.Sprintf("              properties: {\n                serviceName: '%s'\n              }\n", service))
+					sb.WriteString("            }\n")
+				}
+				sb.WriteString("          ]\n")
+			}
+			sb.WriteString("        }\n")
+			sb.WriteString("      }\n")
+		}
+		sb.WriteString("    ]\n")
+		sb.WriteString("  }\n")
+		sb.WriteString("}\n")
+	}
+
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// ApplyBaseline pins every subnet of networks that also appears in a
+// previously exported plan to its old address, so re-planning only places
+// new requirements in the remaining free space. Subnets are matched by name;
+// a subnet whose size changed, or whose old address is no longer inside its
+// parent network, is left unpinned and reported in warnings. Baseline subnets
+// missing from networks are returned as reclaimable.
+func ApplyBaseline(networks []Network, baseline []SubnetResult) (pinned []Network, reclaimable []SubnetResult, warnings []string) {
+	return Planner{}.ApplyBaseline(networks, baseline)
+}
+
+// ApplyBaseline is like the package-level ApplyBaseline, but sizes subnets
+// with p's options (growth, /30 links) when comparing them to the baseline.
+func (p Planner) ApplyBaseline(networks []Network, baseline []SubnetResult) (pinned []Network, reclaimable []SubnetResult, warnings []string) {
+	index, order := indexSubnets(baseline)
+
+	pinned = cloneNetworks(networks)
+	seen := make(map[string]bool)
+	for i := range pinned {
+		n := &pinned[i]
+		// Generated point-to-point links become ordinary subnets so they
+		// can be pinned like the rest
+		n.Subnets, n.P2PLinks = withP2PLinks(*n), 0
+		// Pools are resolved later; a subnet keeps its address if it is in
+		// any of them, and SplitPools places it in that pool
+		var parents []*net.IPNet
+		var err error
+		for _, cidr := range poolCIDRs(*n) {
+			var parent *net.IPNet
+			if _, parent, err = net.ParseCIDR(cidr); err != nil {
+				break
+			}
+			parents = append(parents, parent)
+		}
+		for j := range n.Subnets {
+			s := &n.Subnets[j]
+			// Fabric pairs are pinned by their first fabric
+			names := subnetNames(*s)
+			old, ok := index[names[0]]
+			if !ok || old.Category == "Summary" {
+				continue
+			}
+			for _, name := range names {
+				seen[name] = true
+			}
+			if s.Address != "" || err != nil || len(parents) == 0 {
+				continue
+			}
+			prefix, prefixErr := p.subnetPrefix(*s)
+			oldIP, _, parseErr := net.ParseCIDR(old.Subnet)
+			switch {
+			case prefixErr != nil || parseErr != nil:
+				continue
+			case prefix != old.Prefix:
+				warnings = append(warnings, fmt.Sprintf("subnet %s changed size (/%d -> /%d) and will be re-allocated", s.Name, old.Prefix, prefix))
+			case !containsIP(parents, oldIP):
+				warnings = append(warnings, fmt.Sprintf("subnet %s: baseline address %s is outside %s and will be re-allocated", s.Name, old.Subnet, strings.Join(poolCIDRs(*n), ", ")))
+			case len(s.Fabrics) > 0:
+				s.Address = oldIP.String()
+			default:
+				s.Address = old.Subnet
+			}
+		}
+	}
+
+	for _, name := range order {
+		if r := index[name]; !seen[name] && r.Category != "Summary" {
+			reclaimable = append(reclaimable, r)
+		}
+	}
+	return pinned, reclaimable, warnings
+}
+
+// containsIP reports whether any of blocks contains ip.
+func containsIP(blocks []*net.IPNet, ip net.IP) bool {
+	for _, b := range blocks {
+		if b.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintReclaimable lists baseline subnets that are no longer in the config.
+func PrintReclaimable(w io.Writer, reclaimable []SubnetResult) {
+	if len(reclaimable) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nReclaimable subnets (in the baseline but no longer in the config):\n")
+	for _, r := range reclaimable {
+		fmt.Fprintf(w, "  %-25s %s\n", r.Name, r.Subnet)
+	}
+}
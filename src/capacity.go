@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// exitCapacityThreshold is returned when a network crosses its error threshold.
+const exitCapacityThreshold = 5
+
+// CapacityStatus is the utilization of one parent network checked against
+// its thresholds.
+type CapacityStatus struct {
+	Network     string  `json:"network"`
+	Used        uint64  `json:"used"`
+	Total       uint64  `json:"total"`
+	Utilization float64 `json:"utilization"` // percent
+	WarnAt      int     `json:"warnAt,omitempty"`
+	ErrorAt     int     `json:"errorAt,omitempty"`
+	Level       string  `json:"level"` // ok, warning, or error
+}
+
+// CheckCapacity evaluates every network against its capacityWarn and
+// capacityError thresholds (percent), falling back to warnAt and errorAt
+// for networks that do not set their own. A threshold of 0 is disabled.
+func CheckCapacity(networks []Network, results []SubnetResult, warnAt, errorAt int) []CapacityStatus {
+	var statuses []CapacityStatus
+	for _, n := range networks {
+		used, total := networkUtilization(n, results)
+		if total == 0 {
+			continue
+		}
+		s := CapacityStatus{Network: n.Network, Used: used, Total: total, WarnAt: warnAt, ErrorAt: errorAt, Level: "ok"}
+		if n.CapacityWarn > 0 {
+			s.WarnAt = n.CapacityWarn
+		}
+		if n.CapacityError > 0 {
+			s.ErrorAt = n.CapacityError
+		}
+		s.Utilization = float64(used) * 100 / float64(total)
+		switch {
+		case s.ErrorAt > 0 && s.Utilization >= float64(s.ErrorAt):
+			s.Level = "error"
+		case s.WarnAt > 0 && s.Utilization >= float64(s.WarnAt):
+			s.Level = "warning"
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// capacityLevel returns the most severe level among statuses.
+func capacityLevel(statuses []CapacityStatus) string {
+	level := "ok"
+	for _, s := range statuses {
+		if s.Level == "error" {
+			return "error"
+		}
+		if s.Level == "warning" {
+			level = "warning"
+		}
+	}
+	return level
+}
+
+// PrintCapacity writes a line for every network at or above a threshold.
+func PrintCapacity(w io.Writer, statuses []CapacityStatus) {
+	if capacityLevel(statuses) == "ok" {
+		return
+	}
+	fmt.Fprintf(w, "\nCapacity alerts:\n")
+	for _, s := range statuses {
+		switch s.Level {
+		case "warning":
+			fmt.Fprintf(w, "  WARNING %-18s %5.1f%% used (%d of %d addresses, warn at %d%%)\n", s.Network, s.Utilization, s.Used, s.Total, s.WarnAt)
+		case "error":
+			fmt.Fprintf(w, "  ERROR   %-18s %5.1f%% used (%d of %d addresses, error at %d%%)\n", s.Network, s.Utilization, s.Used, s.Total, s.ErrorAt)
+		}
+	}
+}
+
+// capacityWebhookPayload is the JSON body posted by -webhook.
+type capacityWebhookPayload struct {
+	Source   string           `json:"source"`
+	Version  string           `json:"version"`
+	Time     string           `json:"time"`
+	Level    string           `json:"level"`
+	Networks []CapacityStatus `json:"networks"`
+}
+
+// postCapacityWebhook sends the capacity results to url. Only networks at or
+// above a threshold are included.
+func postCapacityWebhook(url string, statuses []CapacityStatus) error {
+	payload := capacityWebhookPayload{
+		Source:  "IPSubnetPlanner",
+		Version: version,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Level:   capacityLevel(statuses),
+	}
+	for _, s := range statuses {
+		if s.Level != "ok" {
+			payload.Networks = append(payload.Networks, s)
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// networkUtilization returns allocated and total addresses of a parent network.
+func networkUtilization(n Network, results []SubnetResult) (uint64, uint64) {
+	_, ipNet, err := net.ParseCIDR(n.Network)
+	if err != nil {
+		return 0, 0
+	}
+	ones, bits := ipNet.Mask.Size()
+	total := uint64(1) << (bits - ones)
+	var free uint64
+	for _, r := range results {
+		if r.Parent == ipNet.String() && isFreeSpaceRow(r) {
+			free += uint64(1) << (32 - r.Prefix)
+		}
+	}
+	return total - free, total
+}
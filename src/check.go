@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+)
+
+// checkResult is the outcome of checking one config file.
+type checkResult struct {
+	Path     string   `json:"path"`
+	Code     int      `json:"exitCode"`
+	Problems []string `json:"problems"`
+	Warnings []string `json:"warnings"`
+}
+
+// checkConfig validates a config without exporting anything: it must parse
+// and match the schema, parent networks must not overlap, names and VLANs
+// must be unique within each network, and every network's subnets must fit.
+// Lint findings of otherwise valid configs are warnings. env selects one of
+// the config's environments.
+func checkConfig(path string, data []byte, env string) checkResult {
+	res := checkResult{Path: path, Problems: []string{}, Warnings: []string{}}
+	fail := func(code int, problems ...string) checkResult {
+		if res.Code == 0 {
+			res.Code = code
+		}
+		res.Problems = append(res.Problems, problems...)
+		return res
+	}
+
+	cfg, err := loadConfigFile(path, data, env)
+	if err != nil {
+		if schemaErr, ok := err.(*SchemaError); ok {
+			return fail(exitConfigError, schemaErr.Problems...)
+		}
+		return fail(exitConfigError, err.Error())
+	}
+	for _, w := range cfg.Warnings {
+		res.Warnings = append(res.Warnings, w+" (ignored)")
+	}
+	networks, err := resolveHosts(cfg.Networks, nil)
+	if err != nil {
+		return fail(exitConfigError, err.Error())
+	}
+	res.Warnings = append(res.Warnings, normalizeParents(networks)...)
+
+	if problems := duplicateProblems(networks); len(problems) > 0 {
+		fail(exitValidationError, problems...)
+	}
+	if problems := overlappingParents(networks); len(problems) > 0 {
+		fail(exitValidationError, problems...)
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		return fail(planningExitCode(err), err.Error())
+	}
+	if len(res.Problems) == 0 {
+		res.Warnings = append(res.Warnings, lintWarnings(networks, results, 0)...)
+	}
+	return res
+}
+
+// checkEnvironments checks every environment of a config with
+// "environments", unless env selects one, and merges the results with the
+// environment name in front of each message.
+func checkEnvironments(path string, data []byte, env string) checkResult {
+	var envs struct {
+		Environments map[string]json.RawMessage `json:"environments"`
+	}
+	if env != "" || json.Unmarshal(configText(data), &envs) != nil || len(envs.Environments) == 0 {
+		return checkConfig(path, data, env)
+	}
+	merged := checkResult{Path: path, Problems: []string{}, Warnings: []string{}}
+	for _, env := range sortedKeys(envs.Environments) {
+		res := checkConfig(path, data, env)
+		if merged.Code == 0 {
+			merged.Code = res.Code
+		}
+		for _, p := range res.Problems {
+			merged.Problems = append(merged.Problems, env+": "+p)
+		}
+		for _, w := range res.Warnings {
+			merged.Warnings = append(merged.Warnings, env+": "+w)
+		}
+	}
+	return merged
+}
+
+// duplicateProblems lists subnet names and VLANs used more than once
+// within the same parent network.
+func duplicateProblems(networks []Network) []string {
+	var problems []string
+	for _, n := range networks {
+		names := map[string]int{}
+		vlans := map[int]string{}
+		for _, s := range n.Subnets {
+			names[s.Name]++
+			if names[s.Name] == 2 {
+				problems = append(problems, fmt.Sprintf("network %s: subnet name %q is used more than once", n.Network, s.Name))
+			}
+			if s.VLAN == 0 {
+				continue
+			}
+			if other, ok := vlans[s.VLAN]; ok {
+				problems = append(problems, fmt.Sprintf("network %s: VLAN %d is used by %s and %s", n.Network, s.VLAN, other, s.Name))
+			} else {
+				vlans[s.VLAN] = s.Name
+			}
+		}
+	}
+	return problems
+}
+
+// overlappingParents lists parent networks and pools that share addresses.
+func overlappingParents(networks []Network) []string {
+	type block struct {
+		cidr  string
+		start uint64
+		end   uint64
+	}
+	var blocks []block
+	var problems []string
+	for _, n := range networks {
+		for _, cidr := range append([]string{n.Network}, n.Pools...) {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				continue // reported by planning
+			}
+			ones, _ := ipNet.Mask.Size()
+			b := block{cidr: ipNet.String(), start: uint64(ipToUint32(ipNet.IP))}
+			b.end = b.start + uint64(1)<<(32-ones)
+			for _, other := range blocks {
+				if b.start < other.end && other.start < b.end {
+					problems = append(problems, fmt.Sprintf("parent network %s overlaps %s", b.cidr, other.cidr))
+				}
+			}
+			blocks = append(blocks, b)
+		}
+	}
+	return problems
+}
+
+// runCheck implements the "check" subcommand.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	input := fs.String("input", "", "Config file to check (files can also be given as arguments)")
+	env := fs.String("env", "", "Check only this environment of configs with \"environments\" (default: every environment)")
+	vars := &varFlag{}
+	fs.Var(vars, "var", "Set a value for ${name} in the configs, as name=value (repeatable)")
+	fs.StringVar(&vars.file, "var-file", "", "File of values for ${NAME} in the configs: a JSON object or NAME=value lines")
+	strict := fs.Bool("strict", false, "Treat warnings (lint findings, parent networks with host bits, unknown fields) as problems")
+	asJSON := fs.Bool("json", false, "Print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner check [-strict] [-json] (-input config.json | config.json...)\n\n")
+		fmt.Fprintf(os.Stderr, "Validates configs without writing any exports: schema, overlapping parent networks,\n")
+		fmt.Fprintf(os.Stderr, "duplicate subnet names and VLANs, and whether every network's subnets fit.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	paths := fs.Args()
+	if *input != "" {
+		paths = append([]string{*input}, paths...)
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	code := 0
+	var results []checkResult
+	for _, path := range paths {
+		var res checkResult
+		data, err := readInput(path)
+		if err == nil {
+			data, err = substituteVars(path, data, vars)
+		}
+		if err != nil {
+			res = checkResult{Path: path, Code: exitConfigError, Problems: []string{fmt.Sprintf("error reading config file: %v", err)}, Warnings: []string{}}
+		} else {
+			res = checkEnvironments(path, data, *env)
+		}
+		if *strict && len(res.Warnings) > 0 {
+			res.Problems, res.Warnings = append(res.Problems, res.Warnings...), []string{}
+			if res.Code == 0 {
+				res.Code = exitValidationError
+			}
+		}
+		if code == 0 {
+			code = res.Code
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		data, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(data))
+		return code
+	}
+	for _, res := range results {
+		for _, p := range res.Problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", res.Path, p)
+		}
+		for _, w := range res.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", res.Path, w)
+		}
+	}
+	return code
+}
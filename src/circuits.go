@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultCircuitPrefix is assumed for circuit blocks given without a prefix.
+const defaultCircuitPrefix = 29
+
+// Circuit is a carrier/WAN circuit and the block its provider assigned.
+type Circuit struct {
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Block    string `json:"block"`
+	VLAN     int    `json:"vlan,omitempty"`
+}
+
+// circuitAssignments are the standard addresses of a circuit block: the
+// provider edge router, the customer edge router, and the remaining usable
+// addresses for the customer.
+var circuitAssignments = []IPAssignment{
+	{Name: "PE", Position: 1},
+	{Name: "CE", Position: 2},
+	{Name: "Usable", Position: 3, EndPosition: -1},
+}
+
+// circuitBlock parses a circuit's block, defaulting to a /29.
+func circuitBlock(c Circuit) (*net.IPNet, error) {
+	block := strings.TrimSpace(c.Block)
+	if block == "" {
+		return nil, fmt.Errorf("circuit %s: missing 'block' (the provider-assigned network, e.g. 203.0.113.8/29)", c.Name)
+	}
+	if !strings.Contains(block, "/") {
+		block += "/" + strconv.Itoa(defaultCircuitPrefix)
+	}
+	ip, ipNet, err := net.ParseCIDR(block)
+	if err != nil || ip.To4() == nil {
+		return nil, fmt.Errorf("circuit %s: invalid block %q", c.Name, c.Block)
+	}
+	if !ip.Equal(ipNet.IP) {
+		return nil, fmt.Errorf("circuit %s: block %s has host bits set (network is %s)", c.Name, c.Block, ipNet)
+	}
+	if prefix, _ := ipNet.Mask.Size(); prefix > 30 {
+		return nil, fmt.Errorf("circuit %s: block %s is too small for PE and CE addresses", c.Name, c.Block)
+	}
+	return ipNet, nil
+}
+
+// circuitNetworks turns every circuit into a parent network holding one
+// subnet with the standard PE/CE/usable assignments. A /30 has no addresses
+// left after PE and CE, so it gets no usable block.
+func circuitNetworks(circuits []Circuit) ([]Network, error) {
+	var networks []Network
+	for _, c := range circuits {
+		block, err := circuitBlock(c)
+		if err != nil {
+			return nil, err
+		}
+		prefix, _ := block.Mask.Size()
+		assignments := circuitAssignments
+		if prefix == 30 {
+			assignments = assignments[:2]
+		}
+		description := strings.TrimSpace(strings.Join([]string{c.Provider, c.ID}, " "))
+		networks = append(networks, Network{
+			Network: block.String(),
+			Subnets: []Subnet{{
+				Name:          c.Name,
+				VLAN:          c.VLAN,
+				CIDR:          prefix,
+				Description:   description,
+				IPAssignments: append([]IPAssignment(nil), assignments...),
+			}},
+		})
+	}
+	return networks, nil
+}
+
+// ExportCircuits writes the circuit addressing sheet as CSV: one line per
+// circuit with its block, mask, PE, CE, usable range and broadcast address.
+func ExportCircuits(circuits []Circuit, results []SubnetResult, path string) error {
+	if len(circuits) == 0 {
+		return fmt.Errorf("no circuits in the config")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"Circuit", "CircuitID", "Provider", "Block", "Mask", "VLAN", "PE", "CE", "Usable", "Broadcast"})
+	for _, c := range circuits {
+		block, err := circuitBlock(c)
+		if err != nil {
+			return err
+		}
+		cells := map[string]string{}
+		for _, r := range results {
+			if r.Parent == block.String() && r.Name == c.Name {
+				cells[r.Label] = r.IP
+				if cells["Mask"] == "" {
+					cells["Mask"] = r.Mask
+				}
+			}
+		}
+		vlan := ""
+		if c.VLAN > 0 {
+			vlan = strconv.Itoa(c.VLAN)
+		}
+		w.Write([]string{c.Name, c.ID, c.Provider, block.String(), cells["Mask"], vlan, cells["PE"], cells["CE"], cells["Usable"], cells["Broadcast"]})
+	}
+	w.Flush()
+	return w.Error()
+}
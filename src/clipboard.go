@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// renderCopy renders results in a -copy format: table, markdown or csv.
+func renderCopy(results []SubnetResult, format string) (string, error) {
+	var buf bytes.Buffer
+	switch strings.ToLower(format) {
+	case "table":
+		PrintTableTo(&buf, results)
+	case "markdown", "md":
+		buf.WriteString(renderMarkdown(results, nil))
+	case "csv":
+		if err := writeCSV(&buf, results); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("invalid -copy value %q (use table, markdown or csv)", format)
+	}
+	return buf.String(), nil
+}
+
+// clipboardCommands lists the commands that accept clipboard contents on
+// stdin, in order of preference, for the current OS.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip.exe"}, {"powershell.exe", "-NoProfile", "-Command", "$input | Set-Clipboard"}}
+	default:
+		return [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+}
+
+// copyToClipboard puts text on the system clipboard using the first
+// clipboard command found on PATH.
+func copyToClipboard(text string) error {
+	var tried []string
+	for _, command := range clipboardCommands() {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			tried = append(tried, command[0])
+			continue
+		}
+		cmd := exec.Command(path, command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s failed: %v %s", command[0], err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard command found (install one of %s)", strings.Join(tried, ", "))
+}
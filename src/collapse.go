@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CollapseUnused merges the Unused rows of every subnet into one row whose IP
+// lists the ranges comma-separated and whose TotalIPs is their sum. The row
+// takes the place of the subnet's first Unused row; subnets with a single
+// Unused row are left alone.
+func CollapseUnused(results []SubnetResult) []SubnetResult {
+	key := func(r SubnetResult) string { return r.Parent + "|" + r.Subnet + "|" + r.Name }
+	counts := make(map[string]int)
+	for _, r := range results {
+		if r.Category == "Unused" {
+			counts[key(r)]++
+		}
+	}
+
+	var out []SubnetResult
+	merged := make(map[string]int) // subnet -> index of its merged row in out
+	for _, r := range results {
+		k := key(r)
+		if r.Category != "Unused" || counts[k] < 2 {
+			out = append(out, r)
+			continue
+		}
+		i, ok := merged[k]
+		if !ok {
+			r.Label = fmt.Sprintf("Unused (%d ranges)", counts[k])
+			merged[k] = len(out)
+			out = append(out, r)
+			continue
+		}
+		out[i].IP = strings.Join([]string{out[i].IP, r.IP}, ", ")
+		out[i].TotalIPs += r.TotalIPs
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// outputColumn is a column that -columns can select for the CSV export and
+// the console table.
+type outputColumn struct {
+	header string
+	width  int // console table width; 0 for the last, unpadded column
+	value  func(SubnetResult) string
+	table  func(SubnetResult) string // console value, if it differs from value
+}
+
+// outputColumnNames lists the selectable columns in their default order.
+var outputColumnNames = []string{"Subnet", "Name", "Vlan", "Label", "IP", "TotalIPs", "Prefix", "Mask", "Category",
+	"Network", "Broadcast", "FirstHost", "LastHost", "UsableHosts", "Parent", "DHCP", "Change", "Status", "FQDN", "Description", "Tags"}
+
+// outputColumns maps lower-cased column names to columns.
+var outputColumns = map[string]outputColumn{
+	"subnet": {header: "Subnet", width: 20, value: func(r SubnetResult) string { return r.Subnet }},
+	"name":   {header: "Name", width: 25, value: func(r SubnetResult) string { return r.Name }},
+	"vlan": {header: "Vlan", width: 6, value: func(r SubnetResult) string { return strconv.Itoa(r.VLAN) },
+		table: func(r SubnetResult) string {
+			if r.VLAN > 0 {
+				return strconv.Itoa(r.VLAN)
+			}
+			return "-"
+		}},
+	"label":       {header: "Label", width: 20, value: func(r SubnetResult) string { return r.Label }, table: tableLabel},
+	"ip":          {header: "IP", width: 15, value: func(r SubnetResult) string { return r.IP }},
+	"totalips":    {header: "TotalIPs", width: 10, value: func(r SubnetResult) string { return strconv.Itoa(r.TotalIPs) }},
+	"prefix":      {header: "Prefix", width: 8, value: func(r SubnetResult) string { return fmt.Sprintf("/%d", r.Prefix) }},
+	"mask":        {header: "Mask", width: 15, value: func(r SubnetResult) string { return r.Mask }},
+	"category":    {header: "Category", width: 15, value: func(r SubnetResult) string { return r.Category }},
+	"network":     {header: "Network", width: 15, value: func(r SubnetResult) string { return r.Network }},
+	"broadcast":   {header: "Broadcast", width: 15, value: func(r SubnetResult) string { return r.Broadcast }},
+	"firsthost":   {header: "FirstHost", width: 15, value: func(r SubnetResult) string { return r.FirstHost }},
+	"lasthost":    {header: "LastHost", width: 15, value: func(r SubnetResult) string { return r.LastHost }},
+	"usablehosts": {header: "UsableHosts", width: 11, value: func(r SubnetResult) string { return strconv.Itoa(r.UsableHosts) }},
+	"parent":      {header: "Parent", width: 18, value: func(r SubnetResult) string { return r.Parent }},
+	"dhcp":        {header: "DHCP", width: 5, value: func(r SubnetResult) string { return strconv.FormatBool(r.DHCP) }},
+	"change":      {header: "Change", width: 8, value: func(r SubnetResult) string { return r.Change }},
+	"status":      {header: "Status", width: 14, value: func(r SubnetResult) string { return r.Status }},
+	"fqdn":        {header: "FQDN", width: 30, value: func(r SubnetResult) string { return r.FQDN }},
+	"description": {header: "Description", width: 30, value: func(r SubnetResult) string { return r.Description }},
+	"tags":        {header: "Tags", width: 30, value: func(r SubnetResult) string { return formatTags(r.Tags) }},
+}
+
+// parseColumns parses a -columns list such as "Subnet,Name,VLAN,IP,Label".
+// Names match case-insensitively; an empty list selects nothing (nil).
+func parseColumns(spec string) ([]outputColumn, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	var cols []outputColumn
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		col, ok := outputColumns[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (use %s)", name, strings.Join(outputColumnNames, ", "))
+		}
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("-columns lists no columns")
+	}
+	return cols, nil
+}
+
+// writeColumnsCSV writes results as CSV with only the selected columns.
+func writeColumnsCSV(w io.Writer, results []SubnetResult, cols []outputColumn) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.header
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	for _, r := range results {
+		if err := writer.Write(columnCells(r, cols)); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func columnCells(r SubnetResult, cols []outputColumn) []string {
+	cells := make([]string, len(cols))
+	for i, col := range cols {
+		cells[i] = col.value(r)
+	}
+	return cells
+}
+
+// PrintColumnsTo writes the console table with only the selected columns.
+func PrintColumnsTo(w io.Writer, results []SubnetResult, cols []outputColumn) {
+	printColumns(w, results, cols, rowWindow{})
+}
+
+// printColumns writes the table of PrintColumnsTo with the rows of window.
+func printColumns(w io.Writer, results []SubnetResult, cols []outputColumn, window rowWindow) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No subnets generated.")
+		return
+	}
+	fmt.Fprintf(w, "\nGenerated %d subnet entries:\n\n", len(results))
+	line := func(cells []string) {
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				fmt.Fprint(w, cell)
+			} else {
+				fmt.Fprintf(w, "%-*s ", cols[i].width, truncate(cell, cols[i].width))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	header := make([]string, len(cols))
+	rule := make([]string, len(cols))
+	for i, col := range cols {
+		header[i] = col.header
+		rule[i] = strings.Repeat("-", len(col.header))
+	}
+	line(header)
+	line(rule)
+	window.printRows(w, results, func(r SubnetResult) {
+		cells := make([]string, len(cols))
+		for i, col := range cols {
+			if col.table != nil {
+				cells[i] = col.table(r)
+			} else {
+				cells[i] = col.value(r)
+			}
+		}
+		line(cells)
+	})
+}
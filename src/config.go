@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// Config is the wrapped configuration form, which adds named IP assignment
+// templates that subnets reference with "template" and plan-wide options.
+type Config struct {
+	Templates      map[string][]IPAssignment `json:"templates,omitempty"`
+	OutputOrder    string                    `json:"outputOrder,omitempty"`
+	QuarantineDays int                       `json:"quarantineDays,omitempty"`
+	Networks       []Network                 `json:"networks,omitempty"`
+	Circuits       []Circuit                 `json:"circuits,omitempty"`
+	Wireless       []WirelessPlan            `json:"wireless,omitempty"`
+	Sites          []SitePlan                `json:"sites,omitempty"`
+	OOB            *OOBCheck                 `json:"oob,omitempty"`
+	Outputs        []Output                  `json:"outputs,omitempty"`
+	Variables      configVariables           `json:"variables,omitempty"` // host count expression values for every network
+	Environments   map[string][]Network      `json:"environments,omitempty"`
+
+	Warnings []string `json:"-"` // unknown fields, which are ignored
+}
+
+// configVariables are the "variables" of a config or network. Numbers are
+// host count expression values; text values only supply the ${NAME}
+// references of the config (see substituteVars) and are left out.
+type configVariables map[string]float64
+
+// UnmarshalJSON accepts numbers and strings, and keeps the numbers.
+func (v *configVariables) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*v = nil
+	for name, value := range raw {
+		var text string
+		if json.Unmarshal(value, &text) == nil {
+			continue
+		}
+		var n float64
+		if err := json.Unmarshal(value, &n); err != nil {
+			return fmt.Errorf("variables.%s: use a number or a string", name)
+		}
+		if *v == nil {
+			*v = make(configVariables)
+		}
+		(*v)[name] = n
+	}
+	return nil
+}
+
+// selectEnvironment adds the networks of env, the environment selected
+// with -env, to the config's shared networks. A config with environments
+// needs an environment, and an environment needs a config with
+// environments.
+func selectEnvironment(cfg *Config, env string) error {
+	if len(cfg.Environments) == 0 {
+		if env != "" {
+			return fmt.Errorf("environment %s: the config has no environments", env)
+		}
+		return nil
+	}
+	names := sortedKeys(cfg.Environments)
+	if env == "" {
+		return fmt.Errorf("the config has environments %s; select one with -env", strings.Join(names, ", "))
+	}
+	networks, ok := cfg.Environments[env]
+	if !ok {
+		return fmt.Errorf("unknown environment %q (the config has %s)", env, strings.Join(names, ", "))
+	}
+	cfg.Networks = append(append([]Network(nil), cfg.Networks...), networks...)
+	cfg.Environments = nil
+	return nil
+}
+
+// loadConfig decodes a planner configuration, accepting a single network
+// object, an array of networks, or a Config object, whose environment env
+// is selected. Comments, trailing commas, a byte order mark and
+// Windows-1252 text are accepted. Unknown fields are listed in the
+// config's Warnings.
+func loadConfig(data []byte, env string) (Config, error) {
+	data = configText(data)
+	unknown, err := validateConfigSchema(data)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := decodeConfig(data, env)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Warnings = unknown
+	return cfg, nil
+}
+
+// decodeConfig decodes a config that matches the config schema.
+func decodeConfig(data []byte, env string) (Config, error) {
+	var arr []Network
+	if err := json.Unmarshal(data, &arr); err == nil {
+		if err := selectEnvironment(&Config{}, env); err != nil {
+			return Config{}, err
+		}
+		return expandTemplates(Config{Networks: arr})
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err == nil && (cfg.Networks != nil || cfg.Circuits != nil || cfg.Wireless != nil || cfg.Sites != nil || cfg.Environments != nil) {
+		if err := selectEnvironment(&cfg, env); err != nil {
+			return Config{}, err
+		}
+		circuits, err := circuitNetworks(cfg.Circuits)
+		if err != nil {
+			return Config{}, err
+		}
+		wireless, err := wirelessNetworks(cfg.Wireless)
+		if err != nil {
+			return Config{}, err
+		}
+		sites, err := siteNetworks(cfg.Sites)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Networks = append(append(append(cfg.Networks, circuits...), wireless...), sites...)
+		// A network's own variables win over the config-wide ones
+		for i := range cfg.Networks {
+			n := &cfg.Networks[i]
+			for k, v := range cfg.Variables {
+				if _, ok := n.Variables[k]; !ok {
+					if n.Variables == nil {
+						n.Variables = make(map[string]float64)
+					}
+					n.Variables[k] = v
+				}
+			}
+		}
+		return expandTemplates(cfg)
+	}
+	var single Network
+	if err := json.Unmarshal(data, &single); err != nil {
+		// Provide helpful error message
+		errMsg := fmt.Sprintf("error parsing config file: %v\n\n", err)
+		errMsg += "Common issues:\n"
+		errMsg += "  1. Check that 'vlan' and 'cidr' values are integers (not strings)\n"
+		errMsg += "     ✗ Bad:  \"vlan\": \"100\", \"cidr\": \"26\"\n"
+		errMsg += "     ✓ Good: \"vlan\": 100, \"cidr\": 26\n\n"
+		errMsg += "  2. Verify JSON structure:\n"
+		errMsg += "     Single network: {\"network\": \"...\", \"subnets\": [...]}\n"
+		errMsg += "     Multi-network:  [{\"network\": \"...\", \"subnets\": [...]}, ...]\n"
+		errMsg += "     Templates:      {\"templates\": {...}, \"networks\": [...]}\n"
+		errMsg += "     Environments:   {\"environments\": {\"prod\": [...], \"dr\": [...]}}\n"
+		errMsg += "     Circuits:       {\"circuits\": [{\"name\": \"...\", \"block\": \"203.0.113.8/29\"}]}\n"
+		errMsg += "     Sites:          {\"sites\": [{\"supernet\": \"10.0.0.0/8\", \"prefix\": 16, \"subnets\": [...], \"sites\": [...]}]}\n\n"
+		errMsg += "See examples/ directory for reference."
+		return Config{}, fmt.Errorf("%s", errMsg)
+	}
+	if err := selectEnvironment(&Config{}, env); err != nil {
+		return Config{}, err
+	}
+	return expandTemplates(Config{Networks: []Network{single}})
+}
+
+// normalizeParents rewrites parent networks given with host bits set, such
+// as 192.168.1.10/24, to their network address and returns a warning for
+// each: the planner would mask them anyway, but the stray bits are usually
+// a typo.
+func normalizeParents(networks []Network) []string {
+	var warnings []string
+	normalize := func(cidr *string) {
+		ip, ipNet, err := net.ParseCIDR(*cidr)
+		if err != nil || ip.Equal(ipNet.IP) {
+			return
+		}
+		warnings = append(warnings, fmt.Sprintf("parent network %s has host bits set (network address %s)", *cidr, ipNet))
+		*cidr = ipNet.String()
+	}
+	for i := range networks {
+		normalize(&networks[i].Network)
+		for j := range networks[i].Pools {
+			normalize(&networks[i].Pools[j])
+		}
+	}
+	return warnings
+}
+
+// expandTemplates replaces each subnet's template reference with the
+// template's assignments. Assignments listed on the subnet itself win over
+// template entries of the same name.
+func expandTemplates(cfg Config) (Config, error) {
+	for i := range cfg.Networks {
+		for j := range cfg.Networks[i].Subnets {
+			subnet := &cfg.Networks[i].Subnets[j]
+			if subnet.Template == "" {
+				continue
+			}
+			template, ok := cfg.Templates[subnet.Template]
+			if !ok {
+				return Config{}, fmt.Errorf("subnet %s: unknown template %q", subnet.Name, subnet.Template)
+			}
+			own := map[string]bool{}
+			for _, a := range subnet.IPAssignments {
+				own[a.Name] = true
+			}
+			var merged []IPAssignment
+			for _, a := range template {
+				if !own[a.Name] {
+					merged = append(merged, a)
+				}
+			}
+			subnet.IPAssignments = append(merged, subnet.IPAssignments...)
+			subnet.Template = ""
+		}
+	}
+	return cfg, nil
+}
+
+// loadPlanFile reads a previously exported JSON plan (the -exportjson format).
+func loadPlanFile(path string) ([]SubnetResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file: %v", err)
+	}
+	var results []SubnetResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("error parsing plan file %s (expected -exportjson output): %v", path, err)
+	}
+	return results, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ANSI colors of the console table.
+const (
+	ansiReset = "\x1b[0m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiGray  = "\x1b[90m"
+)
+
+// tableStyle controls the console table: colors, the terminal width
+// that wider Name, Label and IP columns may use (0 keeps the default
+// widths, as for files, pipes and the clipboard), and the rows shown.
+type tableStyle struct {
+	color  bool
+	width  int
+	window rowWindow
+}
+
+// rowWindow limits the console table to its first head and last tail
+// rows; the zero value shows every row.
+type rowWindow struct {
+	head, tail int
+}
+
+// noColor is set by -no-color; consoleWindow by -head and -tail.
+var (
+	noColor       bool
+	consoleWindow rowWindow
+)
+
+// consoleStyle detects the style for the table printed to stdout: colors
+// unless -no-color, NO_COLOR or TERM=dumb say otherwise, and the terminal
+// width, only when stdout is a terminal. -head and -tail apply either way.
+func consoleStyle() tableStyle {
+	style := tableStyle{window: consoleWindow}
+	if !isTerminal(os.Stdout) {
+		return style
+	}
+	style.color, style.width = colorSupported(), terminalWidth()
+	return style
+}
+
+// split returns the rows shown above and below the gap, and the number of
+// rows left out in between.
+func (w rowWindow) split(results []SubnetResult) (top, bottom []SubnetResult, omitted int) {
+	if (w.head <= 0 && w.tail <= 0) || w.head+w.tail >= len(results) {
+		return results, nil, 0
+	}
+	return results[:w.head], results[len(results)-w.tail:], len(results) - w.head - w.tail
+}
+
+// printRows prints the rows of a table through print, with a line in
+// place of the rows the window leaves out.
+func (w rowWindow) printRows(out io.Writer, results []SubnetResult, print func(SubnetResult)) {
+	top, bottom, omitted := w.split(results)
+	for _, r := range top {
+		print(r)
+	}
+	if omitted > 0 {
+		fmt.Fprintf(out, "... %d rows not shown (exports contain every row) ...\n", omitted)
+	}
+	for _, r := range bottom {
+		print(r)
+	}
+}
+
+// usePager is set by -pager.
+var usePager bool
+
+// pageOutput runs render with the console output: through the pager when
+// -pager is set and stdout is a terminal, otherwise straight to stdout.
+// The pager is $PAGER, or else "less -FRX" ("more" on Windows), which
+// exits at once when the output fits on one screen.
+func pageOutput(render func(w io.Writer)) {
+	if !usePager || !isTerminal(os.Stdout) {
+		render(os.Stdout)
+		return
+	}
+	command := os.Getenv("PAGER")
+	if command == "" {
+		command = "less -FRX"
+		if runtime.GOOS == "windows" {
+			command = "more"
+		}
+	}
+	fields := strings.Fields(command)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	pipe, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot start the pager %q: %v\n", command, err)
+		render(os.Stdout)
+		return
+	}
+	render(pipe)
+	pipe.Close()
+	_ = cmd.Wait()
+}
+
+// colorSupported reports whether the terminal should get ANSI colors. On
+// Windows only terminals known to handle them do.
+func colorSupported() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return os.Getenv("WT_SESSION") != "" || os.Getenv("TERM") != ""
+	}
+	return true
+}
+
+// isTerminal reports whether f is a terminal: a character device other than
+// the null device, which redirecting from or to /dev/null (NUL on Windows)
+// also gives.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// terminalWidth returns the width of the terminal from COLUMNS or, outside
+// Windows, from "stty size" on the controlling terminal; 0 if unknown.
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if runtime.GOOS == "windows" {
+		return 0
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return 0
+	}
+	defer tty.Close()
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[1])
+	return n
+}
+
+// rowColor returns the color of a table row: red for removed rows and
+// decommissioned or quarantined subnets, green for assignments, gray for
+// unused and available space, and none for the rest.
+func rowColor(r SubnetResult) string {
+	switch {
+	case r.Change == "removed" || r.Status != "":
+		return ansiRed
+	case isAssignedCategory(r.Category) || r.Category == "Reserved":
+		return ansiGreen
+	case r.Category == "Unused" || r.Category == "Available":
+		return ansiGray
+	}
+	return ""
+}
+
+// columnWidths returns the Name, Label and IP widths of the table. Width
+// beyond the default layout goes to these columns, in that order, until
+// their longest values fit; narrower terminals keep the defaults.
+func (s tableStyle) columnWidths(results []SubnetResult, fixed int) (name, label, ip int) {
+	name, label, ip = 25, 20, 15
+	extra := s.width - fixed - name - label - ip
+	if s.width == 0 || extra <= 0 {
+		return name, label, ip
+	}
+	var longName, longLabel, longIP int
+	for _, r := range results {
+		longName = max(longName, len(r.Name))
+		longLabel = max(longLabel, len(tableLabel(r)))
+		longIP = max(longIP, len(r.IP))
+	}
+	for _, col := range []struct {
+		width *int
+		want  int
+	}{{&name, longName}, {&label, longLabel}, {&ip, longIP}} {
+		grow := min(extra, max(col.want-*col.width, 0))
+		*col.width += grow
+		extra -= grow
+	}
+	return name, label, ip
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// exitPanic is the exit code used after an unexpected internal error.
+const exitPanic = 70
+
+// crashConfig is the configuration being planned, recorded so a crash bundle
+// can include a sanitized copy.
+var crashConfig []Network
+
+// diagnosticBundle is written when the planner panics.
+type diagnosticBundle struct {
+	Version string    `json:"version"`
+	Go      string    `json:"go"`
+	OS      string    `json:"os"`
+	Arch    string    `json:"arch"`
+	Time    string    `json:"time"`
+	Flags   []string  `json:"flags"`
+	Panic   string    `json:"panic"`
+	Stack   string    `json:"stack"`
+	Config  []Network `json:"config,omitempty"`
+}
+
+// recoverWithDiagnostics must be deferred in main. On panic it writes a
+// diagnostic bundle, tells the user where it is, and exits with exitPanic.
+// With -errors json the report is a single JSON error on stderr.
+func recoverWithDiagnostics() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	path, err := writeDiagnosticBundle(".", r, debug.Stack())
+	if err != nil {
+		path, err = writeDiagnosticBundle(os.TempDir(), r, debug.Stack())
+	}
+	if errorFormat == "json" {
+		var details []string
+		if err == nil {
+			details = append(details, "diagnostic bundle: "+path)
+		} else {
+			details = append(details, fmt.Sprintf("could not write diagnostic bundle: %v", err))
+		}
+		exitWithError(exitPanic, fmt.Sprintf("internal error: %v", r), details...)
+	}
+	fmt.Fprintf(os.Stderr, "\nIPSubnetPlanner crashed: %v\n", r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not write diagnostic bundle: %v\n%s", err, debug.Stack())
+	} else {
+		fmt.Fprintf(os.Stderr, "A diagnostic bundle was written to %s\n", path)
+		fmt.Fprintf(os.Stderr, "Please attach it when reporting the issue; names in the config have been replaced.\n")
+	}
+	os.Exit(exitPanic)
+}
+
+// writeDiagnosticBundle writes the bundle into dir and returns its path.
+func writeDiagnosticBundle(dir string, cause interface{}, stack []byte) (string, error) {
+	bundle := diagnosticBundle{
+		Version: version,
+		Go:      runtime.Version(),
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Time:    time.Now().UTC().Format(time.RFC3339),
+		Flags:   flagNames(os.Args[1:]),
+		Panic:   fmt.Sprint(cause),
+		Stack:   string(stack),
+		Config:  sanitizeConfig(crashConfig),
+	}
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("ipsubnetplanner-crash-%s.json", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// flagNames returns only the flag names from args; values such as file
+// paths are dropped.
+func flagNames(args []string) []string {
+	names := []string{}
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			name, _, _ := strings.Cut(strings.TrimLeft(a, "-"), "=")
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sanitizeConfig copies only the fields needed to reproduce a planning bug
+// (sizes, positions, addresses and planning options) and replaces subnet,
+// assignment and fabric names by placeholders. Fields not listed here, such
+// as descriptions, tags, owners and delegations, are never copied, so new
+// fields stay out of bundles until they are added deliberately.
+func sanitizeConfig(networks []Network) []Network {
+	var out []Network
+	for i, n := range networks {
+		clean := Network{
+			Network:          n.Network,
+			Pools:            n.Pools,
+			Gateway:          n.Gateway,
+			Redundancy:       n.Redundancy,
+			RedundantGateway: n.RedundantGateway,
+			CapacityWarn:     n.CapacityWarn,
+			CapacityError:    n.CapacityError,
+			P2PLinks:         n.P2PLinks,
+		}
+		for j, s := range n.Subnets {
+			cs := Subnet{
+				Name:             fmt.Sprintf("subnet-%d-%d", i+1, j+1),
+				VLAN:             s.VLAN,
+				Hosts:            s.Hosts,
+				Growth:           s.Growth,
+				CIDR:             s.CIDR,
+				Address:          s.Address,
+				P2P:              s.P2P,
+				Gateway:          s.Gateway,
+				Redundancy:       s.Redundancy,
+				RedundantGateway: s.RedundantGateway,
+				PlannedFor:       s.PlannedFor,
+				Criticality:      s.Criticality,
+				Decommissioned:   s.Decommissioned,
+				DecommissionedOn: s.DecommissionedOn,
+			}
+			for k := range s.Fabrics {
+				cs.Fabrics = append(cs.Fabrics, fmt.Sprintf("fabric-%d", k+1))
+			}
+			for k, a := range s.IPAssignments {
+				cs.IPAssignments = append(cs.IPAssignments, IPAssignment{
+					Name:        fmt.Sprintf("assignment-%d", k+1),
+					Position:    a.Position,
+					Count:       a.Count,
+					EndPosition: a.EndPosition,
+					DHCP:        a.DHCP,
+					Reserved:    a.Reserved,
+					Category:    a.Category,
+				})
+			}
+			clean.Subnets = append(clean.Subnets, cs)
+		}
+		out = append(out, clean)
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// loadConfigFile decodes a config read from path: CSV for .csv files, an
+// Excel template (see importXLSX) for .xlsx files, an
+// -exportjson plan turned back into a config, or the JSON formats of
+// loadConfig, whose environment env is selected. Only JSON configs have
+// environments.
+func loadConfigFile(path string, data []byte, env string) (Config, error) {
+	var networks []Network
+	var err error
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".xlsx"):
+		networks, err = importXLSX(data)
+	case strings.EqualFold(filepath.Ext(path), ".csv"):
+		networks, err = parseCSVConfig(data)
+	case isPlanJSON(data):
+		var rows []SubnetResult
+		if err := json.Unmarshal(configText(data), &rows); err != nil {
+			return Config{}, fmt.Errorf("error parsing plan: %v", err)
+		}
+		networks = planToConfig(rows)
+	default:
+		return loadConfig(data, env)
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	if err := selectEnvironment(&Config{}, env); err != nil {
+		return Config{}, err
+	}
+	return Config{Networks: networks}, nil
+}
+
+// parseCSVConfig reads a spreadsheet-style config: one subnet per row with
+// the columns Network, Name, VLAN, Hosts, CIDR, Address, Assignments and
+// Description (case-insensitive, any order; only Name is required). A
+// combined Hosts/CIDR column takes a host count or a /prefix. An empty
+// Network repeats the previous row's. Assignments are separated by
+// ";" and written Name=Position or Name=First-Last, with a trailing " dhcp"
+// for DHCP ranges, e.g. "Gateway=1; Pool=10-50 dhcp".
+func parseCSVConfig(data []byte) ([]Network, error) {
+	reader := csv.NewReader(bytes.NewReader(decodeText(data)))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSV config: %v", err)
+	}
+	return tableConfig(records, "CSV config")
+}
+
+// tableConfig builds networks from the rows of a CSV config or spreadsheet
+// (see parseCSVConfig); kind names the source in errors.
+func tableConfig(records [][]string, kind string) ([]Network, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", kind)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%s needs a Name column (columns: Network, Name, VLAN, Hosts, CIDR, Address, Assignments, Description)", kind)
+	}
+	cell := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var networks []Network
+	index := make(map[string]int)
+	parent := ""
+	for n, record := range records[1:] {
+		line := n + 2
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		if p := cell(record, "network"); p != "" {
+			parent = p
+		}
+		if parent == "" {
+			return nil, fmt.Errorf("%s line %d: no Network given for subnet %q", kind, line, cell(record, "name"))
+		}
+
+		s := Subnet{Name: cell(record, "name"), Address: cell(record, "address"), Description: cell(record, "description")}
+		if s.Name == "" {
+			return nil, fmt.Errorf("%s line %d: Name is empty", kind, line)
+		}
+		for _, field := range []struct {
+			column string
+			dst    *int
+		}{{"vlan", &s.VLAN}, {"hosts", &s.Hosts}, {"cidr", &s.CIDR}} {
+			text := strings.TrimPrefix(cell(record, field.column), "/")
+			if text == "" {
+				continue
+			}
+			if field.column == "hosts" && strings.HasPrefix(text, "=") {
+				// A formula, as in spreadsheets: "=nodes*3+10"
+				s.HostsExpr = strings.TrimSpace(text[1:])
+				continue
+			}
+			v, err := strconv.Atoi(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: invalid %s %q", kind, line, field.column, text)
+			}
+			*field.dst = v
+		}
+		if size := cell(record, "hosts/cidr"); size != "" {
+			v, err := strconv.Atoi(strings.TrimPrefix(size, "/"))
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: invalid Hosts/CIDR %q", kind, line, size)
+			}
+			if strings.HasPrefix(size, "/") {
+				s.CIDR = v
+			} else {
+				s.Hosts = v
+			}
+		}
+		var err error
+		if s.IPAssignments, err = parseCSVAssignments(cell(record, "assignments")); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", kind, line, err)
+		}
+
+		i, ok := index[parent]
+		if !ok {
+			i = len(networks)
+			index[parent] = i
+			networks = append(networks, Network{Network: parent})
+		}
+		networks[i].Subnets = append(networks[i].Subnets, s)
+	}
+	return networks, nil
+}
+
+// parseCSVAssignments parses the Assignments cell of a CSV config.
+func parseCSVAssignments(text string) ([]IPAssignment, error) {
+	var assignments []IPAssignment
+	for _, part := range strings.Split(text, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid assignment %q (use Name=Position or Name=First-Last)", part)
+		}
+		a := IPAssignment{Name: strings.TrimSpace(name)}
+		fields := strings.Fields(spec)
+		if len(fields) == 2 && strings.EqualFold(fields[1], "dhcp") {
+			a.DHCP = true
+		} else if len(fields) != 1 {
+			return nil, fmt.Errorf("invalid assignment %q (use Name=Position or Name=First-Last)", part)
+		}
+		// Positions may be negative (counted from the end of the subnet)
+		first, last, isRange := fields[0], "", false
+		if j := strings.Index(fields[0][1:], "-"); j >= 0 {
+			first, last, isRange = fields[0][:j+1], fields[0][j+2:], true
+		}
+		var err error
+		if a.Position, err = strconv.Atoi(first); err != nil {
+			return nil, fmt.Errorf("invalid position in assignment %q", part)
+		}
+		if isRange {
+			if a.EndPosition, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("invalid end position in assignment %q", part)
+			}
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, nil
+}
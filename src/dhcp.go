@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dhcpScope is the DHCP configuration derived for one planned subnet.
+type dhcpScope struct {
+	name    string
+	vlan    int
+	network string
+	mask    string
+	router  string
+	labels  []string
+	pools   []ipInterval // leasable ranges (DHCP blocks minus static assignments)
+}
+
+type ipInterval struct{ start, end uint32 }
+
+// buildDHCPScopes collects every subnet containing an assignment flagged
+// DHCP. Static assignments and reserved addresses inside a DHCP block are
+// carved out of the pool.
+func buildDHCPScopes(results []SubnetResult) ([]dhcpScope, error) {
+	var scopes []dhcpScope
+	index := make(map[string]int)
+	statics := make(map[string][]ipInterval)
+
+	for _, r := range results {
+		if !isAssignedCategory(r.Category) && r.Category != "Reserved" {
+			continue
+		}
+		start, end, err := parseIPSpan(r.IP)
+		if err != nil {
+			return nil, fmt.Errorf("subnet %s: %v", r.Name, err)
+		}
+		if !r.DHCP {
+			statics[r.Subnet] = append(statics[r.Subnet], ipInterval{start, end})
+			continue
+		}
+		i, ok := index[r.Subnet]
+		if !ok {
+			network, _, _ := strings.Cut(r.Subnet, "/")
+			scopes = append(scopes, dhcpScope{name: r.Name, vlan: r.VLAN, network: network, mask: r.Mask})
+			i = len(scopes) - 1
+			index[r.Subnet] = i
+		}
+		scopes[i].labels = append(scopes[i].labels, r.Label)
+		scopes[i].pools = append(scopes[i].pools, ipInterval{start, end})
+	}
+
+	for _, r := range results {
+		if isAssignedCategory(r.Category) && !r.DHCP && strings.EqualFold(r.Label, "Gateway") {
+			if i, ok := index[r.Subnet]; ok {
+				scopes[i].router = r.IP
+			}
+		}
+	}
+
+	for subnet, i := range index {
+		scopes[i].pools = subtractIntervals(scopes[i].pools, statics[subnet])
+		if len(scopes[i].pools) == 0 {
+			return nil, fmt.Errorf("subnet %s: DHCP range is entirely covered by static assignments", scopes[i].name)
+		}
+	}
+	return scopes, nil
+}
+
+// subtractIntervals removes every address in holes from pools and returns
+// the remaining ranges in ascending order.
+func subtractIntervals(pools, holes []ipInterval) []ipInterval {
+	sort.Slice(pools, func(i, j int) bool { return pools[i].start < pools[j].start })
+	var out []ipInterval
+	for _, p := range pools {
+		remaining := []ipInterval{p}
+		for _, h := range holes {
+			var next []ipInterval
+			for _, r := range remaining {
+				if h.end < r.start || h.start > r.end {
+					next = append(next, r)
+					continue
+				}
+				if h.start > r.start {
+					next = append(next, ipInterval{r.start, h.start - 1})
+				}
+				if h.end < r.end {
+					next = append(next, ipInterval{h.end + 1, r.end})
+				}
+			}
+			remaining = next
+		}
+		out = append(out, remaining...)
+	}
+	return out
+}
+
+// ExportDHCP writes DHCP server configuration for every subnet containing a
+// DHCP-flagged assignment. format is "powershell" (Windows DHCP Server
+// cmdlets) or "isc" (dhcpd.conf subnet stanzas); empty selects by extension.
+func ExportDHCP(results []SubnetResult, path, format string) error {
+	if format == "" {
+		format = "isc"
+		if strings.EqualFold(filepath.Ext(path), ".ps1") {
+			format = "powershell"
+		}
+	}
+	scopes, err := buildDHCPScopes(results)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	switch format {
+	case "powershell":
+		writeDHCPPowerShell(&sb, scopes)
+	case "isc":
+		writeDHCPISC(&sb, scopes)
+	default:
+		return fmt.Errorf("unknown DHCP format %q (use powershell or isc)", format)
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// writeDHCPPowerShell emits one scope per subnet spanning all DHCP blocks,
+// with exclusion ranges for static assignments and gaps between blocks.
+func writeDHCPPowerShell(sb *strings.Builder, scopes []dhcpScope) {
+	sb.WriteString("# Windows DHCP Server configuration generated by IPSubnetPlanner\n")
+	for _, s := range scopes {
+		first, last := s.pools[0].start, s.pools[len(s.pools)-1].end
+		sb.WriteString(fmt.Sprintf("\n# %s (VLAN %d): %s\n", s.name, s.vlan, strings.Join(s.labels, ", ")))
+		sb.WriteString(fmt.Sprintf("Add-DhcpServerv4Scope -Name \"%s\" -StartRange %s -EndRange %s -SubnetMask %s -State Active\n",
+			s.name, uint32ToIP(first), uint32ToIP(last), s.mask))
+		for i := 1; i < len(s.pools); i++ {
+			sb.WriteString(fmt.Sprintf("Add-DhcpServerv4ExclusionRange -ScopeId %s -StartRange %s -EndRange %s\n",
+				s.network, uint32ToIP(s.pools[i-1].end+1), uint32ToIP(s.pools[i].start-1)))
+		}
+		if s.router != "" {
+			sb.WriteString(fmt.Sprintf("Set-DhcpServerv4OptionValue -ScopeId %s -Router %s\n", s.network, s.router))
+		}
+	}
+}
+
+// writeDHCPISC emits dhcpd.conf subnet stanzas; static assignments are
+// excluded by splitting the pool into several range statements.
+func writeDHCPISC(sb *strings.Builder, scopes []dhcpScope) {
+	sb.WriteString("# ISC dhcpd configuration generated by IPSubnetPlanner\n")
+	for _, s := range scopes {
+		sb.WriteString(fmt.Sprintf("\n# %s (VLAN %d): %s\n", s.name, s.vlan, strings.Join(s.labels, ", ")))
+		sb.WriteString(fmt.Sprintf("subnet %s netmask %s {\n", s.network, s.mask))
+		if s.router != "" {
+			sb.WriteString(fmt.Sprintf("  option routers %s;\n", s.router))
+		}
+		for _, p := range s.pools {
+			sb.WriteString(fmt.Sprintf("  range %s %s;\n", uint32ToIP(p.start), uint32ToIP(p.end)))
+		}
+		sb.WriteString("}\n")
+	}
+}
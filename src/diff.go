@@ -0,0 +1,252 @@
+package main
+
+import "fmt"
+
+// SubnetChange describes a subnet that differs between two plans.
+type SubnetChange struct {
+	Name      string `json:"name"`
+	OldSubnet string `json:"oldSubnet,omitempty"`
+	NewSubnet string `json:"newSubnet,omitempty"`
+}
+
+// AssignmentChange describes a named IP assignment that was added, removed, or
+// moved to a different address. OldIP is empty for additions and NewIP is
+// empty for removals.
+type AssignmentChange struct {
+	Subnet string `json:"subnet"`
+	Label  string `json:"label"`
+	OldIP  string `json:"oldIP,omitempty"`
+	NewIP  string `json:"newIP,omitempty"`
+}
+
+// PlanDiff is the set of changes between a previous plan and a new one.
+type PlanDiff struct {
+	Added       []SubnetChange     `json:"added,omitempty"`
+	Removed     []SubnetChange     `json:"removed,omitempty"`
+	Resized     []SubnetChange     `json:"resized,omitempty"`
+	Moved       []SubnetChange     `json:"moved,omitempty"`
+	Assignments []AssignmentChange `json:"assignments,omitempty"`
+}
+
+// HasChanges reports whether the diff contains any change at all.
+func (d PlanDiff) HasChanges() bool {
+	return len(d.Added)+len(d.Removed)+len(d.Resized)+len(d.Moved)+len(d.Assignments) > 0
+}
+
+// DiffPlans compares two plans subnet by subnet, matched by parent network
+// and name (see parentKey), so equally named subnets of different parents
+// are told apart. A subnet whose prefix changed is reported as resized; one
+// that kept its prefix but got a different network address is reported as
+// moved.
+func DiffPlans(previous, current []SubnetResult) PlanDiff {
+	var d PlanDiff
+	parent := parentKey(previous, current)
+
+	oldSubnets, oldOrder := indexPlanSubnets(previous, parent)
+	newSubnets, newOrder := indexPlanSubnets(current, parent)
+
+	for _, key := range newOrder {
+		cur := newSubnets[key]
+		old, ok := oldSubnets[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, SubnetChange{Name: cur.Name, NewSubnet: cur.Subnet})
+		case old.Prefix != cur.Prefix:
+			d.Resized = append(d.Resized, SubnetChange{Name: cur.Name, OldSubnet: old.Subnet, NewSubnet: cur.Subnet})
+		case old.Subnet != cur.Subnet:
+			d.Moved = append(d.Moved, SubnetChange{Name: cur.Name, OldSubnet: old.Subnet, NewSubnet: cur.Subnet})
+		}
+	}
+	for _, key := range oldOrder {
+		if _, ok := newSubnets[key]; !ok {
+			old := oldSubnets[key]
+			d.Removed = append(d.Removed, SubnetChange{Name: old.Name, OldSubnet: old.Subnet})
+		}
+	}
+
+	oldAssign, oldKeys := indexAssignments(previous, parent)
+	newAssign, newKeys := indexAssignments(current, parent)
+	for _, key := range newKeys {
+		cur := newAssign[key]
+		old, ok := oldAssign[key]
+		if !ok {
+			d.Assignments = append(d.Assignments, AssignmentChange{Subnet: cur.Name, Label: cur.Label, NewIP: cur.IP})
+		} else if old.IP != cur.IP {
+			d.Assignments = append(d.Assignments, AssignmentChange{Subnet: cur.Name, Label: cur.Label, OldIP: old.IP, NewIP: cur.IP})
+		}
+	}
+	for _, key := range oldKeys {
+		if _, ok := newAssign[key]; !ok {
+			old := oldAssign[key]
+			d.Assignments = append(d.Assignments, AssignmentChange{Subnet: old.Name, Label: old.Label, OldIP: old.IP})
+		}
+	}
+
+	return d
+}
+
+// indexSubnets returns the first row of every planned subnet keyed by name,
+// skipping the parent network's remaining "Available" space.
+func indexSubnets(results []SubnetResult) (map[string]SubnetResult, []string) {
+	index := make(map[string]SubnetResult)
+	var order []string
+	for _, r := range results {
+		if isFreeSpaceRow(r) {
+			continue
+		}
+		if _, seen := index[r.Name]; !seen {
+			index[r.Name] = r
+			order = append(order, r.Name)
+		}
+	}
+	return index, order
+}
+
+// parentKey returns the parent network part of the keys that match rows of
+// two plans. It is empty when either plan predates the parent field, so
+// such plans still match by name.
+func parentKey(previous, current []SubnetResult) func(SubnetResult) string {
+	if hasParents(previous) && hasParents(current) {
+		return func(r SubnetResult) string { return r.Parent + "\x00" }
+	}
+	return func(SubnetResult) string { return "" }
+}
+
+func hasParents(results []SubnetResult) bool {
+	for _, r := range results {
+		if r.Parent != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// indexPlanSubnets is like indexSubnets, with keys made of parent and name.
+func indexPlanSubnets(results []SubnetResult, parent func(SubnetResult) string) (map[string]SubnetResult, []string) {
+	index := make(map[string]SubnetResult)
+	var order []string
+	for _, r := range results {
+		if isFreeSpaceRow(r) {
+			continue
+		}
+		key := parent(r) + r.Name
+		if _, seen := index[key]; !seen {
+			index[key] = r
+			order = append(order, key)
+		}
+	}
+	return index, order
+}
+
+func indexAssignments(results []SubnetResult, parent func(SubnetResult) string) (map[string]SubnetResult, []string) {
+	index := make(map[string]SubnetResult)
+	var order []string
+	for _, r := range results {
+		if !isAssignedCategory(r.Category) {
+			continue
+		}
+		key := parent(r) + r.Name + "\x00" + r.Label
+		if _, seen := index[key]; !seen {
+			index[key] = r
+			order = append(order, key)
+		}
+	}
+	return index, order
+}
+
+// isFreeSpaceRow reports whether r describes unallocated parent space rather
+// than a planned subnet.
+func isFreeSpaceRow(r SubnetResult) bool {
+	return r.Name == "Available" && r.Category == "Available" && r.VLAN == 0
+}
+
+// PrintDiff prints a human-readable change report to the console.
+func PrintDiff(d PlanDiff) {
+	fmt.Printf("\nPlan changes:\n")
+	if !d.HasChanges() {
+		fmt.Println("  No changes.")
+		return
+	}
+	for _, c := range d.Added {
+		fmt.Printf("  + added    %-25s %s\n", c.Name, c.NewSubnet)
+	}
+	for _, c := range d.Removed {
+		fmt.Printf("  - removed  %-25s %s\n", c.Name, c.OldSubnet)
+	}
+	for _, c := range d.Resized {
+		fmt.Printf("  ~ resized  %-25s %s -> %s\n", c.Name, c.OldSubnet, c.NewSubnet)
+	}
+	for _, c := range d.Moved {
+		fmt.Printf("  > moved    %-25s %s -> %s\n", c.Name, c.OldSubnet, c.NewSubnet)
+	}
+	if len(d.Assignments) > 0 {
+		fmt.Printf("\nIP assignment changes:\n")
+		for _, c := range d.Assignments {
+			fmt.Printf("  %s\n", describeAssignmentChange(c))
+		}
+	}
+}
+
+func describeAssignmentChange(c AssignmentChange) string {
+	name := c.Subnet + " / " + c.Label
+	switch {
+	case c.OldIP == "":
+		return fmt.Sprintf("+ %s: %s", name, c.NewIP)
+	case c.NewIP == "":
+		return fmt.Sprintf("- %s: %s", name, c.OldIP)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", name, c.OldIP, c.NewIP)
+	}
+}
+
+// DiffRows returns only the rows that were added, changed, or removed between
+// two plans, with Change set to "added", "changed", or "removed". Rows are
+// matched by parent network (see parentKey), subnet name, category, and
+// label; unlabeled free-space rows are additionally matched by address.
+func DiffRows(previous, current []SubnetResult) []SubnetResult {
+	parent := parentKey(previous, current)
+	oldRows := make(map[string]SubnetResult)
+	var oldKeys []string
+	for _, r := range previous {
+		key := parent(r) + rowKey(r)
+		if _, seen := oldRows[key]; !seen {
+			oldRows[key] = r
+			oldKeys = append(oldKeys, key)
+		}
+	}
+
+	var out []SubnetResult
+	seen := make(map[string]bool)
+	for _, r := range current {
+		key := parent(r) + rowKey(r)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		old, ok := oldRows[key]
+		switch {
+		case !ok:
+			r.Change = "added"
+			out = append(out, r)
+		case old.Subnet != r.Subnet || old.VLAN != r.VLAN || old.IP != r.IP || old.TotalIPs != r.TotalIPs || old.Prefix != r.Prefix || old.Mask != r.Mask:
+			r.Change = "changed"
+			out = append(out, r)
+		}
+	}
+	for _, key := range oldKeys {
+		if !seen[key] {
+			r := oldRows[key]
+			r.Change = "removed"
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func rowKey(r SubnetResult) string {
+	key := r.Name + "\x00" + r.Category + "\x00" + r.Label
+	if r.Category == "Unused" || r.Category == "Available" {
+		key += "\x00" + r.IP
+	}
+	return key
+}
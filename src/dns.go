@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var dnsInvalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// maxDNSName is the longest domain name DNS can carry, without the root dot.
+const maxDNSName = 253
+
+// dnsNameProblem returns why name cannot be published in DNS: it is longer
+// than 253 characters or has a label longer than 63. It returns "" for a
+// valid name.
+func dnsNameProblem(name string) string {
+	if len(name) > maxDNSName {
+		return fmt.Sprintf("%s is longer than %d characters", name, maxDNSName)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) > 63 {
+			return fmt.Sprintf("%s has a label longer than 63 characters", name)
+		}
+	}
+	return ""
+}
+
+// dnsLabel converts a name into a DNS label (RFC 1123 host name rules).
+func dnsLabel(name string) string {
+	l := dnsInvalidLabelChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-")
+	l = strings.Trim(l, "-")
+	if len(l) > 63 {
+		l = strings.TrimRight(l[:63], "-")
+	}
+	if l == "" {
+		l = "host"
+	}
+	return l
+}
+
+// dnsRecord is a single-address assignment published in DNS.
+type dnsRecord struct {
+	host   string
+	ip     uint32
+	prefix int    // prefix length of the subnet holding ip
+	label  string // assignment name as planned
+	subnet string // subnet name
+}
+
+// reverseZone is an in-addr.arpa zone and the PTR records it holds, keyed
+// by the owner name relative to the zone.
+type reverseZone struct {
+	name    string
+	comment string
+	ptrs    map[string]string
+}
+
+// ExportDNS writes DNS records for every single-address assignment, as BIND
+// zone files ("bind") or Windows DNS Server PowerShell commands
+// ("powershell"). An empty format picks powershell for .ps1 files and bind
+// otherwise. Names used in more than one subnet are qualified with the
+// subnet name; generated FQDNs inside domain are used as they are.
+// Assignment names that are not valid DNS labels are published in their
+// converted form with a warning; names that still clash within the zone
+// are an error and nothing is written.
+func ExportDNS(results []SubnetResult, path, domain, format string) error {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return fmt.Errorf("-exportdns requires -dnsdomain (e.g. -dnsdomain corp.example.com)")
+	}
+	if format == "" {
+		format = "bind"
+		if strings.EqualFold(filepath.Ext(path), ".ps1") {
+			format = "powershell"
+		}
+	}
+
+	records := dnsRecords(results, domain)
+	fixes, conflicts := dnsNameIssues(records, domain)
+	if len(conflicts) > 0 {
+		return fmt.Errorf("DNS names are not unique in %s:\n  %s", domain, strings.Join(conflicts, "\n  "))
+	}
+	for _, fix := range fixes {
+		fmt.Fprintf(os.Stderr, "warning: DNS: %s\n", fix)
+	}
+	switch format {
+	case "bind":
+		return writeDNSBind(records, path, domain)
+	case "powershell":
+		var sb strings.Builder
+		writeDNSPowerShell(&sb, records, domain)
+		return os.WriteFile(path, []byte(sb.String()), 0644)
+	}
+	return fmt.Errorf("unknown DNS format %q (use bind or powershell)", format)
+}
+
+// dnsRecords collects the single-address assignments with their host names,
+// relative to domain.
+func dnsRecords(results []SubnetResult, domain string) []dnsRecord {
+	counts := make(map[string]int)
+	for _, r := range results {
+		if isAssignedCategory(r.Category) && !strings.Contains(r.IP, " - ") {
+			counts[dnsLabel(r.Label)]++
+		}
+	}
+
+	var records []dnsRecord
+	for _, r := range results {
+		if !isAssignedCategory(r.Category) || strings.Contains(r.IP, " - ") {
+			continue
+		}
+		ip, _, err := parseIPSpan(r.IP)
+		if err != nil {
+			continue
+		}
+		host := dnsLabel(r.Label)
+		if counts[host] > 1 {
+			host = dnsLabel(r.Name + "-" + r.Label)
+		}
+		// Generated FQDNs inside the zone keep their name
+		if rel, ok := strings.CutSuffix(r.FQDN, "."+domain); ok {
+			host = rel
+		}
+		records = append(records, dnsRecord{host: host, ip: ip, prefix: r.Prefix, label: r.Label, subnet: r.Name})
+	}
+	return records
+}
+
+// dnsNameIssues checks the published names of records. fixes lists the
+// assignment names that were converted to become valid DNS labels (such as
+// "Web Server" to "web-server"); conflicts lists names that cannot be
+// published: one name for several addresses, or an FQDN that is too long
+// (see dnsNameProblem).
+func dnsNameIssues(records []dnsRecord, domain string) (fixes, conflicts []string) {
+	owners := make(map[string][]dnsRecord)
+	var hosts []string
+	for _, rec := range records {
+		if _, ok := owners[rec.host]; !ok {
+			hosts = append(hosts, rec.host)
+		}
+		owners[rec.host] = append(owners[rec.host], rec)
+		if want := strings.ToLower(strings.TrimSpace(rec.label)); want != dnsLabel(rec.label) {
+			fixes = append(fixes, fmt.Sprintf("subnet %s: %q is not a valid DNS label; published as %q", rec.subnet, rec.label, rec.host))
+		}
+		if problem := dnsNameProblem(rec.host + "." + domain); problem != "" {
+			conflicts = append(conflicts, problem)
+		}
+	}
+	for _, host := range hosts {
+		recs := owners[host]
+		if len(recs) < 2 {
+			continue
+		}
+		var uses []string
+		for _, rec := range recs {
+			uses = append(uses, fmt.Sprintf("%q in %s (%s)", rec.label, rec.subnet, uint32ToIP(rec.ip)))
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s is used by %s; rename the assignments", host, strings.Join(uses, ", ")))
+	}
+	return fixes, conflicts
+}
+
+// writeDNSBind writes the forward zone to path and one reverse zone file per
+// in-addr.arpa zone next to it (named db.<zone>).
+func writeDNSBind(records []dnsRecord, path, domain string) error {
+	zones := make(map[string]*reverseZone)
+	for _, rec := range records {
+		zone, owner, comment := reverseZoneFor(rec.ip, rec.prefix)
+		z, ok := zones[zone]
+		if !ok {
+			z = &reverseZone{name: zone, comment: comment, ptrs: make(map[string]string)}
+			zones[zone] = z
+		}
+		z.ptrs[owner] = rec.host + "." + domain + "."
+	}
+
+	serial := time.Now().UTC().Format("20060102") + "00"
+	var sb strings.Builder
+	writeZoneHeader(&sb, domain, domain, serial)
+	for _, rec := range records {
+		sb.WriteString(fmt.Sprintf("%-30s IN A     %s\n", rec.host, uint32ToIP(rec.ip)))
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(zones))
+	for name := range zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		z := zones[name]
+		var rb strings.Builder
+		writeZoneHeader(&rb, z.name, domain, serial)
+		if z.comment != "" {
+			rb.WriteString("; " + z.comment + "\n")
+		}
+		owners := make([]string, 0, len(z.ptrs))
+		for owner := range z.ptrs {
+			owners = append(owners, owner)
+		}
+		sort.Slice(owners, func(i, j int) bool { return reverseOwnerLess(owners[i], owners[j]) })
+		for _, owner := range owners {
+			rb.WriteString(fmt.Sprintf("%-30s IN PTR   %s\n", owner, z.ptrs[owner]))
+		}
+		file := filepath.Join(filepath.Dir(path), "db."+z.name)
+		if err := os.WriteFile(file, []byte(rb.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDNSPowerShell emits Add-DnsServerResourceRecordA/Ptr commands. PTR
+// records go into octet-aligned reverse zones (Windows DNS does not host
+// RFC 2317 zones by default), which are created first if missing.
+func writeDNSPowerShell(sb *strings.Builder, records []dnsRecord, domain string) {
+	sb.WriteString("# Windows DNS Server records generated by IPSubnetPlanner\n")
+	sb.WriteString(fmt.Sprintf("$ZoneName = \"%s\"\n", domain))
+
+	type ptr struct{ zone, owner, target string }
+	var ptrs []ptr
+	zoneNetworks := make(map[string]string)
+	var zones []string
+	for _, rec := range records {
+		prefix := rec.prefix
+		if prefix > 24 {
+			prefix = 24
+		}
+		zone, owner, _ := reverseZoneFor(rec.ip, prefix)
+		if _, ok := zoneNetworks[zone]; !ok {
+			bits := 8 * (strings.Count(zone, ".") - 1)
+			zoneNetworks[zone] = fmt.Sprintf("%s/%d", uint32ToIP(rec.ip&^(1<<(32-bits)-1)), bits)
+			zones = append(zones, zone)
+		}
+		ptrs = append(ptrs, ptr{zone, owner, rec.host + "." + domain})
+	}
+
+	sb.WriteString("\n# Forward records\n")
+	for _, rec := range records {
+		sb.WriteString(fmt.Sprintf("Add-DnsServerResourceRecordA -ZoneName $ZoneName -Name \"%s\" -IPv4Address %s\n", rec.host, uint32ToIP(rec.ip)))
+	}
+	if len(zones) == 0 {
+		return
+	}
+	sb.WriteString("\n# Reverse lookup zones\n")
+	for _, zone := range zones {
+		sb.WriteString(fmt.Sprintf("if (-not (Get-DnsServerZone -Name \"%s\" -ErrorAction SilentlyContinue)) { Add-DnsServerPrimaryZone -NetworkId \"%s\" -ReplicationScope Forest }\n", zone, zoneNetworks[zone]))
+	}
+	sb.WriteString("\n# Reverse records\n")
+	for _, p := range ptrs {
+		sb.WriteString(fmt.Sprintf("Add-DnsServerResourceRecordPtr -ZoneName \"%s\" -Name \"%s\" -PtrDomainName \"%s\"\n", p.zone, p.owner, p.target))
+	}
+}
+
+// reverseZoneFor returns the reverse zone holding ip within a subnet of the
+// given prefix, and the PTR owner name relative to that zone. Subnets on an
+// octet boundary (or larger) use the enclosing classful zone; smaller
+// subnets get an RFC 2317 classless zone that the /24 owner delegates.
+func reverseZoneFor(ip uint32, prefix int) (zone, owner, comment string) {
+	octets := []uint32{ip >> 24, ip >> 16 & 0xff, ip >> 8 & 0xff, ip & 0xff}
+	if prefix > 24 {
+		start := octets[3] &^ (1<<(32-prefix) - 1)
+		zone = fmt.Sprintf("%d-%d.%d.%d.%d.in-addr.arpa", start, prefix, octets[2], octets[1], octets[0])
+		parent := fmt.Sprintf("%d.%d.%d.in-addr.arpa", octets[2], octets[1], octets[0])
+		comment = fmt.Sprintf("RFC 2317 classless zone: delegate from %s with CNAMEs (e.g. %d IN CNAME %d.%s.)", parent, octets[3], octets[3], zone)
+		return zone, fmt.Sprint(octets[3]), comment
+	}
+	zoneOctets := (prefix + 7) / 8
+	if zoneOctets == 0 {
+		zoneOctets = 1
+	}
+	var zoneParts, ownerParts []string
+	for i := zoneOctets - 1; i >= 0; i-- {
+		zoneParts = append(zoneParts, fmt.Sprint(octets[i]))
+	}
+	for i := 3; i >= zoneOctets; i-- {
+		ownerParts = append(ownerParts, fmt.Sprint(octets[i]))
+	}
+	return strings.Join(zoneParts, ".") + ".in-addr.arpa", strings.Join(ownerParts, "."), ""
+}
+
+// reverseOwnerLess orders owner names of one zone (such as "5.1" and
+// "7.0") numerically by address.
+func reverseOwnerLess(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := len(pa) - 1; i >= 0 && i < len(pb); i-- {
+		var x, y int
+		fmt.Sscanf(pa[i], "%d", &x)
+		fmt.Sscanf(pb[i], "%d", &y)
+		if x != y {
+			return x < y
+		}
+	}
+	return len(pa) < len(pb)
+}
+
+func writeZoneHeader(sb *strings.Builder, origin, domain, serial string) {
+	sb.WriteString("; Zone generated by IPSubnetPlanner; adjust the SOA/NS names to your name servers\n")
+	sb.WriteString(fmt.Sprintf("$ORIGIN %s.\n", origin))
+	sb.WriteString("$TTL 3600\n")
+	sb.WriteString(fmt.Sprintf("@ IN SOA ns1.%s. hostmaster.%s. ( %s 3600 900 604800 300 )\n", domain, domain, serial))
+	sb.WriteString(fmt.Sprintf("@ IN NS  ns1.%s.\n\n", domain))
+}
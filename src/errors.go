@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Exit codes. exitExportFailure, exitLintScore, exitCapacityThreshold and
+// exitPanic are declared next to the features that return them.
+const (
+	exitFailure          = 1 // unclassified error
+	exitUsage            = 2 // invalid or missing command-line flags
+	exitConfigError      = 6 // config, plan or sidecar file could not be read or parsed
+	exitValidationError  = 7 // config parsed but is not a valid plan
+	exitCapacityExceeded = 8 // requirements do not fit in their parent network
+)
+
+// errorCodes are the stable names printed with -errors json.
+var errorCodes = map[int]string{
+	exitFailure:           "error",
+	exitUsage:             "usage",
+	exitExportFailure:     "export_failed",
+	exitLintScore:         "lint_score_below_minimum",
+	exitCapacityThreshold: "capacity_threshold",
+	exitConfigError:       "config_error",
+	exitValidationError:   "validation_error",
+	exitCapacityExceeded:  "capacity_exceeded",
+	exitPanic:             "internal_error",
+}
+
+// errorFormat is "text" (default) or "json", set by -errors.
+var errorFormat = "text"
+
+// cliError is the -errors json form of a fatal error, written to stderr as
+// a single line.
+type cliError struct {
+	Code     string   `json:"code"`
+	ExitCode int      `json:"exitCode"`
+	Message  string   `json:"message"`
+	Details  []string `json:"details,omitempty"`
+}
+
+// exitWithError reports msg in the selected error format and exits with code.
+// details are only included in JSON; text output has already shown them.
+func exitWithError(code int, msg string, details ...string) {
+	if errorFormat == "json" {
+		data, _ := json.Marshal(struct {
+			Error cliError `json:"error"`
+		}{cliError{Code: errorCodes[code], ExitCode: code, Message: msg, Details: details}})
+		fmt.Fprintln(os.Stderr, string(data))
+	} else {
+		fmt.Fprintf(os.Stderr, "%s\n", msg)
+	}
+	os.Exit(code)
+}
+
+// planningExitCode classifies a planning error.
+func planningExitCode(err error) int {
+	var noSpace *NoSpaceError
+	if errors.As(err, &noSpace) {
+		return exitCapacityExceeded
+	}
+	return exitValidationError
+}
+
+// errorFormatArg finds -errors in args before flag parsing, so errors raised
+// while validating flags already use the requested format.
+func errorFormatArg(args []string) string {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "errors" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "errors=") {
+			return strings.TrimPrefix(name, "errors=")
+		}
+	}
+	return "text"
+}
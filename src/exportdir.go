@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exportDirWriters are the formats -exportdir can write per network.
+var exportDirWriters = map[string]func([]SubnetResult, string) error{
+	"json": ExportJSON,
+	"csv":  ExportCSV,
+	"md":   ExportMarkdown,
+	"svg":  ExportSVG,
+}
+
+// ExportDir writes one file per parent network and format into dir, named
+// after the network (10.0.0.0/16 becomes 10.0.0.0-16.md), plus an index.md
+// linking them. formats is a comma-separated list of json, csv, md and svg.
+func ExportDir(results []SubnetResult, dir, formats string) error {
+	var names []string
+	for _, name := range strings.Split(formats, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := exportDirWriters[name]; !ok {
+			return fmt.Errorf("unknown -exportdir format %q (use json, csv, md or svg)", name)
+		}
+		names = append(names, name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var parents []string
+	byParent := make(map[string][]SubnetResult)
+	for _, r := range results {
+		if r.Parent == "" {
+			continue
+		}
+		if _, ok := byParent[r.Parent]; !ok {
+			parents = append(parents, r.Parent)
+		}
+		byParent[r.Parent] = append(byParent[r.Parent], r)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Subnet Plan Index\n\n")
+	sb.WriteString("| Network | Subnets | Files |\n")
+	sb.WriteString("|---------|---------|-------|\n")
+	for _, parent := range parents {
+		rows := byParent[parent]
+		base := strings.ReplaceAll(parent, "/", "-")
+		var links []string
+		for _, name := range names {
+			file := base + "." + name
+			if err := exportDirWriters[name](rows, filepath.Join(dir, file)); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+			links = append(links, fmt.Sprintf("[%s](%s)", name, file))
+		}
+		subnets := 0
+		for _, r := range rows {
+			if r.Category == "Network" {
+				subnets++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %s |\n", parent, subnets, strings.Join(links, " ")))
+	}
+	return os.WriteFile(filepath.Join(dir, "index.md"), []byte(sb.String()), 0644)
+}
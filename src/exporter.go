@@ -3,11 +3,24 @@ package main
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// stdoutPath is the export path that streams to standard output.
+const stdoutPath = "-"
+
+// streamOutput receives exports written to stdoutPath. It keeps the real
+// stdout when -quiet silences the console output.
+var streamOutput io.Writer = os.Stdout
+
 // ExportJSON exports results to JSON file
 func ExportJSON(results []SubnetResult, filepath string) error {
 	data, err := json.MarshalIndent(results, "", "  ")
@@ -15,39 +28,58 @@ func ExportJSON(results []SubnetResult, filepath string) error {
 		return fmt.Errorf("failed to marshal JSON: %v", err)
 	}
 
+	if filepath == stdoutPath {
+		_, err := streamOutput.Write(append(data, '\n'))
+		return err
+	}
 	return os.WriteFile(filepath, data, 0644)
 }
 
 // ExportCSV exports results to CSV file
 func ExportCSV(results []SubnetResult, filepath string) error {
+	if filepath == stdoutPath {
+		return writeCSV(streamOutput, results)
+	}
 	file, err := os.Create(filepath)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV file: %v", err)
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
+	return writeCSV(file, results)
+}
+
+// ExportCSVColumns exports results to a CSV file with only the selected
+// columns, in their order.
+func ExportCSVColumns(results []SubnetResult, filepath string, cols []outputColumn) error {
+	if filepath == stdoutPath {
+		return writeColumnsCSV(streamOutput, results, cols)
+	}
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %v", err)
+	}
+	defer file.Close()
+
+	return writeColumnsCSV(file, results, cols)
+}
+
+// writeCSV writes results as CSV to w.
+func writeCSV(w io.Writer, results []SubnetResult) error {
+	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
-	// Write header matching expected format
-	header := []string{"Subnet", "Name", "Vlan", "Label", "IP", "TotalIPs", "Prefix", "Mask", "Category"}
+	// Write header matching expected format; optional columns (Change,
+	// Status, Description, Tags) are only added when some row uses them
+	extra := optionalColumns(results)
+	header := append(append([]string{}, csvHeader...), extra...)
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %v", err)
 	}
 
 	// Write data
 	for _, result := range results {
-		row := []string{
-			result.Subnet,
-			result.Name,
-			fmt.Sprintf("%d", result.VLAN),
-			result.Label,
-			result.IP,
-			fmt.Sprintf("%d", result.TotalIPs),
-			fmt.Sprintf("/%d", result.Prefix),
-			result.Mask,
-			result.Category,
-		}
+		row := append(csvRow(result), optionalCells(result, extra)...)
 		if err := writer.Write(row); err != nil {
 			return fmt.Errorf("failed to write CSV row: %v", err)
 		}
@@ -56,95 +88,648 @@ func ExportCSV(results []SubnetResult, filepath string) error {
 	return nil
 }
 
+// csvHeader is the column layout shared by ExportCSV and ExportCSVAppend.
+var csvHeader = []string{"Subnet", "Name", "Vlan", "Label", "IP", "TotalIPs", "Prefix", "Mask", "Category"}
+
+func csvRow(result SubnetResult) []string {
+	return []string{
+		result.Subnet,
+		result.Name,
+		fmt.Sprintf("%d", result.VLAN),
+		result.Label,
+		result.IP,
+		fmt.Sprintf("%d", result.TotalIPs),
+		fmt.Sprintf("/%d", result.Prefix),
+		result.Mask,
+		result.Category,
+	}
+}
+
+// ExportCSVAppend merges results into an existing CSV keyed by
+// Subnet+Label+Category (and IP for free-space rows, which share their
+// labels). Matching rows have their known columns updated in place, new rows
+// are appended, and planner rows that are no longer in the plan are removed.
+// Rows and extra columns the planner does not know about (such as
+// hand-maintained comments) are preserved. A missing file is created.
+func ExportCSVAppend(results []SubnetResult, filepath string) error {
+	existing, err := os.Open(filepath)
+	if os.IsNotExist(err) {
+		return ExportCSV(results, filepath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file: %v", err)
+	}
+	reader := csv.NewReader(existing)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	existing.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read existing CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return ExportCSV(results, filepath)
+	}
+
+	// Map known columns to their position in the existing header; columns
+	// missing from an older file are appended. Optional columns the file
+	// already has stay, and are cleared on planner rows that no longer use
+	// them.
+	header := records[0]
+	colIndex := make(map[string]int)
+	for i, name := range header {
+		colIndex[name] = i
+	}
+	for _, name := range append(append([]string{}, csvHeader...), optionalColumns(results)...) {
+		if _, ok := colIndex[name]; !ok {
+			colIndex[name] = len(header)
+			header = append(header, name)
+		}
+	}
+	var extra []string
+	for _, name := range allOptionalColumns {
+		if _, ok := colIndex[name]; ok {
+			extra = append(extra, name)
+		}
+	}
+	columns := append(append([]string{}, csvHeader...), extra...)
+	cell := func(row []string, name string) string { return csvCell(row, colIndex[name]) }
+	planner := make(map[string]bool)
+	for _, category := range planCategories {
+		planner[category] = true
+	}
+
+	// Planner rows are matched in order, so repeated keys pair up one to
+	// one; hand-added rows (any other category) are never matched.
+	rows := records[1:]
+	keep := make([]bool, len(rows))
+	rowIndex := make(map[string][]int)
+	for i, row := range rows {
+		if !planner[cell(row, "Category")] {
+			keep[i] = true
+			continue
+		}
+		key := appendKey(cell(row, "Subnet"), cell(row, "Label"), cell(row, "Category"), cell(row, "IP"))
+		rowIndex[key] = append(rowIndex[key], i)
+	}
+
+	for _, result := range results {
+		key := appendKey(result.Subnet, result.Label, result.Category, result.IP)
+		var i int
+		if matches := rowIndex[key]; len(matches) > 0 {
+			i, rowIndex[key] = matches[0], matches[1:]
+		} else {
+			rows = append(rows, make([]string, len(header)))
+			keep = append(keep, false)
+			i = len(rows) - 1
+		}
+		keep[i] = true
+		for len(rows[i]) < len(header) {
+			rows[i] = append(rows[i], "")
+		}
+		for c, value := range append(csvRow(result), optionalCells(result, extra)...) {
+			rows[i][colIndex[columns[c]]] = value
+		}
+	}
+
+	merged := rows[:0]
+	for i, row := range rows {
+		if keep[i] {
+			merged = append(merged, row)
+		}
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to create CSV file: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	if err := writer.WriteAll(merged); err != nil {
+		return fmt.Errorf("failed to write CSV row: %v", err)
+	}
+	return nil
+}
+
+// allOptionalColumns lists every column optionalColumns can add, in order.
+var allOptionalColumns = []string{"Change", "Status", "FQDN", "Description", "Tags"}
+
+// appendKey identifies a row of an appended CSV, whatever -csvipformat
+// the file and the new rows were written with. Free-space rows share their
+// labels, so their IP tells them apart.
+func appendKey(subnet, label, category, ip string) string {
+	key := unpadOctets(strings.TrimSpace(subnet)) + "\x00" + label + "\x00" + category
+	if category == "Unused" || category == "Available" {
+		key += "\x00" + unpadOctets(strings.TrimSpace(ip))
+	}
+	return key
+}
+
+// optionalColumns lists the extra CSV columns present in results.
+func optionalColumns(results []SubnetResult) []string {
+	var cols []string
+	if hasChanges(results) {
+		cols = append(cols, "Change")
+	}
+	if hasStatus(results) {
+		cols = append(cols, "Status")
+	}
+	return append(cols, metadataColumns(results)...)
+}
+
+// metadataColumns lists the FQDN, Description and Tags columns present in
+// results.
+func metadataColumns(results []SubnetResult) []string {
+	var fqdn, description, tags bool
+	for _, r := range results {
+		fqdn = fqdn || r.FQDN != ""
+		description = description || r.Description != ""
+		tags = tags || len(r.Tags) > 0
+	}
+	var cols []string
+	if fqdn {
+		cols = append(cols, "FQDN")
+	}
+	if description {
+		cols = append(cols, "Description")
+	}
+	if tags {
+		cols = append(cols, "Tags")
+	}
+	return cols
+}
+
+// formatTags renders tags as "key=value" pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// hasTaggedSubnets reports whether any subnet has the tag key.
+func hasTaggedSubnets(networks []Network, key string) bool {
+	for _, n := range networks {
+		for _, s := range n.Subnets {
+			if _, ok := s.Tags[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func optionalCells(result SubnetResult, cols []string) []string {
+	cells := make([]string, len(cols))
+	for i, col := range cols {
+		switch col {
+		case "Change":
+			cells[i] = result.Change
+		case "Status":
+			cells[i] = result.Status
+		case "FQDN":
+			cells[i] = result.FQDN
+		case "Description":
+			cells[i] = result.Description
+		case "Tags":
+			cells[i] = formatTags(result.Tags)
+		}
+	}
+	return cells
+}
+
+// hasStatus reports whether any row carries a lifecycle status.
+func hasStatus(results []SubnetResult) bool {
+	for _, r := range results {
+		if r.Status != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasChanges reports whether results came from a differential export.
+func hasChanges(results []SubnetResult) bool {
+	for _, r := range results {
+		if r.Change != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func csvCell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
 // ExportMarkdown exports results to Markdown table
 func ExportMarkdown(results []SubnetResult, filepath string) error {
-	var sb strings.Builder
+	return ExportMarkdownAnnotated(results, nil, filepath)
+}
+
+// ExportMarkdownAnnotated exports the Markdown table followed by review
+// notes for subnets listed in annotations.
+func ExportMarkdownAnnotated(results []SubnetResult, annotations map[string]Annotation, filepath string) error {
+	return os.WriteFile(filepath, []byte(renderMarkdown(results, annotations)), 0644)
+}
 
-	// Write header
+// renderMarkdown renders the Markdown export: an overview table with one
+// row per subnet, then a section per subnet listing its rows like the CSV
+// and console do. Sections are grouped by parent network, each followed by
+// the network's free space and summary routes.
+func renderMarkdown(results []SubnetResult, annotations map[string]Annotation) string {
+	type section struct {
+		rows []SubnetResult
+	}
+	type network struct {
+		parent        string
+		subnets       []*section
+		free, summary []SubnetResult
+	}
+	var networks []*network
+	byParent := make(map[string]*network)
+	bySubnet := make(map[string]*section)
+	for _, r := range results {
+		n, ok := byParent[r.Parent]
+		if !ok {
+			n = &network{parent: r.Parent}
+			byParent[r.Parent] = n
+			networks = append(networks, n)
+		}
+		switch {
+		case isFreeSpaceRow(r):
+			n.free = append(n.free, r)
+		case r.Category == "Summary":
+			n.summary = append(n.summary, r)
+		default:
+			key := r.Parent + " " + r.Subnet + " " + r.Name
+			s, ok := bySubnet[key]
+			if !ok {
+				s = &section{}
+				bySubnet[key] = s
+				n.subnets = append(n.subnets, s)
+			}
+			s.rows = append(s.rows, r)
+		}
+	}
+
+	var sb strings.Builder
 	sb.WriteString("# Subnet Plan\n\n")
-	sb.WriteString("| Name | VLAN | Subnet | Prefix | Network | Broadcast | First Host | Last Host | Usable Hosts | Total IPs |\n")
-	sb.WriteString("|------|------|--------|--------|---------|-----------|------------|-----------|--------------|----------|\n")
+	if len(bySubnet) == 0 {
+		sb.WriteString("No subnets planned.\n")
+	} else {
+		sb.WriteString("| Name | VLAN | Subnet | Mask | Host Range | Gateway | Usable Hosts | Total IPs |\n")
+		sb.WriteString("|------|------|--------|------|------------|---------|--------------|-----------|\n")
+		for _, n := range networks {
+			for _, s := range n.subnets {
+				r := s.rows[0]
+				first, last := hostRange(r.Subnet)
+				hosts := ""
+				if first != "" {
+					hosts = first + " – " + last
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %d | %d |\n",
+					markdownName(r), markdownVLAN(r.VLAN), r.Subnet, r.Mask, hosts, markdownGateway(s.rows),
+					usableHostCount(r.Prefix), uint64(1)<<(32-r.Prefix)))
+			}
+		}
+	}
 
-	// Write data
-	for _, result := range results {
-		sb.WriteString(fmt.Sprintf("| %s | %d | %s | %d | %s | %s | %s | %s | %d | %d |\n",
-			result.Name,
-			result.VLAN,
-			result.Subnet,
-			result.Prefix,
-			result.Network,
-			result.Broadcast,
-			result.FirstHost,
-			result.LastHost,
-			result.UsableHosts,
-			result.TotalIPs,
-		))
+	// Per-subnet sections use the columns of the CSV export
+	columns := optionalColumns(results)
+	header := "| IP | Label | Category | Total IPs |"
+	rule := "|----|-------|----------|-----------|"
+	for _, col := range columns {
+		header += " " + col + " |"
+		rule += strings.Repeat("-", len(col)+2) + "|"
+	}
+	for _, n := range networks {
+		level := "##"
+		if n.parent != "" {
+			level = "###"
+			sb.WriteString(fmt.Sprintf("\n## %s\n", n.parent))
+		}
+		for _, s := range n.subnets {
+			r := s.rows[0]
+			title := markdownName(r)
+			if r.VLAN > 0 {
+				title += fmt.Sprintf(" (VLAN %d)", r.VLAN)
+			}
+			sb.WriteString(fmt.Sprintf("\n%s %s — %s\n\n", level, title, r.Subnet))
+			sb.WriteString(header + "\n" + rule + "\n")
+			for _, row := range s.rows {
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s | %d |", row.IP, markdownCell(tableLabel(row)), row.Category, row.TotalIPs))
+				for _, cell := range optionalCells(row, columns) {
+					sb.WriteString(" " + markdownCell(cell) + " |")
+				}
+				sb.WriteString("\n")
+			}
+		}
+		if len(n.free) > 0 {
+			sb.WriteString(fmt.Sprintf("\n%s Free Space\n\n", level))
+			sb.WriteString("| Subnet | Range | Total IPs |\n|--------|-------|-----------|\n")
+			for _, r := range n.free {
+				first, last := blockRange(r.Subnet)
+				sb.WriteString(fmt.Sprintf("| %s | %s – %s | %d |\n", r.Subnet, first, last, uint64(1)<<(32-r.Prefix)))
+			}
+		}
+		if len(n.summary) > 0 {
+			sb.WriteString(fmt.Sprintf("\n%s Summary Routes\n\n", level))
+			sb.WriteString("| Route | Range | Total IPs |\n|-------|-------|-----------|\n")
+			for _, r := range n.summary {
+				sb.WriteString(fmt.Sprintf("| %s | %s | %d |\n", r.Subnet, r.IP, r.TotalIPs))
+			}
+		}
 	}
 
-	return os.WriteFile(filepath, []byte(sb.String()), 0644)
+	writeMarkdownAnnotations(&sb, results, annotations)
+
+	return sb.String()
+}
+
+// markdownName is a subnet's name, struck through with its status when it
+// is decommissioned or planned.
+func markdownName(r SubnetResult) string {
+	name := markdownCell(r.Name)
+	if r.Status != "" {
+		name = fmt.Sprintf("~~%s~~ (%s)", name, strings.ToLower(r.Status))
+	}
+	return name
+}
+
+func markdownVLAN(vlan int) string {
+	if vlan > 0 {
+		return fmt.Sprint(vlan)
+	}
+	return "-"
+}
+
+// markdownGateway is the address of a subnet's Gateway assignment, if any.
+func markdownGateway(rows []SubnetResult) string {
+	for _, r := range rows {
+		if isAssignedCategory(r.Category) && strings.EqualFold(r.Label, "Gateway") {
+			return r.IP
+		}
+	}
+	return ""
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
 }
 
-// PrintTable prints results as a formatted table to console
+// hostRange returns the first and last host address of a CIDR block; /31
+// and /32 blocks use all their addresses. Both are empty for an invalid
+// block.
+func hostRange(cidr string) (first, last string) {
+	return cidrRange(cidr, true)
+}
+
+// blockRange returns the first and last address of a CIDR block.
+func blockRange(cidr string) (first, last string) {
+	return cidrRange(cidr, false)
+}
+
+func cidrRange(cidr string, hosts bool) (first, last string) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", ""
+	}
+	ones, _ := ipNet.Mask.Size()
+	start := ipToUint32(ipNet.IP)
+	end := start + uint32(uint64(1)<<(32-ones)-1)
+	if hosts && ones < 31 {
+		start, end = start+1, end-1
+	}
+	return uint32ToIP(start).String(), uint32ToIP(end).String()
+}
+
+// PrintTable prints results as a formatted table to console,
+// with colors and column widths suited to the terminal.
 func PrintTable(results []SubnetResult) {
+	printTable(os.Stdout, results, consoleStyle())
+}
+
+// PrintTableTo writes the console table to w, without colors and with the
+// default column widths.
+func PrintTableTo(w io.Writer, results []SubnetResult) {
+	printTable(w, results, tableStyle{})
+}
+
+func printTable(w io.Writer, results []SubnetResult, style tableStyle) {
 	if len(results) == 0 {
-		fmt.Println("No subnets generated.")
+		fmt.Fprintln(w, "No subnets generated.")
 		return
 	}
 
-	fmt.Printf("\nGenerated %d subnet entries:\n\n", len(results))
+	if hasChanges(results) {
+		fmt.Fprintf(w, "\nChanged %d subnet entries:\n\n", len(results))
+	} else {
+		fmt.Fprintf(w, "\nGenerated %d subnet entries:\n\n", len(results))
+	}
+
+	withStatus := hasStatus(results)
+	// Width of the Subnet, VLAN, TotalIPs, Prefix and Category columns
+	// and of the Change and Status columns shown
+	fixed := 66
+	if hasChanges(results) {
+		fixed += 9
+	}
+	if withStatus {
+		longest := len("Status")
+		for _, r := range results {
+			longest = max(longest, len(r.Status))
+		}
+		fixed += 1 + longest
+	}
+	nameW, labelW, ipW := style.columnWidths(results, fixed)
 
 	// Print header matching CSV format
-	fmt.Printf("%-20s %-25s %-6s %-20s %-15s %-10s %-8s %-15s\n",
-		"Subnet", "Name", "VLAN", "Label", "IP", "TotalIPs", "Prefix", "Category")
-	fmt.Printf("%-20s %-25s %-6s %-20s %-15s %-10s %-8s %-15s\n",
-		"------", "----", "----", "-----", "--", "--------", "------", "--------")
+	if hasChanges(results) {
+		fmt.Fprintf(w, "%-8s ", "Change")
+	}
+	fmt.Fprintf(w, "%-20s %-*s %-6s %-*s %-*s %-10s %-8s %-15s",
+		"Subnet", nameW, "Name", "VLAN", labelW, "Label", ipW, "IP", "TotalIPs", "Prefix", "Category")
+	if withStatus {
+		fmt.Fprintf(w, " %s", "Status")
+	}
+	fmt.Fprintln(w)
+	if hasChanges(results) {
+		fmt.Fprintf(w, "%-8s ", "------")
+	}
+	fmt.Fprintf(w, "%-20s %-*s %-6s %-*s %-*s %-10s %-8s %-15s",
+		"------", nameW, "----", "----", labelW, "-----", ipW, "--", "--------", "------", "--------")
+	if withStatus {
+		fmt.Fprintf(w, " %s", "------")
+	}
+	fmt.Fprintln(w)
 
 	// Print all results in the same format as CSV
-	for _, result := range results {
+	style.window.printRows(w, results, func(result SubnetResult) {
 		vlanStr := "-"
 		if result.VLAN > 0 {
 			vlanStr = fmt.Sprintf("%d", result.VLAN)
 		}
 
-		// Handle empty/default values
-		label := result.Label
-		if label == "" {
-			switch result.Category {
-			case "Network":
-				label = "Network"
-			case "Available":
-				if strings.Contains(result.IP, " - ") {
-					label = "Available Range"
-				} else {
-					label = "Available"
-				}
-			case "Broadcast":
-				label = "Broadcast"
-			case "Assignment":
-				label = result.Label // Keep original assignment name
-			case "Unused":
-				if strings.Contains(result.IP, " - ") {
-					label = "Unused Range"
-				} else {
-					label = "Unused"
-				}
-			default:
-				label = result.Category
-			}
-		}
+		label := tableLabel(result)
 
-		fmt.Printf("%-20s %-25s %-6s %-20s %-15s %-10d %-8s %-15s\n",
+		color := ""
+		if style.color {
+			color = rowColor(result)
+		}
+		fmt.Fprint(w, color)
+		if result.Change != "" {
+			fmt.Fprintf(w, "%-8s ", result.Change)
+		}
+		fmt.Fprintf(w, "%-20s %-*s %-6s %-*s %-*s %-10d %-8s %-15s",
 			result.Subnet,
-			truncate(result.Name, 25),
+			nameW, truncate(result.Name, nameW),
 			vlanStr,
-			truncate(label, 20),
-			truncate(result.IP, 15),
+			labelW, truncate(label, labelW),
+			ipW, truncate(result.IP, ipW),
 			result.TotalIPs,
 			fmt.Sprintf("/%d", result.Prefix),
 			result.Category)
+		if withStatus {
+			fmt.Fprintf(w, " %s", result.Status)
+		}
+		if color != "" {
+			fmt.Fprint(w, ansiReset)
+		}
+		fmt.Fprintln(w)
+	})
+
+	fmt.Fprintf(w, "\nThis matches the detailed format in export files.\n")
+}
+
+// PrintGroupedTo writes the console table grouped by parent network and
+// subnet: a header line per subnet (CIDR, mask, VLAN, usable hosts) above
+// its rows, the network's free space, and a totals line per network and
+// for the whole plan.
+func PrintGroupedTo(w io.Writer, results []SubnetResult) {
+	if len(results) == 0 {
+		fmt.Fprintln(w, "No subnets generated.")
+		return
+	}
+	fmt.Fprintf(w, "\nGenerated %d subnet entries:\n", len(results))
+
+	parents, byNetwork, _, _ := collectUsage(results)
+	var subnets, assignments int
+	var total, allocated uint64
+	for _, parent := range parents {
+		header := parent
+		if header == "" {
+			header = "(no parent network)"
+		}
+		fmt.Fprintf(w, "\n%s\n", header)
+		count, assigned := 0, 0
+		// Rows are grouped by subnet, in the order subnets first appear, so
+		// sorting by category does not split a subnet into several blocks
+		var keys []string
+		rowsByKey := make(map[string][]SubnetResult)
+		var free []SubnetResult
+		for _, r := range results {
+			if r.Parent != parent || r.Category == "Summary" {
+				continue
+			}
+			if isFreeSpaceRow(r) {
+				free = append(free, r)
+				continue
+			}
+			key := r.Subnet + " " + r.Name
+			if _, ok := rowsByKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			rowsByKey[key] = append(rowsByKey[key], r)
+		}
+		for _, key := range keys {
+			rows := rowsByKey[key]
+			first := rows[0]
+			count++
+			vlan := "no VLAN"
+			if first.VLAN > 0 {
+				vlan = fmt.Sprintf("VLAN %d", first.VLAN)
+			}
+			fmt.Fprintf(w, "\n  %s  %s  mask %s  %s  %d usable", first.Name, first.Subnet, first.Mask, vlan, usableHostCount(first.Prefix))
+			if first.Status != "" {
+				fmt.Fprintf(w, "  [%s]", first.Status)
+			}
+			fmt.Fprintln(w)
+			for _, r := range rows {
+				if isAssignedCategory(r.Category) {
+					assigned++
+				}
+				change := ""
+				if r.Change != "" {
+					change = r.Change + " "
+				}
+				fmt.Fprintf(w, "    %s%-20s %-31s %-10d %s\n", change, truncate(tableLabel(r), 20), r.IP, r.TotalIPs, r.Category)
+			}
+		}
+		if len(free) > 0 {
+			fmt.Fprintf(w, "\n  Free space\n")
+			for _, r := range free {
+				first, last := blockRange(r.Subnet)
+				fmt.Fprintf(w, "    %-20s %-31s %d\n", r.Subnet, first+" - "+last, uint64(1)<<(32-r.Prefix))
+			}
+		}
+		u := byNetwork[parent]
+		fmt.Fprintf(w, "\n  Total: %s, %s, %d of %d addresses allocated (%s)\n",
+			plural(count, "subnet"), plural(assigned, "assignment"), u.allocated(), u.total, percentText(u.allocated(), u.total))
+		subnets += count
+		assignments += assigned
+		total += u.total
+		allocated += u.allocated()
+	}
+	if len(parents) > 1 {
+		fmt.Fprintf(w, "\nTotal: %s, %s, %s, %d of %d addresses allocated (%s)\n",
+			plural(len(parents), "network"), plural(subnets, "subnet"), plural(assignments, "assignment"), allocated, total, percentText(allocated, total))
 	}
+}
 
-	fmt.Printf("\nThis matches the detailed format in export files.\n")
+// tableLabel is the console label of a row: the assignment name, or a
+// description of the row when it has none.
+func tableLabel(result SubnetResult) string {
+	label := result.Label
+	if label == "" {
+		switch result.Category {
+		case "Network":
+			label = "Network"
+		case "Available":
+			if strings.Contains(result.IP, " - ") {
+				label = "Available Range"
+			} else {
+				label = "Available"
+			}
+		case "Broadcast":
+			label = "Broadcast"
+		case "Assignment", "Reserved", "Virtual IP", "Router":
+			label = result.Label // Keep original assignment name
+		case "Unused":
+			if strings.Contains(result.IP, ", ") {
+				label = result.Label // collapsed ranges
+			} else if strings.Contains(result.IP, " - ") {
+				label = "Unused Range"
+			} else {
+				label = "Unused"
+			}
+		default:
+			label = result.Category
+		}
+	}
+	return label
 }
 
 func truncate(s string, max int) string {
@@ -153,3 +738,31 @@ func truncate(s string, max int) string {
 	}
 	return s[:max-3] + "..."
 }
+
+// exportWithRetry runs an export, retrying transient I/O failures (common on
+// SMB/NFS network shares) with a short linear backoff.
+func exportWithRetry(write func() error, retries int) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = write()
+		if err == nil || attempt >= retries || !isTransientIOError(err) {
+			return err
+		}
+		time.Sleep(time.Duration(attempt+1) * exportRetryDelay)
+	}
+}
+
+// exportRetryDelay is the base backoff between export retries.
+var exportRetryDelay = 200 * time.Millisecond
+
+// isTransientIOError reports whether err looks like a temporary file-system
+// condition worth retrying rather than a permanent failure such as a missing
+// directory or denied permission.
+func isTransientIOError(err error) bool {
+	for _, errno := range []syscall.Errno{syscall.EAGAIN, syscall.EBUSY, syscall.EINTR, syscall.ETIMEDOUT, syscall.ESTALE, syscall.EIO} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
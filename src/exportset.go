@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// exportSuffixes are the file name endings -export gives each format. The
+// format names are the -export<name> flags without their prefix.
+var exportSuffixes = map[string]string{
+	"json":        ".json",
+	"csv":         ".csv",
+	"md":          ".md",
+	"html":        ".html",
+	"dhcp":        "-dhcp.conf",
+	"bicep":       ".bicep",
+	"aws":         "-aws.tf",
+	"ansible":     "-inventory.yml",
+	"dns":         ".zone",
+	"ticket":      "-ticket.txt",
+	"lint":        "-lint.json",
+	"circuits":    "-circuits.csv",
+	"metrics":     ".prom",
+	"svg":         ".svg",
+	"routes":      "-routes.sh",
+	"topology":    ".mmd",
+	"fragments":   "-subnets",
+	"k8s":         "-k8s.yaml",
+	"config":      "-config.json",
+	"dir":         "-networks",
+	"whereabouts": "-nad.yaml",
+	"view":        "-view.json",
+	"graph":       "-graph.json",
+	"impact":      "-impact.md",
+}
+
+// parseExportSet parses an -export value of the form "formats[:dir]", where
+// formats is "all" or a comma-separated list such as "json,csv,md". all
+// reports whether "all" was given, so callers can skip formats that need
+// input the run does not have.
+func parseExportSet(spec string) (formats []string, dir string, all bool, err error) {
+	list := spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		list, dir = spec[:i], spec[i+1:]
+	}
+	if list == "all" {
+		return exportSetNames(), dir, true, nil
+	}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := exportSuffixes[name]; !ok {
+			return nil, "", false, fmt.Errorf("unknown export format %q in -export %q (use all or a list of %s)", name, spec, strings.Join(exportSetNames(), ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			formats = append(formats, name)
+		}
+	}
+	return formats, dir, false, nil
+}
+
+// exportSetPaths maps the -export<name> flag of every format to its output
+// file: dir/base plus the format's suffix.
+func exportSetPaths(formats []string, dir, base string) map[string]string {
+	paths := make(map[string]string)
+	for _, name := range formats {
+		paths["export"+name] = filepath.Join(dir, base+exportSuffixes[name])
+	}
+	return paths
+}
+
+// exportSetNames lists the formats in -exportX flag order.
+func exportSetNames() []string {
+	var names []string
+	for _, f := range fileExportFlags {
+		names = append(names, strings.TrimPrefix(f, "export"))
+	}
+	return names
+}
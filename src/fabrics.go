@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// fabricBits returns how many prefix bits a subnet's fabrics need: the
+// fabrics share one aligned block twice (or four times) the subnet's size,
+// so the copies are adjacent and summarize to a single route.
+func fabricBits(subnet Subnet) (int, error) {
+	n := len(subnet.Fabrics)
+	if n == 0 {
+		return 0, nil
+	}
+	if n != 2 && n != 4 {
+		return 0, fmt.Errorf("subnet %s: fabrics must list 2 or 4 fabric names, not %d", subnet.Name, n)
+	}
+	seen := make(map[string]bool)
+	for _, f := range subnet.Fabrics {
+		if f == "" || seen[f] {
+			return 0, fmt.Errorf("subnet %s: fabric names must be unique and non-empty", subnet.Name)
+		}
+		seen[f] = true
+	}
+	return bits.TrailingZeros(uint(n)), nil
+}
+
+// fabricSubnets expands a subnet into one copy per fabric, named
+// "<name>-<fabric>" with mirrored assignments and a "fabric" tag. VLANs
+// count up from the subnet's VLAN. A subnet without fabrics is returned as is.
+func fabricSubnets(subnet Subnet) []Subnet {
+	if len(subnet.Fabrics) == 0 {
+		return []Subnet{subnet}
+	}
+	out := make([]Subnet, len(subnet.Fabrics))
+	for i, f := range subnet.Fabrics {
+		s := subnet
+		s.Name = subnet.Name + "-" + f
+		s.Fabrics = nil
+		if s.VLAN > 0 {
+			s.VLAN += i
+		}
+		s.Tags = map[string]string{"fabric": f}
+		for k, v := range subnet.Tags {
+			s.Tags[k] = v
+		}
+		out[i] = s
+	}
+	return out
+}
+
+// subnetNames returns the names a subnet's rows carry in the output.
+func subnetNames(subnet Subnet) []string {
+	var names []string
+	for _, s := range fabricSubnets(subnet) {
+		names = append(names, s.Name)
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// fallbackDirEnv sets the default of -fallback-dir, so wrapper scripts and
+// containers can choose the fallback once.
+const fallbackDirEnv = "IPSUBNETPLANNER_FALLBACK_DIR"
+
+// defaultFallbackDir is -fallback-dir's default: the environment's choice,
+// or none, since a fallback must be asked for.
+func defaultFallbackDir() string {
+	if dir := os.Getenv(fallbackDirEnv); dir != "" {
+		return dir
+	}
+	return "none"
+}
+
+// resolveFallbackDir turns a -fallback-dir value into a directory; "none"
+// (no fallback) gives "". "temp" creates a new private directory in the
+// system temp directory, so other users cannot prepare or read it.
+func resolveFallbackDir(value string) (string, error) {
+	switch value {
+	case "", "none":
+		return "", nil
+	case "temp":
+		return os.MkdirTemp("", "ipsubnetplanner-")
+	}
+	return value, nil
+}
+
+// isReadOnlyError reports whether err means the file system refuses writes:
+// a read-only mount or missing permissions.
+func isReadOnlyError(err error) bool {
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS)
+}
+
+// unwritableTarget reports whether an export to path failed with err because
+// its directory cannot be written. The directory is created again to tell a
+// read-only parent apart from other failures, since writing into a directory
+// that could not be created only reports that it does not exist.
+func unwritableTarget(path string, err error) bool {
+	if isReadOnlyError(err) {
+		return true
+	}
+	if dirErr := os.MkdirAll(filepath.Dir(path), 0755); dirErr != nil {
+		return isReadOnlyError(dirErr)
+	}
+	return false
+}
+
+// fallbackPath is where an export to path goes in the fallback directory:
+// the same relative path, or the absolute path below dir for targets
+// outside the working directory, so two exports never share a file.
+func fallbackPath(dir, path string) string {
+	if !filepath.IsLocal(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = strings.TrimPrefix(abs, filepath.VolumeName(abs))
+		}
+	}
+	return filepath.Join(dir, path)
+}
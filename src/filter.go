@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// filterFields are the row fields a -filter expression can compare; tag.X
+// reads the tag X.
+var filterFields = map[string]func(SubnetResult) string{
+	"name":        func(r SubnetResult) string { return r.Name },
+	"vlan":        func(r SubnetResult) string { return strconv.Itoa(r.VLAN) },
+	"subnet":      func(r SubnetResult) string { return r.Subnet },
+	"prefix":      func(r SubnetResult) string { return strconv.Itoa(r.Prefix) },
+	"label":       func(r SubnetResult) string { return r.Label },
+	"ip":          func(r SubnetResult) string { return r.IP },
+	"category":    func(r SubnetResult) string { return r.Category },
+	"parent":      func(r SubnetResult) string { return r.Parent },
+	"totalips":    func(r SubnetResult) string { return strconv.Itoa(r.TotalIPs) },
+	"usablehosts": func(r SubnetResult) string { return strconv.Itoa(r.UsableHosts) },
+	"dhcp":        func(r SubnetResult) string { return strconv.FormatBool(r.DHCP) },
+	"status":      func(r SubnetResult) string { return r.Status },
+	"change":      func(r SubnetResult) string { return r.Change },
+	"description": func(r SubnetResult) string { return r.Description },
+	"fqdn":        func(r SubnetResult) string { return r.FQDN },
+}
+
+// rowFilter is a compiled -filter expression.
+type rowFilter func(SubnetResult) bool
+
+// compileFilter parses a filter expression such as
+//
+//	category==Assignment && vlan in (100,110)
+//
+// Comparisons are field op value with op one of ==, !=, <, <=, >, >= or ~
+// (contains), or field in (v1, v2, ...). They combine with &&, ||, ! and
+// parentheses. Values may be quoted; strings compare case-insensitively
+// and numbers numerically.
+func compileFilter(expr string) (rowFilter, error) {
+	tokens, err := filterTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid -filter %q: %v", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid -filter %q: unexpected %q", expr, p.tokens[p.pos].text)
+	}
+	return f, nil
+}
+
+// planCategories are the row categories a plan can contain.
+var planCategories = []string{"Network", "Assignment", "Reserved", "Virtual IP", "Router", "Available", "Unused", "Broadcast", "Summary"}
+
+// categoryFilter keeps rows whose category is in include (all if empty)
+// and not in exclude; both are comma-separated category names, matched
+// case-insensitively. It returns nil when both are empty.
+func categoryFilter(include, exclude string) (rowFilter, error) {
+	parse := func(flagName, list string) (map[string]bool, error) {
+		set := make(map[string]bool)
+		for _, name := range strings.Split(list, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			known := false
+			for _, c := range planCategories {
+				if strings.EqualFold(c, name) {
+					set[c], known = true, true
+				}
+			}
+			if !known {
+				return nil, fmt.Errorf("unknown category %q in -%s (use %s)", name, flagName, strings.Join(planCategories, ", "))
+			}
+		}
+		return set, nil
+	}
+	in, err := parse("include-categories", include)
+	if err != nil {
+		return nil, err
+	}
+	out, err := parse("exclude-categories", exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(in) == 0 && len(out) == 0 {
+		return nil, nil
+	}
+	return func(r SubnetResult) bool {
+		return (len(in) == 0 || in[r.Category]) && !out[r.Category]
+	}, nil
+}
+
+// andFilters combines filters that must all match; nil filters are skipped.
+func andFilters(filters ...rowFilter) rowFilter {
+	var active []rowFilter
+	for _, f := range filters {
+		if f != nil {
+			active = append(active, f)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return func(r SubnetResult) bool {
+		for _, f := range active {
+			if !f(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterResults keeps the rows matching f.
+func FilterResults(results []SubnetResult, f rowFilter) []SubnetResult {
+	var out []SubnetResult
+	for _, r := range results {
+		if f(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+type filterToken struct {
+	text   string
+	quoted bool // a quoted value, never an operator
+}
+
+// filterTokens splits expr into words, quoted strings, operators,
+// parentheses and commas.
+func filterTokens(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid -filter %q: unterminated string", expr)
+			}
+			tokens = append(tokens, filterToken{text: expr[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.ContainsRune("()!,<>=~&|", rune(c)):
+			op := string(c)
+			if i+1 < len(expr) {
+				switch two := expr[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "&" || op == "|" || op == "=" {
+				return nil, fmt.Errorf("invalid -filter %q: use %s%s", expr, op, op)
+			}
+			tokens = append(tokens, filterToken{text: op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t()!,<>=~&|\"'", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{text: expr[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *filterParser) accept(op string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && p.tokens[p.pos].text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) next() (filterToken, error) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) or() (rowFilter, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r SubnetResult) bool { return l(r) || right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (rowFilter, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r SubnetResult) bool { return l(r) && right(r) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (rowFilter, error) {
+	if p.accept("!") {
+		f, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(r SubnetResult) bool { return !f(r) }, nil
+	}
+	if p.accept("(") {
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return f, nil
+	}
+	return p.comparison()
+}
+
+func (p *filterParser) comparison() (rowFilter, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	field, err := filterField(tok.text)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos].text, "in") && !p.tokens[p.pos].quoted {
+		p.pos++
+		if !p.accept("(") {
+			return nil, fmt.Errorf("expected ( after in")
+		}
+		var values []string
+		for {
+			v, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v.text)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return nil, fmt.Errorf("expected , or ) in list")
+			}
+		}
+		return func(r SubnetResult) bool {
+			got := field(r)
+			for _, v := range values {
+				if compareFilterValues(got, v) == 0 {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted {
+		return nil, fmt.Errorf("expected an operator after %s, got %q", tok.text, op.text)
+	}
+	switch op.text {
+	case "==":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) == 0 }, nil
+	case "!=":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) != 0 }, nil
+	case "<":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) < 0 }, nil
+	case "<=":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) <= 0 }, nil
+	case ">":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) > 0 }, nil
+	case ">=":
+		return func(r SubnetResult) bool { return compareFilterValues(field(r), value.text) >= 0 }, nil
+	case "~":
+		v := strings.ToLower(value.text)
+		return func(r SubnetResult) bool { return strings.Contains(strings.ToLower(field(r)), v) }, nil
+	}
+	return nil, fmt.Errorf("expected an operator after %s, got %q", tok.text, op.text)
+}
+
+// filterField resolves a field name, including tag.X.
+func filterField(name string) (func(SubnetResult) string, error) {
+	if tag, ok := strings.CutPrefix(name, "tag."); ok && tag != "" {
+		return func(r SubnetResult) string { return r.Tags[tag] }, nil
+	}
+	if f, ok := filterFields[strings.ToLower(name)]; ok {
+		return f, nil
+	}
+	var names []string
+	for n := range filterFields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown field %q (use %s or tag.<key>)", name, strings.Join(names, ", "))
+}
+
+// compareFilterValues compares numerically when both values are numbers and
+// case-insensitively otherwise.
+func compareFilterValues(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FitReport describes what still fits in one parent network's free space.
+type FitReport struct {
+	Network string `json:"network"`
+	Free    uint64 `json:"freeAddresses"`
+	Largest int    `json:"largestPrefix,omitempty"` // prefix of the largest free block; 0 if full
+	Prefix  int    `json:"prefix,omitempty"`        // the size asked about
+	Fits    uint64 `json:"fits"`                    // how many subnets of Prefix fit
+}
+
+// FitCapacity reports, per parent network, the free addresses, the largest
+// subnet that still fits, and how many subnets of prefix fit (prefix 0 skips
+// the count). Free space rows are aligned blocks, so a /q block holds
+// 2^(prefix-q) subnets of the given prefix.
+func FitCapacity(results []SubnetResult, prefix int) []FitReport {
+	var reports []FitReport
+	index := make(map[string]int)
+	for _, r := range results {
+		if r.Category == "Summary" {
+			continue
+		}
+		i, ok := index[r.Parent]
+		if !ok {
+			i = len(reports)
+			index[r.Parent] = i
+			reports = append(reports, FitReport{Network: r.Parent, Prefix: prefix})
+		}
+		if !isFreeSpaceRow(r) {
+			continue
+		}
+		rep := &reports[i]
+		rep.Free += uint64(1) << (32 - r.Prefix)
+		if rep.Largest == 0 || r.Prefix < rep.Largest {
+			rep.Largest = r.Prefix
+		}
+		if prefix > 0 && prefix >= r.Prefix {
+			rep.Fits += uint64(1) << (prefix - r.Prefix)
+		}
+	}
+	return reports
+}
+
+// PrintFitCapacity prints the capacity report with a total over all networks.
+func PrintFitCapacity(w io.Writer, reports []FitReport, prefix int) {
+	var total uint64
+	for _, r := range reports {
+		largest := "none (full)"
+		if r.Largest > 0 {
+			largest = fmt.Sprintf("/%d", r.Largest)
+		}
+		fmt.Fprintf(w, "%-20s %10d free addresses, largest free block %s", r.Network, r.Free, largest)
+		if prefix > 0 {
+			fmt.Fprintf(w, ", %d x /%d fit", r.Fits, prefix)
+		}
+		fmt.Fprintln(w)
+		total += r.Fits
+	}
+	if prefix > 0 && len(reports) > 1 {
+		fmt.Fprintf(w, "Total: %d x /%d fit\n", total, prefix)
+	}
+}
+
+// runCapacity implements the "capacity" subcommand.
+func runCapacity(args []string) int {
+	fs := flag.NewFlagSet("capacity", flag.ExitOnError)
+	network := fs.String("network", "", "Empty parent network to check (e.g. 10.0.0.0/22)")
+	input := fs.String("input", "", "Config file whose plan's remaining free space is checked")
+	cidr := fs.Int("cidr", 0, "Count how many subnets of this prefix fit")
+	hosts := fs.Int("hosts", 0, "Count how many subnets for this many hosts fit")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	vars := &varFlag{}
+	fs.Var(vars, "var", "Set a value for ${name} in the config, as name=value (repeatable)")
+	fs.StringVar(&vars.file, "var-file", "", "File of values for ${NAME} in the config: a JSON object or NAME=value lines")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner capacity (-network CIDR | -input config.json) [-cidr N | -hosts N] [-json]\n\n")
+		fmt.Fprintf(os.Stderr, "Reports how many subnets of a size fit and the largest subnet that still fits.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if (*network == "") == (*input == "") || (*cidr > 0 && *hosts > 0) || fs.NArg() > 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	prefix := *cidr
+	if *hosts > 0 {
+		prefix = calculatePrefixFromHosts(*hosts)
+	}
+	if prefix < 0 || prefix > 32 {
+		fmt.Fprintf(os.Stderr, "capacity: invalid prefix /%d\n", prefix)
+		return exitUsage
+	}
+
+	networks := []Network{{Network: *network}}
+	if *input != "" {
+		data, err := os.ReadFile(*input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config file: %v\n", err)
+			return exitConfigError
+		}
+		if data, err = substituteVars(*input, data, vars); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitConfigError
+		}
+		cfg, err := loadConfigFile(*input, data, "")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitConfigError
+		}
+		for _, w := range cfg.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s (ignored)\n", w)
+		}
+		networks = cfg.Networks
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "planning error: %v\n", err)
+		return planningExitCode(err)
+	}
+
+	reports := FitCapacity(results, prefix)
+	if *asJSON {
+		data, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		PrintFitCapacity(os.Stdout, reports, prefix)
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+)
+
+// formatConfig rewrites a config into canonical form: keys in the order of
+// the config structs, two-space indentation, CIDRs with host bits cleared,
+// and, unless sortKey is "" or "input", subnets sorted per network by
+// "name", "vlan", or "size" (largest first). warnings lists every CIDR that
+// was changed.
+func formatConfig(data []byte, sortKey string) (formatted []byte, warnings []string, err error) {
+	if _, stripped := stripJSONC(data); stripped {
+		warnings = append(warnings, "comments and trailing commas are not kept in the formatted config")
+	}
+	data = configText(data)
+	cfg, shape, err := decodeConfigRaw(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkKnownFields(data); err != nil {
+		return nil, nil, err
+	}
+
+	normalize := func(what, cidr string) string {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil || ip.Equal(ipNet.IP) {
+			return cidr
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: %s has host bits set, rewritten as %s", what, cidr, ipNet))
+		return ipNet.String()
+	}
+	lists := [][]Network{cfg.Networks}
+	for _, env := range sortedKeys(cfg.Environments) {
+		lists = append(lists, cfg.Environments[env])
+	}
+	for _, networks := range lists {
+		for i := range networks {
+			n := &networks[i]
+			n.Network = normalize("network "+n.Network, n.Network)
+			for j, pool := range n.Pools {
+				n.Pools[j] = normalize("pool "+pool, pool)
+			}
+			for j := range n.Subnets {
+				s := &n.Subnets[j]
+				s.Address = normalize("subnet "+s.Name, s.Address)
+			}
+			if err := sortSubnets(n.Subnets, sortKey); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for i := range cfg.Circuits {
+		c := &cfg.Circuits[i]
+		c.Block = normalize("circuit "+c.Name, c.Block)
+	}
+	for i := range cfg.Wireless {
+		w := &cfg.Wireless[i]
+		w.Network = normalize("wireless network "+w.Network, w.Network)
+	}
+	for i := range cfg.Sites {
+		p := &cfg.Sites[i]
+		p.Supernet = normalize("site supernet "+p.Supernet, p.Supernet)
+		for j := range p.Sites {
+			site := &p.Sites[j]
+			site.Network = normalize("site "+site.Name, site.Network)
+		}
+	}
+
+	formatted, err = encodeConfig(cfg, shape)
+	return formatted, warnings, err
+}
+
+// checkKnownFields rejects configs with keys the planner does not know, which
+// would otherwise be dropped silently when the config is re-encoded.
+func checkKnownFields(data []byte) error {
+	if unknown, _ := validateConfigSchema(data); len(unknown) > 0 {
+		return fmt.Errorf("%s (remove or rename it; formatting would drop it)", unknown[0])
+	}
+	return nil
+}
+
+// sortSubnets orders subnets in place by key; ties keep their config order.
+func sortSubnets(subnets []Subnet, key string) error {
+	var less func(a, b Subnet) bool
+	switch key {
+	case "", "input":
+		return nil
+	case "name":
+		less = func(a, b Subnet) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "vlan":
+		less = func(a, b Subnet) bool { return a.VLAN < b.VLAN }
+	case "size":
+		prefix := func(s Subnet) int {
+			p, err := Planner{}.subnetPrefix(s)
+			bits, fabricErr := fabricBits(s)
+			if err != nil || fabricErr != nil {
+				return 33
+			}
+			return p - bits
+		}
+		less = func(a, b Subnet) bool { return prefix(a) < prefix(b) }
+	default:
+		return fmt.Errorf("unknown sort key %q (use input, name, vlan or size)", key)
+	}
+	sort.SliceStable(subnets, func(i, j int) bool { return less(subnets[i], subnets[j]) })
+	return nil
+}
+
+// runFormat implements the "fmt" subcommand.
+func runFormat(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := fs.Bool("w", false, "Write the result back to each file instead of printing it")
+	check := fs.Bool("check", false, "List files that are not formatted and exit with 1 if there are any")
+	sortKey := fs.String("sort", "input", "Order subnets within each network by: input, name, vlan or size")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner fmt [-w | -check] [-sort input|name|vlan|size] config.json...\n\n")
+		fmt.Fprintf(os.Stderr, "Rewrites configs in canonical form (key order, indentation, CIDRs without host bits).\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	code := 0
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config file: %v\n", err)
+			code = exitConfigError
+			continue
+		}
+		formatted, warnings, err := formatConfig(data, *sortKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			code = exitConfigError
+			continue
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", path, w)
+		}
+		switch {
+		case *check:
+			if !bytes.Equal(data, formatted) {
+				fmt.Println(path)
+				if code == 0 {
+					code = exitFailure
+				}
+			}
+		case *write:
+			if bytes.Equal(data, formatted) {
+				continue
+			}
+			if err := os.WriteFile(path, formatted, 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing %s: %v\n", path, err)
+				code = exitFailure
+			}
+		default:
+			os.Stdout.Write(formatted)
+		}
+	}
+	return code
+}
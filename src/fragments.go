@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// subnetFragment is the content of one per-subnet file of -exportfragments.
+type subnetFragment struct {
+	Name        string            `json:"name"`
+	VLAN        int               `json:"vlan,omitempty"`
+	Subnet      string            `json:"subnet"`
+	Prefix      int               `json:"prefix"`
+	Mask        string            `json:"mask"`
+	Parent      string            `json:"parent"`
+	Gateway     string            `json:"gateway,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Rows        []SubnetResult    `json:"rows"`
+}
+
+// fragmentManifest lists the fragments the last -exportfragments run wrote,
+// one path per line relative to the export directory.
+const fragmentManifest = ".fragments"
+
+// ExportFragments writes one file per subnet to dir/<parent>/<name>.json (or
+// .yaml when format is "yaml"), so GitOps pipelines can reconcile each
+// subnet on its own. Fragments the previous run wrote that are not part of
+// the plan any more are removed, so the tree mirrors the plan; the run's
+// files are recorded in dir/.fragments, and other files are never touched.
+func ExportFragments(results []SubnetResult, dir, format string) error {
+	ext := ".json"
+	switch format {
+	case "", "json":
+	case "yaml", "yml":
+		ext = ".yaml"
+	default:
+		return fmt.Errorf("unknown fragment format %q (use json or yaml)", format)
+	}
+
+	fragments, paths := subnetFragments(results)
+	written := make(map[string]bool)
+	var manifest strings.Builder
+	for i, f := range fragments {
+		rel := filepath.ToSlash(paths[i] + ext)
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		var data []byte
+		if ext == ".json" {
+			data, _ = json.MarshalIndent(f, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data = []byte(fragmentYAML(f))
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		written[rel] = true
+		manifest.WriteString(rel + "\n")
+	}
+
+	// Remove fragments of deleted subnets, but only ones this tool wrote
+	manifestPath := filepath.Join(dir, fragmentManifest)
+	if previous, err := os.ReadFile(manifestPath); err == nil {
+		for _, rel := range strings.Split(string(previous), "\n") {
+			if rel == "" || written[rel] || !filepath.IsLocal(filepath.FromSlash(rel)) {
+				continue
+			}
+			path := filepath.Join(dir, filepath.FromSlash(rel))
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			// Drop the network directory once its last fragment is gone
+			_ = os.Remove(filepath.Dir(path))
+		}
+	}
+	return os.WriteFile(manifestPath, []byte(manifest.String()), 0644)
+}
+
+// subnetFragments groups the rows of every planned subnet and returns each
+// fragment with its file path (without extension) relative to the export
+// directory: the parent network, then the subnet name.
+func subnetFragments(results []SubnetResult) ([]subnetFragment, []string) {
+	var fragments []subnetFragment
+	var paths []string
+	index := make(map[string]int)
+	used := make(map[string]int)
+	for _, r := range results {
+		if isFreeSpaceRow(r) || r.Category == "Summary" || r.Subnet == "" {
+			continue
+		}
+		key := r.Parent + " " + r.Subnet
+		i, ok := index[key]
+		if !ok {
+			i = len(fragments)
+			index[key] = i
+			fragments = append(fragments, subnetFragment{
+				Name: r.Name, VLAN: r.VLAN, Subnet: r.Subnet, Prefix: r.Prefix, Mask: r.Mask, Parent: r.Parent,
+			})
+			path := filepath.Join(dnsLabel(strings.ReplaceAll(r.Parent, "/", "-")), dnsLabel(r.Name))
+			if used[path]++; used[path] > 1 {
+				path = fmt.Sprintf("%s-%d", path, used[path])
+			}
+			paths = append(paths, path)
+		}
+		f := &fragments[i]
+		if r.Category == "Network" {
+			f.Description, f.Tags = r.Description, r.Tags
+		}
+		if isAssignedCategory(r.Category) && strings.EqualFold(r.Label, "Gateway") {
+			f.Gateway = r.IP
+		}
+		f.Rows = append(f.Rows, r)
+	}
+	return fragments, paths
+}
+
+// fragmentYAML renders a fragment as YAML.
+func fragmentYAML(f subnetFragment) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("name: %s\n", yamlString(f.Name)))
+	if f.VLAN > 0 {
+		sb.WriteString(fmt.Sprintf("vlan: %d\n", f.VLAN))
+	}
+	sb.WriteString(fmt.Sprintf("subnet: %s\n", yamlString(f.Subnet)))
+	sb.WriteString(fmt.Sprintf("prefix: %d\n", f.Prefix))
+	sb.WriteString(fmt.Sprintf("mask: %s\n", yamlString(f.Mask)))
+	sb.WriteString(fmt.Sprintf("parent: %s\n", yamlString(f.Parent)))
+	if f.Gateway != "" {
+		sb.WriteString(fmt.Sprintf("gateway: %s\n", yamlString(f.Gateway)))
+	}
+	if f.Description != "" {
+		sb.WriteString(fmt.Sprintf("description: %s\n", yamlString(f.Description)))
+	}
+	if len(f.Tags) > 0 {
+		sb.WriteString("tags:\n")
+		keys := make([]string, 0, len(f.Tags))
+		for k := range f.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", yamlString(k), yamlString(f.Tags[k])))
+		}
+	}
+	sb.WriteString("rows:\n")
+	for _, r := range f.Rows {
+		sb.WriteString(fmt.Sprintf("  - label: %s\n", yamlString(r.Label)))
+		sb.WriteString(fmt.Sprintf("    ip: %s\n", yamlString(r.IP)))
+		sb.WriteString(fmt.Sprintf("    category: %s\n", yamlString(r.Category)))
+		sb.WriteString(fmt.Sprintf("    totalIPs: %d\n", r.TotalIPs))
+		if r.DHCP {
+			sb.WriteString("    dhcp: true\n")
+		}
+		if r.FQDN != "" {
+			sb.WriteString(fmt.Sprintf("    fqdn: %s\n", yamlString(r.FQDN)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gatewayAssignment converts a gateway convention (first, last, offset:N)
+// into the position of the Gateway assignment.
+func gatewayAssignment(convention string) (IPAssignment, error) {
+	gateway := IPAssignment{Name: "Gateway"}
+	switch {
+	case convention == "first":
+		gateway.Position = 1
+	case convention == "last":
+		gateway.Position = -1
+	case strings.HasPrefix(convention, "offset:"):
+		n, err := strconv.Atoi(strings.TrimPrefix(convention, "offset:"))
+		if err != nil || n == 0 {
+			return gateway, fmt.Errorf("invalid gateway offset %q (use offset:N with N != 0)", convention)
+		}
+		gateway.Position = n
+	default:
+		return gateway, fmt.Errorf("invalid gateway %q (use first, last, offset:N or none)", convention)
+	}
+	return gateway, nil
+}
+
+// applyGateway adds the Gateway assignment required by the subnet's (or
+// else the network's) convention. Subnets that already name a Gateway, opt
+// out with "none", are too small to route (/31, /32), or are point-to-point
+// links without their own gateway setting are left unchanged.
+func applyGateway(subnet Subnet, networkDefault string, prefix int) (Subnet, error) {
+	convention := subnet.Gateway
+	if convention == "" && !subnet.P2P {
+		convention = networkDefault
+	}
+	if convention == "" || convention == "none" || prefix > 30 {
+		return subnet, nil
+	}
+	for _, a := range subnet.IPAssignments {
+		if strings.EqualFold(a.Name, "Gateway") {
+			return subnet, nil
+		}
+	}
+	gateway, err := gatewayAssignment(convention)
+	if err != nil {
+		return subnet, fmt.Errorf("subnet %s: %v", subnet.Name, err)
+	}
+	subnet.IPAssignments = append([]IPAssignment{gateway}, subnet.IPAssignments...)
+	return subnet, nil
+}
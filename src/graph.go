@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// deviceTag names the device an assignment belongs to, so that one device
+// with addresses in several subnets becomes a single graph node.
+const deviceTag = "device"
+
+// Graph is the plan as a property graph: parent networks contain subnets,
+// and devices (from single-address assignments) connect to subnets.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a network, subnet or device.
+type GraphNode struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"` // Network, Subnet or Device
+	Label      string            `json:"label"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// GraphEdge is a CONTAINS (network to subnet) or CONNECTS (device to
+// subnet) relationship.
+type GraphEdge struct {
+	Source     string            `json:"source"`
+	Target     string            `json:"target"`
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// BuildGraph derives the graph from planned results, skipping free space
+// and summaries. Devices are identified by their "device" tag, then by
+// FQDN, and otherwise by their name within the subnet.
+func BuildGraph(results []SubnetResult) Graph {
+	g := Graph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	seen := make(map[string]bool)
+	node := func(n GraphNode) {
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			g.Nodes = append(g.Nodes, n)
+		}
+	}
+	for _, r := range results {
+		if r.Category == "Summary" || isFreeSpaceRow(r) {
+			continue
+		}
+		networkID := "network:" + r.Parent
+		subnetID := "subnet:" + r.Subnet
+		if !seen[subnetID] {
+			node(GraphNode{ID: networkID, Type: "Network", Label: r.Parent, Properties: map[string]string{"cidr": r.Parent}})
+			props := map[string]string{"name": r.Name, "cidr": r.Subnet, "mask": r.Mask}
+			if r.VLAN > 0 {
+				props["vlan"] = strconv.Itoa(r.VLAN)
+			}
+			if r.Status != "" {
+				props["status"] = r.Status
+			}
+			node(GraphNode{ID: subnetID, Type: "Subnet", Label: r.Name, Properties: props})
+			g.Edges = append(g.Edges, GraphEdge{Source: networkID, Target: subnetID, Type: "CONTAINS"})
+		}
+		if !isAssignedCategory(r.Category) || r.TotalIPs != 1 {
+			continue
+		}
+		deviceID := "device:" + r.Subnet + "/" + r.Label
+		props := map[string]string{"name": r.Label}
+		if name := r.Tags[deviceTag]; name != "" {
+			deviceID, props["name"] = "device:"+name, name
+		} else if r.FQDN != "" {
+			deviceID = "device:" + r.FQDN
+		}
+		if r.FQDN != "" {
+			props["fqdn"] = r.FQDN
+		}
+		node(GraphNode{ID: deviceID, Type: "Device", Label: props["name"], Properties: props})
+		edge := map[string]string{"ip": r.IP, "assignment": r.Label}
+		if r.DHCP {
+			edge["dhcp"] = "true"
+		}
+		g.Edges = append(g.Edges, GraphEdge{Source: deviceID, Target: subnetID, Type: "CONNECTS", Properties: edge})
+	}
+	return g
+}
+
+// ExportGraph writes the plan's graph. format is "json" or "graphml"
+// (for yEd, Gephi and Neo4j's apoc.import.graphml); empty selects graphml
+// for .graphml/.xml files and json otherwise.
+func ExportGraph(results []SubnetResult, path, format string) error {
+	if format == "" {
+		format = "json"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".graphml" || ext == ".xml" {
+			format = "graphml"
+		}
+	}
+	g := BuildGraph(results)
+	var data []byte
+	switch format {
+	case "json":
+		var err error
+		data, err = json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal graph: %v", err)
+		}
+		data = append(data, '\n')
+	case "graphml":
+		data = []byte(renderGraphML(g))
+	default:
+		return fmt.Errorf("unknown graph format %q (use json or graphml)", format)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// renderGraphML renders the graph as GraphML. Every property becomes a
+// string attribute; "labels" on nodes and "label" on edges carry the
+// node and relationship types the way Neo4j imports them.
+func renderGraphML(g Graph) string {
+	nodeKeys := map[string]bool{}
+	edgeKeys := map[string]bool{}
+	for _, n := range g.Nodes {
+		for k := range n.Properties {
+			nodeKeys[k] = true
+		}
+	}
+	for _, e := range g.Edges {
+		for k := range e.Properties {
+			edgeKeys[k] = true
+		}
+	}
+
+	e := html.EscapeString
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	sb.WriteString("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	sb.WriteString("  <key id=\"labels\" for=\"node\" attr.name=\"labels\" attr.type=\"string\"/>\n")
+	for _, k := range sortedKeys(nodeKeys) {
+		fmt.Fprintf(&sb, "  <key id=\"n_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", e(k), e(k))
+	}
+	sb.WriteString("  <key id=\"label\" for=\"edge\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	for _, k := range sortedKeys(edgeKeys) {
+		fmt.Fprintf(&sb, "  <key id=\"e_%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"string\"/>\n", e(k), e(k))
+	}
+	sb.WriteString("  <graph id=\"plan\" edgedefault=\"directed\">\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "    <node id=\"%s\" labels=\":%s\">\n", e(n.ID), e(n.Type))
+		fmt.Fprintf(&sb, "      <data key=\"labels\">:%s</data>\n", e(n.Type))
+		for _, k := range sortedKeys(n.Properties) {
+			fmt.Fprintf(&sb, "      <data key=\"n_%s\">%s</data>\n", e(k), e(n.Properties[k]))
+		}
+		sb.WriteString("    </node>\n")
+	}
+	for i, ed := range g.Edges {
+		fmt.Fprintf(&sb, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\" label=\"%s\">\n", i, e(ed.Source), e(ed.Target), e(ed.Type))
+		fmt.Fprintf(&sb, "      <data key=\"label\">%s</data>\n", e(ed.Type))
+		for _, k := range sortedKeys(ed.Properties) {
+			fmt.Fprintf(&sb, "      <data key=\"e_%s\">%s</data>\n", e(k), e(ed.Properties[k]))
+		}
+		sb.WriteString("    </edge>\n")
+	}
+	sb.WriteString("  </graph>\n</graphml>\n")
+	return sb.String()
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// systemDirs are the directories exports are never written into unless
+// -unsafe-exports is given.
+var systemDirs = []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/sbin", "/sys", "/usr", "/System", "/Library"}
+
+// exportGuard holds the safety checks applied to every export target before
+// it is written.
+type exportGuard struct {
+	input     string                   // config file the plan was read from; never overwritten
+	newerDays int                      // confirm before overwriting targets this many days newer than input; 0 disables
+	unsafe    bool                     // allow the filesystem root and system directories
+	force     bool                     // overwrite newer targets without asking
+	confirm   func(prompt string) bool // asks the user; nil when not interactive
+}
+
+// check returns an error if writing path would clobber the input config,
+// land in the filesystem root or a system directory, or overwrite a file
+// that is much newer than the input without confirmation.
+func (g exportGuard) check(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !g.unsafe {
+		if dir := filepath.Dir(abs); dir == filepath.VolumeName(dir)+string(filepath.Separator) {
+			return fmt.Errorf("refusing to write %s into the filesystem root (use -unsafe-exports to allow)", path)
+		}
+		if dir, ok := inSystemDir(abs); ok {
+			return fmt.Errorf("refusing to write %s inside system directory %s (use -unsafe-exports to allow)", path, dir)
+		}
+	}
+	if g.input == "" {
+		return nil
+	}
+
+	target, err := os.Stat(path)
+	if err != nil {
+		return nil // a new file
+	}
+	input, err := os.Stat(g.input)
+	if err != nil {
+		return nil
+	}
+	if os.SameFile(target, input) {
+		return fmt.Errorf("refusing to overwrite the input config %s", g.input)
+	}
+	if g.newerDays <= 0 || g.force || target.IsDir() {
+		return nil
+	}
+	newer := target.ModTime().Sub(input.ModTime())
+	if newer <= time.Duration(g.newerDays)*24*time.Hour {
+		return nil
+	}
+	days := int(newer.Hours() / 24)
+	if g.confirm != nil && g.confirm(fmt.Sprintf("%s is %d days newer than %s. Overwrite it?", path, days, g.input)) {
+		return nil
+	}
+	return fmt.Errorf("%s is %d days newer than %s; refusing to overwrite (use -force to overwrite)", path, days, g.input)
+}
+
+// inSystemDir reports the system directory containing path, if any.
+func inSystemDir(path string) (string, bool) {
+	dirs := systemDirs
+	if runtime.GOOS == "windows" {
+		dirs = nil
+		if root := os.Getenv("SystemRoot"); root != "" {
+			dirs = append(dirs, root)
+		}
+	}
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// stdinConfirm returns a confirm function that asks on stderr and reads the
+// answer from stdin, or nil when stdin is not a terminal.
+func stdinConfirm() func(string) bool {
+	if !isTerminal(os.Stdin) {
+		return nil
+	}
+	reader := bufio.NewReader(os.Stdin)
+	return func(prompt string) bool {
+		return confirmPrompt(os.Stderr, reader, prompt)
+	}
+}
+
+// confirmPrompt writes prompt to w and reports whether the answer read from
+// r is yes.
+func confirmPrompt(w io.Writer, r *bufio.Reader, prompt string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", prompt)
+	answer, _ := r.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// UnmarshalJSON accepts "hosts" as a number or as an expression string such
+// as "nodes*3+10", which is kept in HostsExpr until resolveHosts evaluates it.
+func (s *Subnet) UnmarshalJSON(data []byte) error {
+	type plain Subnet
+	aux := struct {
+		*plain
+		Hosts json.RawMessage `json:"hosts,omitempty"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Hosts) == 0 || string(aux.Hosts) == "null" {
+		return nil
+	}
+	var expr string
+	if err := json.Unmarshal(aux.Hosts, &expr); err != nil {
+		return json.Unmarshal(aux.Hosts, &s.Hosts)
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(expr)); err == nil {
+		s.Hosts = n
+	} else {
+		s.HostsExpr = expr
+	}
+	return nil
+}
+
+// MarshalJSON writes an unresolved HostsExpr back as "hosts".
+func (s Subnet) MarshalJSON() ([]byte, error) {
+	type plain Subnet
+	if s.HostsExpr == "" {
+		return json.Marshal(plain(s))
+	}
+	return json.Marshal(struct {
+		plain
+		Hosts string `json:"hosts"`
+	}{plain(s), s.HostsExpr})
+}
+
+// resolveHosts returns networks with every host count expression evaluated.
+// Variables come from overrides (-var), then the network's own variables.
+// The input is not modified.
+func resolveHosts(networks []Network, overrides map[string]float64) ([]Network, error) {
+	out := networks
+	copied := make(map[int]bool)
+	for i, n := range networks {
+		for j, s := range n.Subnets {
+			if s.HostsExpr == "" {
+				continue
+			}
+			vars := make(map[string]float64, len(n.Variables)+len(overrides))
+			for k, v := range n.Variables {
+				vars[k] = v
+			}
+			for k, v := range overrides {
+				vars[k] = v
+			}
+			value, err := evalExpr(s.HostsExpr, vars)
+			if err != nil {
+				return nil, fmt.Errorf("subnet %s: hosts %q: %v", s.Name, s.HostsExpr, err)
+			}
+			hosts := math.Ceil(value - 1e-9)
+			if hosts < 1 || hosts > math.MaxInt32 {
+				return nil, fmt.Errorf("subnet %s: hosts %q evaluates to %g", s.Name, s.HostsExpr, value)
+			}
+			if len(copied) == 0 {
+				out = append([]Network(nil), networks...)
+			}
+			if !copied[i] {
+				out[i].Subnets = append([]Subnet(nil), n.Subnets...)
+				copied[i] = true
+			}
+			out[i].Subnets[j].Hosts = int(hosts)
+			out[i].Subnets[j].HostsExpr = ""
+		}
+	}
+	return out, nil
+}
+
+// exprFuncs are the functions available in host count expressions.
+var exprFuncs = map[string]func(args []float64) (float64, error){
+	"ceil":  oneArg(math.Ceil),
+	"floor": oneArg(math.Floor),
+	"round": oneArg(math.Round),
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("min needs at least one argument")
+		}
+		sort.Float64s(args)
+		return args[0], nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("max needs at least one argument")
+		}
+		sort.Float64s(args)
+		return args[len(args)-1], nil
+	},
+}
+
+func oneArg(f func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+// evalExpr evaluates an arithmetic expression with + - * / %, parentheses,
+// numbers, variables and the functions of exprFuncs.
+func evalExpr(text string, vars map[string]float64) (float64, error) {
+	p := &exprParser{text: text, vars: vars}
+	v, err := p.sum()
+	if err != nil {
+		return 0, err
+	}
+	p.space()
+	if p.pos < len(p.text) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.text[p.pos:], p.pos+1)
+	}
+	return v, nil
+}
+
+// exprParser is a recursive-descent parser that evaluates as it parses.
+type exprParser struct {
+	text string
+	pos  int
+	vars map[string]float64
+}
+
+func (p *exprParser) space() {
+	for p.pos < len(p.text) && p.text[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.space()
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) sum() (float64, error) {
+	v, err := p.product()
+	if err != nil {
+		return 0, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			v += r
+		} else {
+			v -= r
+		}
+	}
+	return v, nil
+}
+
+func (p *exprParser) product() (float64, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for op := p.peek(); op == '*' || op == '/' || op == '%'; op = p.peek() {
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case op == '*':
+			v *= r
+		case r == 0:
+			return 0, fmt.Errorf("division by zero")
+		case op == '/':
+			v /= r
+		default:
+			v = math.Mod(v, r)
+		}
+	}
+	return v, nil
+}
+
+func (p *exprParser) unary() (float64, error) {
+	if p.peek() == '-' {
+		p.pos++
+		v, err := p.unary()
+		return -v, err
+	}
+	return p.operand()
+}
+
+func (p *exprParser) operand() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.sum()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return v, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] >= '0' && p.text[p.pos] <= '9' || p.text[p.pos] == '.') {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.text[start:p.pos], 64)
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.text) && (p.text[p.pos] == '_' || p.text[p.pos] == '.' || unicode.IsLetter(rune(p.text[p.pos])) || unicode.IsDigit(rune(p.text[p.pos]))) {
+			p.pos++
+		}
+		name := p.text[start:p.pos]
+		if p.peek() == '(' {
+			return p.call(name)
+		}
+		v, ok := p.vars[name]
+		if ok && math.IsNaN(v) {
+			return 0, fmt.Errorf("variable %q is not a number", name)
+		}
+		if !ok {
+			return 0, fmt.Errorf("unknown variable %q (set it with -var %s=<value> or in \"variables\")", name, name)
+		}
+		return v, nil
+	case c == 0:
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+}
+
+func (p *exprParser) call(name string) (float64, error) {
+	f, ok := exprFuncs[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown function %s (use ceil, floor, round, min or max)", name)
+	}
+	p.pos++ // (
+	var args []float64
+	if p.peek() != ')' {
+		for {
+			v, err := p.sum()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, v)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peek() != ')' {
+		return 0, fmt.Errorf("missing ) after arguments of %s", name)
+	}
+	p.pos++
+	v, err := f(args)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", name, err)
+	}
+	return v, nil
+}
+
+// varFlag collects repeated -var name=value flags and the -var-file path.
+// Every value is used for ${name} references in the config; numbers also
+// for host count expressions, where text values are NaN so they are
+// reported as not numbers.
+type varFlag struct {
+	values  map[string]string
+	numbers map[string]float64
+	file    string
+}
+
+func (v *varFlag) String() string {
+	if v == nil {
+		return ""
+	}
+	names := sortedKeys(v.values)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = k + "=" + v.values[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+func (v *varFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" {
+		return fmt.Errorf("use name=value")
+	}
+	if v.values == nil {
+		v.values, v.numbers = make(map[string]string), make(map[string]float64)
+	}
+	v.values[name] = value
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		f = math.NaN()
+	}
+	v.numbers[name] = f
+	return nil
+}
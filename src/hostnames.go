@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultNameTemplate names assignments when a network has a dnsSuffix but
+// no nameTemplate.
+const defaultNameTemplate = "{{.Subnet}}-{{.Label}}"
+
+// hostnameFields are the values a nameTemplate can use.
+type hostnameFields struct {
+	Subnet   string // subnet name
+	Label    string // assignment name
+	Index    int    // 1-based index of the assignment within its subnet
+	Position int
+	VLAN     int
+	IP       string
+	Network  string // parent network CIDR
+}
+
+// applyHostnames sets the FQDN of every single-address assignment row of a
+// network that has a nameTemplate or dnsSuffix. Each dot-separated part of
+// the rendered name is turned into a valid DNS label; dnsSuffix, if set, is
+// appended. A name too long for DNS (see dnsNameProblem) is an error.
+func applyHostnames(network Network, rows []SubnetResult) error {
+	if network.NameTemplate == "" && network.DNSSuffix == "" {
+		return nil
+	}
+	text := network.NameTemplate
+	if text == "" {
+		text = defaultNameTemplate
+	}
+	tmpl, err := template.New("nameTemplate").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid nameTemplate %q: %v", text, err)
+	}
+	suffix := strings.Trim(strings.TrimSpace(network.DNSSuffix), ".")
+
+	index := make(map[string]int)
+	for i := range rows {
+		r := &rows[i]
+		if !isAssignedCategory(r.Category) || strings.Contains(r.IP, " - ") {
+			continue
+		}
+		index[r.Subnet]++
+		var sb strings.Builder
+		err := tmpl.Execute(&sb, hostnameFields{
+			Subnet:   r.Name,
+			Label:    r.Label,
+			Index:    index[r.Subnet],
+			Position: assignmentPosition(*r),
+			VLAN:     r.VLAN,
+			IP:       r.IP,
+			Network:  network.Network,
+		})
+		if err != nil {
+			return fmt.Errorf("nameTemplate %q: %v", text, err)
+		}
+		var labels []string
+		for _, part := range strings.Split(sb.String(), ".") {
+			if strings.TrimSpace(part) != "" {
+				labels = append(labels, dnsLabel(part))
+			}
+		}
+		if suffix != "" {
+			labels = append(labels, suffix)
+		}
+		r.FQDN = strings.Join(labels, ".")
+		if problem := dnsNameProblem(r.FQDN); problem != "" {
+			return fmt.Errorf("subnet %s: assignment %s: %s", r.Name, r.Label, problem)
+		}
+	}
+	return nil
+}
+
+// assignmentPosition is the offset of a single-address row from its subnet's
+// network address.
+func assignmentPosition(r SubnetResult) int {
+	ip, _, err := parseIPSpan(r.IP)
+	if err != nil {
+		return 0
+	}
+	network, _, err := parseIPSpan(strings.SplitN(r.Subnet, "/", 2)[0])
+	if err != nil {
+		return 0
+	}
+	return int(ip - network)
+}
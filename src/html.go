@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// htmlStyle is the report's stylesheet, inlined so the file needs nothing
+// but a browser.
+const htmlStyle = `body { font-family: sans-serif; font-size: 14px; margin: 2em; color: #222; }
+h1, h2, h3 { font-weight: 600; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f2f2f2; }
+td.num { text-align: right; }
+.status { text-decoration: line-through; }
+.stats { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 1.5em; }
+.stat { border: 1px solid #ccc; border-radius: 4px; padding: 8px 16px; }
+.stat b { display: block; font-size: 20px; }
+svg { max-width: 100%; height: auto; }
+`
+
+// ExportHTML writes a self-contained HTML report for sharing: summary
+// statistics, the allocation map of ExportSVG and the plan's tables, with
+// no external assets.
+func ExportHTML(results []SubnetResult, path string) error {
+	return os.WriteFile(path, []byte(renderHTML(results)), 0644)
+}
+
+func renderHTML(results []SubnetResult) string {
+	e := html.EscapeString
+	networks, byNetwork, subnets, _ := collectUsage(results)
+	rows := make(map[subnetKey][]SubnetResult)
+	var free, summary []SubnetResult
+	for _, r := range results {
+		switch {
+		case r.Category == "Summary":
+			summary = append(summary, r)
+		case isFreeSpaceRow(r):
+			free = append(free, r)
+		default:
+			key := subnetKey{network: r.Parent, subnet: r.Subnet, name: r.Name, vlan: r.VLAN}
+			rows[key] = append(rows[key], r)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>Subnet Plan</title>\n<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+	sb.WriteString("<h1>Subnet Plan</h1>\n")
+	if len(subnets) == 0 {
+		sb.WriteString("<p>No subnets planned.</p>\n</body>\n</html>\n")
+		return sb.String()
+	}
+
+	var total addressUsage
+	for _, n := range networks {
+		total.total += byNetwork[n].total
+		total.assigned += byNetwork[n].assigned
+		total.free += byNetwork[n].free
+	}
+	sb.WriteString("<div class=\"stats\">\n")
+	for _, stat := range []struct{ label, value string }{
+		{"Networks", fmt.Sprint(len(networks))},
+		{"Subnets", fmt.Sprint(len(subnets))},
+		{"Addresses", fmt.Sprint(total.total)},
+		{"Allocated", fmt.Sprintf("%d (%s)", total.allocated(), htmlPercent(total.allocated(), total.total))},
+		{"Assigned", fmt.Sprint(total.assigned)},
+		{"Free", fmt.Sprint(total.free)},
+	} {
+		sb.WriteString(fmt.Sprintf("<div class=\"stat\"><b>%s</b>%s</div>\n", e(stat.value), stat.label))
+	}
+	sb.WriteString("</div>\n")
+
+	sb.WriteString("<h2>Networks</h2>\n<table>\n")
+	sb.WriteString("<tr><th>Network</th><th>Total IPs</th><th>Allocated</th><th>Assigned</th><th>Free</th><th>Utilization</th></tr>\n")
+	for _, n := range networks {
+		u := byNetwork[n]
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td class=\"num\">%d</td><td class=\"num\">%d</td><td class=\"num\">%d</td><td class=\"num\">%d</td><td class=\"num\">%s</td></tr>\n",
+			e(n), u.total, u.allocated(), u.assigned, u.free, htmlPercent(u.allocated(), u.total)))
+	}
+	sb.WriteString("</table>\n")
+
+	sb.WriteString("<h2>Allocation Map</h2>\n")
+	sb.WriteString(renderSVG(results))
+
+	sb.WriteString("<h2>Subnets</h2>\n<table>\n")
+	sb.WriteString("<tr><th>Name</th><th>VLAN</th><th>Subnet</th><th>Mask</th><th>Host Range</th><th>Gateway</th><th>Usable Hosts</th><th>Total IPs</th></tr>\n")
+	for _, key := range subnets {
+		r := rows[key][0]
+		first, last := hostRange(r.Subnet)
+		hosts := ""
+		if first != "" {
+			hosts = first + " – " + last
+		}
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td class=\"num\">%d</td><td class=\"num\">%d</td></tr>\n",
+			htmlName(r), markdownVLAN(r.VLAN), e(r.Subnet), e(r.Mask), hosts, markdownGateway(rows[key]),
+			usableHostCount(r.Prefix), uint64(1)<<(32-r.Prefix)))
+	}
+	sb.WriteString("</table>\n")
+
+	// Per-subnet sections use the columns of the CSV export
+	columns := optionalColumns(results)
+	header := "<tr><th>IP</th><th>Label</th><th>Category</th><th>Total IPs</th>"
+	for _, col := range columns {
+		header += "<th>" + e(col) + "</th>"
+	}
+	header += "</tr>\n"
+	for _, key := range subnets {
+		r := rows[key][0]
+		title := htmlName(r)
+		if r.VLAN > 0 {
+			title += fmt.Sprintf(" (VLAN %d)", r.VLAN)
+		}
+		sb.WriteString(fmt.Sprintf("<h3>%s — %s</h3>\n<table>\n%s", title, e(r.Subnet), header))
+		for _, row := range rows[key] {
+			sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td class=\"num\">%d</td>", e(row.IP), e(tableLabel(row)), e(row.Category), row.TotalIPs))
+			for _, cell := range optionalCells(row, columns) {
+				sb.WriteString("<td>" + e(cell) + "</td>")
+			}
+			sb.WriteString("</tr>\n")
+		}
+		sb.WriteString("</table>\n")
+	}
+
+	if len(free) > 0 {
+		sb.WriteString("<h2>Free Space</h2>\n<table>\n<tr><th>Network</th><th>Subnet</th><th>Range</th><th>Total IPs</th></tr>\n")
+		for _, r := range free {
+			first, last := blockRange(r.Subnet)
+			sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s – %s</td><td class=\"num\">%d</td></tr>\n", e(r.Parent), e(r.Subnet), first, last, uint64(1)<<(32-r.Prefix)))
+		}
+		sb.WriteString("</table>\n")
+	}
+	if len(summary) > 0 {
+		sb.WriteString("<h2>Summary Routes</h2>\n<table>\n<tr><th>Route</th><th>Range</th><th>Total IPs</th></tr>\n")
+		for _, r := range summary {
+			sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td class=\"num\">%d</td></tr>\n", e(r.Subnet), e(r.IP), r.TotalIPs))
+		}
+		sb.WriteString("</table>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// htmlName is a subnet's name, struck through with its status when it is
+// decommissioned or planned.
+func htmlName(r SubnetResult) string {
+	name := html.EscapeString(r.Name)
+	if r.Status != "" {
+		name = fmt.Sprintf("<span class=\"status\">%s</span> (%s)", name, strings.ToLower(r.Status))
+	}
+	return name
+}
+
+// htmlPercent formats part of whole as a percentage.
+func htmlPercent(part, whole uint64) string {
+	if whole == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(whole))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// criticalityLevels are the values of a subnet's "criticality", most
+// critical first.
+var criticalityLevels = []string{"critical", "high", "medium", "low"}
+
+// criticalityRank orders criticality levels; unclassified subnets come
+// last.
+func criticalityRank(level string) int {
+	for i, l := range criticalityLevels {
+		if l == level {
+			return i
+		}
+	}
+	return len(criticalityLevels)
+}
+
+// validateCriticality checks a subnet's criticality level.
+func validateCriticality(subnet Subnet) error {
+	if subnet.Criticality != "" && criticalityRank(subnet.Criticality) == len(criticalityLevels) {
+		return fmt.Errorf("subnet %s: invalid criticality %q (use %s)", subnet.Name, subnet.Criticality, strings.Join(criticalityLevels, ", "))
+	}
+	return nil
+}
+
+// ImpactReport lists subnets by criticality with what they depend on, and
+// every shared dependency with the subnets a maintenance on it affects.
+type ImpactReport struct {
+	Subnets      []ImpactSubnet     `json:"subnets"`
+	Dependencies []ImpactDependency `json:"dependencies"`
+}
+
+// ImpactSubnet is one subnet of an ImpactReport.
+type ImpactSubnet struct {
+	Name         string   `json:"name"`
+	Subnet       string   `json:"subnet"`
+	VLAN         int      `json:"vlan,omitempty"`
+	Parent       string   `json:"parent"`
+	Criticality  string   `json:"criticality,omitempty"`
+	Gateway      string   `json:"gateway,omitempty"`
+	Dependencies []string `json:"dependencies"` // keys of the shared dependencies
+}
+
+// ImpactDependency is a parent network, gateway device or VLAN shared by
+// several subnets. Criticality is the highest of its subnets.
+type ImpactDependency struct {
+	Key         string   `json:"key"` // "parent 10.0.0.0/16", "gateway core1" or "vlan 100"
+	Criticality string   `json:"criticality,omitempty"`
+	Subnets     []string `json:"subnets"`
+}
+
+// BuildImpactReport derives the report from planned results. Subnets
+// depend on their parent network, on the devices of their gateway (the
+// "device" tag of the Gateway, virtual IP and router addresses) and on
+// their VLAN; only dependencies shared by more than one subnet are listed.
+func BuildImpactReport(results []SubnetResult) ImpactReport {
+	var subnets []*ImpactSubnet
+	bySubnet := make(map[string]*ImpactSubnet)
+	members := make(map[string][]*ImpactSubnet)
+	depend := func(s *ImpactSubnet, key string) {
+		for _, k := range s.Dependencies {
+			if k == key {
+				return
+			}
+		}
+		s.Dependencies = append(s.Dependencies, key)
+		members[key] = append(members[key], s)
+	}
+	for _, r := range results {
+		if r.Category == "Summary" || isFreeSpaceRow(r) {
+			continue
+		}
+		key := r.Parent + " " + r.Subnet
+		s, ok := bySubnet[key]
+		if !ok {
+			s = &ImpactSubnet{Name: r.Name, Subnet: r.Subnet, VLAN: r.VLAN, Parent: r.Parent, Criticality: r.Criticality, Dependencies: []string{}}
+			bySubnet[key] = s
+			subnets = append(subnets, s)
+			depend(s, "parent "+r.Parent)
+			if r.VLAN > 0 {
+				depend(s, fmt.Sprintf("vlan %d", r.VLAN))
+			}
+		}
+		gateway := r.Category == "Virtual IP" || r.Category == "Router" || (r.Category == "Assignment" && strings.EqualFold(r.Label, "Gateway"))
+		if !gateway {
+			continue
+		}
+		if r.Label == "Gateway" && s.Gateway == "" {
+			s.Gateway = r.IP
+		}
+		if device := r.Tags[deviceTag]; device != "" {
+			depend(s, "gateway "+device)
+		}
+	}
+
+	report := ImpactReport{Subnets: []ImpactSubnet{}, Dependencies: []ImpactDependency{}}
+	for _, key := range sortedKeys(members) {
+		if len(members[key]) < 2 {
+			continue
+		}
+		d := ImpactDependency{Key: key, Subnets: []string{}}
+		rank := len(criticalityLevels)
+		for _, s := range members[key] {
+			d.Subnets = append(d.Subnets, s.Name)
+			if r := criticalityRank(s.Criticality); r < rank {
+				rank, d.Criticality = r, s.Criticality
+			}
+		}
+		report.Dependencies = append(report.Dependencies, d)
+	}
+	sort.SliceStable(report.Dependencies, func(i, j int) bool {
+		return criticalityRank(report.Dependencies[i].Criticality) < criticalityRank(report.Dependencies[j].Criticality)
+	})
+	for _, s := range subnets {
+		shared := []string{}
+		for _, key := range s.Dependencies {
+			if len(members[key]) > 1 {
+				shared = append(shared, key)
+			}
+		}
+		s.Dependencies = shared
+		report.Subnets = append(report.Subnets, *s)
+	}
+	sort.SliceStable(report.Subnets, func(i, j int) bool {
+		return criticalityRank(report.Subnets[i].Criticality) < criticalityRank(report.Subnets[j].Criticality)
+	})
+	return report
+}
+
+// ExportImpact writes the criticality and impact report as Markdown, or as
+// JSON for .json files.
+func ExportImpact(results []SubnetResult, path string) error {
+	report := BuildImpactReport(results)
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var err error
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal impact report: %v", err)
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(renderImpactReport(report))
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// renderImpactReport renders the report as Markdown tables.
+func renderImpactReport(report ImpactReport) string {
+	level := func(c string) string {
+		if c == "" {
+			return "unclassified"
+		}
+		return c
+	}
+	var sb strings.Builder
+	sb.WriteString("# Criticality and Impact\n\n## Subnets\n\n")
+	sb.WriteString("| Criticality | Name | Subnet | VLAN | Gateway | Shared dependencies |\n")
+	sb.WriteString("|---|---|---|---|---|---|\n")
+	for _, s := range report.Subnets {
+		vlan := "-"
+		if s.VLAN > 0 {
+			vlan = fmt.Sprint(s.VLAN)
+		}
+		gateway := s.Gateway
+		if gateway == "" {
+			gateway = "-"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n", level(s.Criticality), markdownCell(s.Name), s.Subnet, vlan, gateway, markdownCell(strings.Join(s.Dependencies, ", ")))
+	}
+	sb.WriteString("\n## Shared Dependencies\n\n")
+	if len(report.Dependencies) == 0 {
+		sb.WriteString("No subnets share a parent network, gateway device or VLAN.\n")
+		return sb.String()
+	}
+	sb.WriteString("A maintenance on a dependency affects all its subnets; the criticality is the highest among them.\n\n")
+	sb.WriteString("| Dependency | Criticality | Subnets |\n|---|---|---|\n")
+	for _, d := range report.Dependencies {
+		fmt.Fprintf(&sb, "| %s | %s | %s |\n", markdownCell(d.Key), level(d.Criticality), markdownCell(strings.Join(d.Subnets, ", ")))
+	}
+	return sb.String()
+}
+
+// hasCriticality reports whether any subnet sets a criticality.
+func hasCriticality(networks []Network) bool {
+	for _, n := range networks {
+		for _, s := range n.Subnets {
+			if s.Criticality != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
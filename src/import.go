@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// importers convert the formats of other planning tools into networks.
+var importers = map[string]func(data []byte) ([]Network, error){
+	"solarwinds": importSolarWinds,
+	"xlsx":       importXLSX,
+	"ipcalc":     importIPCalc,
+}
+
+// detectImportFormat guesses the format of an import file from its
+// extension.
+func detectImportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx", ".xlsm":
+		return "xlsx"
+	case ".csv":
+		return "solarwinds"
+	}
+	return "ipcalc"
+}
+
+// importXLSX reads an Excel template laid out like a CSV config: a header
+// row with Network, Name, VLAN, Hosts, CIDR, Address, Assignments and
+// Description columns on the first sheet, one subnet per row.
+func importXLSX(data []byte) ([]Network, error) {
+	rows, err := readXLSX(data)
+	if err != nil {
+		return nil, err
+	}
+	// Templates often have a title above the header; start at the first
+	// row with a Name column
+	for i, row := range rows {
+		for _, cell := range row {
+			if strings.EqualFold(strings.TrimSpace(cell), "name") {
+				return tableConfig(rows[i:], "Excel sheet")
+			}
+		}
+	}
+	return nil, fmt.Errorf("Excel sheet needs a Name column (columns: Network, Name, VLAN, Hosts, CIDR, Address, Assignments, Description)")
+}
+
+// solarWindsColumns maps the column headers of a SolarWinds IPAM subnet
+// export (and common variants) to fields.
+var solarWindsColumns = map[string]string{
+	"address":         "address",
+	"subnet address":  "address",
+	"network address": "address",
+	"cidr":            "cidr",
+	"prefix":          "cidr",
+	"address mask":    "cidr",
+	"mask":            "cidr",
+	"friendlyname":    "name",
+	"friendly name":   "name",
+	"displayname":     "name",
+	"display name":    "name",
+	"name":            "name",
+	"vlan":            "vlan",
+	"vlan id":         "vlan",
+	"comments":        "description",
+	"description":     "description",
+	"grouptype":       "type",
+	"group type":      "type",
+	"type":            "type",
+}
+
+// importSolarWinds reads a SolarWinds IPAM subnet export (CSV). Supernet
+// rows become networks; every subnet is pinned to its address in the
+// smallest supernet containing it, or becomes a network of its own.
+func importSolarWinds(data []byte) ([]Network, error) {
+	reader := csv.NewReader(bytes.NewReader(decodeText(data)))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SolarWinds export: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("SolarWinds export is empty")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		if field, ok := solarWindsColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, fmt.Errorf("SolarWinds export needs an Address column")
+	}
+	if _, ok := columns["cidr"]; !ok {
+		return nil, fmt.Errorf("SolarWinds export needs a CIDR column")
+	}
+	cell := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	type row struct {
+		ipNet  *net.IPNet
+		subnet Subnet
+	}
+	var supernets, subnets []row
+	for n, record := range records[1:] {
+		line := n + 2
+		address := cell(record, "address")
+		if address == "" {
+			continue // groups without an address range
+		}
+		ipNet, err := solarWindsPrefix(address, cell(record, "cidr"))
+		if err != nil {
+			return nil, fmt.Errorf("SolarWinds export line %d: %v", line, err)
+		}
+		ones, _ := ipNet.Mask.Size()
+		s := Subnet{Name: cell(record, "name"), CIDR: ones, Address: ipNet.IP.String(), Description: cell(record, "description")}
+		if s.Name == "" {
+			s.Name = ipNet.String()
+		}
+		if v := cell(record, "vlan"); v != "" {
+			if s.VLAN, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("SolarWinds export line %d: invalid VLAN %q", line, v)
+			}
+		}
+		if strings.EqualFold(cell(record, "type"), "supernet") {
+			supernets = append(supernets, row{ipNet, s})
+		} else {
+			subnets = append(subnets, row{ipNet, s})
+		}
+	}
+
+	var networks []Network
+	index := make(map[string]int)
+	for _, sn := range supernets {
+		if _, ok := index[sn.ipNet.String()]; !ok {
+			index[sn.ipNet.String()] = len(networks)
+			networks = append(networks, Network{Network: sn.ipNet.String()})
+		}
+	}
+	for _, sub := range subnets {
+		parent, best := sub.ipNet.String(), -1
+		subOnes, _ := sub.ipNet.Mask.Size()
+		for _, sn := range supernets {
+			ones, _ := sn.ipNet.Mask.Size()
+			if ones <= subOnes && ones > best && sn.ipNet.Contains(sub.ipNet.IP) {
+				parent, best = sn.ipNet.String(), ones
+			}
+		}
+		i, ok := index[parent]
+		if !ok {
+			i = len(networks)
+			index[parent] = i
+			networks = append(networks, Network{Network: parent})
+		}
+		networks[i].Subnets = append(networks[i].Subnets, sub.subnet)
+	}
+	return networks, nil
+}
+
+// solarWindsPrefix combines an address with a prefix length ("24", "/24")
+// or a dotted mask.
+func solarWindsPrefix(address, mask string) (*net.IPNet, error) {
+	mask = strings.TrimPrefix(mask, "/")
+	if m := net.ParseIP(mask); m != nil && m.To4() != nil {
+		ones, bits := net.IPMask(m.To4()).Size()
+		if bits == 0 {
+			return nil, fmt.Errorf("invalid mask %q", mask)
+		}
+		mask = strconv.Itoa(ones)
+	}
+	_, ipNet, err := net.ParseCIDR(address + "/" + mask)
+	if err != nil || ipNet.IP.To4() == nil {
+		return nil, fmt.Errorf("invalid subnet %s/%s", address, mask)
+	}
+	return ipNet, nil
+}
+
+// importIPCalc reads a batch of ipcalc command lines. "ipcalc NET -s 50 20"
+// adds subnets for 50 and 20 hosts to NET, and "ipcalc NET /26" (or a dotted
+// mask) divides NET into equal /26 subnets. The leading "ipcalc" is
+// optional; blank lines, comments and other options are ignored.
+func importIPCalc(data []byte) ([]Network, error) {
+	var networks []Network
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(decodeText(data)))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) > 0 && filepath.Base(fields[0]) == "ipcalc" {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		var cidr, splitMask string
+		var hosts []int
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			switch {
+			case f == "-s" || f == "--split":
+				for i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") {
+					n, err := strconv.Atoi(fields[i+1])
+					if err != nil || n <= 0 {
+						return nil, fmt.Errorf("ipcalc line %d: invalid host count %q", line, fields[i+1])
+					}
+					hosts = append(hosts, n)
+					i++
+				}
+			case strings.HasPrefix(f, "-"):
+				// other ipcalc options do not change the plan
+			case cidr == "":
+				cidr = f
+			case !strings.Contains(cidr, "/") && net.ParseIP(f) != nil:
+				// "ipcalc 10.0.0.0 255.255.255.0": the network's own mask
+				ipNet, err := solarWindsPrefix(cidr, f)
+				if err != nil {
+					return nil, fmt.Errorf("ipcalc line %d: %v", line, err)
+				}
+				cidr = ipNet.String()
+			default:
+				splitMask = f
+			}
+		}
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			cidr += "/32"
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil || ipNet.IP.To4() == nil {
+			return nil, fmt.Errorf("ipcalc line %d: invalid network %q", line, cidr)
+		}
+
+		i, ok := index[ipNet.String()]
+		if !ok {
+			i = len(networks)
+			index[ipNet.String()] = i
+			networks = append(networks, Network{Network: ipNet.String()})
+		}
+		n := &networks[i]
+		for _, h := range hosts {
+			n.Subnets = append(n.Subnets, Subnet{Name: fmt.Sprintf("Subnet-%d", len(n.Subnets)+1), Hosts: h})
+		}
+		if splitMask != "" {
+			prefix, err := strconv.Atoi(strings.TrimPrefix(splitMask, "/"))
+			if err != nil {
+				m := net.ParseIP(splitMask)
+				if m == nil || m.To4() == nil {
+					return nil, fmt.Errorf("ipcalc line %d: invalid subnet mask %q", line, splitMask)
+				}
+				prefix, _ = net.IPMask(m.To4()).Size()
+			}
+			split, err := splitNetwork(ipNet.String(), 0, prefix, "Subnet")
+			if err != nil {
+				return nil, fmt.Errorf("ipcalc line %d: %v", line, err)
+			}
+			for _, s := range split.Subnets {
+				s.Name = fmt.Sprintf("Subnet-%d", len(n.Subnets)+1)
+				n.Subnets = append(n.Subnets, s)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(networks) == 0 {
+		return nil, fmt.Errorf("no ipcalc command lines found")
+	}
+	return networks, nil
+}
+
+// runImport implements the "import" subcommand.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	from := fs.String("from", "", "Source format: solarwinds, xlsx or ipcalc (default: xlsx for .xlsx, solarwinds for .csv, otherwise ipcalc)")
+	output := fs.String("o", "", "Write the config to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner import [-from solarwinds|xlsx|ipcalc] [-o config.json] file\n\n")
+		fmt.Fprintf(os.Stderr, "Converts a subnet list from another planning tool into a config.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	var files []string
+	for fs.NArg() > 0 {
+		files = append(files, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	data, err := readInput(files[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\n", files[0], err)
+		return exitConfigError
+	}
+	format := *from
+	if format == "" {
+		format = detectImportFormat(files[0])
+	}
+	importer, ok := importers[format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "import: unknown format %q (use solarwinds, xlsx or ipcalc)\n", format)
+		return exitUsage
+	}
+	networks, err := importer(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %s: %v\n", files[0], err)
+		return exitConfigError
+	}
+	if _, err := PlanSubnets(networks); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: the imported config does not plan: %v\n", err)
+	}
+
+	out, err := encodeConfig(Config{Networks: networks}, shapeArray)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return exitFailure
+	}
+	if *output == "" {
+		os.Stdout.Write(out)
+		return 0
+	}
+	if err := os.WriteFile(*output, out, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", *output, err)
+		return exitFailure
+	}
+	return 0
+}
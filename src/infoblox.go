@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables with the Infoblox credentials, so they stay out of
+// shell history and process listings.
+const (
+	infobloxUserEnv     = "INFOBLOX_USERNAME"
+	infobloxPasswordEnv = "INFOBLOX_PASSWORD"
+)
+
+// infobloxClient talks to the WAPI of an Infoblox Grid Master, e.g.
+// https://gm.example.com/wapi/v2.12, with basic authentication.
+type infobloxClient struct {
+	base     string
+	user     string
+	password string
+	view     string // network view
+	client   *http.Client
+}
+
+// do sends one request and decodes the JSON response into v. WAPI errors
+// come as {"Error": ..., "text": ...}.
+func (c *infobloxClient) do(method, object string, query url.Values, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := strings.TrimSuffix(c.base, "/") + "/" + object
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.user, c.password)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var wapiErr struct {
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(data, &wapiErr) != nil || wapiErr.Text == "" {
+			wapiErr.Text = strings.TrimSpace(string(data))
+		}
+		return fmt.Errorf("infoblox: %s %s: HTTP %d: %s", method, object, resp.StatusCode, wapiErr.Text)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("infoblox: %s %s: %v", method, object, err)
+	}
+	return nil
+}
+
+// exists reports whether a search for object finds anything in the
+// network view.
+func (c *infobloxClient) exists(object string, query url.Values) (bool, error) {
+	query.Set("network_view", c.view)
+	var found []json.RawMessage
+	if err := c.do(http.MethodGet, object, query, nil, &found); err != nil {
+		return false, err
+	}
+	return len(found) > 0, nil
+}
+
+// addressUsed reports whether an address already has a record, such as a
+// fixed address, host record or lease.
+func (c *infobloxClient) addressUsed(ip string) (bool, error) {
+	query := url.Values{"ip_address": {ip}, "network_view": {c.view}, "_return_fields": {"status"}}
+	var found []struct {
+		Status string `json:"status"`
+	}
+	if err := c.do(http.MethodGet, "ipv4address", query, nil, &found); err != nil {
+		return false, err
+	}
+	return len(found) > 0 && found[0].Status == "USED", nil
+}
+
+// create adds an object in the network view.
+func (c *infobloxClient) create(object string, body map[string]interface{}) error {
+	body["network_view"] = c.view
+	var ref string
+	return c.do(http.MethodPost, object, nil, body, &ref)
+}
+
+// pushInfoblox creates the plan in Infoblox: each parent network as a
+// network container, the planned subnets as networks, DHCP ranges as
+// ranges, and each assigned or reserved address as a host record (when it
+// has an FQDN, see dnsSuffix) or else a reserved fixed address. Objects
+// that already exist are left as they are, so a push can be repeated
+// after the plan grows. Address blocks without DHCP, free space and
+// decommissioned or quarantined subnets are skipped. With dryRun nothing
+// is created; a nil client assumes an empty Infoblox. Every action is
+// reported on out.
+func pushInfoblox(c *infobloxClient, results []SubnetResult, dryRun bool, out io.Writer) (pushSummary, error) {
+	log := &pushLog{out: out, dryRun: dryRun}
+	// ensure finds an object, or creates it; lookups are skipped under a
+	// parent that a dry run only pretended to create.
+	ensure := func(kind, name string, lookup bool, exists func() (bool, error), create func() error) (bool, error) {
+		if c != nil && lookup {
+			found, err := exists()
+			if err != nil {
+				return false, err
+			}
+			if found {
+				log.exists(kind, name)
+				return true, nil
+			}
+		}
+		log.create(kind, name)
+		if dryRun {
+			return false, nil
+		}
+		return true, create()
+	}
+
+	containers := make(map[string]bool) // parent -> present in Infoblox
+	networks := make(map[string]bool)   // subnet -> present in Infoblox
+	for _, r := range results {
+		if r.Category == "Summary" || isFreeSpaceRow(r) || r.Status != "" || r.Subnet == "" {
+			continue
+		}
+		if _, ok := containers[r.Parent]; !ok && r.Parent != "" {
+			present, err := ensure("network container", r.Parent, true, func() (bool, error) {
+				return c.exists("networkcontainer", url.Values{"network": {r.Parent}})
+			}, func() error {
+				return c.create("networkcontainer", map[string]interface{}{"network": r.Parent, "comment": "Created by IPSubnetPlanner"})
+			})
+			if err != nil {
+				return log.sum, err
+			}
+			containers[r.Parent] = present
+		}
+		key := r.Parent + " " + r.Subnet
+		present, ok := networks[key]
+		if !ok {
+			comment := r.Name
+			if r.VLAN > 0 {
+				comment = fmt.Sprintf("%s (VLAN %d)", r.Name, r.VLAN)
+			}
+			var err error
+			present, err = ensure("network", r.Subnet+" "+comment, containers[r.Parent] || r.Parent == "", func() (bool, error) {
+				return c.exists("network", url.Values{"network": {r.Subnet}})
+			}, func() error {
+				return c.create("network", map[string]interface{}{"network": r.Subnet, "comment": comment})
+			})
+			if err != nil {
+				return log.sum, err
+			}
+			networks[key] = present
+		}
+		if !isAssignedCategory(r.Category) && r.Category != "Reserved" {
+			continue
+		}
+
+		name := r.IP + " (" + r.Name + ": " + r.Label + ")"
+		comment := strings.TrimSpace(r.Label + " " + r.Description)
+		var err error
+		switch {
+		case strings.Contains(r.IP, "-") && r.DHCP:
+			start, end, _ := parseIPSpan(r.IP)
+			first, last := uint32ToIP(start).String(), uint32ToIP(end).String()
+			_, err = ensure("range", name, present, func() (bool, error) {
+				return c.exists("range", url.Values{"start_addr": {first}, "end_addr": {last}})
+			}, func() error {
+				return c.create("range", map[string]interface{}{"start_addr": first, "end_addr": last, "comment": comment})
+			})
+		case strings.Contains(r.IP, "-"):
+			log.skip("range", name)
+		case r.FQDN != "" && r.Category != "Reserved":
+			_, err = ensure("host record", r.FQDN+" "+name, present, func() (bool, error) {
+				return c.addressUsed(r.IP)
+			}, func() error {
+				return c.create("record:host", map[string]interface{}{
+					"name":              r.FQDN,
+					"ipv4addrs":         []map[string]string{{"ipv4addr": r.IP}},
+					"configure_for_dns": false,
+					"comment":           comment,
+				})
+			})
+		default:
+			_, err = ensure("fixed address", name, present, func() (bool, error) {
+				return c.addressUsed(r.IP)
+			}, func() error {
+				return c.create("fixedaddress", map[string]interface{}{"ipv4addr": r.IP, "match_client": "RESERVED", "name": r.Label, "comment": comment})
+			})
+		}
+		if err != nil {
+			return log.sum, err
+		}
+	}
+	return log.sum, nil
+}
+
+// runInfoblox implements the "infoblox" subcommand.
+func runInfoblox(args []string) int {
+	fs := flag.NewFlagSet("infoblox", flag.ExitOnError)
+	apiURL := fs.String("url", "", "WAPI URL of the Grid Master including the version, e.g. https://gm.example.com/wapi/v2.12")
+	view := fs.String("network-view", "default", "Network view to create the objects in")
+	dryRun := fs.Bool("dry-run", false, "Show what would be created without changing Infoblox; without -url, assume an empty Infoblox")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner infoblox -url URL [-network-view name] [-dry-run] plan.json\n\n")
+		fmt.Fprintf(os.Stderr, "Creates the network containers, networks, ranges and addresses of an -exportjson plan in Infoblox.\n")
+		fmt.Fprintf(os.Stderr, "Credentials are read from %s and %s.\n\n", infobloxUserEnv, infobloxPasswordEnv)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	var files []string
+	for fs.NArg() > 0 {
+		files = append(files, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(files) != 1 || *view == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	var client *infobloxClient
+	if *apiURL != "" {
+		user, password := os.Getenv(infobloxUserEnv), os.Getenv(infobloxPasswordEnv)
+		if user == "" || password == "" {
+			fmt.Fprintf(os.Stderr, "infoblox: set %s and %s\n", infobloxUserEnv, infobloxPasswordEnv)
+			return exitUsage
+		}
+		client = &infobloxClient{base: *apiURL, user: user, password: password, view: *view, client: &http.Client{Timeout: 30 * time.Second}}
+	} else if !*dryRun {
+		fmt.Fprintln(os.Stderr, "infoblox: -url is required (or use -dry-run)")
+		return exitUsage
+	}
+
+	results, err := loadPlanFile(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
+	}
+	sum, err := pushInfoblox(client, results, *dryRun, os.Stdout)
+	fmt.Printf("\n%s\n", pushTotals(sum, *dryRun))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return 0
+}
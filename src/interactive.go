@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const interactiveHelp = `Commands:
+  net add <cidr>              add a parent network and select it
+  net rm <n|cidr>             remove a parent network
+  use <n|cidr>                select the network that 'add' targets
+  add <name> <hosts|/prefix> [vlan]
+  resize <name> <hosts|/prefix>
+  move <name> <n|cidr>        move a subnet to another parent network
+  reorder <name> <position>   move a subnet to a position in its network
+  rm <name>                   remove a subnet
+  list                        show the full allocation table
+  save <file.json>            save the configuration
+  export <file>               export the plan (.json, .csv or .md)
+  help, quit
+`
+
+// interactiveSession holds the configuration being edited and the plan
+// computed from it.
+type interactiveSession struct {
+	networks []Network
+	results  []SubnetResult
+	current  int
+	out      io.Writer
+}
+
+// runInteractive reads commands from in until EOF or quit, re-planning and
+// redrawing utilization after every change.
+func runInteractive(networks []Network, in io.Reader, out io.Writer) error {
+	s := &interactiveSession{networks: networks, out: out}
+	if err := s.replan(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "IPSubnetPlanner interactive mode. Type 'help' for commands.")
+	s.printUtilization()
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "quit" || fields[0] == "exit" {
+			return nil
+		}
+		if err := s.execute(fields); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
+
+// execute runs one command. Changes that make the plan invalid are rolled back.
+func (s *interactiveSession) execute(fields []string) error {
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "help":
+		fmt.Fprint(s.out, interactiveHelp)
+		return nil
+	case "list":
+		PrintTableTo(s.out, s.results)
+		return nil
+	case "save":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: save <file.json>")
+		}
+		return s.save(args[0])
+	case "export":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: export <file>")
+		}
+		return s.export(args[0])
+	case "use":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: use <n|cidr>")
+		}
+		i, err := s.findNetwork(args[0])
+		if err != nil {
+			return err
+		}
+		s.current = i
+		s.printUtilization()
+		return nil
+	}
+
+	before := cloneNetworks(s.networks)
+	previous := s.current
+	if err := s.edit(cmd, args); err != nil {
+		return err
+	}
+	if err := s.replan(); err != nil {
+		s.networks, s.current = before, previous
+		s.replan()
+		return fmt.Errorf("%v (change reverted)", err)
+	}
+	s.printUtilization()
+	return nil
+}
+
+// edit applies a modifying command to the configuration.
+func (s *interactiveSession) edit(cmd string, args []string) error {
+	switch cmd {
+	case "net":
+		if len(args) != 2 || (args[0] != "add" && args[0] != "rm") {
+			return fmt.Errorf("usage: net add <cidr> | net rm <n|cidr>")
+		}
+		if args[0] == "add" {
+			s.networks = append(s.networks, Network{Network: args[1]})
+			s.current = len(s.networks) - 1
+			return nil
+		}
+		i, err := s.findNetwork(args[1])
+		if err != nil {
+			return err
+		}
+		s.networks = append(s.networks[:i], s.networks[i+1:]...)
+		s.current = 0
+		return nil
+	case "add":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: add <name> <hosts|/prefix> [vlan]")
+		}
+		if len(s.networks) == 0 {
+			return fmt.Errorf("no parent network; use 'net add <cidr>' first")
+		}
+		subnet := Subnet{Name: args[0]}
+		if err := setSubnetSize(&subnet, args[1]); err != nil {
+			return err
+		}
+		if len(args) == 3 {
+			vlan, err := strconv.Atoi(args[2])
+			if err != nil {
+				return fmt.Errorf("invalid VLAN %q", args[2])
+			}
+			subnet.VLAN = vlan
+		}
+		n := &s.networks[s.current]
+		n.Subnets = append(n.Subnets, subnet)
+		return nil
+	case "resize":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: resize <name> <hosts|/prefix>")
+		}
+		ni, si, err := s.findSubnet(args[0])
+		if err != nil {
+			return err
+		}
+		return setSubnetSize(&s.networks[ni].Subnets[si], args[1])
+	case "move":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: move <name> <n|cidr>")
+		}
+		ni, si, err := s.findSubnet(args[0])
+		if err != nil {
+			return err
+		}
+		target, err := s.findNetwork(args[1])
+		if err != nil {
+			return err
+		}
+		subnet := s.networks[ni].Subnets[si]
+		s.networks[ni].Subnets = append(s.networks[ni].Subnets[:si], s.networks[ni].Subnets[si+1:]...)
+		s.networks[target].Subnets = append(s.networks[target].Subnets, subnet)
+		return nil
+	case "reorder":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: reorder <name> <position>")
+		}
+		ni, si, err := s.findSubnet(args[0])
+		if err != nil {
+			return err
+		}
+		subnets := s.networks[ni].Subnets
+		pos, err := strconv.Atoi(args[1])
+		if err != nil || pos < 1 || pos > len(subnets) {
+			return fmt.Errorf("invalid position %q (1-%d)", args[1], len(subnets))
+		}
+		// Equal-sized subnets are placed in config order
+		subnet := subnets[si]
+		subnets = append(subnets[:si], subnets[si+1:]...)
+		subnets = append(subnets[:pos-1], append([]Subnet{subnet}, subnets[pos-1:]...)...)
+		s.networks[ni].Subnets = subnets
+		return nil
+	case "rm":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: rm <name>")
+		}
+		ni, si, err := s.findSubnet(args[0])
+		if err != nil {
+			return err
+		}
+		s.networks[ni].Subnets = append(s.networks[ni].Subnets[:si], s.networks[ni].Subnets[si+1:]...)
+		return nil
+	}
+	return fmt.Errorf("unknown command %q (type 'help')", cmd)
+}
+
+func (s *interactiveSession) replan() error {
+	results, err := PlanSubnets(s.networks)
+	if err != nil {
+		return err
+	}
+	s.results = results
+	return nil
+}
+
+func (s *interactiveSession) save(path string) error {
+	data, err := json.MarshalIndent(s.networks, "", "  ")
+	if err != nil {
+		return err
+	}
+	ensureDir(path)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "✓ Config: %s\n", path)
+	return nil
+}
+
+func (s *interactiveSession) export(path string) error {
+	var write func([]SubnetResult, string) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		write = ExportJSON
+	case ".csv":
+		write = ExportCSV
+	case ".md":
+		write = ExportMarkdown
+	default:
+		return fmt.Errorf("unsupported export type %q (use .json, .csv or .md)", filepath.Ext(path))
+	}
+	ensureDir(path)
+	if err := write(s.results, path); err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "✓ Export: %s\n", path)
+	return nil
+}
+
+// findNetwork resolves a 1-based index or a CIDR to a network index.
+func (s *interactiveSession) findNetwork(ref string) (int, error) {
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 || n > len(s.networks) {
+			return 0, fmt.Errorf("network %d does not exist", n)
+		}
+		return n - 1, nil
+	}
+	for i, n := range s.networks {
+		if n.Network == ref {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("network %s not found", ref)
+}
+
+// findSubnet locates a subnet by name, preferring the selected network.
+func (s *interactiveSession) findSubnet(name string) (int, int, error) {
+	order := []int{}
+	if s.current < len(s.networks) {
+		order = append(order, s.current)
+	}
+	for i := range s.networks {
+		if i != s.current {
+			order = append(order, i)
+		}
+	}
+	for _, ni := range order {
+		for si, subnet := range s.networks[ni].Subnets {
+			if subnet.Name == name {
+				return ni, si, nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("subnet %s not found", name)
+}
+
+// printUtilization draws one bar per parent network.
+func (s *interactiveSession) printUtilization() {
+	for i, n := range s.networks {
+		marker := " "
+		if i == s.current {
+			marker = "*"
+		}
+		used, total := networkUtilization(n, s.results)
+		pct := 0
+		if total > 0 {
+			pct = int(used * 100 / total)
+		}
+		fmt.Fprintf(s.out, "%s%d %-18s %s %3d%%  %d subnets\n", marker, i+1, n.Network, utilizationBar(pct, 30), pct, len(n.Subnets))
+	}
+}
+
+func utilizationBar(pct, width int) string {
+	filled := pct * width / 100
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// setSubnetSize interprets "/26" as a CIDR prefix and "50" as a host count.
+func setSubnetSize(subnet *Subnet, size string) error {
+	if strings.HasPrefix(size, "/") {
+		prefix, err := strconv.Atoi(size[1:])
+		if err != nil || prefix < 1 || prefix > 32 {
+			return fmt.Errorf("invalid prefix %q", size)
+		}
+		subnet.CIDR, subnet.Hosts = prefix, 0
+		return nil
+	}
+	hosts, err := strconv.Atoi(size)
+	if err != nil || hosts <= 0 {
+		return fmt.Errorf("invalid host count %q", size)
+	}
+	subnet.Hosts, subnet.CIDR = hosts, 0
+	return nil
+}
+
+func cloneNetworks(networks []Network) []Network {
+	out := make([]Network, len(networks))
+	for i, n := range networks {
+		out[i] = n
+		out[i].Subnets = append([]Subnet(nil), n.Subnets...)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ipFormats are the values of -csvipformat: plain addresses, zero-padded
+// octets (010.060.048.128) so addresses sort as text and have a fixed width,
+// or plain addresses padded with trailing spaces to a fixed column width.
+var ipFormats = []string{"plain", "padded", "fixed"}
+
+// ipv4Text matches the dotted-quad addresses inside a cell, such as the IP
+// of a range ("10.0.0.10 - 10.0.0.50") or the address of a CIDR.
+var ipv4Text = regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`)
+
+// addressFields are the fields of a result row that hold addresses.
+func addressFields(r *SubnetResult) []*string {
+	return []*string{&r.Subnet, &r.Network, &r.Broadcast, &r.FirstHost, &r.LastHost, &r.IP, &r.Mask, &r.Parent}
+}
+
+// validateIPFormat checks a -csvipformat value.
+func validateIPFormat(format string) error {
+	for _, f := range ipFormats {
+		if format == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid -csvipformat %q (use %s)", format, strings.Join(ipFormats, ", "))
+}
+
+// formatIPs returns a copy of results with the addresses written in format;
+// plain returns results unchanged.
+func formatIPs(results []SubnetResult, format string) []SubnetResult {
+	if format == "" || format == "plain" {
+		return results
+	}
+	out := append([]SubnetResult(nil), results...)
+	switch format {
+	case "padded":
+		for i := range out {
+			for _, field := range addressFields(&out[i]) {
+				*field = padOctets(*field)
+			}
+		}
+	case "fixed":
+		var widths []int
+		for i := range out {
+			for j, field := range addressFields(&out[i]) {
+				if j == len(widths) {
+					widths = append(widths, 0)
+				}
+				widths[j] = max(widths[j], len(*field))
+			}
+		}
+		for i := range out {
+			for j, field := range addressFields(&out[i]) {
+				if *field != "" {
+					*field += strings.Repeat(" ", widths[j]-len(*field))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// padOctets zero-pads every octet of the addresses in text to three digits.
+func padOctets(text string) string {
+	return ipv4Text.ReplaceAllStringFunc(text, func(ip string) string {
+		octets := strings.Split(ip, ".")
+		for i, o := range octets {
+			octets[i] = strings.Repeat("0", 3-len(o)) + o
+		}
+		return strings.Join(octets, ".")
+	})
+}
+
+// unpadOctets undoes padOctets, so zero-padded plans can be read back.
+func unpadOctets(text string) string {
+	return ipv4Text.ReplaceAllStringFunc(text, func(ip string) string {
+		octets := strings.Split(ip, ".")
+		for i, o := range octets {
+			if trimmed := strings.TrimLeft(o, "0"); trimmed != "" {
+				octets[i] = trimmed
+			} else {
+				octets[i] = "0"
+			}
+		}
+		return strings.Join(octets, ".")
+	})
+}
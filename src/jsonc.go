@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// cp1252 maps the bytes 0x80-0x9F of Windows-1252 to Unicode; the other
+// bytes above 0x7F are the same as Latin-1. Unassigned bytes map to
+// themselves.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// configText converts a config file to UTF-8 JSON: the text is decoded as
+// by decodeText, and JSONC comments and trailing commas are blanked out.
+// Removed characters become spaces, so line and column numbers in error
+// messages still match the file.
+func configText(data []byte) []byte {
+	out, _ := stripJSONC(decodeText(data))
+	return out
+}
+
+// decodeText converts a text file to UTF-8: a byte order mark is dropped
+// (UTF-16 files are decoded), and text that is not valid UTF-8 is read as
+// Windows-1252. CSV and other non-JSON input use it without configText's
+// comment stripping, since "//" is ordinary text there.
+func decodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		data = decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		data = decodeUTF16(data[2:], binary.BigEndian)
+	}
+	if !utf8.Valid(data) {
+		data = decodeCP1252(data)
+	}
+	return data
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+func decodeCP1252(data []byte) []byte {
+	var buf bytes.Buffer
+	for _, b := range data {
+		switch {
+		case b < 0x80:
+			buf.WriteByte(b)
+		case b < 0xA0:
+			buf.WriteRune(cp1252[b-0x80])
+		default:
+			buf.WriteRune(rune(b))
+		}
+	}
+	return buf.Bytes()
+}
+
+// stripJSONC blanks out // and /* */ comments and trailing commas before a
+// closing } or ], leaving string contents alone. stripped reports whether
+// anything was removed.
+func stripJSONC(data []byte) (out []byte, stripped bool) {
+	out = append([]byte(nil), data...)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+		stripped = true
+	}
+
+	// Comments first, so a comment between a comma and a bracket does not
+	// hide the trailing comma
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			i = skipString(out, i)
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return out, stripped // unterminated; let the JSON parser report it
+			}
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		}
+	}
+
+	comma := -1
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '"':
+			i = skipString(out, i)
+			comma = -1
+		case c == ',':
+			comma = i
+		case (c == '}' || c == ']') && comma >= 0:
+			blank(comma, comma+1)
+			comma = -1
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			comma = -1
+		}
+	}
+	return out, stripped
+}
+
+// skipString returns the index of the quote closing the string starting at
+// data[start].
+func skipString(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultK8sAPIVersion is the group/version of the Subnet custom resources
+// written by -exportk8s.
+const defaultK8sAPIVersion = "ipsubnetplanner.microsoft.com/v1alpha1"
+
+// ExportK8s writes one Subnet custom resource per planned subnet as a
+// multi-document YAML file, with spec.cidr, vlan, gateway and the single
+// addresses assigned in the subnet. apiVersion defaults to
+// defaultK8sAPIVersion; namespace is left out when empty. withCRD prepends
+// the CustomResourceDefinition of the Subnet kind.
+func ExportK8s(results []SubnetResult, path, apiVersion, namespace string, withCRD bool) error {
+	if apiVersion == "" {
+		apiVersion = defaultK8sAPIVersion
+	}
+	group, version, ok := strings.Cut(apiVersion, "/")
+	if !ok || group == "" || version == "" {
+		return fmt.Errorf("invalid -k8sapi %q (use group/version, e.g. %s)", apiVersion, defaultK8sAPIVersion)
+	}
+
+	var sb strings.Builder
+	if withCRD {
+		writeSubnetCRD(&sb, group, version)
+	}
+	fragments, _ := subnetFragments(results)
+	names := make(map[string]int)
+	for _, f := range fragments {
+		name := dnsLabel(f.Name)
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+		sb.WriteString("---\n")
+		sb.WriteString(fmt.Sprintf("apiVersion: %s\n", apiVersion))
+		sb.WriteString("kind: Subnet\n")
+		sb.WriteString("metadata:\n")
+		sb.WriteString(fmt.Sprintf("  name: %s\n", name))
+		if namespace != "" {
+			sb.WriteString(fmt.Sprintf("  namespace: %s\n", namespace))
+		}
+		sb.WriteString("  labels:\n")
+		sb.WriteString(fmt.Sprintf("    ipsubnetplanner.microsoft.com/parent: %s\n", yamlString(dnsLabel(strings.ReplaceAll(f.Parent, "/", "-")))))
+		if f.VLAN > 0 {
+			sb.WriteString(fmt.Sprintf("    ipsubnetplanner.microsoft.com/vlan: \"%d\"\n", f.VLAN))
+		}
+		if f.Description != "" {
+			sb.WriteString("  annotations:\n")
+			sb.WriteString(fmt.Sprintf("    ipsubnetplanner.microsoft.com/description: %s\n", yamlString(f.Description)))
+		}
+		sb.WriteString("spec:\n")
+		sb.WriteString(fmt.Sprintf("  name: %s\n", yamlString(f.Name)))
+		sb.WriteString(fmt.Sprintf("  cidr: %s\n", yamlString(f.Subnet)))
+		sb.WriteString(fmt.Sprintf("  parent: %s\n", yamlString(f.Parent)))
+		if f.VLAN > 0 {
+			sb.WriteString(fmt.Sprintf("  vlan: %d\n", f.VLAN))
+		}
+		if f.Gateway != "" {
+			sb.WriteString(fmt.Sprintf("  gateway: %s\n", yamlString(f.Gateway)))
+		}
+		var assignments []SubnetResult
+		for _, r := range f.Rows {
+			if isAssignedCategory(r.Category) && !strings.Contains(r.IP, " - ") {
+				assignments = append(assignments, r)
+			}
+		}
+		if len(assignments) > 0 {
+			sb.WriteString("  assignments:\n")
+			for _, r := range assignments {
+				sb.WriteString(fmt.Sprintf("    - name: %s\n", yamlString(r.Label)))
+				sb.WriteString(fmt.Sprintf("      ip: %s\n", yamlString(r.IP)))
+			}
+		}
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// writeSubnetCRD writes the CustomResourceDefinition of the Subnet kind.
+func writeSubnetCRD(sb *strings.Builder, group, version string) {
+	sb.WriteString("---\n")
+	sb.WriteString("apiVersion: apiextensions.k8s.io/v1\n")
+	sb.WriteString("kind: CustomResourceDefinition\n")
+	sb.WriteString("metadata:\n")
+	sb.WriteString(fmt.Sprintf("  name: subnets.%s\n", group))
+	sb.WriteString("spec:\n")
+	sb.WriteString(fmt.Sprintf("  group: %s\n", group))
+	sb.WriteString("  scope: Namespaced\n")
+	sb.WriteString("  names:\n")
+	sb.WriteString("    kind: Subnet\n")
+	sb.WriteString("    plural: subnets\n")
+	sb.WriteString("    singular: subnet\n")
+	sb.WriteString("  versions:\n")
+	sb.WriteString(fmt.Sprintf("    - name: %s\n", version))
+	sb.WriteString("      served: true\n")
+	sb.WriteString("      storage: true\n")
+	sb.WriteString("      schema:\n")
+	sb.WriteString("        openAPIV3Schema:\n")
+	sb.WriteString("          type: object\n")
+	sb.WriteString("          properties:\n")
+	sb.WriteString("            spec:\n")
+	sb.WriteString("              type: object\n")
+	sb.WriteString("              required: [name, cidr]\n")
+	sb.WriteString("              properties:\n")
+	sb.WriteString("                name: {type: string}\n")
+	sb.WriteString("                cidr: {type: string}\n")
+	sb.WriteString("                parent: {type: string}\n")
+	sb.WriteString("                vlan: {type: integer}\n")
+	sb.WriteString("                gateway: {type: string}\n")
+	sb.WriteString("                assignments:\n")
+	sb.WriteString("                  type: array\n")
+	sb.WriteString("                  items:\n")
+	sb.WriteString("                    type: object\n")
+	sb.WriteString("                    properties:\n")
+	sb.WriteString("                      name: {type: string}\n")
+	sb.WriteString("                      ip: {type: string}\n")
+}
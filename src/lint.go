@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"regexp"
+)
+
+// exitLintScore is returned when the plan scores below -min-score.
+const exitLintScore = 4
+
+// LintReport scores plan hygiene. Each of the four components contributes up
+// to 25 points to Score.
+type LintReport struct {
+	Score         int      `json:"score"`
+	Efficiency    float64  `json:"efficiency"`
+	Naming        float64  `json:"naming"`
+	NamingStyle   string   `json:"namingStyle,omitempty"`
+	Documentation float64  `json:"documentation"`
+	Warnings      []string `json:"warnings"`
+}
+
+// namingStyles are the conventions recognised when scoring name consistency.
+var namingStyles = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"kebab-case", regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)},
+	{"snake_case", regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)},
+	{"Title Case", regexp.MustCompile(`^[A-Z][A-Za-z0-9]*([ -][A-Z0-9][A-Za-z0-9]*)*$`)},
+	{"UPPER_CASE", regexp.MustCompile(`^[A-Z0-9]+([_-][A-Z0-9]+)*$`)},
+}
+
+// LintPlan scores the configuration and its computed plan; growth is the
+// planner's growth percentage (Planner.Growth) for subnets without their own.
+func LintPlan(networks []Network, results []SubnetResult, growth int) LintReport {
+	var subnets []Subnet
+	for _, n := range networks {
+		subnets = append(subnets, n.Subnets...)
+	}
+
+	report := LintReport{Warnings: lintWarnings(networks, results, growth)}
+	report.Efficiency = hostEfficiency(subnets, growth)
+	report.Naming, report.NamingStyle = namingConsistency(subnets)
+	report.Documentation = documentationCompleteness(subnets)
+
+	penalty := math.Max(0, 25-5*float64(len(report.Warnings)))
+	report.Score = int(math.Round(25*report.Efficiency + 25*report.Naming + 25*report.Documentation + penalty))
+	return report
+}
+
+// hostEfficiency is requested hosts divided by usable addresses allocated,
+// over subnets sized by host count.
+func hostEfficiency(subnets []Subnet, growth int) float64 {
+	var requested, allocated int
+	for _, s := range subnets {
+		if s.CIDR > 0 || s.Hosts <= 0 {
+			continue
+		}
+		// Growth padding is intentional and counts as requested
+		hosts := grownHosts(s.Hosts, s.growth(growth))
+		requested += hosts
+		allocated += usableHostCount(calculatePrefixFromHosts(hosts))
+	}
+	if allocated == 0 {
+		return 1
+	}
+	return math.Min(1, float64(requested)/float64(allocated))
+}
+
+func usableHostCount(prefix int) int {
+	switch prefix {
+	case 32:
+		return 1
+	case 31:
+		return 2
+	}
+	return 1<<(32-prefix) - 2
+}
+
+// namingConsistency returns the share of names following the most common style.
+func namingConsistency(subnets []Subnet) (float64, string) {
+	if len(subnets) == 0 {
+		return 1, ""
+	}
+	best, bestStyle := 0, ""
+	for _, style := range namingStyles {
+		matches := 0
+		for _, s := range subnets {
+			if style.pattern.MatchString(s.Name) {
+				matches++
+			}
+		}
+		if matches > best {
+			best, bestStyle = matches, style.name
+		}
+	}
+	return float64(best) / float64(len(subnets)), bestStyle
+}
+
+// documentationCompleteness gives half credit each for description and owner.
+func documentationCompleteness(subnets []Subnet) float64 {
+	if len(subnets) == 0 {
+		return 1
+	}
+	filled := 0
+	for _, s := range subnets {
+		if s.Description != "" {
+			filled++
+		}
+		if s.Owner != "" {
+			filled++
+		}
+	}
+	return float64(filled) / float64(2*len(subnets))
+}
+
+// lintWarnings reports problems that do not stop planning but usually
+// indicate a mistake or a plan without room to grow. growth is as for
+// LintPlan.
+func lintWarnings(networks []Network, results []SubnetResult, growth int) []string {
+	warnings := []string{}
+	names := map[string]int{}
+	vlans := map[int]string{}
+	for _, n := range networks {
+		withVLAN := 0
+		for _, s := range n.Subnets {
+			if s.VLAN > 0 {
+				withVLAN++
+			}
+		}
+		for _, s := range n.Subnets {
+			names[s.Name]++
+			if names[s.Name] == 2 {
+				warnings = append(warnings, fmt.Sprintf("subnet name %q is used more than once", s.Name))
+			}
+			if s.VLAN > 4094 {
+				warnings = append(warnings, fmt.Sprintf("subnet %s: VLAN %d is outside 1-4094", s.Name, s.VLAN))
+			}
+			if s.VLAN > 0 {
+				if other, ok := vlans[s.VLAN]; ok {
+					warnings = append(warnings, fmt.Sprintf("VLAN %d is shared by %s and %s", s.VLAN, other, s.Name))
+				} else {
+					vlans[s.VLAN] = s.Name
+				}
+			} else if withVLAN > 0 {
+				warnings = append(warnings, fmt.Sprintf("subnet %s has no VLAN while others in %s do", s.Name, n.Network))
+			}
+			if s.CIDR == 0 && s.Hosts > 0 && s.growth(growth) == 0 && float64(s.Hosts) > 0.9*float64(usableHostCount(calculatePrefixFromHosts(s.Hosts))) {
+				warnings = append(warnings, fmt.Sprintf("subnet %s: %d hosts leave less than 10%% headroom", s.Name, s.Hosts))
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(n.Network)
+		if err != nil {
+			continue
+		}
+		used, total := networkUtilization(n, results)
+		if total > 0 && float64(used) > 0.9*float64(total) {
+			warnings = append(warnings, fmt.Sprintf("network %s is %d%% allocated", ipNet, used*100/total))
+		}
+	}
+	return warnings
+}
+
+// PrintLintReport writes a human-readable report to w.
+func PrintLintReport(w io.Writer, r LintReport) {
+	fmt.Fprintf(w, "\nPlan quality score: %d/100\n", r.Score)
+	fmt.Fprintf(w, "  %-24s %4.0f%%\n", "Utilization efficiency", 100*r.Efficiency)
+	if r.NamingStyle != "" {
+		fmt.Fprintf(w, "  %-24s %4.0f%%  (%s)\n", "Naming consistency", 100*r.Naming, r.NamingStyle)
+	} else {
+		fmt.Fprintf(w, "  %-24s %4.0f%%\n", "Naming consistency", 100*r.Naming)
+	}
+	fmt.Fprintf(w, "  %-24s %4.0f%%  (description/owner)\n", "Documentation", 100*r.Documentation)
+	fmt.Fprintf(w, "  %-24s %4d\n", "Validation warnings", len(r.Warnings))
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "    - %s\n", warning)
+	}
+}
+
+// ExportLintReport writes the report as JSON so scores can be tracked over time.
+func ExportLintReport(r LintReport, filepath string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, data, 0644)
+}
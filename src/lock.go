@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockedWrite runs write while holding an advisory "<path>.lock" file, so two
+// planners exporting to the same shared location do not interleave. Before
+// writing, the target is compared with the checksum recorded the last time
+// this tool generated it; if someone edited it since, the write is refused
+// unless force is set.
+func lockedWrite(path string, force bool, write func() error) error {
+	lockPath := path + ".lock"
+	lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			owner, _ := os.ReadFile(lockPath)
+			return fmt.Errorf("%s is locked by another export (%s); remove %s if it is stale",
+				path, strings.TrimSpace(string(owner)), lockPath)
+		}
+		return fmt.Errorf("failed to create lock file: %v", err)
+	}
+	host, _ := os.Hostname()
+	fmt.Fprintf(lock, "pid %d on %s at %s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+	lock.Close()
+	defer os.Remove(lockPath)
+
+	statePath := exportStatePath(path)
+	if recorded, err := os.ReadFile(statePath); err == nil {
+		current, err := fileChecksum(path)
+		if err == nil && current != strings.TrimSpace(string(recorded)) {
+			if !force {
+				return fmt.Errorf("%s was modified since it was last generated; refusing to overwrite (use -force to overwrite)", path)
+			}
+			fmt.Fprintf(os.Stderr, "warning: overwriting %s, which was modified since it was last generated\n", path)
+		}
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+
+	sum, err := fileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("failed to record export state: %v", err)
+	}
+	return os.WriteFile(statePath, []byte(sum+"\n"), 0644)
+}
+
+// exportStatePath is the hidden sidecar holding the checksum of the last
+// generated version of path.
+func exportStatePath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".ipsubnetplanner")
+}
+
+func fileChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
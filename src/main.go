@@ -1,20 +1,35 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 )
 
 // version can be set at build time with -ldflags "-X main.version=x.y.z"
 var version = "1.0.0"
 
+// exitExportFailure is returned when -export-errors=fail and any export failed.
+const exitExportFailure = 3
+
+// defaultExportErrorMode fails the run on export errors in CI (CI env var set)
+// and only warns for interactive use.
+func defaultExportErrorMode() string {
+	if os.Getenv("CI") != "" {
+		return "fail"
+	}
+	return "warn"
+}
+
 func fatal(msg string) {
-	fmt.Fprintf(os.Stderr, "%s\n", msg)
-	os.Exit(1)
+	exitWithError(exitFailure, msg)
 }
 
 // parseSpecs converts spec string value:count pairs into Subnet slice.
@@ -56,8 +71,32 @@ func parseSpecs(spec string, isHosts bool) ([]Subnet, error) {
 	return out, nil
 }
 
+// subcommands maps the first command-line argument to an alternate entry point.
+var subcommands = map[string]func(args []string) int{
+	"serve":    runServe,
+	"search":   runSearch,
+	"refactor": runRefactor,
+	"fmt":      runFormat,
+	"capacity": runCapacity,
+	"split":    runSplit,
+	"view":     runView,
+	"import":   runImport,
+	"check":    runCheck,
+	"phpipam":  runPHPIPAM,
+	"infoblox": runInfoblox,
+}
+
 func main() {
+	defer recoverWithDiagnostics()
+
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	// Pre-parse validation to give clearer error if user supplies a bare string export flag without value.
+	errorFormat = errorFormatArg(os.Args[1:])
 	validateBareOutputFlags()
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "IPSubnetPlanner\n\n")
@@ -68,104 +107,691 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  ipsubnetplanner -input config.json -exportjson plan.json -exportcsv plan.csv\n")
 		fmt.Fprintf(os.Stderr, "  ipsubnetplanner -network 192.168.1.0/24 -hosts 50:2,10:3\n")
 		fmt.Fprintf(os.Stderr, "  ipsubnetplanner -network 10.0.0.0/16 -cidr 26:2,28:1\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner -input config.json -diff previous-plan.json\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner -interactive -network 10.0.0.0/22 -hosts 50:2\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner serve -listen :8080\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner search 10.0.0.25 plans/\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner refactor -input config.json -w rename Web Frontend\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner fmt -w -sort name configs/*.json\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner capacity -network 10.0.0.0/22 -cidr 27\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner split 10.0.0.0/24 -into /26\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner import -o config.json solarwinds-subnets.csv\n")
+		fmt.Fprintf(os.Stderr, "  ipsubnetplanner view -group network -search 10.0.0.25 plan.json\n")
 		fmt.Fprintf(os.Stderr, "\n")
 	}
 
 	// Flags
-	inputFile := flag.String("input", "", "Path to JSON configuration file")
+	inputFile := flag.String("input", "", "Path to JSON (or .csv/.xlsx) configuration file (- reads JSON from stdin)")
+	env := flag.String("env", "", "Environment to plan from a config with \"environments\" (e.g. prod)")
 	network := flag.String("network", "", "Parent network in CIDR notation (e.g., 192.168.1.0/24)")
 	hostSpec := flag.String("hosts", "", "Host requirements spec (e.g., 50:2,10:3 => 2x50-host, 3x10-host)")
 	cidrSpec := flag.String("cidr", "", "CIDR prefix spec (e.g., 26:2,28:1 => 2x/26, 1x/28)")
-	exportJSON := flag.String("exportjson", "", "Export to JSON file (disabled by default; specify filename to enable)")
-	exportCSV := flag.String("exportcsv", "", "Export to CSV file (disabled by default; specify filename to enable)")
+	p2pLinks := flag.Int("p2p", 0, "Number of point-to-point links (p2p-1, p2p-2, ...) to add with -network; sized /31 unless -p2p30")
+	legacyP2P := flag.Bool("p2p30", false, "Legacy mode: size point-to-point links as /30 instead of /31 for carrier equipment that rejects /31")
+	exportJSON := flag.String("exportjson", "", "Export to JSON file (disabled by default; specify filename to enable, or - for stdout)")
+	exportCSV := flag.String("exportcsv", "", "Export to CSV file (disabled by default; specify filename to enable, or - for stdout)")
+	csvAppend := flag.Bool("exportcsv-append", false, "Merge into an existing -exportcsv file (keyed by Subnet+Label) instead of replacing it")
+	csvIPFormat := flag.String("csvipformat", "plain", "How -exportcsv writes addresses: plain, padded (zero-padded octets, 010.060.048.128) or fixed (padded with spaces to a fixed column width)")
 	exportMD := flag.String("exportmd", "plan.md", "Export to Markdown file (default plan.md; set empty to disable)")
+	exportHTML := flag.String("exporthtml", "", "Export a self-contained HTML report with summary statistics, the allocation map and the plan tables")
+	exportDHCP := flag.String("exportdhcp", "", "Export DHCP scopes for assignments flagged \"DHCP\": true")
+	dhcpFormat := flag.String("dhcpformat", "", "DHCP export format: powershell or isc (default: powershell for .ps1, otherwise isc)")
+	exportBicep := flag.String("exportbicep", "", "Export an Azure Bicep template (one VNet per parent network)")
+	exportAWS := flag.String("exportaws", "", "Export AWS VPCs and subnets, placed in the availability zone of their \"az\" tag")
+	awsFormat := flag.String("awsformat", "", "AWS export format: terraform or cloudformation (default: terraform for .tf, otherwise a CloudFormation JSON template)")
+	exportAnsible := flag.String("exportansible", "", "Export an Ansible YAML inventory (one group per subnet, one host per named assignment)")
+	exportCircuits := flag.String("exportcircuits", "", "Export the circuit addressing sheet (CSV: block, PE, CE, usable range per circuit) for circuits in the config")
+	exportMetrics := flag.String("exportmetrics", "", "Export per-network and per-subnet utilization gauges in Prometheus text format (e.g. for the node_exporter textfile collector)")
+	exportSwitch := flag.String("exportswitch", "", "Export switch VLAN and SVI configuration in this dialect: ios or eos (written to -switchfile)")
+	switchFile := flag.String("switchfile", "", "Output file for -exportswitch (default switch-<dialect>.cfg)")
+	exportSVG := flag.String("exportsvg", "", "Export an SVG diagram with one proportional bar per parent network (subnets, free space, and address use)")
+	exportSet := flag.String("export", "", "Write several formats in one run: \"all\" or a list such as \"json,csv,md\", optionally followed by :<dir> (e.g. all:out/); -exportX flags given explicitly win")
+	exportName := flag.String("exportname", "", "Base file name for -export (default: the -input file name without extension, or \"plan\")")
+	exportFragments := flag.String("exportfragments", "", "Export one file per subnet into this directory (<network>/<subnet>.json) for GitOps")
+	fragmentFormat := flag.String("fragmentformat", "json", "File format of -exportfragments: json or yaml")
+	exportDir := flag.String("exportdir", "", "Export one file per parent network and format into this directory, plus an index.md")
+	exportDirFormats := flag.String("exportdirformats", "json,csv,md", "Formats written by -exportdir: json, csv, md, svg")
+	exportConfig := flag.String("exportconfig", "", "Export a config that reproduces the plan, with every subnet pinned to its address")
+	exportK8s := flag.String("exportk8s", "", "Export a Kubernetes Subnet custom resource per subnet (multi-document YAML)")
+	k8sAPI := flag.String("k8sapi", defaultK8sAPIVersion, "apiVersion (group/version) of the -exportk8s custom resources")
+	k8sNamespace := flag.String("k8snamespace", "", "Namespace of the -exportk8s custom resources")
+	exportWhereabouts := flag.String("exportwhereabouts", "", "Export Multus NetworkAttachmentDefinitions with whereabouts IPAM for subnets tagged \"multus\"")
+	multusCNI := flag.String("multuscni", "macvlan", "CNI plugin of the -exportwhereabouts attachments (e.g. macvlan, ipvlan, vlan)")
+	k8sCRD := flag.Bool("k8scrd", false, "Include the Subnet CustomResourceDefinition in the -exportk8s file")
+	exportTopology := flag.String("exporttopology", "", "Export a topology diagram of networks, subnets, and gateways/routers (Mermaid, or Graphviz DOT for .dot/.gv)")
+	exportView := flag.String("exportview", "", "Export a view model for web front-ends: per-network and per-subnet utilization, sorted assignments and display strings (JSON)")
+	exportRoutes := flag.String("exportroutes", "", "Export static routes to the destinations of subnets tagged \"transit\" (shell script, or PowerShell for .ps1)")
+	routeFormat := flag.String("routeformat", "", "Static route syntax: linux, windows, ios, eos or junos (default: windows for .ps1, otherwise linux)")
+	exportGraph := flag.String("exportgraph", "", "Export a graph of networks, subnets and devices for topology tools and Neo4j (JSON, or GraphML for .graphml/.xml)")
+	exportImpact := flag.String("exportimpact", "", "Export a report of subnets by criticality with their shared parents, gateway devices and VLANs, for maintenance impact analysis (Markdown, or JSON for .json)")
+	graphFormat := flag.String("graphformat", "", "Graph format: json or graphml (default: graphml for .graphml/.xml, otherwise json)")
+	proposeParents := flag.String("propose-parents", "", "Candidate parent CIDRs (comma-separated): when subnets do not fit, propose distributing them over these parents instead of only failing")
+	topologyFormat := flag.String("topologyformat", "", "Topology diagram format: mermaid or dot (default: dot for .dot/.gv, otherwise mermaid)")
+	exportDNS := flag.String("exportdns", "", "Export DNS records: a BIND forward zone with reverse zones (db.<zone>) next to it, or Windows DNS PowerShell for .ps1; requires -dnsdomain")
+	dnsDomain := flag.String("dnsdomain", "", "DNS domain (zone suffix) for -exportdns (e.g. corp.example.com)")
+	dnsFormat := flag.String("dnsformat", "", "DNS export format: bind or powershell (default: powershell for .ps1, otherwise bind)")
+	exportErrors := flag.String("export-errors", defaultExportErrorMode(), "Export failure handling: warn (exit 0) or fail (exit 3); defaults to fail when CI is set")
+	exportRetries := flag.Int("export-retries", 2, "Retries for transient I/O errors (e.g. network shares) per export")
+	fallbackDir := flag.String("fallback-dir", defaultFallbackDir(), "Where exports go when their target is read-only or not writable: a directory, temp (a new private directory in <tmp>) or none to fail them (default none, or "+fallbackDirEnv+")")
+	lockExports := flag.Bool("lock", false, "Use advisory lock files and refuse to overwrite exports edited since they were generated")
+	force := flag.Bool("force", false, "Overwrite exports even if they were modified since they were last generated or are much newer than the input config")
+	unsafeExports := flag.Bool("unsafe-exports", false, "Allow exports into the filesystem root and system directories")
+	newerDays := flag.Int("newer-days", 0, "Ask before overwriting an export more than this many days newer than the input config (off by default; 0 disables)")
+	baselinePlan := flag.String("baseline", "", "Keep the addresses of subnets in a previous -exportjson plan; only new or resized subnets are allocated, and removed ones are reported as reclaimable")
+	diffPlan := flag.String("diff", "", "Compare the computed plan against a previous -exportjson file and report changes")
+	capacityWarn := flag.Int("capacity-warn", 80, "Warn when a network's utilization reaches this percentage, 0 to disable (networks can override with capacityWarn)")
+	capacityError := flag.Int("capacity-error", 95, "Exit with code 5 when a network's utilization reaches this percentage, 0 to disable (networks can override with capacityError)")
+	webhook := flag.String("webhook", "", "POST capacity alerts as JSON to this URL when a threshold is reached")
+	annotationsFile := flag.String("annotations", "", "JSON sidecar of per-subnet review notes ({\"<subnet>\": {\"note\", \"approval\", \"reviewer\"}}) merged into the Markdown export")
+	exportTicket := flag.String("exportticket", "", "With -diff, write a change ticket body (summary, affected subnets, before/after, rollback notes)")
+	ticketFormat := flag.String("ticketformat", "", "Change ticket format: jira or servicenow (default: servicenow for .html, otherwise jira)")
+	summarize := flag.Bool("summarize", false, "Append route summarization (minimal aggregates per parent network) as Summary rows")
+	changesOnly := flag.Bool("changes-only", false, "With -diff, output only added/changed/removed rows with a Change column")
+	usageStatsFile := flag.String("usage-stats", "", "Opt in to anonymous usage stats (flag names and plan sizes only), appended locally to this file; nothing is ever sent over the network")
+	lint := flag.Bool("lint", false, "Print a plan quality score (efficiency, naming, documentation, warnings)")
+	minScore := flag.Int("min-score", 0, "Exit with code 4 if the plan quality score is below this value (implies -lint)")
+	exportLint := flag.String("exportlint", "", "Export the plan quality report as JSON")
+	asOf := flag.String("as-of", "", "Only output subnets whose plannedFor date is on or before this date (YYYY-MM-DD, RFC 3339, today or now)")
+	timeZone := flag.String("tz", "Local", "Time zone for -as-of and date-only plannedFor values (IANA name, e.g. Europe/Berlin)")
+	sortOrder := flag.String("sort", "", "Output order for console and exports: input, address, name, vlan or category, or several keys such as vlan,name (default: allocation order, or outputOrder from the config)")
+	reclaim := flag.Bool("reclaim", false, "Release the space of decommissioned subnets for reuse instead of keeping it quarantined")
+	quarantine := flag.Int("quarantine-days", 0, "Days after decommissionedOn before -reclaim may release a subnet's space (overrides quarantineDays in the config)")
+	interactive := flag.Bool("interactive", false, "Edit the plan interactively (add/resize/move subnets with live utilization), starting from -input or -network if given")
+	flag.StringVar(&errorFormat, "errors", errorFormat, "Error output: text, or json for a single {\"error\": {code, exitCode, message, details}} line on stderr")
+	filterExpr := flag.String("filter", "", "Show and export only rows matching an expression, e.g. 'category==Assignment && vlan in (100,110)'")
+	includeCategories := flag.String("include-categories", "", "Show and export only rows of these categories, e.g. Network,Assignment,Broadcast")
+	excludeCategories := flag.String("exclude-categories", "", "Hide rows of these categories from the console and exports, e.g. Unused,Available")
+	group := flag.Bool("group", false, "Group the console table by parent network and subnet, with a header per subnet and totals")
+	columnList := flag.String("columns", "", "Select and order the columns of the console table and -exportcsv, e.g. Subnet,Name,VLAN,IP,Label")
+	copyFormat := flag.String("copy", "", "Copy the plan to the system clipboard as table, markdown or csv")
+	collapseUnused := flag.Bool("collapse-unused", false, "Merge each subnet's Unused rows into one row listing the ranges and their total in the console, CSV, Markdown and HTML outputs (smaller CSVs for sparse subnets); JSON keeps every row")
+	stream := flag.Bool("stream", false, "Write -exportjson/-exportcsv while planning, one network at a time, without building the whole plan in memory (no console table or other exports)")
+	vars := &varFlag{}
+	flag.Var(vars, "var", "Set a variable, as name=value (repeatable): a value for ${name} in the config, or a number for host count expressions (overrides the config's \"variables\")")
+	flag.StringVar(&vars.file, "var-file", "", "File of values for ${NAME} in the config: a JSON object or NAME=value lines (-var and then this file win over environment variables)")
+	workers := flag.Int("workers", 0, "Number of networks planned concurrently (default: number of CPUs; 1 plans them one at a time)")
+	growth := flag.Int("growth", 0, "Percentage added to every subnet's host count before sizing (e.g. 30 sizes 100 hosts for 130); a subnet's \"growth\" overrides it")
+	strict := flag.Bool("strict", false, "Treat parent networks with host bits set (e.g. 192.168.1.10/24) and unknown config fields as errors instead of warnings")
+	oobStrict := flag.Bool("oob-strict", false, "Exit with code 7 when the out-of-band check (\"oob\" in the config) finds devices without an OOB address")
+	quiet := flag.Bool("quiet", false, "Suppress the console table, other output on stdout and the default plan.md (implied when an export writes to stdout)")
+	flag.BoolVar(&noColor, "no-color", false, "Print the console table without colors (also set by the NO_COLOR environment variable)")
+	flag.IntVar(&consoleWindow.head, "head", 0, "Show only the first N rows of the console table (exports keep every row)")
+	flag.IntVar(&consoleWindow.tail, "tail", 0, "Show only the last N rows of the console table (exports keep every row); with -head, both ends")
+	flag.BoolVar(&usePager, "pager", false, "Page the console table through $PAGER (default: less -FRX, or more on Windows) when stdout is a terminal")
+	showSchema := flag.Bool("schema", false, "Print the JSON Schema of the config format and exit")
 	showVersion := flag.Bool("version", false, "Print version and exit")
 
 	flag.Parse()
 
+	if errorFormat != "text" && errorFormat != "json" {
+		format := errorFormat
+		errorFormat = "text"
+		exitWithError(exitUsage, fmt.Sprintf("invalid -errors value %q (use text or json)", format))
+	}
+	if *exportErrors != "warn" && *exportErrors != "fail" {
+		exitWithError(exitUsage, fmt.Sprintf("invalid -export-errors value %q (use warn or fail)", *exportErrors))
+	}
+
 	if *showVersion {
 		fmt.Println("IPSubnetPlanner version", version)
 		return
 	}
+	if *showSchema {
+		os.Stdout.Write(configSchemaJSON)
+		return
+	}
 
+	var rowMatch rowFilter
+	if *filterExpr != "" {
+		f, err := compileFilter(*filterExpr)
+		if err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+		rowMatch = f
+	}
+	categories, err := categoryFilter(*includeCategories, *excludeCategories)
+	if err != nil {
+		exitWithError(exitUsage, err.Error())
+	}
+	rowMatch = andFilters(rowMatch, categories)
+	columns, err := parseColumns(*columnList)
+	if err != nil {
+		exitWithError(exitUsage, err.Error())
+	}
+	if columns != nil && *group {
+		exitWithError(exitUsage, "-group cannot be combined with -columns")
+	}
+	if consoleWindow.head < 0 || consoleWindow.tail < 0 {
+		exitWithError(exitUsage, "-head and -tail must not be negative")
+	}
+	if *group && consoleWindow != (rowWindow{}) {
+		exitWithError(exitUsage, "-group cannot be combined with -head or -tail, since its totals cover every row")
+	}
+	if columns != nil && *csvAppend {
+		exitWithError(exitUsage, "-columns cannot be combined with -exportcsv-append, which keeps the existing file's columns")
+	}
+	if err := validateIPFormat(*csvIPFormat); err != nil {
+		exitWithError(exitUsage, err.Error())
+	}
+	if *copyFormat != "" {
+		if _, err := renderCopy(nil, *copyFormat); err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+	}
+
+	// An export streamed to stdout must not be mixed with console output
+	streamed, err := streamedExport()
+	if err != nil {
+		exitWithError(exitUsage, err.Error())
+	}
+	// Without console output, plan.md is only written when asked for
+	defaultMD := !isFlagSet("exportmd") && !*quiet && !*stream && streamed == ""
+	if *quiet || streamed != "" {
+		if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+			os.Stdout = devNull
+		}
+	}
+
+	started := time.Now()
 	var networks []Network
+	order, quarantineDays := "", 0
+	var circuits []Circuit
+	var oob *OOBCheck
+	var outputs []Output
 
 	if *inputFile != "" {
-		data, err := os.ReadFile(*inputFile)
+		data, err := readInput(*inputFile)
 		if err != nil {
-			fatal(fmt.Sprintf("error reading config file: %v", err))
+			exitWithError(exitConfigError, fmt.Sprintf("error reading config file: %v", err))
 		}
-		// Try array first
-		var arr []Network
-		if err := json.Unmarshal(data, &arr); err == nil {
-			networks = arr
-		} else {
-			var single Network
-			if err := json.Unmarshal(data, &single); err != nil {
-				// Provide helpful error message
-				errMsg := fmt.Sprintf("error parsing config file: %v\n\n", err)
-				errMsg += "Common issues:\n"
-				errMsg += "  1. Check that 'vlan' and 'cidr' values are integers (not strings)\n"
-				errMsg += "     ✗ Bad:  \"vlan\": \"100\", \"cidr\": \"26\"\n"
-				errMsg += "     ✓ Good: \"vlan\": 100, \"cidr\": 26\n\n"
-				errMsg += "  2. Verify JSON structure:\n"
-				errMsg += "     Single network: {\"network\": \"...\", \"subnets\": [...]}\n"
-				errMsg += "     Multi-network:  [{\"network\": \"...\", \"subnets\": [...]}, ...]\n\n"
-				errMsg += "See examples/ directory for reference."
-				fatal(errMsg)
-			}
-			networks = []Network{single}
+		if data, err = substituteVars(*inputFile, data, vars); err != nil {
+			exitWithError(exitConfigError, err.Error())
+		}
+		cfg, err := loadConfigFile(*inputFile, data, *env)
+		if err != nil {
+			var details []string
+			if schemaErr, ok := err.(*SchemaError); ok {
+				details = schemaErr.Problems
+			}
+			exitWithError(exitConfigError, err.Error(), details...)
+		}
+		if len(cfg.Warnings) > 0 && *strict {
+			exitWithError(exitConfigError, "config has unknown fields:\n  "+strings.Join(cfg.Warnings, "\n  "), cfg.Warnings...)
 		}
+		for _, w := range cfg.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s (ignored)\n", w)
+		}
+		networks, order, quarantineDays, circuits, oob = cfg.Networks, cfg.OutputOrder, cfg.QuarantineDays, cfg.Circuits, cfg.OOB
+		outputs = cfg.Outputs
 	} else if *network != "" {
 		// Build network from specs
 		hostSubs, err := parseSpecs(*hostSpec, true)
 		if err != nil {
-			fatal(err.Error())
+			exitWithError(exitUsage, err.Error())
 		}
 		cidrSubs, err := parseSpecs(*cidrSpec, false)
 		if err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+		if len(hostSubs) == 0 && len(cidrSubs) == 0 && *p2pLinks == 0 {
+			exitWithError(exitUsage, "provide at least one -hosts, -cidr or -p2p spec when using -network")
+		}
+		networks = []Network{{Network: *network, Subnets: append(hostSubs, cidrSubs...), P2PLinks: *p2pLinks}}
+	} else if !*interactive {
+		exitWithError(exitUsage, "either -input (or legacy -f) or -network must be provided")
+	}
+	if networks, err = resolveHosts(networks, vars.numbers); err != nil {
+		exitWithError(exitConfigError, err.Error())
+	}
+	if warnings := normalizeParents(networks); len(warnings) > 0 {
+		if *strict {
+			exitWithError(exitValidationError, strings.Join(warnings, "\n"), warnings...)
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s; using the network address\n", w)
+		}
+	}
+
+	if *interactive {
+		crashConfig = networks
+		if err := runInteractive(networks, os.Stdin, os.Stdout); err != nil {
 			fatal(err.Error())
 		}
-		if len(hostSubs) == 0 && len(cidrSubs) == 0 {
-			fatal("provide at least one -hosts or -cidr spec when using -network")
+		return
+	}
+
+	var at time.Time
+	if *asOf != "" {
+		var err error
+		if at, err = parseAsOf(*asOf, *timeZone); err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+	}
+	if isFlagSet("quarantine-days") {
+		quarantineDays = *quarantine
+	}
+	if *workers < 0 {
+		exitWithError(exitUsage, fmt.Sprintf("invalid -workers %d (use 1 or more, or 0 for the number of CPUs)", *workers))
+	}
+	if *growth < 0 {
+		exitWithError(exitUsage, fmt.Sprintf("invalid -growth %d (use a percentage of 0 or more)", *growth))
+	}
+
+	candidateParents, err := parseCandidateParents(*proposeParents)
+	if err != nil {
+		exitWithError(exitUsage, err.Error())
+	}
+	if *workers <= 0 {
+		*workers = runtime.NumCPU()
+	}
+	planner := Planner{Reclaim: *reclaim, QuarantineDays: quarantineDays, Now: at, LegacyP2P: *legacyP2P, Growth: *growth, Workers: *workers, Vars: vars.numbers}
+
+	var reclaimable []SubnetResult
+	if *baselinePlan != "" {
+		baseline, err := loadPlanFile(*baselinePlan)
+		if err != nil {
+			exitWithError(exitConfigError, err.Error())
+		}
+		var warnings []string
+		networks, reclaimable, warnings = planner.ApplyBaseline(networks, baseline)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
 		}
-		networks = []Network{{Network: *network, Subnets: append(hostSubs, cidrSubs...)}}
-	} else {
-		fatal("either -input (or legacy -f) or -network must be provided")
 	}
 
-	results, err := PlanSubnets(networks)
+	crashConfig = networks
+	// planningFailed reports a planning error, after proposing how to split
+	// networks that do not fit when -propose-parents lists candidates
+	configured := networks
+	planningFailed := func(err error) {
+		var noSpace *NoSpaceError
+		if errors.As(err, &noSpace) && len(candidateParents) > 0 {
+			proposals, perr := planner.ProposeSplits(configured, candidateParents)
+			PrintSplitProposals(os.Stdout, proposals)
+			if perr != nil {
+				err = perr
+			} else {
+				err = fmt.Errorf("%w (see the proposed split above)", err)
+			}
+		}
+		exitWithError(planningExitCode(err), fmt.Sprintf("planning error: %v", err))
+	}
+	// Networks with several pools are planned as one network per pool
+	networks, err = planner.SplitPools(networks)
 	if err != nil {
-		fatal(fmt.Sprintf("planning error: %v", err))
+		planningFailed(err)
+	}
+	if *stream {
+		if err := checkStreamFlags(flag.CommandLine, len(outputs) > 0, order); err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+		guardInput := *inputFile
+		if guardInput == stdoutPath {
+			guardInput = ""
+		}
+		guard := &exportGuard{input: guardInput, newerDays: *newerDays, unsafe: *unsafeExports, force: *force, confirm: stdinConfirm()}
+		opts := exportOptions{retries: *exportRetries, lock: *lockExports, force: *force, guard: guard, fallback: *fallbackDir}
+		fallbacks, err := streamExports(planner, networks, rowMatch, *exportJSON, *exportCSV, columns, opts)
+		if err != nil {
+			exitWithError(planningExitCode(err), err.Error())
+		}
+		if len(fallbacks) > 0 && *exportErrors == "fail" {
+			var details []string
+			for _, err := range fallbacks {
+				details = append(details, err.Error())
+			}
+			exitWithError(exitExportFailure, fmt.Sprintf("%d export(s) failed", len(fallbacks)), details...)
+		}
+		return
 	}
 
-	PrintTable(results)
+	results, err := planner.Plan(networks)
+	if err != nil {
+		planningFailed(err)
+	}
 
-	// Exports
-	if *exportJSON != "" {
-		ensureDir(*exportJSON)
-		if err := ExportJSON(results, *exportJSON); err != nil {
-			fmt.Fprintf(os.Stderr, "error exporting JSON: %v\n", err)
-		} else {
-			fmt.Printf("\n✓ JSON: %s\n", *exportJSON)
+	if *asOf != "" {
+		results = FilterAsOf(results, at)
+	}
+
+	if *summarize {
+		results = append(results, SummarizeRoutes(results)...)
+	}
+
+	if *sortOrder != "" {
+		order = *sortOrder
+	}
+	results, err = SortResults(results, order, networks)
+	if err != nil {
+		exitWithError(exitValidationError, err.Error())
+	}
+
+	if *exportSet != "" {
+		formats, dir, all, err := parseExportSet(*exportSet)
+		if err != nil {
+			exitWithError(exitUsage, err.Error())
+		}
+		base := *exportName
+		if base == "" {
+			base = "plan"
+			if *inputFile != "" && *inputFile != stdoutPath {
+				base = strings.TrimSuffix(filepath.Base(*inputFile), filepath.Ext(*inputFile))
+			}
+		}
+		// "all" leaves out formats this run has no input for
+		skip := map[string]bool{"dns": *dnsDomain == "", "ticket": *diffPlan == "", "circuits": len(circuits) == 0, "whereabouts": !hasTaggedSubnets(networks, multusTag), "routes": !hasTaggedSubnets(networks, transitTag), "impact": !hasCriticality(networks), "aws": !hasTaggedSubnets(networks, azTag)}
+		for name, path := range exportSetPaths(formats, dir, base) {
+			if !isFlagSet(name) && !(all && skip[strings.TrimPrefix(name, "export")]) {
+				_ = flag.Set(name, path)
+			}
 		}
 	}
-	if *exportCSV != "" {
-		ensureDir(*exportCSV)
-		if err := ExportCSV(results, *exportCSV); err != nil {
-			fmt.Fprintf(os.Stderr, "error exporting CSV: %v\n", err)
-		} else {
-			fmt.Printf("✓ CSV: %s\n", *exportCSV)
+
+	// Flags given on the command line (including -export) win over outputs
+	if len(outputs) > 0 {
+		values, err := outputFlags(outputs, filepath.Dir(*inputFile))
+		if err != nil {
+			exitWithError(exitConfigError, err.Error())
+		}
+		for name, value := range values {
+			if isFlagSet(name) {
+				continue
+			}
+			if err := flag.Set(name, value); err != nil {
+				exitWithError(exitConfigError, fmt.Sprintf("outputs: invalid %s value %q: %v", name, value, err))
+			}
 		}
+		// The config lists every export, so plan.md is only written if listed
+		defaultMD = false
+	}
+	if !defaultMD && !isFlagSet("exportmd") {
+		_ = flag.Set("exportmd", "")
+	}
+
+	var previous []SubnetResult
+	if *diffPlan != "" {
+		previous, err = loadPlanFile(*diffPlan)
+		if err != nil {
+			exitWithError(exitConfigError, err.Error())
+		}
+	} else if *changesOnly {
+		exitWithError(exitUsage, "-changes-only requires -diff <previous-plan.json>")
+	} else if *exportTicket != "" {
+		exitWithError(exitUsage, "-exportticket requires -diff <previous-plan.json>")
+	}
+	full := results
+	if *changesOnly {
+		// Differential export: console and every export only carry changed rows
+		results = DiffRows(previous, results)
 	}
-	if *exportMD != "" {
-		ensureDir(*exportMD)
-		if err := ExportMarkdown(results, *exportMD); err != nil {
-			fmt.Fprintf(os.Stderr, "error exporting Markdown: %v\n", err)
+
+	if rowMatch != nil {
+		results = FilterResults(results, rowMatch)
+	}
+	// -collapse-unused only shortens the human-readable outputs; JSON and
+	// other machine-readable exports keep every Unused row
+	readable := results
+	collapsed := func(write func([]SubnetResult, string) error) func([]SubnetResult, string) error {
+		return write
+	}
+	if *collapseUnused {
+		readable = CollapseUnused(results)
+		collapsed = func(write func([]SubnetResult, string) error) func([]SubnetResult, string) error {
+			return func(r []SubnetResult, p string) error { return write(CollapseUnused(r), p) }
+		}
+	}
+
+	pageOutput(func(w io.Writer) {
+		if *group {
+			PrintGroupedTo(w, readable)
+		} else if columns != nil {
+			printColumns(w, readable, columns, consoleWindow)
+		} else {
+			printTable(w, readable, consoleStyle())
+		}
+		PrintReclaimable(w, reclaimable)
+	})
+
+	if *copyFormat != "" {
+		text, err := renderCopy(readable, *copyFormat)
+		if err == nil {
+			err = copyToClipboard(text)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not copy to the clipboard: %v\n", err)
 		} else {
-			fmt.Printf("✓ Markdown: %s\n", *exportMD)
+			fmt.Printf("✓ Copied the plan to the clipboard as %s\n", strings.ToLower(*copyFormat))
 		}
 	}
+
+	var planDiff PlanDiff
+	if *diffPlan != "" {
+		planDiff = DiffPlans(previous, full)
+		PrintDiff(planDiff)
+	}
+
+	var report LintReport
+	if *lint || *minScore > 0 || *exportLint != "" {
+		report = LintPlan(networks, full, *growth)
+		if *lint || *minScore > 0 {
+			PrintLintReport(os.Stdout, report)
+		}
+	}
+
+	var oobMissing []OOBMissing
+	if oob != nil {
+		var checked int
+		oobMissing, checked = CheckOOB(*oob, full)
+		PrintOOBCheck(os.Stdout, oobMissing, checked, oob.Subnets)
+	} else if *oobStrict {
+		exitWithError(exitUsage, "-oob-strict requires an \"oob\" section in the -input config")
+	}
+
+	capacity := CheckCapacity(networks, full, *capacityWarn, *capacityError)
+	PrintCapacity(os.Stdout, capacity)
+	if *webhook != "" && capacityLevel(capacity) != "ok" {
+		if err := postCapacityWebhook(*webhook, capacity); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: capacity webhook failed: %v\n", err)
+		}
+	}
+
+	var annotations map[string]Annotation
+	if *annotationsFile != "" {
+		if annotations, err = loadAnnotations(*annotationsFile); err != nil {
+			exitWithError(exitConfigError, err.Error())
+		}
+		for _, name := range staleAnnotations(annotations, full) {
+			fmt.Fprintf(os.Stderr, "warning: annotations file mentions subnet %q, which is not in the plan\n", name)
+		}
+	}
+
+	// Exports
+	csvWriter := ExportCSV
+	if *csvAppend {
+		csvWriter = ExportCSVAppend
+	} else if columns != nil {
+		csvWriter = func(r []SubnetResult, p string) error { return ExportCSVColumns(r, p, columns) }
+	}
+	if *csvIPFormat != "plain" {
+		write := csvWriter
+		csvWriter = func(r []SubnetResult, p string) error { return write(formatIPs(r, *csvIPFormat), p) }
+	}
+	switchPath := *switchFile
+	if *exportSwitch == "" {
+		switchPath = ""
+	} else if switchPath == "" {
+		switchPath = "switch-" + *exportSwitch + ".cfg"
+	}
+	tasks := []exportTask{
+		{label: "JSON", path: *exportJSON, write: ExportJSON},
+		{label: "CSV", path: *exportCSV, write: collapsed(csvWriter)},
+		{label: "Markdown", path: *exportMD, write: collapsed(func(r []SubnetResult, p string) error { return ExportMarkdownAnnotated(r, annotations, p) })},
+		{label: "HTML report", path: *exportHTML, write: collapsed(ExportHTML)},
+		{label: "Bicep", path: *exportBicep, write: func(r []SubnetResult, p string) error { return ExportBicep(r, networks, p) }},
+		{label: "AWS", path: *exportAWS, write: func(r []SubnetResult, p string) error { return ExportAWS(r, p, *awsFormat) }},
+		{label: "DHCP", path: *exportDHCP, write: func(r []SubnetResult, p string) error { return ExportDHCP(r, p, *dhcpFormat) }},
+		{label: "Ansible", path: *exportAnsible, write: ExportAnsible},
+		{label: "Circuits", path: *exportCircuits, write: func(r []SubnetResult, p string) error { return ExportCircuits(circuits, r, p) }},
+		{label: "Metrics", path: *exportMetrics, write: ExportMetrics},
+		{label: "Switch config", path: switchPath, write: func(r []SubnetResult, p string) error { return ExportSwitch(r, p, *exportSwitch) }},
+		{label: "SVG diagram", path: *exportSVG, write: ExportSVG},
+		{label: "View model", path: *exportView, write: ExportView},
+		{label: "Static routes", path: *exportRoutes, write: func(r []SubnetResult, p string) error { return ExportRoutes(r, p, *routeFormat) }},
+		{label: "Impact report", path: *exportImpact, write: ExportImpact},
+		{label: "Graph", path: *exportGraph, write: func(r []SubnetResult, p string) error { return ExportGraph(r, p, *graphFormat) }},
+		{label: "Topology diagram", path: *exportTopology, write: func(r []SubnetResult, p string) error { return ExportTopology(r, p, *topologyFormat) }},
+		{label: "Per-network files", path: *exportDir, dir: true, write: func(r []SubnetResult, p string) error { return ExportDir(r, p, *exportDirFormats) }},
+		{label: "Config", path: *exportConfig, write: func(_ []SubnetResult, p string) error { return ExportConfig(full, p) }},
+		{label: "Kubernetes resources", path: *exportK8s, write: func(r []SubnetResult, p string) error { return ExportK8s(r, p, *k8sAPI, *k8sNamespace, *k8sCRD) }},
+		{label: "Whereabouts attachments", path: *exportWhereabouts, write: func(r []SubnetResult, p string) error { return ExportWhereabouts(r, p, *multusCNI, *k8sNamespace) }},
+		{label: "Subnet fragments", path: *exportFragments, dir: true, write: func(r []SubnetResult, p string) error { return ExportFragments(r, p, *fragmentFormat) }},
+		{label: "DNS zones", path: *exportDNS, write: func(r []SubnetResult, p string) error { return ExportDNS(r, p, *dnsDomain, *dnsFormat) }},
+		{label: "Change ticket", path: *exportTicket, write: func(_ []SubnetResult, p string) error { return ExportTicket(planDiff, *diffPlan, p, *ticketFormat) }},
+		{label: "Lint report", path: *exportLint, write: func(_ []SubnetResult, p string) error { return ExportLintReport(report, p) }},
+	}
+	if path := usageStatsPath(*usageStatsFile); path != "" {
+		if err := appendUsageStats(path, collectUsageStats(networks, results, started)); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not record usage stats: %v\n", err)
+		}
+	}
+
+	guardInput := *inputFile
+	if guardInput == stdoutPath {
+		guardInput = ""
+	}
+	guard := &exportGuard{input: guardInput, newerDays: *newerDays, unsafe: *unsafeExports, force: *force, confirm: stdinConfirm()}
+	opts := exportOptions{retries: *exportRetries, lock: *lockExports, force: *force, guard: guard, fallback: *fallbackDir}
+	if errs := runExports(results, tasks, opts); len(errs) > 0 && *exportErrors == "fail" {
+		var details []string
+		for _, err := range errs {
+			details = append(details, err.Error())
+		}
+		exitWithError(exitExportFailure, fmt.Sprintf("%d export(s) failed", len(errs)), details...)
+	}
+
+	if *minScore > 0 && report.Score < *minScore {
+		exitWithError(exitLintScore, fmt.Sprintf("plan quality score %d is below -min-score %d", report.Score, *minScore))
+	}
+
+	if *oobStrict && len(oobMissing) > 0 {
+		var details []string
+		for _, m := range oobMissing {
+			details = append(details, fmt.Sprintf("%s (%s) has no OOB address %s", m.Device, m.Subnet, m.Expected))
+		}
+		exitWithError(exitValidationError, fmt.Sprintf("%d device(s) have no out-of-band address", len(oobMissing)), details...)
+	}
+
+	if capacityLevel(capacity) == "error" {
+		var details []string
+		for _, c := range capacity {
+			if c.Level == "error" {
+				details = append(details, fmt.Sprintf("%s is %.1f%% allocated (error at %d%%)", c.Network, c.Utilization, c.ErrorAt))
+			}
+		}
+		exitWithError(exitCapacityThreshold, "capacity error threshold reached", details...)
+	}
+}
+
+// exportTask is a single file export requested on the command line.
+type exportTask struct {
+	label string
+	path  string
+	dir   bool // path is a directory the export writes several files into
+	write func([]SubnetResult, string) error
+}
+
+// exportOptions controls how runExports writes files.
+type exportOptions struct {
+	retries int  // retries for transient I/O errors
+	lock    bool // advisory lock files and conflict detection
+	force   bool // overwrite targets edited since they were generated
+	guard   *exportGuard
+	// fallback is the -fallback-dir value for exports whose target is
+	// read-only; empty or none fails them instead
+	fallback string
+}
+
+// runExports writes every task with a non-empty path and returns the
+// collected failures instead of stopping at the first one. Exports written
+// to the fallback directory count as failures too, since their target was
+// not updated.
+func runExports(results []SubnetResult, tasks []exportTask, opts exportOptions) []error {
+	var errs []error
+	first := true
+	// The fallback directory is only resolved (and for temp, created) once
+	// an export needs it
+	var fallbackDir string
+	var fallbackErr error
+	resolved := false
+	fallback := func() (string, error) {
+		if !resolved {
+			fallbackDir, fallbackErr = resolveFallbackDir(opts.fallback)
+			resolved = true
+		}
+		return fallbackDir, fallbackErr
+	}
+	for _, task := range tasks {
+		if task.path == "" {
+			continue
+		}
+		if task.path == stdoutPath {
+			if err := task.write(results, task.path); err != nil {
+				fmt.Fprintf(os.Stderr, "error exporting %s: %v\n", task.label, err)
+				errs = append(errs, fmt.Errorf("%s export to stdout: %w", task.label, err))
+			}
+			continue
+		}
+		if opts.guard != nil {
+			if err := opts.guard.check(task.path); err != nil {
+				if errorFormat != "json" {
+					fmt.Fprintf(os.Stderr, "error exporting %s: %v\n", task.label, err)
+				}
+				errs = append(errs, fmt.Errorf("%s export to %s: %w", task.label, task.path, err))
+				continue
+			}
+		}
+		writeTo := func(path string) error {
+			ensureDir(path)
+			write := func() error { return task.write(results, path) }
+			if opts.lock && !task.dir {
+				unlocked := write
+				write = func() error { return lockedWrite(path, opts.force, unlocked) }
+			}
+			return exportWithRetry(write, opts.retries)
+		}
+		path := task.path
+		err := writeTo(path)
+		if err != nil && opts.fallback != "" && opts.fallback != "none" && unwritableTarget(path, err) {
+			dir, dirErr := fallback()
+			alt := fallbackPath(dir, path)
+			if dirErr == nil && opts.guard != nil {
+				dirErr = opts.guard.check(alt)
+			}
+			if dirErr == nil {
+				dirErr = writeTo(alt)
+			}
+			if dirErr != nil {
+				err = fmt.Errorf("%v (fallback to %s failed: %v)", err, alt, dirErr)
+			} else {
+				fmt.Fprintf(os.Stderr, "warning: %s is not writable (%v); wrote the %s export to %s instead\n", path, err, task.label, alt)
+				errs = append(errs, fmt.Errorf("%s export to %s: %w (written to %s instead)", task.label, task.path, err, alt))
+				path, err = alt, nil
+			}
+		}
+		if err != nil {
+			if errorFormat != "json" {
+				fmt.Fprintf(os.Stderr, "error exporting %s: %v\n", task.label, err)
+			}
+			errs = append(errs, fmt.Errorf("%s export to %s: %w", task.label, task.path, err))
+			continue
+		}
+		if first {
+			fmt.Println()
+			first = false
+		}
+		fmt.Printf("✓ %s: %s\n", task.label, path)
+	}
+	return errs
 }
 
 func ensureDir(filePath string) {
@@ -185,18 +811,121 @@ func validateBareOutputFlags() {
 	for i := 0; i < len(os.Args); i++ {
 		arg := os.Args[i]
 		// Check for export flags without values
-		if arg == "-exportjson" || arg == "--exportjson" || arg == "-exportcsv" || arg == "--exportcsv" || arg == "-exportmd" || arg == "--exportmd" {
+		if isFileExportFlag(arg) {
 			// If next token missing or starts with '-' then it's bare.
-			if i+1 >= len(os.Args) || strings.HasPrefix(os.Args[i+1], "-") {
+			if i+1 >= len(os.Args) || (strings.HasPrefix(os.Args[i+1], "-") && os.Args[i+1] != stdoutPath) {
+				if errorFormat == "json" {
+					exitWithError(exitUsage, fmt.Sprintf("%s requires a filename", arg))
+				}
 				// Tailor message: markdown has a default; json/csv are disabled until filename provided.
 				if arg == "-exportmd" || arg == "--exportmd" {
 					fmt.Fprintf(os.Stderr, "Error: %s requires a filename (or use %s=\"\" to disable). Default is plan.md if you omit the flag entirely.\n", arg, arg)
 					fmt.Fprintf(os.Stderr, "Tip: Just omit %s to get plan.md automatically.\n", arg)
 				} else {
-					fmt.Fprintf(os.Stderr, "Error: %s requires a filename (e.g. %s output.json). JSON/CSV exports are disabled unless you provide one.\n", arg, arg)
+					fmt.Fprintf(os.Stderr, "Error: %s requires a filename (e.g. %s output.json). File exports other than Markdown are disabled unless you provide one.\n", arg, arg)
 				}
 				os.Exit(2)
 			}
 		}
 	}
 }
+
+// stdoutExportFlags are the export flags that accept - to write to stdout.
+var stdoutExportFlags = []string{"exportjson", "exportcsv", "exportview"}
+
+// streamedExport returns the export flag set to -, if any. Only one export
+// can use stdout, and only the formats in stdoutExportFlags support it.
+func streamedExport() (string, error) {
+	var streamed []string
+	flag.Visit(func(f *flag.Flag) {
+		if f.Value.String() == stdoutPath && isFileExportFlag("-"+f.Name) {
+			streamed = append(streamed, f.Name)
+		}
+	})
+	if len(streamed) == 0 {
+		return "", nil
+	}
+	if len(streamed) > 1 {
+		return "", fmt.Errorf("only one export can write to stdout (got -%s)", strings.Join(streamed, ", -"))
+	}
+	if !slices.Contains(stdoutExportFlags, streamed[0]) {
+		return "", fmt.Errorf("-%s cannot write to stdout (only -exportjson, -exportcsv and -exportview support -)", streamed[0])
+	}
+	if streamed[0] == "exportcsv" && isFlagSet("exportcsv-append") {
+		return "", fmt.Errorf("-exportcsv-append cannot be used with -exportcsv -")
+	}
+	return streamed[0], nil
+}
+
+// readInput reads the config file at path, or stdin for -.
+func readInput(path string) ([]byte, error) {
+	if path == stdoutPath {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// isFlagSet reports whether a flag was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// streamFlags are the flags -stream can be combined with besides -exportjson
+// and -exportcsv; every other flag needs the whole plan.
+var streamFlags = []string{"input", "network", "hosts", "cidr", "p2p", "p2p30", "env", "var", "var-file", "stream", "exportjson", "exportcsv",
+	"filter", "include-categories", "exclude-categories", "columns", "baseline", "reclaim", "quarantine-days", "growth", "workers",
+	"force", "unsafe-exports", "newer-days", "export-retries", "lock", "fallback-dir", "export-errors",
+	"strict", "quiet", "errors"}
+
+// checkStreamFlags reports flags of set, the parsed command line, that
+// cannot be combined with -stream. hasOutputs and order come from the
+// config.
+func checkStreamFlags(set *flag.FlagSet, hasOutputs bool, order string) error {
+	allowed := make(map[string]bool)
+	for _, name := range streamFlags {
+		allowed[name] = true
+	}
+	given := make(map[string]bool)
+	var bad []string
+	set.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+		if !allowed[f.Name] && !(f.Name == "exportmd" && f.Value.String() == "") {
+			bad = append(bad, "-"+f.Name)
+		}
+	})
+	if len(bad) > 0 {
+		return fmt.Errorf("-stream cannot be combined with %s", strings.Join(bad, ", "))
+	}
+	if !given["exportjson"] && !given["exportcsv"] {
+		return fmt.Errorf("-stream needs -exportjson or -exportcsv")
+	}
+	if hasOutputs {
+		return fmt.Errorf("-stream cannot write the config's outputs; use -exportjson or -exportcsv")
+	}
+	if order != "" && order != "input" {
+		return fmt.Errorf("-stream writes rows in input order, but the config sets outputOrder %q", order)
+	}
+	return nil
+}
+
+// fileExportFlags are the export flags whose value is an output filename.
+var fileExportFlags = []string{"exportjson", "exportcsv", "exportmd", "exporthtml", "exportdhcp", "exportbicep", "exportaws", "exportansible", "exportdns", "exportticket", "exportlint", "exportcircuits", "exportmetrics", "exportsvg", "exporttopology", "exportfragments", "exportk8s", "exportconfig", "exportdir", "exportwhereabouts", "exportroutes", "exportview", "exportgraph", "exportimpact"}
+
+func isFileExportFlag(arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if name == arg {
+		return false
+	}
+	for _, f := range fileExportFlags {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
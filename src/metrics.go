@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// addressUsage counts addresses of a network or subnet. Allocated is
+// everything that is not free: for a network the space inside subnets, for
+// a subnet its network, broadcast and assigned addresses.
+type addressUsage struct {
+	total, assigned, free uint64
+}
+
+func (u addressUsage) allocated() uint64 { return u.total - u.free }
+
+// subnetKey identifies a planned subnet in metrics.
+type subnetKey struct {
+	network, subnet, name string
+	vlan                  int
+}
+
+// collectUsage totals the plan's addresses per parent network and per subnet,
+// in plan order.
+func collectUsage(results []SubnetResult) (networks []string, byNetwork map[string]*addressUsage, subnets []subnetKey, bySubnet map[subnetKey]*addressUsage) {
+	byNetwork = make(map[string]*addressUsage)
+	bySubnet = make(map[subnetKey]*addressUsage)
+	for _, r := range results {
+		if r.Category == "Summary" {
+			continue
+		}
+		n, ok := byNetwork[r.Parent]
+		if !ok {
+			n = &addressUsage{}
+			if _, ipNet, err := net.ParseCIDR(r.Parent); err == nil {
+				ones, _ := ipNet.Mask.Size()
+				n.total = uint64(1) << (32 - ones)
+			}
+			byNetwork[r.Parent] = n
+			networks = append(networks, r.Parent)
+		}
+		if isFreeSpaceRow(r) {
+			n.free += uint64(1) << (32 - r.Prefix)
+			continue
+		}
+
+		key := subnetKey{network: r.Parent, subnet: r.Subnet, name: r.Name, vlan: r.VLAN}
+		s, ok := bySubnet[key]
+		if !ok {
+			s = &addressUsage{total: uint64(1) << (32 - r.Prefix)}
+			bySubnet[key] = s
+			subnets = append(subnets, key)
+		}
+		switch r.Category {
+		case "Assignment", "Virtual IP", "Router":
+			s.assigned += uint64(r.TotalIPs)
+			n.assigned += uint64(r.TotalIPs)
+		case "Available", "Unused":
+			s.free += uint64(r.TotalIPs)
+		}
+	}
+	return networks, byNetwork, subnets, bySubnet
+}
+
+// WriteMetrics writes per-network and per-subnet address gauges in the
+// Prometheus text exposition format.
+func WriteMetrics(w io.Writer, results []SubnetResult) {
+	networks, byNetwork, subnets, bySubnet := collectUsage(results)
+
+	gauges := []struct {
+		name, help string
+		value      func(addressUsage) uint64
+	}{
+		{"total", "Addresses in the block.", func(u addressUsage) uint64 { return u.total }},
+		{"allocated", "Addresses not free: in subnets (networks) or network, broadcast and assigned (subnets).", addressUsage.allocated},
+		{"assigned", "Addresses given to named IP assignments.", func(u addressUsage) uint64 { return u.assigned }},
+		{"free", "Addresses still available.", func(u addressUsage) uint64 { return u.free }},
+	}
+	for _, g := range gauges {
+		name := "ipsubnetplanner_network_" + g.name + "_addresses"
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+		for _, n := range networks {
+			fmt.Fprintf(w, "%s{network=%s} %d\n", name, metricLabel(n), g.value(*byNetwork[n]))
+		}
+	}
+	for _, g := range gauges {
+		name := "ipsubnetplanner_subnet_" + g.name + "_addresses"
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+		for _, s := range subnets {
+			fmt.Fprintf(w, "%s{network=%s,subnet=%s,name=%s,vlan=%s} %d\n", name,
+				metricLabel(s.network), metricLabel(s.subnet), metricLabel(s.name), metricLabel(strconv.Itoa(s.vlan)), g.value(*bySubnet[s]))
+		}
+	}
+}
+
+// metricLabel quotes a label value for the Prometheus text format.
+func metricLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+// ExportMetrics writes the gauges to a file, e.g. for the node_exporter
+// textfile collector.
+func ExportMetrics(results []SubnetResult, path string) error {
+	var sb strings.Builder
+	WriteMetrics(&sb, results)
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
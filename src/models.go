@@ -2,39 +2,90 @@ package main
 
 // Network represents a parent network to be subdivided
 type Network struct {
-	Network string   `json:"network"`
-	Subnets []Subnet `json:"subnets"`
+	Network          string            `json:"network,omitempty"`
+	Pools            []string          `json:"pools,omitempty"` // further parent CIDRs, used once network is full
+	Gateway          string            `json:"gateway,omitempty"`
+	Redundancy       *Redundancy       `json:"redundancy,omitempty"`       // default for the subnets
+	RedundantGateway *RedundantGateway `json:"redundantGateway,omitempty"` // default for the subnets
+	CapacityWarn     int               `json:"capacityWarn,omitempty"`
+	CapacityError    int               `json:"capacityError,omitempty"`
+	P2PLinks         int               `json:"p2pLinks,omitempty"`
+	NameTemplate     string            `json:"nameTemplate,omitempty"` // host name of assignments, e.g. "{{.Subnet}}-{{.Label}}"
+	DNSSuffix        string            `json:"dnsSuffix,omitempty"`    // appended to host names to form FQDNs
+	Variables        configVariables   `json:"variables,omitempty"`    // values for host count expressions
+	Subnets          []Subnet          `json:"subnets"`
 }
 
 // Subnet represents a subnet requirement
 type Subnet struct {
-	Name          string         `json:"name"`
-	VLAN          int            `json:"vlan,omitempty"`
-	Hosts         int            `json:"hosts,omitempty"`
-	CIDR          int            `json:"cidr,omitempty"`
-	IPAssignments []IPAssignment `json:"IPAssignments,omitempty"`
+	Name             string            `json:"name"`
+	VLAN             int               `json:"vlan,omitempty"`
+	Hosts            int               `json:"hosts,omitempty"`
+	HostsExpr        string            `json:"-"`                // "hosts" given as an expression, e.g. "nodes*3+10"
+	Growth           *int              `json:"growth,omitempty"` // percent added to hosts before sizing; 0 opts out of -growth
+	CIDR             int               `json:"cidr,omitempty"`
+	Address          string            `json:"address,omitempty"`
+	P2P              bool              `json:"p2p,omitempty"`
+	Fabrics          []string          `json:"fabrics,omitempty"`
+	Gateway          string            `json:"gateway,omitempty"`
+	Redundancy       *Redundancy       `json:"redundancy,omitempty"`
+	RedundantGateway *RedundantGateway `json:"redundantGateway,omitempty"`
+	Template         string            `json:"template,omitempty"`
+	IPAssignments    []IPAssignment    `json:"IPAssignments,omitempty"`
+	Delegations      []string          `json:"delegations,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	Owner            string            `json:"owner,omitempty"`
+	PlannedFor       string            `json:"plannedFor,omitempty"`
+	Criticality      string            `json:"criticality,omitempty"` // critical, high, medium or low
+	Decommissioned   bool              `json:"decommissioned,omitempty"`
+	DecommissionedOn string            `json:"decommissionedOn,omitempty"`
 }
 
-// IPAssignment represents a named IP address assignment
+// IPAssignment represents a named IP address assignment. Setting Count (> 1)
+// or EndPosition turns it into a named block of consecutive addresses.
+// Reserved marks addresses that are intentionally held rather than assigned.
+// Category is set on the addresses added for a redundancy protocol.
+// Positions may be expressions, resolved when the subnet is planned.
 type IPAssignment struct {
-	Name     string `json:"Name"`
-	Position int    `json:"Position"`
+	Name        string            `json:"Name"`
+	Position    int               `json:"Position"`
+	Count       int               `json:"Count,omitempty"`
+	EndPosition int               `json:"EndPosition,omitempty"`
+	DHCP        bool              `json:"DHCP,omitempty"`
+	Reserved    bool              `json:"Reserved,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Category    string            `json:"-"` // Virtual IP or Router
+	// Position and EndPosition given as expressions, e.g. "gateway+1"
+	PositionExpr    string `json:"-"`
+	EndPositionExpr string `json:"-"`
 }
 
 // SubnetResult represents the calculated subnet information
 type SubnetResult struct {
-	Name        string `json:"name"`
-	VLAN        int    `json:"vlan,omitempty"`
-	Subnet      string `json:"subnet"`
-	Prefix      int    `json:"prefix"`
-	Network     string `json:"network"`
-	Broadcast   string `json:"broadcast,omitempty"`
-	FirstHost   string `json:"firstHost,omitempty"`
-	LastHost    string `json:"lastHost,omitempty"`
-	UsableHosts int    `json:"usableHosts"`
-	TotalIPs    int    `json:"totalIPs"`
-	Label       string `json:"label,omitempty"`
-	IP          string `json:"ip,omitempty"`
-	Mask        string `json:"mask,omitempty"`
-	Category    string `json:"category,omitempty"`
+	Name             string            `json:"name"`
+	VLAN             int               `json:"vlan,omitempty"`
+	Subnet           string            `json:"subnet"`
+	Prefix           int               `json:"prefix"`
+	Network          string            `json:"network"`
+	Broadcast        string            `json:"broadcast,omitempty"`
+	FirstHost        string            `json:"firstHost,omitempty"`
+	LastHost         string            `json:"lastHost,omitempty"`
+	UsableHosts      int               `json:"usableHosts"`
+	TotalIPs         int               `json:"totalIPs"`
+	Label            string            `json:"label,omitempty"`
+	IP               string            `json:"ip,omitempty"`
+	Mask             string            `json:"mask,omitempty"`
+	Category         string            `json:"category,omitempty"`
+	DHCP             bool              `json:"dhcp,omitempty"`
+	Change           string            `json:"change,omitempty"`
+	Parent           string            `json:"parent,omitempty"`
+	PlannedFor       string            `json:"plannedFor,omitempty"`
+	Criticality      string            `json:"criticality,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	DecommissionedOn string            `json:"decommissionedOn,omitempty"` // start of a decommissioned subnet's quarantine
+	Description      string            `json:"description,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	FQDN             string            `json:"fqdn,omitempty"`
 }
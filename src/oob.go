@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OOBCheck configures the out-of-band cross-check: every device assigned an
+// address in an in-band subnet must also have one in an OOB (BMC/iLO/iDRAC)
+// subnet.
+type OOBCheck struct {
+	Subnets []string          `json:"subnets"`          // names of the OOB subnets
+	InBand  []string          `json:"inband,omitempty"` // subnets to check; default every non-OOB subnet
+	Name    string            `json:"name,omitempty"`   // OOB name pattern, "{name}" is the in-band name; default "{name}"
+	Map     map[string]string `json:"map,omitempty"`    // explicit in-band -> OOB names, overriding Name
+	Ignore  []string          `json:"ignore,omitempty"` // assignments that are not devices; default Gateway
+}
+
+// OOBMissing is an in-band device without an OOB address.
+type OOBMissing struct {
+	Device   string `json:"device"`
+	Subnet   string `json:"subnet"`
+	Expected string `json:"expected"`
+}
+
+// CheckOOB returns the in-band devices missing from the OOB subnets and how
+// many devices were checked. Only single-address assignments count as
+// devices; names are compared case-insensitively.
+func CheckOOB(check OOBCheck, results []SubnetResult) (missing []OOBMissing, checked int) {
+	lower := func(names []string) map[string]bool {
+		set := make(map[string]bool)
+		for _, n := range names {
+			set[strings.ToLower(n)] = true
+		}
+		return set
+	}
+	oobSubnets, inBand := lower(check.Subnets), lower(check.InBand)
+	ignore := lower(check.Ignore)
+	if check.Ignore == nil {
+		ignore = lower([]string{"Gateway"})
+	}
+	pattern := check.Name
+	if pattern == "" {
+		pattern = "{name}"
+	}
+
+	oobNames := make(map[string]bool)
+	for _, r := range results {
+		if isAssignedCategory(r.Category) && oobSubnets[strings.ToLower(r.Name)] {
+			oobNames[strings.ToLower(r.Label)] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range results {
+		subnet := strings.ToLower(r.Name)
+		if !isAssignedCategory(r.Category) || strings.Contains(r.IP, " - ") || oobSubnets[subnet] || ignore[strings.ToLower(r.Label)] {
+			continue
+		}
+		if len(inBand) > 0 && !inBand[subnet] {
+			continue
+		}
+		if seen[strings.ToLower(r.Label)] {
+			continue
+		}
+		seen[strings.ToLower(r.Label)] = true
+		checked++
+
+		expected, ok := check.Map[r.Label]
+		if !ok {
+			expected = strings.ReplaceAll(pattern, "{name}", r.Label)
+		}
+		if !oobNames[strings.ToLower(expected)] {
+			missing = append(missing, OOBMissing{Device: r.Label, Subnet: r.Name, Expected: expected})
+		}
+	}
+	return missing, checked
+}
+
+// PrintOOBCheck prints the cross-check result.
+func PrintOOBCheck(w io.Writer, missing []OOBMissing, checked int, oobSubnets []string) {
+	fmt.Fprintf(w, "\nOut-of-band check (%s):\n", strings.Join(oobSubnets, ", "))
+	if len(missing) == 0 {
+		fmt.Fprintf(w, "  All %d in-band device(s) have an OOB address.\n", checked)
+		return
+	}
+	fmt.Fprintf(w, "  %d of %d in-band device(s) have no OOB address:\n", len(missing), checked)
+	for _, m := range missing {
+		fmt.Fprintf(w, "  ! %-25s in %-20s expected %s\n", m.Device, m.Subnet, m.Expected)
+	}
+}
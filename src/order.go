@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// outputOrders are the sort keys of -sort / outputOrder. An order is one
+// key or several separated by commas, compared in turn; an empty order
+// keeps allocation order (largest subnets first, then free space).
+var outputOrders = []string{"input", "address", "name", "vlan", "category"}
+
+// resultBlock is a run of consecutive rows belonging to one subnet (or one
+// free-space or summary row). Sorting moves whole blocks so a subnet's rows
+// stay together; within a block rows are put in address order, or in
+// category order first when the order has the category key.
+type resultBlock struct {
+	rows   []SubnetResult
+	start  uint32
+	subnet bool // false for free space and summary rows
+	index  int  // position of the subnet in the config
+}
+
+// parseOutputOrder splits an order into its keys.
+func parseOutputOrder(order string) ([]string, error) {
+	var keys []string
+	for _, key := range strings.Split(order, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		valid := false
+		for _, o := range outputOrders {
+			if key == o {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid output order %q (use %s, or several separated by commas, e.g. vlan,name)", order, strings.Join(outputOrders, ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// categoryRank orders categories as planCategories lists them.
+func categoryRank(category string) int {
+	for i, c := range planCategories {
+		if c == category {
+			return i
+		}
+	}
+	return len(planCategories)
+}
+
+// SortResults reorders results by order (see outputOrders), or keeps them
+// for an empty order. networks supplies the config order for "input".
+func SortResults(results []SubnetResult, order string, networks []Network) ([]SubnetResult, error) {
+	if order == "" {
+		return results, nil
+	}
+	keys, err := parseOutputOrder(order)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := false
+	for _, key := range keys {
+		byCategory = byCategory || key == "category"
+	}
+
+	// Rows are matched to config entries by subnet name
+	inputIndex := map[string]int{}
+	for _, n := range networks {
+		for _, s := range withP2PLinks(n) {
+			for _, name := range subnetNames(s) {
+				if _, seen := inputIndex[name]; !seen {
+					inputIndex[name] = len(inputIndex)
+				}
+			}
+		}
+	}
+
+	var blocks []resultBlock
+	for _, r := range results {
+		if n := len(blocks); n > 0 && !isFreeSpaceRow(r) && r.Category != "Summary" {
+			last := blocks[n-1].rows[0]
+			if last.Subnet == r.Subnet && last.Name == r.Name && last.Parent == r.Parent {
+				blocks[n-1].rows = append(blocks[n-1].rows, r)
+				continue
+			}
+		}
+		start, _, _ := parseIPSpan(strings.Split(r.Subnet, "/")[0])
+		isSubnet := !isFreeSpaceRow(r) && r.Category != "Summary"
+		blocks = append(blocks, resultBlock{rows: []SubnetResult{r}, start: start, subnet: isSubnet, index: inputIndex[r.Name]})
+	}
+	for _, b := range blocks {
+		sort.SliceStable(b.rows, func(i, j int) bool {
+			if byCategory {
+				if a, c := categoryRank(b.rows[i].Category), categoryRank(b.rows[j].Category); a != c {
+					return a < c
+				}
+			}
+			a, _, _ := parseIPSpan(b.rows[i].IP)
+			c, _, _ := parseIPSpan(b.rows[j].IP)
+			return a < c
+		})
+	}
+
+	// Keep parent networks in config order; sort blocks within each parent
+	parentRank := map[string]int{}
+	for _, b := range blocks {
+		if _, ok := parentRank[b.rows[0].Parent]; !ok {
+			parentRank[b.rows[0].Parent] = len(parentRank)
+		}
+	}
+	byAddress := func(a, b resultBlock) bool {
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		return a.rows[0].Prefix < b.rows[0].Prefix
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		a, b := blocks[i], blocks[j]
+		if pa, pb := parentRank[a.rows[0].Parent], parentRank[b.rows[0].Parent]; pa != pb {
+			return pa < pb
+		}
+		if keys[0] != "address" && a.subnet != b.subnet {
+			// Free space and summaries follow the subnets, by address
+			return a.subnet
+		}
+		for _, key := range keys {
+			switch key {
+			case "input":
+				if a.index != b.index {
+					return a.index < b.index
+				}
+			case "address":
+				if a.start != b.start || a.rows[0].Prefix != b.rows[0].Prefix {
+					return byAddress(a, b)
+				}
+			case "name":
+				if a.rows[0].Name != b.rows[0].Name {
+					return a.rows[0].Name < b.rows[0].Name
+				}
+			case "vlan":
+				if a.rows[0].VLAN != b.rows[0].VLAN {
+					return a.rows[0].VLAN < b.rows[0].VLAN
+				}
+			}
+		}
+		return byAddress(a, b)
+	})
+
+	sorted := make([]SubnetResult, 0, len(results))
+	for _, b := range blocks {
+		sorted = append(sorted, b.rows...)
+	}
+	return sorted, nil
+}
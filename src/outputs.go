@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Output is an export declared in the "outputs" section of a wrapped config,
+// so a bare -input run reproduces every artifact of the plan.
+type Output struct {
+	Format  string            `json:"format"`
+	Path    string            `json:"path"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// outputOptionFlags maps each format's options to the command-line flags
+// they stand for.
+var outputOptionFlags = map[string]map[string]string{
+	"csv":         {"append": "exportcsv-append", "ipformat": "csvipformat"},
+	"dhcp":        {"format": "dhcpformat"},
+	"aws":         {"format": "awsformat"},
+	"dns":         {"domain": "dnsdomain", "format": "dnsformat"},
+	"ticket":      {"format": "ticketformat"},
+	"routes":      {"format": "routeformat"},
+	"topology":    {"format": "topologyformat"},
+	"graph":       {"format": "graphformat"},
+	"fragments":   {"format": "fragmentformat"},
+	"k8s":         {"apiVersion": "k8sapi", "namespace": "k8snamespace", "crd": "k8scrd"},
+	"dir":         {"formats": "exportdirformats"},
+	"whereabouts": {"cni": "multuscni", "namespace": "k8snamespace"},
+	"switch":      {"dialect": "exportswitch"},
+}
+
+// outputFlags resolves outputs into command-line flag values: the format's
+// export flag set to its path, plus its options. Relative paths are taken
+// relative to dir, the config file's directory.
+func outputFlags(outputs []Output, dir string) (map[string]string, error) {
+	values := make(map[string]string)
+	seen := make(map[string]bool)
+	for i, o := range outputs {
+		format := strings.ToLower(o.Format)
+		pathFlag := "export" + format
+		if format == "switch" {
+			pathFlag = "switchfile"
+			if o.Options["dialect"] == "" {
+				return nil, fmt.Errorf("outputs[%d]: switch output needs a \"dialect\" option (ios or eos)", i)
+			}
+		} else if _, ok := exportSuffixes[format]; !ok {
+			return nil, fmt.Errorf("outputs[%d]: unknown format %q (use switch or %s)", i, o.Format, strings.Join(exportSetNames(), ", "))
+		}
+		if seen[format] {
+			return nil, fmt.Errorf("outputs[%d]: format %s is listed more than once", i, format)
+		}
+		seen[format] = true
+		if o.Path == "" {
+			return nil, fmt.Errorf("outputs[%d]: %s output needs a \"path\"", i, format)
+		}
+
+		path := o.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		values[pathFlag] = path
+
+		var names []string
+		for name := range o.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			flagName, ok := outputOptionFlags[format][name]
+			if !ok {
+				return nil, fmt.Errorf("outputs[%d]: unknown option %q for format %s", i, name, format)
+			}
+			values[flagName] = o.Options[name]
+		}
+	}
+	return values, nil
+}
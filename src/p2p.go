@@ -0,0 +1,42 @@
+package main
+
+import "fmt"
+
+// withP2PLinks returns the network's subnets followed by the point-to-point
+// links requested with p2pLinks, named p2p-1, p2p-2, ...
+func withP2PLinks(n Network) []Subnet {
+	if n.P2PLinks <= 0 {
+		return n.Subnets
+	}
+	subnets := append([]Subnet(nil), n.Subnets...)
+	for i := 1; i <= n.P2PLinks; i++ {
+		subnets = append(subnets, Subnet{Name: fmt.Sprintf("p2p-%d", i), P2P: true})
+	}
+	return subnets
+}
+
+// linkPrefix is the prefix used for point-to-point links: /31 (RFC 3021),
+// or /30 in legacy mode for carrier equipment that rejects /31.
+func (p Planner) linkPrefix() int {
+	if p.LegacyP2P {
+		return 30
+	}
+	return 31
+}
+
+// subnetPrefix returns the prefix a subnet requires. Point-to-point links
+// (p2p, optionally with hosts: 2) get linkPrefix; other subnets with hosts: 2
+// still need network and broadcast addresses and stay /30.
+func (p Planner) subnetPrefix(subnet Subnet) (int, error) {
+	switch {
+	case subnet.CIDR > 0:
+		return subnet.CIDR, nil
+	case subnet.P2P && subnet.Hosts > 2:
+		return 0, fmt.Errorf("subnet %s: a point-to-point link has at most 2 hosts, not %d", subnet.Name, subnet.Hosts)
+	case subnet.P2P:
+		return p.linkPrefix(), nil
+	case subnet.Hosts > 0:
+		return calculatePrefixFromHosts(grownHosts(subnet.Hosts, subnet.growth(p.Growth))), nil
+	}
+	return 0, fmt.Errorf("subnet %s must specify either 'hosts' or 'cidr'", subnet.Name)
+}
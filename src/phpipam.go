@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// phpipamTokenEnv holds the phpIPAM API token, so it stays out of shell
+// history and process listings.
+const phpipamTokenEnv = "PHPIPAM_TOKEN"
+
+// phpIPAM's default address tags.
+const (
+	phpipamTagUsed     = 2
+	phpipamTagReserved = 3
+)
+
+// phpipamClient talks to the REST API of a phpIPAM application, e.g.
+// https://ipam.example.com/api/planner, authorized by an app code token.
+type phpipamClient struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// phpipamResponse is the envelope of every phpIPAM API response. IDs and
+// flags come as numbers or strings depending on the phpIPAM version.
+type phpipamResponse struct {
+	Code    int             `json:"code"`
+	Success json.RawMessage `json:"success"`
+	Message string          `json:"message"`
+	ID      json.RawMessage `json:"id"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// phpipamObject is the part of a section, subnet or address the push needs.
+type phpipamObject struct {
+	ID        json.RawMessage `json:"id"`
+	SectionID json.RawMessage `json:"sectionId"`
+}
+
+// phpipamID reads an ID given as a number or a string.
+func phpipamID(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// do sends one request. A 404, or an unsuccessful response to a lookup,
+// means the object does not exist and gives found == false.
+func (c *phpipamClient) do(method, path string, body interface{}) (phpipamResponse, bool, error) {
+	var resp phpipamResponse
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return resp, false, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.base, "/")+"/"+path, reader)
+	if err != nil {
+		return resp, false, err
+	}
+	req.Header.Set("token", c.token)
+	req.Header.Set("Content-Type", "application/json")
+	r, err := c.client.Do(req)
+	if err != nil {
+		return resp, false, err
+	}
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return resp, false, err
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return resp, false, fmt.Errorf("phpipam: %s %s: HTTP %d, not a phpIPAM response", method, path, r.StatusCode)
+	}
+	success := string(resp.Success) == "true" || string(resp.Success) == "1"
+	if method == http.MethodGet && (r.StatusCode == http.StatusNotFound || r.StatusCode < 300 && !success) {
+		return resp, false, nil
+	}
+	if r.StatusCode >= 300 || !success {
+		return resp, false, fmt.Errorf("phpipam: %s %s: HTTP %d: %s", method, path, r.StatusCode, resp.Message)
+	}
+	return resp, true, nil
+}
+
+// create posts an object and returns its new ID.
+func (c *phpipamClient) create(path string, body interface{}) (string, error) {
+	resp, _, err := c.do(http.MethodPost, path, body)
+	if err != nil {
+		return "", err
+	}
+	return phpipamID(resp.ID), nil
+}
+
+// sectionID looks up a section by name.
+func (c *phpipamClient) sectionID(name string) (string, error) {
+	resp, found, err := c.do(http.MethodGet, "sections/"+url.PathEscape(name)+"/", nil)
+	if err != nil || !found {
+		return "", err
+	}
+	var section phpipamObject
+	if err := json.Unmarshal(resp.Data, &section); err != nil {
+		return "", fmt.Errorf("phpipam: section %s: %v", name, err)
+	}
+	return phpipamID(section.ID), nil
+}
+
+// subnetID looks up a subnet of the section by its CIDR.
+func (c *phpipamClient) subnetID(sectionID string, ipNet *net.IPNet) (string, error) {
+	ones, _ := ipNet.Mask.Size()
+	resp, found, err := c.do(http.MethodGet, fmt.Sprintf("subnets/cidr/%s/%d/", ipNet.IP, ones), nil)
+	if err != nil || !found {
+		return "", err
+	}
+	var subnets []phpipamObject
+	if err := json.Unmarshal(resp.Data, &subnets); err != nil {
+		return "", fmt.Errorf("phpipam: subnet %s: %v", ipNet, err)
+	}
+	for _, s := range subnets {
+		if phpipamID(s.SectionID) == sectionID {
+			return phpipamID(s.ID), nil
+		}
+	}
+	return "", nil
+}
+
+// addressExists reports whether the subnet holds the address.
+func (c *phpipamClient) addressExists(subnetID, ip string) (bool, error) {
+	_, found, err := c.do(http.MethodGet, "addresses/"+ip+"/"+subnetID+"/", nil)
+	return found, err
+}
+
+// pushPHPIPAM creates the plan in phpIPAM: a section, each parent network
+// as a subnet of it, the planned subnets inside their parents, and one
+// address per assigned or reserved IP. Objects that already exist are left
+// as they are, so a push can be repeated after the plan grows. Address
+// blocks (DHCP pools and other ranges), free space and decommissioned or
+// quarantined subnets are skipped. With dryRun nothing is created; a nil
+// client assumes an empty phpIPAM. Every action is reported on out.
+func pushPHPIPAM(c *phpipamClient, results []SubnetResult, section string, dryRun bool, out io.Writer) (pushSummary, error) {
+	log := &pushLog{out: out, dryRun: dryRun}
+	// ensure finds an object, or creates it, under a parent that exists
+	// (parentID != ""); in a dry run new objects get no ID.
+	ensure := func(kind, name, parentID string, lookup func() (string, error), create func() (string, error)) (string, error) {
+		if c != nil && (parentID != "" || kind == "section") {
+			id, err := lookup()
+			if err != nil {
+				return "", err
+			}
+			if id != "" {
+				log.exists(kind, name)
+				return id, nil
+			}
+		}
+		log.create(kind, name)
+		if dryRun {
+			return "", nil
+		}
+		return create()
+	}
+
+	sectionID, err := ensure("section", section, "", func() (string, error) {
+		return c.sectionID(section)
+	}, func() (string, error) {
+		return c.create("sections/", map[string]string{"name": section, "description": "Created by IPSubnetPlanner"})
+	})
+	if err != nil {
+		return log.sum, err
+	}
+
+	// subnet ensures one subnet under the section, or under a master subnet
+	subnet := func(cidr, masterID, description string, vlan int) (string, error) {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return "", fmt.Errorf("phpipam: invalid subnet %q: %v", cidr, err)
+		}
+		ones, _ := ipNet.Mask.Size()
+		if vlan > 0 {
+			description = fmt.Sprintf("%s (VLAN %d)", description, vlan)
+		}
+		name := cidr
+		if description != "" {
+			name += " " + description
+		}
+		return ensure("subnet", name, sectionID, func() (string, error) {
+			return c.subnetID(sectionID, ipNet)
+		}, func() (string, error) {
+			body := map[string]string{
+				"subnet":      ipNet.IP.String(),
+				"mask":        strconv.Itoa(ones),
+				"sectionId":   sectionID,
+				"description": description,
+			}
+			if masterID != "" {
+				body["masterSubnetId"] = masterID
+			}
+			return c.create("subnets/", body)
+		})
+	}
+
+	parents := make(map[string]string)
+	subnets := make(map[string]string)
+	for _, r := range results {
+		if r.Category == "Summary" || isFreeSpaceRow(r) || r.Status != "" || r.Subnet == "" {
+			continue
+		}
+		if _, ok := parents[r.Parent]; !ok && r.Parent != "" {
+			if parents[r.Parent], err = subnet(r.Parent, "", "", 0); err != nil {
+				return log.sum, err
+			}
+		}
+		key := r.Parent + " " + r.Subnet
+		subnetID, ok := subnets[key]
+		if !ok {
+			if subnetID, err = subnet(r.Subnet, parents[r.Parent], r.Name, r.VLAN); err != nil {
+				return log.sum, err
+			}
+			subnets[key] = subnetID
+		}
+		if !isAssignedCategory(r.Category) && r.Category != "Reserved" {
+			continue
+		}
+		if strings.Contains(r.IP, "-") {
+			log.skip("range", r.IP+" ("+r.Name+": "+r.Label+")")
+			continue
+		}
+		tag := phpipamTagUsed
+		if r.Category == "Reserved" {
+			tag = phpipamTagReserved
+		}
+		name := r.IP + " (" + r.Name + ": " + r.Label + ")"
+		_, err := ensure("address", name, subnetID, func() (string, error) {
+			found, err := c.addressExists(subnetID, r.IP)
+			if !found {
+				return "", err
+			}
+			return r.IP, err
+		}, func() (string, error) {
+			body := map[string]interface{}{
+				"ip":          r.IP,
+				"subnetId":    subnetID,
+				"hostname":    r.FQDN,
+				"description": strings.TrimSpace(r.Label + " " + r.Description),
+				"tag":         tag,
+			}
+			return c.create("addresses/", body)
+		})
+		if err != nil {
+			return log.sum, err
+		}
+	}
+	return log.sum, nil
+}
+
+// runPHPIPAM implements the "phpipam" subcommand.
+func runPHPIPAM(args []string) int {
+	fs := flag.NewFlagSet("phpipam", flag.ExitOnError)
+	apiURL := fs.String("url", "", "phpIPAM API URL including the app ID, e.g. https://ipam.example.com/api/planner")
+	token := fs.String("token", "", "phpIPAM app code token (default from "+phpipamTokenEnv+")")
+	section := fs.String("section", "IPSubnetPlanner", "Section to create the subnets in; created if missing")
+	dryRun := fs.Bool("dry-run", false, "Show what would be created without changing phpIPAM; without -url, assume an empty phpIPAM")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner phpipam -url URL [-token token] [-section name] [-dry-run] plan.json\n\n")
+		fmt.Fprintf(os.Stderr, "Creates the sections, subnets and addresses of an -exportjson plan in phpIPAM.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	var files []string
+	for fs.NArg() > 0 {
+		files = append(files, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(files) != 1 || *section == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	if *token == "" {
+		*token = os.Getenv(phpipamTokenEnv)
+	}
+	var client *phpipamClient
+	switch {
+	case *apiURL != "" && *token == "":
+		fmt.Fprintf(os.Stderr, "phpipam: -token or %s is required\n", phpipamTokenEnv)
+		return exitUsage
+	case *apiURL != "":
+		client = &phpipamClient{base: *apiURL, token: *token, client: &http.Client{Timeout: 30 * time.Second}}
+	case !*dryRun:
+		fmt.Fprintln(os.Stderr, "phpipam: -url is required (or use -dry-run)")
+		return exitUsage
+	}
+
+	results, err := loadPlanFile(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
+	}
+	sum, err := pushPHPIPAM(client, results, *section, *dryRun, os.Stdout)
+	fmt.Printf("\n%s\n", pushTotals(sum, *dryRun))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return 0
+}
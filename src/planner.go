@@ -5,25 +5,206 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"net/netip"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Constraint reports whether candidate may be used for subnet. The allocator
+// consults it for every placement it considers; returning false makes it try
+// the next aligned block, so bespoke rules (e.g. avoid a third octet of 13)
+// can be enforced without changing the allocator. Networks are planned
+// concurrently when the Planner's Workers is above 1, and a Constraint must
+// then be safe for concurrent use.
+type Constraint func(candidate netip.Prefix, subnet Subnet) bool
+
+// Planner holds optional planning behaviour. The zero value plans exactly
+// like PlanSubnets.
+type Planner struct {
+	// Constraint, if set, is consulted during placement.
+	Constraint Constraint
+
+	// Reclaim drops decommissioned subnets so their space can be reused.
+	// Without it they keep their allocation and are flagged in the output.
+	Reclaim bool
+
+	// QuarantineDays is the cool-down after a subnet's DecommissionedOn date
+	// during which Reclaim does not release it.
+	QuarantineDays int
+
+	// Now is the reference time for quarantine checks (default time.Now).
+	Now time.Time
+
+	// LegacyP2P sizes point-to-point links as /30 instead of /31.
+	LegacyP2P bool
+
+	// Growth is the percentage added to host counts before sizing, for
+	// subnets that do not set their own growth.
+	Growth int
+
+	// Workers is how many networks are planned concurrently; 0 or 1 plans
+	// them one at a time. Output order is the same either way.
+	Workers int
+
+	// Vars override the variables of host count expressions (-var).
+	Vars map[string]float64
+}
+
 // PlanSubnets calculates subnet allocation for a given network
 func PlanSubnets(networks []Network) ([]SubnetResult, error) {
+	return Planner{}.Plan(networks)
+}
+
+// PlanEach plans networks and passes every result row to fn in output
+// order, so embedding services can stream rows into their own sink without
+// materializing the whole plan. fn is always called from the caller's
+// goroutine. Planning stops at the first error, and an error returned by fn
+// is passed through unchanged.
+func PlanEach(networks []Network, fn func(SubnetResult) error) error {
+	return Planner{}.Each(networks, fn)
+}
+
+// Plan calculates subnet allocation for networks using the planner's options.
+func (p Planner) Plan(networks []Network) ([]SubnetResult, error) {
 	var allResults []SubnetResult
 
-	for _, network := range networks {
-		results, err := planSingleNetwork(network)
-		if err != nil {
-			return nil, fmt.Errorf("error planning network %s: %v", network.Network, err)
-		}
-		allResults = append(allResults, results...)
+	err := p.Each(networks, func(result SubnetResult) error {
+		allResults = append(allResults, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return allResults, nil
 }
 
+// Each is the streaming form of Plan; see PlanEach.
+func (p Planner) Each(networks []Network, fn func(SubnetResult) error) error {
+	networks, err := resolveHosts(networks, p.Vars)
+	if err != nil {
+		return err
+	}
+	networks, err = p.SplitPools(networks)
+	if err != nil {
+		return err
+	}
+	workers := min(p.Workers, len(networks))
+	if workers <= 1 {
+		for _, network := range networks {
+			results, err := p.planNetwork(network)
+			if err := emitNetwork(network, results, err, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Networks are independent, so workers plan them in any order while the
+	// caller's goroutine emits them in input order. At most 2*workers planned
+	// networks wait to be emitted, which keeps streaming memory bounded.
+	type planned struct {
+		results []SubnetResult
+		err     error
+		done    chan struct{}
+	}
+	slots := make([]planned, len(networks))
+	for i := range slots {
+		slots[i].done = make(chan struct{})
+	}
+	jobs := make(chan int)
+	window := make(chan struct{}, 2*workers)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(jobs)
+		for i := range networks {
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				slots[i].results, slots[i].err = p.planNetwork(networks[i])
+				close(slots[i].done)
+			}
+		}()
+	}
+
+	for i, network := range networks {
+		<-slots[i].done
+		if err := emitNetwork(network, slots[i].results, slots[i].err, fn); err != nil {
+			return err
+		}
+		slots[i].results = nil
+		<-window
+	}
+	return nil
+}
+
+// emitNetwork passes one network's planned rows to fn, or wraps the error
+// that planning it returned.
+func emitNetwork(network Network, results []SubnetResult, err error, fn func(SubnetResult) error) error {
+	if err != nil {
+		return fmt.Errorf("error planning network %s: %w", network.Network, err)
+	}
+	for _, result := range results {
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Planner) now() time.Time {
+	if p.Now.IsZero() {
+		return time.Now()
+	}
+	return p.Now
+}
+
+// quarantineUntil returns when a decommissioned subnet's space may be
+// reclaimed; the zero time means immediately.
+func (p Planner) quarantineUntil(subnet Subnet) (time.Time, error) {
+	if subnet.DecommissionedOn == "" {
+		return time.Time{}, nil
+	}
+	on, err := parsePlanDate(subnet.DecommissionedOn, p.now().Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("subnet %s: decommissionedOn: %v", subnet.Name, err)
+	}
+	return on.AddDate(0, 0, p.QuarantineDays), nil
+}
+
+// NoSpaceError reports a subnet that does not fit in the space left in its
+// parent network.
+type NoSpaceError struct {
+	Subnet  string
+	Prefix  int
+	Network string
+}
+
+func (e *NoSpaceError) Error() string {
+	return fmt.Sprintf("subnet %s (/%d) does not fit in the remaining space of %s", e.Subnet, e.Prefix, e.Network)
+}
+
 func planSingleNetwork(network Network) ([]SubnetResult, error) {
+	return Planner{}.planNetwork(network)
+}
+
+func (p Planner) planNetwork(network Network) ([]SubnetResult, error) {
 	// Parse parent network
 	if network.Network == "" {
 		return nil, fmt.Errorf("missing 'network' field - each network must specify a CIDR (e.g., \"network\": \"10.0.0.0/24\")")
@@ -38,69 +219,233 @@ func planSingleNetwork(network Network) ([]SubnetResult, error) {
 	networkIP := ipNet.IP.Mask(ipNet.Mask)
 	networkInt := ipToUint32(networkIP)
 
+	// Position expressions see the same variables as host count expressions
+	positionVars := make(map[string]float64, len(network.Variables)+len(p.Vars))
+	for k, v := range network.Variables {
+		positionVars[k] = v
+	}
+	for k, v := range p.Vars {
+		positionVars[k] = v
+	}
+
 	// Calculate required prefix for each subnet
 	type subnetReq struct {
 		subnet Subnet
 		prefix int
+		block  int // prefix of the allocated block, shorter than prefix for fabric pairs
 		size   uint32
+		status string
+		start  uint64
+		pinned bool
 	}
 
 	var requirements []subnetReq
-	for _, subnet := range network.Subnets {
-		var prefix int
-		if subnet.CIDR > 0 {
-			prefix = subnet.CIDR
-		} else if subnet.Hosts > 0 {
-			prefix = calculatePrefixFromHosts(subnet.Hosts)
-		} else {
-			return nil, fmt.Errorf("subnet %s must specify either 'hosts' or 'cidr'", subnet.Name)
+	for _, subnet := range withP2PLinks(network) {
+		var status string
+		if subnet.Decommissioned {
+			until, err := p.quarantineUntil(subnet)
+			if err != nil {
+				return nil, err
+			}
+			if until.After(p.now()) {
+				status = "Quarantined until " + until.Format("2006-01-02")
+			} else if p.Reclaim {
+				continue
+			} else {
+				status = "Decommissioned"
+			}
+		}
+
+		prefix, err := p.subnetPrefix(subnet)
+		if err != nil {
+			return nil, err
 		}
 
 		if prefix < parentPrefix || prefix > 32 {
 			return nil, fmt.Errorf("subnet %s: prefix /%d is invalid for parent network /%d", subnet.Name, prefix, parentPrefix)
 		}
 
-		size := uint32(1 << (32 - prefix))
-		requirements = append(requirements, subnetReq{subnet: subnet, prefix: prefix, size: size})
+		if subnet.PlannedFor != "" {
+			if _, err := parsePlanDate(subnet.PlannedFor, time.UTC); err != nil {
+				return nil, fmt.Errorf("subnet %s: %v", subnet.Name, err)
+			}
+		}
+
+		if err := validateCriticality(subnet); err != nil {
+			return nil, err
+		}
+
+		subnet, err := applyRedundancy(subnet, network.Redundancy, network.RedundantGateway, network.Gateway, prefix)
+		if err != nil {
+			return nil, err
+		}
+		subnet, err = applyGateway(subnet, network.Gateway, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		subnet, err = resolvePositions(subnet, prefix, positionVars)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validateAssignments(subnet, prefix); err != nil {
+			return nil, err
+		}
+
+		fabrics, err := fabricBits(subnet)
+		if err != nil {
+			return nil, err
+		}
+		block := prefix - fabrics
+		if block < parentPrefix {
+			return nil, fmt.Errorf("subnet %s: %d fabrics of /%d need a /%d, larger than parent network /%d", subnet.Name, len(subnet.Fabrics), prefix, block, parentPrefix)
+		}
+
+		size := uint32(1 << (32 - block))
+		req := subnetReq{subnet: subnet, prefix: prefix, block: block, size: size, status: status}
+		if subnet.Address != "" {
+			start, err := pinnedAddress(subnet, block, networkInt, parentPrefix)
+			if err != nil {
+				return nil, err
+			}
+			req.start, req.pinned = start, true
+		}
+		requirements = append(requirements, req)
 	}
 
 	// Sort by size (largest first) for optimal allocation
-	sort.Slice(requirements, func(i, j int) bool {
+	sort.SliceStable(requirements, func(i, j int) bool {
 		return requirements[i].size > requirements[j].size
 	})
 
-	// Allocate subnets
-	var results []SubnetResult
-	currentIP := networkInt
-
+	// Pinned subnets keep their address, so reserve them before placing the rest
+	alloc := newAllocator(networkInt, parentPrefix)
+	pinnedBy := make(map[uint64]string)
 	for _, req := range requirements {
-		subnetIP := uint32ToIP(currentIP)
-		subnetCIDR := fmt.Sprintf("%s/%d", subnetIP.String(), req.prefix)
+		if !req.pinned {
+			continue
+		}
+		block := span{req.start, req.start + uint64(req.size)}
+		if blocker, ok := alloc.overlap(block); ok {
+			return nil, fmt.Errorf("subnet %s address %s overlaps subnet %s", req.subnet.Name, req.subnet.Address, pinnedBy[blocker.start])
+		}
+		alloc.reserve(block)
+		pinnedBy[block.start] = req.subnet.Name
+	}
 
-		// Handle IP assignments if specified
-		if len(req.subnet.IPAssignments) > 0 {
-			assignmentResults := processIPAssignments(req.subnet, subnetCIDR, req.prefix)
-			results = append(results, assignmentResults...)
-		} else {
-			// For subnets without IP assignments, create basic entries
-			basicResults := createBasicSubnetEntries(req.subnet, subnetCIDR, req.prefix)
-			results = append(results, basicResults...)
+	// Allocate subnets
+	for i := range requirements {
+		req := &requirements[i]
+		if req.pinned {
+			continue
+		}
+		var accept func(uint64) bool
+		if p.Constraint != nil {
+			accept = func(start uint64) bool {
+				candidate := netip.PrefixFrom(netip.AddrFrom4([4]byte(uint32ToIP(uint32(start)))), req.block)
+				return p.Constraint(candidate, req.subnet)
+			}
+		}
+		start, ok := alloc.allocate(uint64(req.size), accept)
+		if !ok {
+			return nil, &NoSpaceError{Subnet: req.subnet.Name, Prefix: req.block, Network: network.Network}
 		}
+		req.start = start
+	}
 
-		currentIP += req.size
+	var results []SubnetResult
+	for _, req := range requirements {
+		// Fabric copies sit side by side in the allocated block
+		for k, subnet := range fabricSubnets(req.subnet) {
+			start := req.start + uint64(k)<<(32-req.prefix)
+			subnetIP := uint32ToIP(uint32(start))
+			subnetCIDR := fmt.Sprintf("%s/%d", subnetIP.String(), req.prefix)
+
+			// Handle IP assignments if specified
+			var rows []SubnetResult
+			if len(subnet.IPAssignments) > 0 {
+				rows = processIPAssignments(subnet, subnetCIDR, req.prefix)
+			} else {
+				// For subnets without IP assignments, create basic entries
+				rows = createBasicSubnetEntries(subnet, subnetCIDR, req.prefix)
+			}
+			for i := range rows {
+				rows[i].PlannedFor = subnet.PlannedFor
+				rows[i].Criticality = subnet.Criticality
+				rows[i].Status = req.status
+				if subnet.Decommissioned {
+					rows[i].DecommissionedOn = subnet.DecommissionedOn
+				}
+				// Assignment rows carry their own metadata, the rest the subnet's
+				if !isAssignedCategory(rows[i].Category) && rows[i].Category != "Reserved" {
+					rows[i].Description = subnet.Description
+					rows[i].Tags = subnet.Tags
+				}
+			}
+			results = append(results, rows...)
+		}
 	}
 
-	// Calculate remaining available space
-	parentSize := uint32(1 << (32 - parentPrefix))
-	parentEnd := networkInt + parentSize
-	if currentIP < parentEnd {
-		available := calculateAvailableSpace(currentIP, parentEnd, parentPrefix)
+	// Calculate remaining available space, including holes left by constraints
+	for _, gap := range alloc.free() {
+		available := calculateAvailableSpace(uint32(gap.start), uint32(gap.end), parentPrefix)
 		results = append(results, available...)
 	}
 
+	if err := applyHostnames(network, results); err != nil {
+		return nil, err
+	}
+
+	// Record which parent network every row belongs to
+	parentCIDR := fmt.Sprintf("%s/%d", networkIP.String(), parentPrefix)
+	for i := range results {
+		results[i].Parent = parentCIDR
+	}
+
 	return results, nil
 }
 
+// pinnedAddress parses a subnet's fixed address ("10.0.0.64" or
+// "10.0.0.64/26") and checks it is an aligned block inside the parent.
+func pinnedAddress(subnet Subnet, prefix int, parentStart uint32, parentPrefix int) (uint64, error) {
+	addr := subnet.Address
+	if i := strings.Index(addr, "/"); i >= 0 {
+		if p, err := strconv.Atoi(addr[i+1:]); err != nil || p != prefix {
+			return 0, fmt.Errorf("subnet %s: address %s does not match its size /%d", subnet.Name, subnet.Address, prefix)
+		}
+		addr = addr[:i]
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		return 0, fmt.Errorf("subnet %s: invalid address %q", subnet.Name, subnet.Address)
+	}
+	start := uint64(ipToUint32(ip))
+	size := uint64(1) << (32 - prefix)
+	if start%size != 0 {
+		return 0, fmt.Errorf("subnet %s: address %s is not aligned to /%d", subnet.Name, subnet.Address, prefix)
+	}
+	parentEnd := uint64(parentStart) + uint64(1)<<(32-parentPrefix)
+	if start < uint64(parentStart) || start+size > parentEnd {
+		return 0, fmt.Errorf("subnet %s: address %s is outside the parent network", subnet.Name, subnet.Address)
+	}
+	return start, nil
+}
+
+// growth returns the subnet's own growth percentage, or def, the planner's
+// growth, when the subnet does not set one. An explicit 0 wins over def.
+func (s Subnet) growth(def int) int {
+	if s.Growth != nil {
+		return *s.Growth
+	}
+	return def
+}
+
+// grownHosts adds growth percent to hosts, rounding up.
+func grownHosts(hosts, growth int) int {
+	return (hosts*(100+growth) + 99) / 100
+}
+
 func calculatePrefixFromHosts(hosts int) int {
 	// Need hosts + 2 (network and broadcast)
 	requiredIPs := hosts + 2
@@ -178,9 +523,6 @@ func processIPAssignments(subnet Subnet, cidr string, prefix int) []SubnetResult
 	// Calculate subnet mask
 	mask := net.CIDRMask(prefix, 32)
 
-	// Create a map to track assigned positions
-	assignedPositions := make(map[int]bool)
-
 	// Add network address entry
 	results = append(results, SubnetResult{
 		Subnet:   cidr,
@@ -202,95 +544,60 @@ func processIPAssignments(subnet Subnet, cidr string, prefix int) []SubnetResult
 	// Process IP assignments
 	totalIPs := 1 << (32 - prefix)
 	for _, assignment := range subnet.IPAssignments {
-		var assignedIP net.IP
-		position := assignment.Position
-
-		// Handle negative positions (count from end)
-		if position < 0 {
-			// Negative positions count backwards from broadcast
-			if prefix == 32 {
-				assignedIP = networkIP
-			} else if prefix == 31 {
-				assignedIP = uint32ToIP(networkInt + uint32(totalIPs) + uint32(position))
-			} else {
-				assignedIP = uint32ToIP(networkInt + uint32(totalIPs) - 1 + uint32(position))
-			}
-		} else if position == 0 {
-			// Position 0 means use the network address (for /32 and special cases)
-			assignedIP = networkIP
+		start, end := assignmentSpan(assignment, prefix, totalIPs)
+
+		var ip string
+		if start == end {
+			ip = uint32ToIP(networkInt + uint32(start)).String()
 		} else {
-			// Positive positions
-			assignedIP = uint32ToIP(networkInt + uint32(position))
+			// Named blocks render as a single range row
+			ip = fmt.Sprintf("%s - %s", uint32ToIP(networkInt+uint32(start)).String(), uint32ToIP(networkInt+uint32(end)).String())
 		}
 
-		assignedPositions[position] = true
-
+		category := "Assignment"
+		if assignment.Reserved {
+			category = "Reserved"
+		} else if assignment.Category != "" {
+			category = assignment.Category
+		}
 		results = append(results, SubnetResult{
-			Subnet:   cidr,
-			Name:     subnet.Name,
-			VLAN:     subnet.VLAN,
-			Label:    assignment.Name,
-			IP:       assignedIP.String(),
-			TotalIPs: 1,
-			Prefix:   prefix,
-			Mask:     fmt.Sprintf("%d.%d.%d.%d", mask[0], mask[1], mask[2], mask[3]),
-			Category: "Assignment",
+			Subnet:      cidr,
+			Name:        subnet.Name,
+			VLAN:        subnet.VLAN,
+			Label:       assignment.Name,
+			IP:          ip,
+			TotalIPs:    end - start + 1,
+			Prefix:      prefix,
+			Mask:        fmt.Sprintf("%d.%d.%d.%d", mask[0], mask[1], mask[2], mask[3]),
+			Category:    category,
+			DHCP:        assignment.DHCP && !assignment.Reserved,
+			Description: assignment.Description,
+			Tags:        assignment.Tags,
 		})
 	}
 
 	// Add unused ranges
 	if prefix < 31 {
-		// Find gaps in assignments and mark as unused
-		usedIPs := make(map[uint32]bool)
-
-		// Mark network address
-		usedIPs[networkInt] = true
-
-		// Mark assigned IPs
-		for _, assignment := range subnet.IPAssignments {
-			position := assignment.Position
-			var assignedInt uint32
-
-			if position < 0 {
-				if prefix == 31 {
-					assignedInt = networkInt + uint32(totalIPs) + uint32(position)
-				} else {
-					assignedInt = networkInt + uint32(totalIPs) - 1 + uint32(position)
-				}
-			} else if position == 0 {
-				assignedInt = networkInt
-			} else {
-				assignedInt = networkInt + uint32(position)
-			}
-			usedIPs[assignedInt] = true
-		}
-
-		// Mark broadcast (for non-/31 and non-/32)
+		// Find the gaps between assignments by walking their spans in
+		// address order, so memory depends on the number of assignments
+		// rather than the size of the subnet
 		broadcastInt := networkInt + uint32(totalIPs) - 1
-		if prefix < 31 {
-			usedIPs[broadcastInt] = true
+		spans := make([][2]int, 0, len(subnet.IPAssignments))
+		for _, assignment := range subnet.IPAssignments {
+			start, end := assignmentSpan(assignment, prefix, totalIPs)
+			spans = append(spans, [2]int{start, end})
 		}
+		sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
 
-		// Find continuous unused ranges
-		rangeStart := -1
-		for i := 1; i < totalIPs-1; i++ { // Skip network (0) and broadcast (totalIPs-1)
-			currentIP := networkInt + uint32(i)
-			if !usedIPs[currentIP] {
-				if rangeStart == -1 {
-					rangeStart = i
-				}
-			} else {
-				if rangeStart != -1 {
-					// End of unused range
-					addUnusedRange(&results, subnet, cidr, prefix, mask, networkInt, rangeStart, i-1)
-					rangeStart = -1
-				}
+		next := 1 // skip the network address; the broadcast is never unused
+		for _, span := range spans {
+			if span[0] > next && next <= totalIPs-2 {
+				addUnusedRange(&results, subnet, cidr, prefix, mask, networkInt, next, min(span[0]-1, totalIPs-2))
 			}
+			next = max(next, span[1]+1)
 		}
-
-		// Handle final unused range
-		if rangeStart != -1 {
-			addUnusedRange(&results, subnet, cidr, prefix, mask, networkInt, rangeStart, totalIPs-2)
+		if next <= totalIPs-2 {
+			addUnusedRange(&results, subnet, cidr, prefix, mask, networkInt, next, totalIPs-2)
 		}
 
 		// Add broadcast entry
@@ -310,6 +617,58 @@ func processIPAssignments(subnet Subnet, cidr string, prefix int) []SubnetResult
 	return results
 }
 
+// assignmentOffset converts an assignment position into an offset from the
+// network address. Negative positions count backwards from the broadcast
+// address (or from the end of the block for /31 and /32).
+func assignmentOffset(position, prefix, totalIPs int) int {
+	if position >= 0 {
+		return position
+	}
+	switch prefix {
+	case 32:
+		return 0
+	case 31:
+		return totalIPs + position
+	default:
+		return totalIPs - 1 + position
+	}
+}
+
+// assignmentSpan returns the first and last offsets covered by an assignment.
+// Single addresses have start == end; named blocks use EndPosition or Count.
+func assignmentSpan(a IPAssignment, prefix, totalIPs int) (int, int) {
+	start := assignmentOffset(a.Position, prefix, totalIPs)
+	end := start
+	if a.EndPosition != 0 {
+		end = assignmentOffset(a.EndPosition, prefix, totalIPs)
+	} else if a.Count > 1 {
+		end = start + a.Count - 1
+	}
+	return start, end
+}
+
+// validateAssignments checks that every assignment (and every block) lies
+// inside the subnet.
+func validateAssignments(subnet Subnet, prefix int) error {
+	totalIPs := 1 << (32 - prefix)
+	for _, a := range subnet.IPAssignments {
+		if a.Count < 0 {
+			return fmt.Errorf("subnet %s: assignment %s has negative Count %d", subnet.Name, a.Name, a.Count)
+		}
+		if a.Count > 0 && a.EndPosition != 0 {
+			return fmt.Errorf("subnet %s: assignment %s sets both Count and EndPosition", subnet.Name, a.Name)
+		}
+		start, end := assignmentSpan(a, prefix, totalIPs)
+		if start < 0 || end >= totalIPs {
+			return fmt.Errorf("subnet %s: assignment %s is outside the /%d subnet (%d addresses)", subnet.Name, a.Name, prefix, totalIPs)
+		}
+		if end < start {
+			return fmt.Errorf("subnet %s: assignment %s ends before it starts", subnet.Name, a.Name)
+		}
+	}
+	return nil
+}
+
 func addUnusedRange(results *[]SubnetResult, subnet Subnet, cidr string, prefix int, mask net.IPMask, networkInt uint32, start, end int) {
 	startIP := uint32ToIP(networkInt + uint32(start))
 	endIP := uint32ToIP(networkInt + uint32(end))
@@ -431,6 +790,25 @@ func uint32ToIP(n uint32) net.IP {
 	return ip
 }
 
+// parseIPSpan parses a result IP column ("10.0.0.5" or "10.0.0.5 - 10.0.0.9")
+// into its first and last address.
+func parseIPSpan(s string) (uint32, uint32, error) {
+	first, last, isRange := strings.Cut(s, " - ")
+	start := net.ParseIP(strings.TrimSpace(first))
+	if start == nil || start.To4() == nil {
+		return 0, 0, fmt.Errorf("invalid IP %q", s)
+	}
+	if !isRange {
+		n := ipToUint32(start)
+		return n, n, nil
+	}
+	end := net.ParseIP(strings.TrimSpace(last))
+	if end == nil || end.To4() == nil {
+		return 0, 0, fmt.Errorf("invalid IP range %q", s)
+	}
+	return ipToUint32(start), ipToUint32(end), nil
+}
+
 func createBasicSubnetEntries(subnet Subnet, cidr string, prefix int) []SubnetResult {
 	var results []SubnetResult
 
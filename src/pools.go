@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// poolCIDRs returns every parent CIDR of a network: network first, then
+// pools.
+func poolCIDRs(n Network) []string {
+	if n.Network == "" {
+		return n.Pools
+	}
+	return append([]string{n.Network}, n.Pools...)
+}
+
+// SplitPools replaces every network with pools by one network per pool CIDR.
+// Subnets are placed largest first, each in the first pool that still has
+// room, so allocation spills over into the next pool once a pool is full.
+// Subnets with a fixed address go to the pool containing it.
+func (p Planner) SplitPools(networks []Network) ([]Network, error) {
+	var out []Network
+	for _, n := range networks {
+		parts, err := p.splitPools(n)
+		if err != nil {
+			return nil, fmt.Errorf("error planning network %s: %w", strings.Join(poolCIDRs(n), ", "), err)
+		}
+		out = append(out, parts...)
+	}
+	return out, nil
+}
+
+func (p Planner) splitPools(n Network) ([]Network, error) {
+	if len(n.Pools) == 0 {
+		return []Network{n}, nil
+	}
+	cidrs := poolCIDRs(n)
+	parts := make([]Network, len(cidrs))
+	pools := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool CIDR '%s': %v", cidr, err)
+		}
+		pools[i] = ipNet
+		parts[i] = n
+		parts[i].Network, parts[i].Pools, parts[i].P2PLinks, parts[i].Subnets = cidr, nil, 0, nil
+	}
+
+	subnets := withP2PLinks(n)
+	blocks := make([]int, len(subnets))
+	for i, s := range subnets {
+		prefix, err := p.subnetPrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		bits, err := fabricBits(s)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = prefix - bits
+	}
+	order := make([]int, len(subnets))
+	for i := range order {
+		order[i] = i
+	}
+	// Fixed addresses first, as in planNetwork, then largest first
+	sort.SliceStable(order, func(a, b int) bool {
+		pa, pb := subnets[order[a]].Address != "", subnets[order[b]].Address != ""
+		if pa != pb {
+			return pa
+		}
+		return blocks[order[a]] < blocks[order[b]]
+	})
+
+	// Try each subnet in the pools in order and keep the first plan that fits
+	placed := make([][]int, len(parts))
+	for _, idx := range order {
+		s := subnets[idx]
+		var pinned net.IP
+		if s.Address != "" {
+			pinned = net.ParseIP(strings.SplitN(s.Address, "/", 2)[0])
+		}
+		fits := false
+		for i := range parts {
+			ones, _ := pools[i].Mask.Size()
+			if blocks[idx] < ones || (pinned != nil && !pools[i].Contains(pinned)) {
+				continue
+			}
+			trial := parts[i]
+			trial.Subnets = append(append([]Subnet(nil), parts[i].Subnets...), s)
+			if _, err := p.planNetwork(trial); err != nil {
+				var noSpace *NoSpaceError
+				if errors.As(err, &noSpace) {
+					continue
+				}
+				return nil, err
+			}
+			parts[i] = trial
+			placed[i] = append(placed[i], idx)
+			fits = true
+			break
+		}
+		if !fits && pinned != nil {
+			return nil, fmt.Errorf("subnet %s: address %s is not in any pool with room for it", s.Name, s.Address)
+		}
+		if !fits {
+			return nil, &NoSpaceError{Subnet: s.Name, Prefix: blocks[idx], Network: strings.Join(cidrs, ", ")}
+		}
+	}
+
+	// Keep the config order of subnets within each pool
+	for i := range parts {
+		sort.Ints(placed[i])
+		parts[i].Subnets = nil
+		for _, idx := range placed[i] {
+			parts[i].Subnets = append(parts[i].Subnets, subnets[idx])
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalJSON accepts "Position" and "EndPosition" as numbers or as
+// expressions such as "gateway+1" or "last-10", which are kept in
+// PositionExpr and EndPositionExpr until resolvePositions evaluates them.
+func (a *IPAssignment) UnmarshalJSON(data []byte) error {
+	type plain IPAssignment
+	aux := struct {
+		*plain
+		Position    json.RawMessage `json:"Position"`
+		EndPosition json.RawMessage `json:"EndPosition,omitempty"`
+	}{plain: (*plain)(a)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if a.Position, a.PositionExpr, err = decodePosition(aux.Position); err != nil {
+		return fmt.Errorf("assignment %s: Position: %v", a.Name, err)
+	}
+	if a.EndPosition, a.EndPositionExpr, err = decodePosition(aux.EndPosition); err != nil {
+		return fmt.Errorf("assignment %s: EndPosition: %v", a.Name, err)
+	}
+	return nil
+}
+
+// decodePosition decodes a position given as a number, a numeric string or
+// an expression.
+func decodePosition(raw json.RawMessage) (int, string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, "", nil
+	}
+	var expr string
+	if err := json.Unmarshal(raw, &expr); err != nil {
+		var n int
+		err := json.Unmarshal(raw, &n)
+		return n, "", err
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(expr)); err == nil {
+		return n, "", nil
+	}
+	return 0, expr, nil
+}
+
+// MarshalJSON writes unresolved position expressions back as "Position" and
+// "EndPosition".
+func (a IPAssignment) MarshalJSON() ([]byte, error) {
+	type plain IPAssignment
+	if a.PositionExpr == "" && a.EndPositionExpr == "" {
+		return json.Marshal(plain(a))
+	}
+	var position, end interface{} = a.Position, nil
+	if a.PositionExpr != "" {
+		position = a.PositionExpr
+	}
+	if a.EndPositionExpr != "" {
+		end = a.EndPositionExpr
+	} else if a.EndPosition != 0 {
+		end = a.EndPosition
+	}
+	return json.Marshal(struct {
+		plain
+		Position    interface{} `json:"Position"`
+		EndPosition interface{} `json:"EndPosition,omitempty"`
+	}{plain(a), position, end})
+}
+
+// resolvePositions evaluates the subnet's position expressions into offsets
+// from the subnet's network address. Expressions may use the anchors first
+// and last (the first and last usable address), the start of any other
+// assignment by its name and the end of a block as <name>.end (names are
+// case-insensitive; spaces and dashes become _), and the network's
+// variables. Assignments may refer to each other in any order, but not in
+// a cycle.
+func resolvePositions(subnet Subnet, prefix int, vars map[string]float64) (Subnet, error) {
+	pending := 0
+	for _, a := range subnet.IPAssignments {
+		if a.PositionExpr != "" || a.EndPositionExpr != "" {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return subnet, nil
+	}
+	assignments := append([]IPAssignment(nil), subnet.IPAssignments...)
+	totalIPs := 1 << (32 - prefix)
+	first := 1
+	if prefix > 30 {
+		first = 0
+	}
+	for {
+		env := make(map[string]float64, len(vars)+2*len(assignments)+2)
+		for k, v := range vars {
+			env[strings.ToLower(k)] = v
+		}
+		env["first"] = float64(first)
+		env["last"] = float64(assignmentOffset(-1, prefix, totalIPs))
+		for _, a := range assignments {
+			if a.PositionExpr != "" || a.EndPositionExpr != "" {
+				continue
+			}
+			start, end := assignmentSpan(a, prefix, totalIPs)
+			name := positionName(a.Name)
+			env[name] = float64(start)
+			env[name+".end"] = float64(end)
+		}
+
+		progress := false
+		var firstErr error
+		for i := range assignments {
+			a := &assignments[i]
+			if a.PositionExpr == "" && a.EndPositionExpr == "" {
+				continue
+			}
+			position, err := evalPosition(a.PositionExpr, a.Position, env)
+			if err == nil {
+				// The end of a block may refer to its own start
+				env[positionName(a.Name)] = float64(assignmentOffset(position, prefix, totalIPs))
+				a.EndPosition, err = evalPosition(a.EndPositionExpr, a.EndPosition, env)
+			}
+			if err == nil && a.EndPositionExpr != "" && a.EndPosition == 0 {
+				err = fmt.Errorf("EndPosition %q evaluates to the network address", a.EndPositionExpr)
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("subnet %s: assignment %s: %v", subnet.Name, a.Name, err)
+				}
+				continue
+			}
+			a.Position, a.PositionExpr, a.EndPositionExpr = position, "", ""
+			progress = true
+			pending--
+		}
+		if pending == 0 {
+			break
+		}
+		if !progress {
+			return subnet, firstErr
+		}
+	}
+	subnet.IPAssignments = assignments
+	return subnet, nil
+}
+
+// evalPosition evaluates a position expression into an offset; an empty
+// expression keeps the numeric position.
+func evalPosition(expr string, position int, env map[string]float64) (int, error) {
+	if expr == "" {
+		return position, nil
+	}
+	value, err := evalExpr(strings.ToLower(expr), env)
+	if err != nil {
+		return 0, fmt.Errorf("position %q: %v", expr, err)
+	}
+	if value != math.Trunc(value) || value < 0 {
+		return 0, fmt.Errorf("position %q evaluates to %g, not an address of the subnet", expr, value)
+	}
+	return int(value), nil
+}
+
+// positionName is the name an assignment is referred to by in position
+// expressions.
+func positionName(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// SplitProposal proposes how the subnets of a network that does not fit
+// its parent could be distributed over additional candidate parents.
+type SplitProposal struct {
+	Network string           `json:"network"` // the parents of the config
+	Parents []ProposedParent `json:"parents"`
+	Config  Network          `json:"config"` // the network with the candidates added as pools
+}
+
+// ProposedParent is one parent of a SplitProposal and the subnets it gets.
+type ProposedParent struct {
+	CIDR      string   `json:"cidr"`
+	Candidate bool     `json:"candidate"` // taken from the candidate list
+	Subnets   []string `json:"subnets"`
+	Allocated uint64   `json:"allocated"`
+	Total     uint64   `json:"total"`
+}
+
+// parseCandidateParents parses the -propose-parents list of CIDRs.
+func parseCandidateParents(spec string) ([]string, error) {
+	var cidrs []string
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -propose-parents CIDR %q", s)
+		}
+		cidrs = append(cidrs, ipNet.String())
+	}
+	return cidrs, nil
+}
+
+// ProposeSplits finds the networks whose subnets do not fit their parents
+// and proposes, for each, a distribution over the candidate parents: the
+// candidates are added as pools in order, so the config's own parents are
+// filled first and only as many candidates are used as needed. Candidates
+// that overlap a parent of the config, or that an earlier proposal used,
+// are skipped.
+func (p Planner) ProposeSplits(networks []Network, candidates []string) ([]SplitProposal, error) {
+	var taken []*net.IPNet
+	for _, n := range networks {
+		for _, cidr := range poolCIDRs(n) {
+			if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+				taken = append(taken, ipNet)
+			}
+		}
+	}
+	free := func(cidr string) bool {
+		_, ipNet, _ := net.ParseCIDR(cidr)
+		for _, t := range taken {
+			if t.Contains(ipNet.IP) || ipNet.Contains(t.IP) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var proposals []SplitProposal
+	for _, n := range networks {
+		_, err := p.Plan([]Network{n})
+		var noSpace *NoSpaceError
+		if !errors.As(err, &noSpace) {
+			continue
+		}
+		own := len(poolCIDRs(n))
+		trial := n
+		trial.Pools = append([]string(nil), n.Pools...)
+		for _, cidr := range candidates {
+			if free(cidr) {
+				trial.Pools = append(trial.Pools, cidr)
+			}
+		}
+		parts, err := p.splitPools(trial)
+		if err != nil {
+			return proposals, fmt.Errorf("network %s does not fit even with the candidate parents: %v", strings.Join(poolCIDRs(n), ", "), err)
+		}
+
+		proposal := SplitProposal{Network: strings.Join(poolCIDRs(n), ", "), Config: n}
+		proposal.Config.Pools = append([]string(nil), n.Pools...)
+		for i, part := range parts {
+			candidate := i >= own
+			if candidate && len(part.Subnets) == 0 {
+				continue
+			}
+			parent := ProposedParent{CIDR: part.Network, Candidate: candidate, Subnets: []string{}}
+			for _, s := range part.Subnets {
+				parent.Subnets = append(parent.Subnets, s.Name)
+			}
+			if results, err := p.planNetwork(part); err == nil {
+				_, byNetwork, _, _ := collectUsage(results)
+				if u := byNetwork[part.Network]; u != nil {
+					parent.Allocated, parent.Total = u.allocated(), u.total
+				}
+			}
+			if candidate {
+				_, ipNet, _ := net.ParseCIDR(part.Network)
+				taken = append(taken, ipNet)
+				proposal.Config.Pools = append(proposal.Config.Pools, part.Network)
+			}
+			proposal.Parents = append(proposal.Parents, parent)
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// PrintSplitProposals writes the proposed distributions and the network
+// configs that implement them.
+func PrintSplitProposals(w io.Writer, proposals []SplitProposal) {
+	for _, prop := range proposals {
+		fmt.Fprintf(w, "\nProposed split of %s:\n", prop.Network)
+		for _, parent := range prop.Parents {
+			source := "existing "
+			if parent.Candidate {
+				source = "candidate"
+			}
+			fmt.Fprintf(w, "  %-18s %s  %s of %s addresses (%s): %s\n", parent.CIDR, source, groupDigits(parent.Allocated), groupDigits(parent.Total),
+				percentText(parent.Allocated, parent.Total), strings.Join(parent.Subnets, ", "))
+		}
+		data, _ := json.MarshalIndent(prop.Config, "  ", "  ")
+		fmt.Fprintf(w, "  Config with the candidates as pools:\n  %s\n", data)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// pushSummary counts what a push to an IPAM created (or would create),
+// found already present, and skipped.
+type pushSummary struct {
+	Created, Existing, Skipped int
+}
+
+// pushLog reports the actions of a push to an IPAM on out, one line per
+// object, and counts them.
+type pushLog struct {
+	out    io.Writer
+	dryRun bool
+	sum    pushSummary
+}
+
+func (l *pushLog) exists(kind, name string) {
+	l.sum.Existing++
+	fmt.Fprintf(l.out, "%-12s %s %s\n", "exists", kind, name)
+}
+
+func (l *pushLog) create(kind, name string) {
+	l.sum.Created++
+	verb := "create"
+	if l.dryRun {
+		verb = "would create"
+	}
+	fmt.Fprintf(l.out, "%-12s %s %s\n", verb, kind, name)
+}
+
+func (l *pushLog) skip(kind, name string) {
+	l.sum.Skipped++
+	fmt.Fprintf(l.out, "%-12s %s %s\n", "skip", kind, name)
+}
+
+// pushTotals is the closing line of a push with the counts.
+func pushTotals(sum pushSummary, dryRun bool) string {
+	verb := "created"
+	if dryRun {
+		verb = "to create"
+	}
+	return fmt.Sprintf("%d %s, %d existing, %d skipped", sum.Created, verb, sum.Existing, sum.Skipped)
+}
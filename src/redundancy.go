@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Redundancy configures a first-hop redundancy protocol for a subnet: the
+// virtual gateway address shared by the routers and one physical address
+// per router.
+type Redundancy struct {
+	Protocol string `json:"protocol"`          // hsrp, vrrp, glbp or none
+	Routers  int    `json:"routers,omitempty"` // physical routers, default 2
+
+	// Set by redundantGateway, which is shorthand for a redundancy of no
+	// particular protocol with positions for the virtual IP and routers
+	anyProtocol bool
+	vip         string
+	positions   []string
+}
+
+// redundancyMaxRouters is the number of routers each protocol supports in
+// one group: GLBP load-balances over at most four forwarders.
+var redundancyMaxRouters = map[string]int{
+	"hsrp": 255,
+	"vrrp": 255,
+	"glbp": 4,
+}
+
+// isAssignedCategory reports whether rows of category hold addresses in use
+// by devices: assignments and the addresses of redundancy protocols.
+func isAssignedCategory(category string) bool {
+	return category == "Assignment" || category == "Virtual IP" || category == "Router"
+}
+
+// applyRedundancy adds the addresses of the subnet's (or else the network's)
+// redundancy protocol or redundantGateway, which are mutually exclusive. The
+// virtual IP becomes the subnet's Gateway, placed by redundantGateway's
+// position or else the gateway convention (first by default), and the
+// routers take the addresses next to it, inwards from the end of the subnet
+// for negative positions. Protocol routers are Router1, Router2, ...;
+// redundantGateway's are Router-A and Router-B. Point-to-point links and
+// /31 and /32 subnets are left unchanged unless they set their own
+// redundancy, which is then an error for the small prefixes. A subnet's own
+// redundancy wins over the network's redundantGateway, and
+// "redundantGateway": false only opts out of the network's redundantGateway.
+func applyRedundancy(subnet Subnet, networkDefault *Redundancy, networkGateway *RedundantGateway, gatewayDefault string, prefix int) (Subnet, error) {
+	r := subnet.Redundancy
+	if g := subnet.RedundantGateway; g != nil && g.Enabled {
+		if r != nil {
+			return subnet, fmt.Errorf("subnet %s: redundantGateway conflicts with redundancy; set only one", subnet.Name)
+		}
+		r = g.redundancy()
+	}
+	own := r != nil
+	if r == nil && !subnet.P2P {
+		r = networkDefault
+		if subnet.RedundantGateway == nil && networkGateway != nil && networkGateway.Enabled {
+			if r != nil {
+				return subnet, fmt.Errorf("subnet %s: the network's redundantGateway conflicts with its redundancy; set only one", subnet.Name)
+			}
+			r = networkGateway.redundancy()
+		}
+	}
+	if r == nil || strings.EqualFold(r.Protocol, "none") {
+		return subnet, nil
+	}
+	protocol := strings.ToLower(r.Protocol)
+	kind := "a redundant gateway"
+	if !r.anyProtocol {
+		max, ok := redundancyMaxRouters[protocol]
+		if !ok {
+			return subnet, fmt.Errorf("subnet %s: invalid redundancy protocol %q (use hsrp, vrrp, glbp or none)", subnet.Name, r.Protocol)
+		}
+		if r.Routers != 0 && (r.Routers < 2 || r.Routers > max) {
+			return subnet, fmt.Errorf("subnet %s: %s needs 2 to %d routers, not %d", subnet.Name, strings.ToUpper(protocol), max, r.Routers)
+		}
+		kind = strings.ToUpper(protocol) + " redundancy"
+	}
+	routers := r.Routers
+	if routers == 0 {
+		routers = 2
+	}
+	if prefix > 30 {
+		if !own {
+			return subnet, nil
+		}
+		return subnet, fmt.Errorf("subnet %s: /%d is too small for %s", subnet.Name, prefix, kind)
+	}
+	for _, a := range subnet.IPAssignments {
+		if strings.EqualFold(a.Name, "Gateway") {
+			return subnet, fmt.Errorf("subnet %s: the Gateway assignment conflicts with %s, whose virtual IP is the gateway", subnet.Name, kind)
+		}
+	}
+
+	vip := IPAssignment{Name: "Gateway"}
+	if r.vip != "" {
+		setPosition(&vip, r.vip)
+	} else {
+		convention := subnet.Gateway
+		if convention == "" {
+			convention = gatewayDefault
+		}
+		if convention == "" || convention == "none" {
+			convention = "first"
+		}
+		var err error
+		if vip, err = gatewayAssignment(convention); err != nil {
+			return subnet, fmt.Errorf("subnet %s: %v", subnet.Name, err)
+		}
+	}
+	vipDescription := "Virtual IP"
+	if !r.anyProtocol {
+		vipDescription = strings.ToUpper(protocol) + " virtual IP"
+	}
+	// Each assignment gets its own tags map
+	tags := func() map[string]string {
+		if r.anyProtocol {
+			return nil
+		}
+		return map[string]string{"protocol": protocol}
+	}
+	vip.Category = "Virtual IP"
+	vip.Description = vipDescription
+	vip.Tags = tags()
+	step := 1
+	if vip.Position < 0 {
+		step = -1
+	}
+	added := []IPAssignment{vip}
+	for i := 1; i <= routers; i++ {
+		// Protocol routers are numbered, redundantGateway's two lettered
+		name, description := fmt.Sprintf("Router%d", i), fmt.Sprintf("%s router %d", strings.ToUpper(protocol), i)
+		if r.anyProtocol {
+			letter := string(rune('A' + i - 1))
+			name, description = "Router-"+letter, "Router "+letter
+		}
+		router := IPAssignment{
+			Name:        name,
+			Category:    "Router",
+			Description: description,
+			Tags:        tags(),
+		}
+		switch {
+		case i <= len(r.positions) && r.positions[i-1] != "":
+			setPosition(&router, r.positions[i-1])
+		case vip.PositionExpr != "":
+			router.PositionExpr = fmt.Sprintf("gateway+%d", i)
+		default:
+			router.Position = vip.Position + i*step
+		}
+		added = append(added, router)
+	}
+	subnet.IPAssignments = append(added, subnet.IPAssignments...)
+	return subnet, nil
+}
+
+// RedundantGateway adds the gateway triplet used with any first-hop
+// redundancy protocol: the virtual IP, which is the subnet's Gateway, and
+// the addresses of two routers, Router-A and Router-B. In configs it is true,
+// false (to opt out of the network's default) or an object setting the
+// positions, as numbers or position expressions. It is shorthand for a
+// Redundancy of no particular protocol (see redundancy).
+type RedundantGateway struct {
+	Enabled bool
+	VIP     string
+	RouterA string
+	RouterB string
+}
+
+// redundantGatewayFields are the JSON fields of a RedundantGateway given as
+// an object.
+type redundantGatewayFields struct {
+	VIP     json.RawMessage `json:"vip,omitempty"`
+	RouterA json.RawMessage `json:"routerA,omitempty"`
+	RouterB json.RawMessage `json:"routerB,omitempty"`
+}
+
+// UnmarshalJSON accepts true, false or an object of positions.
+func (g *RedundantGateway) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &g.Enabled); err == nil {
+		return nil
+	}
+	var fields redundantGatewayFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("redundantGateway: use true, false or an object with vip, routerA and routerB positions: %v", err)
+	}
+	for _, f := range []struct {
+		name string
+		raw  json.RawMessage
+		dst  *string
+	}{{"vip", fields.VIP, &g.VIP}, {"routerA", fields.RouterA, &g.RouterA}, {"routerB", fields.RouterB, &g.RouterB}} {
+		if len(f.raw) == 0 || string(f.raw) == "null" {
+			continue
+		}
+		n, expr, err := decodePosition(f.raw)
+		if err != nil {
+			return fmt.Errorf("redundantGateway: %s: %v", f.name, err)
+		}
+		if expr == "" {
+			expr = strconv.Itoa(n)
+		}
+		*f.dst = expr
+	}
+	g.Enabled = true
+	return nil
+}
+
+// MarshalJSON writes the triplet back as a bool, or as an object when it
+// sets positions.
+func (g RedundantGateway) MarshalJSON() ([]byte, error) {
+	if g.VIP == "" && g.RouterA == "" && g.RouterB == "" {
+		return json.Marshal(g.Enabled)
+	}
+	position := func(text string) json.RawMessage {
+		if text == "" {
+			return nil
+		}
+		if _, err := strconv.Atoi(text); err == nil {
+			return json.RawMessage(text)
+		}
+		data, _ := json.Marshal(text)
+		return data
+	}
+	return json.Marshal(redundantGatewayFields{position(g.VIP), position(g.RouterA), position(g.RouterB)})
+}
+
+// redundancy returns the Redundancy the triplet stands for: two routers of
+// no particular protocol, at the triplet's positions.
+func (g *RedundantGateway) redundancy() *Redundancy {
+	return &Redundancy{Routers: 2, anyProtocol: true, vip: g.VIP, positions: []string{g.RouterA, g.RouterB}}
+}
+
+// setPosition sets an assignment's position from a number or an
+// expression.
+func setPosition(a *IPAssignment, text string) {
+	if n, err := strconv.Atoi(text); err == nil {
+		a.Position = n
+	} else {
+		a.PositionExpr = text
+	}
+}
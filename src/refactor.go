@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// configShape is the top-level JSON form of a config file, kept so a
+// refactored config is written back in the same form.
+type configShape int
+
+const (
+	shapeSingle configShape = iota
+	shapeArray
+	shapeWrapped
+)
+
+// decodeConfigRaw decodes a config without expanding templates, circuits
+// or wireless plans, so it can be edited and written back.
+func decodeConfigRaw(data []byte) (Config, configShape, error) {
+	data = configText(data)
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var arr []Network
+		err := json.Unmarshal(data, &arr)
+		return Config{Networks: arr}, shapeArray, err
+	}
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Config{}, shapeSingle, err
+	}
+	_, network := probe["network"]
+	_, pools := probe["pools"]
+	if !network && !pools {
+		var cfg Config
+		err := json.Unmarshal(data, &cfg)
+		return cfg, shapeWrapped, err
+	}
+	var n Network
+	err := json.Unmarshal(data, &n)
+	return Config{Networks: []Network{n}}, shapeSingle, err
+}
+
+// encodeConfig renders cfg in the given shape.
+func encodeConfig(cfg Config, shape configShape) ([]byte, error) {
+	var v interface{} = cfg
+	switch shape {
+	case shapeArray:
+		v = cfg.Networks
+	case shapeSingle:
+		v = cfg.Networks[0]
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// refactorConfig applies one operation to every matching subnet and returns
+// how many subnets changed and the renamed subnets (old -> new name).
+//
+//	rename <old> <new>           rename a subnet (and its OOB references)
+//	vlan <subnet> <vlan>         change a subnet's VLAN
+//	prefix <old> <new>           replace a name prefix on every subnet
+func refactorConfig(cfg *Config, op string, args []string) (int, map[string]string, error) {
+	if len(args) != 2 {
+		return 0, nil, fmt.Errorf("%s takes two arguments", op)
+	}
+	var apply func(s *Subnet) bool
+	renamed := map[string]string{}
+	matched := false // a subnet of the vlan op already has the VLAN
+	switch op {
+	case "rename":
+		from, to := args[0], args[1]
+		apply = func(s *Subnet) bool {
+			if s.Name != from {
+				return false
+			}
+			s.Name = to
+			renamed[from] = to
+			return true
+		}
+	case "vlan":
+		vlan, err := strconv.Atoi(args[1])
+		if err != nil || vlan < 0 || vlan > 4094 {
+			return 0, nil, fmt.Errorf("invalid VLAN %q (use 0-4094)", args[1])
+		}
+		apply = func(s *Subnet) bool {
+			if s.Name != args[0] {
+				return false
+			}
+			if s.VLAN == vlan {
+				matched = true
+				return false
+			}
+			s.VLAN = vlan
+			return true
+		}
+	case "prefix":
+		from, to := args[0], args[1]
+		apply = func(s *Subnet) bool {
+			if !strings.HasPrefix(s.Name, from) {
+				return false
+			}
+			renamed[s.Name] = to + strings.TrimPrefix(s.Name, from)
+			s.Name = renamed[s.Name]
+			return true
+		}
+	default:
+		return 0, nil, fmt.Errorf("unknown operation %q (use rename, vlan or prefix)", op)
+	}
+
+	// Subnets of every environment change too, keeping environments alike
+	changed := 0
+	lists := [][]Network{cfg.Networks}
+	for _, env := range sortedKeys(cfg.Environments) {
+		lists = append(lists, cfg.Environments[env])
+	}
+	for _, networks := range lists {
+		for i := range networks {
+			for j := range networks[i].Subnets {
+				if apply(&networks[i].Subnets[j]) {
+					changed++
+				}
+			}
+		}
+	}
+	if changed == 0 && !matched {
+		return 0, nil, fmt.Errorf("no subnet matches %q", args[0])
+	}
+	if oob := cfg.OOB; oob != nil {
+		for _, names := range [][]string{oob.Subnets, oob.InBand} {
+			for i, name := range names {
+				if to, ok := renamed[name]; ok {
+					names[i] = to
+				}
+			}
+		}
+	}
+	return changed, renamed, nil
+}
+
+// runRefactor implements the "refactor" subcommand.
+func runRefactor(args []string) int {
+	fs := flag.NewFlagSet("refactor", flag.ExitOnError)
+	input := fs.String("input", "", "Config file to refactor")
+	output := fs.String("o", "", "Write the refactored config to this file")
+	write := fs.Bool("w", false, "Overwrite the -input file with the refactored config")
+	dropComments := fs.Bool("drop-comments", false, "Allow -w to rewrite a config with comments or trailing commas, which are not kept")
+	env := fs.String("env", "", "Environment whose plan changes are shown, for configs with \"environments\" (all environments are refactored)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner refactor -input config.json [-w [-drop-comments] | -o new.json] <operation> <args>\n\n")
+		fmt.Fprintf(os.Stderr, "Operations:\n")
+		fmt.Fprintf(os.Stderr, "  rename <old> <new>       rename a subnet\n")
+		fmt.Fprintf(os.Stderr, "  vlan <subnet> <vlan>     change a subnet's VLAN\n")
+		fmt.Fprintf(os.Stderr, "  prefix <old> <new>       replace a name prefix on every subnet (e.g. prefix prod- prd-)\n\n")
+		fmt.Fprintf(os.Stderr, "The plan is re-run and the changes are shown; without -w or -o nothing is written.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if *input == "" || fs.NArg() < 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading config file: %v\n", err)
+		return exitConfigError
+	}
+	// The refactored config is plain JSON, so comments would be lost
+	_, jsonc := stripJSONC(decodeText(data))
+	if jsonc && *write && !*dropComments {
+		fmt.Fprintf(os.Stderr, "refactor: %s has comments or trailing commas, which -w would drop; use -o, or add -drop-comments to overwrite it anyway\n", *input)
+		return exitUsage
+	}
+	before, err := loadConfig(data, *env)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
+	}
+	cfg, shape, err := decodeConfigRaw(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing config file: %v\n", err)
+		return exitConfigError
+	}
+
+	changed, renamed, err := refactorConfig(&cfg, fs.Arg(0), fs.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "refactor: %v\n", err)
+		return exitUsage
+	}
+	updated, err := encodeConfig(cfg, shape)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "refactor: %v\n", err)
+		return exitFailure
+	}
+	after, err := loadConfig(updated, *env)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitValidationError
+	}
+
+	oldPlan, err := PlanSubnets(before.Networks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "planning error (original config): %v\n", err)
+		return planningExitCode(err)
+	}
+	newPlan, err := PlanSubnets(after.Networks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "planning error (refactored config): %v\n", err)
+		return planningExitCode(err)
+	}
+	fmt.Printf("%d subnet(s) changed.\n", changed)
+	printed := make(map[string]bool)
+	for i, r := range oldPlan {
+		to, ok := renamed[r.Name]
+		if !ok {
+			continue
+		}
+		if !printed[r.Name] {
+			printed[r.Name] = true
+			fmt.Printf("  renamed  %-25s -> %s\n", r.Name, to)
+		}
+		// Compare under the new names so renames alone do not show as changes
+		oldPlan[i].Name = to
+	}
+	PrintDiff(DiffPlans(oldPlan, newPlan))
+	if changes := vlanChanges(oldPlan, newPlan); len(changes) > 0 {
+		fmt.Printf("\nVLAN changes:\n")
+		for _, c := range changes {
+			fmt.Printf("  %s\n", c)
+		}
+	}
+
+	target := *output
+	if *write {
+		target = *input
+	}
+	if target == "" {
+		fmt.Println("\nDry run: use -w to update the config in place or -o to write a new file.")
+		return 0
+	}
+	if jsonc {
+		fmt.Fprintf(os.Stderr, "warning: comments and trailing commas are not kept in %s\n", target)
+	}
+	if err := os.WriteFile(target, updated, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", target, err)
+		return exitFailure
+	}
+	fmt.Printf("\n✓ Config: %s\n", target)
+	return 0
+}
+
+// vlanChanges lists subnets whose VLAN differs between two plans, which
+// DiffPlans does not report.
+func vlanChanges(previous, current []SubnetResult) []string {
+	old, _ := indexSubnets(previous)
+	cur, order := indexSubnets(current)
+	var changes []string
+	for _, name := range order {
+		if o, ok := old[name]; ok && o.VLAN != cur[name].VLAN {
+			changes = append(changes, fmt.Sprintf("%-25s VLAN %d -> %d", name, o.VLAN, cur[name].VLAN))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// planCheck is the outcome of re-validating the latest version of a stored
+// plan.
+type planCheck struct {
+	Plan    string    `json:"plan"`
+	Version int       `json:"version"`
+	Checked time.Time `json:"checked"`
+	// Drift lists how re-planning the stored config differs from the
+	// stored results, e.g. after a planner upgrade.
+	Drifted bool     `json:"drifted"`
+	Drift   PlanDiff `json:"drift"`
+	// LiveDrift lists how the subnets of the live import differ from the
+	// stored results.
+	Live        string    `json:"live,omitempty"`
+	LiveDrifted bool      `json:"liveDrifted"`
+	LiveDrift   *PlanDiff `json:"liveDrift,omitempty"`
+	// Stale is set when the version is older than -stale-after.
+	Stale    bool     `json:"stale"`
+	Warnings []string `json:"warnings"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ok reports whether the check found nothing to act on.
+func (c planCheck) ok() bool {
+	return !c.Drifted && !c.LiveDrifted && !c.Stale && len(c.Warnings) == 0 && c.Error == ""
+}
+
+// revalidator periodically re-checks every stored plan and keeps the latest
+// result of each.
+type revalidator struct {
+	store      planStore
+	live       map[string]string // plan name -> live import file
+	staleAfter time.Duration     // 0 never flags plans as stale
+	now        func() time.Time
+
+	mu     sync.Mutex
+	checks map[string]planCheck
+}
+
+func newRevalidator(store planStore, live map[string]string, staleAfter time.Duration) *revalidator {
+	return &revalidator{store: store, live: live, staleAfter: staleAfter, now: time.Now, checks: make(map[string]planCheck)}
+}
+
+// start checks all plans now and then every interval, until the process
+// exits.
+func (v *revalidator) start(interval time.Duration) {
+	go func() {
+		for {
+			if err := v.run(); err != nil {
+				log.Printf("revalidation: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// run checks every stored plan.
+func (v *revalidator) run() error {
+	plans, err := v.store.Plans()
+	if err != nil {
+		return err
+	}
+	checks := make(map[string]planCheck, len(plans))
+	for _, plan := range plans {
+		c := v.check(plan)
+		if !c.ok() {
+			log.Printf("revalidation: plan %s version %d needs attention (drifted %v, live drifted %v, stale %v, %d warnings) %s",
+				c.Plan, c.Version, c.Drifted, c.LiveDrifted, c.Stale, len(c.Warnings), c.Error)
+		}
+		checks[plan] = c
+	}
+	v.mu.Lock()
+	v.checks = checks
+	v.mu.Unlock()
+	return nil
+}
+
+// check re-validates the latest version of plan: it plans the stored config
+// again and compares the results, lints the config, compares the live
+// import if one is configured, and checks the version's age.
+func (v *revalidator) check(plan string) planCheck {
+	c := planCheck{Plan: plan, Checked: v.now().UTC(), Warnings: []string{}}
+	versions, err := v.store.Versions(plan)
+	if err == nil && len(versions) == 0 {
+		err = fmt.Errorf("no versions")
+	}
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	c.Version = versions[len(versions)-1]
+	stored, err := v.store.Load(plan, c.Version)
+	if err != nil {
+		c.Error = err.Error()
+		return c
+	}
+	c.Stale = v.staleAfter > 0 && v.now().Sub(stored.Created) > v.staleAfter
+
+	cfg, err := loadConfig(stored.Config, stored.Env)
+	networks := cfg.Networks
+	if err != nil {
+		c.Error = fmt.Sprintf("stored config no longer parses: %v", err)
+		return c
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		c.Error = fmt.Sprintf("stored config no longer plans: %v", err)
+		return c
+	}
+	c.Drift = DiffPlans(stored.Results, results)
+	c.Drifted = c.Drift.HasChanges()
+	c.Warnings = lintWarnings(networks, results, 0)
+
+	if path, ok := v.live[plan]; ok {
+		c.Live = path
+		live, err := liveResults(path)
+		if err != nil {
+			c.Error = fmt.Sprintf("live import %s: %v", path, err)
+			return c
+		}
+		// Imports carry subnets only, so assignments are not compared, and
+		// their parent networks are made up, so subnets match by name
+		for i := range live {
+			live[i].Parent = ""
+		}
+		d := DiffPlans(stored.Results, live)
+		d.Assignments = nil
+		c.LiveDrift = &d
+		c.LiveDrifted = d.HasChanges()
+	}
+	return c
+}
+
+// liveResults imports and plans a live export of another planning tool.
+func liveResults(path string) ([]SubnetResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := importers[detectImportFormat(path)](data)
+	if err != nil {
+		return nil, err
+	}
+	return PlanSubnets(networks)
+}
+
+// results returns the latest checks sorted by plan name.
+func (v *revalidator) results() []planCheck {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	checks := make([]planCheck, 0, len(v.checks))
+	for _, c := range v.checks {
+		checks = append(checks, c)
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Plan < checks[j].Plan })
+	return checks
+}
+
+// result returns the latest check of one plan.
+func (v *revalidator) result(plan string) (planCheck, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	c, ok := v.checks[plan]
+	return c, ok
+}
+
+// writeMetrics writes the latest checks as Prometheus gauges.
+func (v *revalidator) writeMetrics(w io.Writer) {
+	checks := v.results()
+	gauges := []struct {
+		name, help string
+		value      func(planCheck) float64
+	}{
+		{"drifted", "1 if re-planning the stored config gives different results.", func(c planCheck) float64 { return boolGauge(c.Drifted) }},
+		{"live_drifted", "1 if the live import's subnets differ from the stored plan.", func(c planCheck) float64 { return boolGauge(c.LiveDrifted) }},
+		{"stale", "1 if the latest version is older than -stale-after.", func(c planCheck) float64 { return boolGauge(c.Stale) }},
+		{"warnings", "Validation warnings of the latest version.", func(c planCheck) float64 { return float64(len(c.Warnings)) }},
+		{"check_errors", "1 if the plan could not be checked.", func(c planCheck) float64 { return boolGauge(c.Error != "") }},
+		{"version", "Latest stored version.", func(c planCheck) float64 { return float64(c.Version) }},
+		{"checked_timestamp_seconds", "Time of the last check.", func(c planCheck) float64 { return float64(c.Checked.Unix()) }},
+	}
+	for _, g := range gauges {
+		name := "ipsubnetplanner_plan_" + g.name
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, g.help, name)
+		for _, c := range checks {
+			fmt.Fprintf(w, "%s{plan=%s} %s\n", name, metricLabel(c.Plan), strconv.FormatFloat(g.value(c), 'f', -1, 64))
+		}
+	}
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// liveFlag collects repeated -live plan=file flags.
+type liveFlag map[string]string
+
+func (l liveFlag) String() string {
+	names := make([]string, 0, len(l))
+	for k := range l {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = k + "=" + l[k]
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l liveFlag) Set(s string) error {
+	plan, path, ok := strings.Cut(s, "=")
+	if !ok || !planNamePattern.MatchString(plan) || path == "" {
+		return fmt.Errorf("use plan=file")
+	}
+	l[plan] = path
+	return nil
+}
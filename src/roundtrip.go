@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// isPlanJSON reports whether data is an -exportjson plan (a list of result
+// rows) rather than a config.
+func isPlanJSON(data []byte) bool {
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(configText(data), &rows); err != nil || len(rows) == 0 {
+		return false
+	}
+	_, subnet := rows[0]["subnet"]
+	_, category := rows[0]["category"]
+	return subnet && category
+}
+
+// planToConfig rebuilds a config from plan rows. Every subnet is pinned to
+// its planned address, so planning the config again reproduces the plan;
+// assignments, reserved addresses, descriptions and tags are kept, and the
+// addresses of a redundancy protocol become the subnet's redundancy again.
+func planToConfig(results []SubnetResult) []Network {
+	var networks []Network
+	netIndex := make(map[string]int)
+	subnetIndex := make(map[string][2]int)
+	redundant := make(map[[2]int][]SubnetResult)
+	var redundantOrder [][2]int
+	for _, r := range results {
+		if isFreeSpaceRow(r) || r.Category == "Summary" || r.Subnet == "" || r.Parent == "" {
+			continue
+		}
+		ni, ok := netIndex[r.Parent]
+		if !ok {
+			ni = len(networks)
+			netIndex[r.Parent] = ni
+			networks = append(networks, Network{Network: r.Parent})
+		}
+		key := r.Parent + " " + r.Subnet
+		idx, ok := subnetIndex[key]
+		if !ok {
+			n := &networks[ni]
+			idx = [2]int{ni, len(n.Subnets)}
+			subnetIndex[key] = idx
+			n.Subnets = append(n.Subnets, Subnet{
+				Name:             r.Name,
+				VLAN:             r.VLAN,
+				CIDR:             r.Prefix,
+				Address:          r.Subnet,
+				PlannedFor:       r.PlannedFor,
+				Criticality:      r.Criticality,
+				Decommissioned:   isDecommissionedStatus(r.Status),
+				DecommissionedOn: r.DecommissionedOn,
+			})
+		}
+		s := &networks[idx[0]].Subnets[idx[1]]
+
+		switch r.Category {
+		case "Network":
+			s.Description, s.Tags = r.Description, r.Tags
+		case "Assignment", "Reserved":
+			if a, ok := rowAssignment(r); ok {
+				s.IPAssignments = append(s.IPAssignments, a)
+			}
+		case "Virtual IP", "Router":
+			if _, seen := redundant[idx]; !seen {
+				redundantOrder = append(redundantOrder, idx)
+			}
+			redundant[idx] = append(redundant[idx], r)
+		}
+	}
+	for _, idx := range redundantOrder {
+		restoreRedundancy(&networks[idx[0]].Subnets[idx[1]], redundant[idx])
+	}
+	return networks
+}
+
+// isDecommissionedStatus reports whether a row's status is one the planner
+// gives decommissioned subnets. Other statuses, such as those of imported
+// rows, do not decommission a subnet.
+func isDecommissionedStatus(status string) bool {
+	switch {
+	case status == "Decommissioned":
+		return true
+	case strings.HasPrefix(status, "Quarantined until "):
+		return true
+	default:
+		return false
+	}
+}
+
+// restoreRedundancy sets the redundancy and gateway convention that place
+// the virtual IP and router rows where they were planned.
+func restoreRedundancy(s *Subnet, rows []SubnetResult) {
+	vip, below, routers := -1, false, 0
+	for _, r := range rows {
+		a, ok := rowAssignment(r)
+		if !ok {
+			continue
+		}
+		if r.Category == "Virtual IP" {
+			if r.Tags["protocol"] == "" {
+				restoreRedundantGateway(s, rows)
+				return
+			}
+			vip = a.Position
+			s.Redundancy = &Redundancy{Protocol: r.Tags["protocol"]}
+		}
+	}
+	if s.Redundancy == nil {
+		return
+	}
+	for _, r := range rows {
+		if a, ok := rowAssignment(r); ok && r.Category == "Router" {
+			routers++
+			below = a.Position < vip
+		}
+	}
+	if routers != 2 {
+		s.Redundancy.Routers = routers
+	}
+	// Routers below the virtual IP were placed from the end of the subnet
+	offset := vip
+	if below {
+		offset = vip - (1 << (32 - s.CIDR)) + 1
+	}
+	switch offset {
+	case 1:
+		s.Gateway = "first"
+	case -1:
+		s.Gateway = "last"
+	default:
+		s.Gateway = fmt.Sprintf("offset:%d", offset)
+	}
+}
+
+// restoreRedundantGateway sets the gateway triplet, with the planned
+// positions unless they are the defaults.
+func restoreRedundantGateway(s *Subnet, rows []SubnetResult) {
+	g := &RedundantGateway{Enabled: true}
+	for _, r := range rows {
+		a, ok := rowAssignment(r)
+		if !ok {
+			continue
+		}
+		switch r.Label {
+		case "Gateway":
+			g.VIP = strconv.Itoa(a.Position)
+		case "Router-A":
+			g.RouterA = strconv.Itoa(a.Position)
+		case "Router-B":
+			g.RouterB = strconv.Itoa(a.Position)
+		}
+	}
+	if g.VIP == "1" && g.RouterA == "2" && g.RouterB == "3" {
+		g.VIP, g.RouterA, g.RouterB = "", "", ""
+	}
+	s.RedundantGateway = g
+}
+
+// rowAssignment turns an Assignment or Reserved row back into the
+// IPAssignment that produced it.
+func rowAssignment(r SubnetResult) (IPAssignment, bool) {
+	_, ipNet, err := net.ParseCIDR(r.Subnet)
+	if err != nil {
+		return IPAssignment{}, false
+	}
+	start, end, err := parseIPSpan(r.IP)
+	if err != nil {
+		return IPAssignment{}, false
+	}
+	base := ipToUint32(ipNet.IP)
+	a := IPAssignment{
+		Name:        r.Label,
+		Position:    int(start - base),
+		DHCP:        r.DHCP,
+		Reserved:    r.Category == "Reserved",
+		Description: r.Description,
+		Tags:        r.Tags,
+	}
+	if end != start {
+		a.EndPosition = int(end - base)
+	}
+	return a, true
+}
+
+// ExportConfig writes a config (an array of networks) that reproduces
+// results, pinned to the planned addresses.
+func ExportConfig(results []SubnetResult, path string) error {
+	data, err := encodeConfig(Config{Networks: planToConfig(results)}, shapeArray)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// transitTag marks a transit subnet. Its value lists the destinations routed
+// through it as subnet names or CIDRs (comma-separated); "true" routes every
+// other subnet of the same parent network.
+const transitTag = "transit"
+
+// staticRoute is one destination reached via a transit next hop.
+type staticRoute struct {
+	dest    *net.IPNet
+	name    string // destination subnet name; empty for a CIDR destination
+	nextHop string
+	transit string // transit subnet name
+}
+
+// label is the destination's subnet name, or its CIDR.
+func (r staticRoute) label() string {
+	if r.name == "" {
+		return r.dest.String()
+	}
+	return r.name
+}
+
+// nameClause is the " name <subnet>" suffix of an IOS/EOS route.
+func (r staticRoute) nameClause() string {
+	if r.name == "" {
+		return ""
+	}
+	return " name " + switchVLANName(r.name)
+}
+
+// transitRoutes derives the static routes of the plan: for each subnet
+// tagged "transit", its destinations via the first gateway, router, firewall
+// or VIP assignment of the transit subnet.
+func transitRoutes(results []SubnetResult) ([]staticRoute, error) {
+	fragments, _ := subnetFragments(results)
+	var routes []staticRoute
+	for _, t := range fragments {
+		value, ok := t.Tags[transitTag]
+		if !ok {
+			continue
+		}
+		var nextHop string
+		for _, r := range t.Rows {
+			if isAssignedCategory(r.Category) && !strings.Contains(r.IP, "-") && isTopologyKey(r.Label) {
+				nextHop = r.IP
+				break
+			}
+		}
+		if nextHop == "" {
+			return nil, fmt.Errorf("transit subnet %s has no gateway, router, firewall or VIP assignment to route via", t.Name)
+		}
+
+		var dests []subnetFragment
+		if value == "" || strings.EqualFold(value, "true") {
+			for _, f := range fragments {
+				if _, transit := f.Tags[transitTag]; f.Parent == t.Parent && !transit {
+					dests = append(dests, f)
+				}
+			}
+		} else {
+			for _, item := range strings.Split(value, ",") {
+				item = strings.TrimSpace(item)
+				if item == "" {
+					continue
+				}
+				if _, ipNet, err := net.ParseCIDR(item); err == nil {
+					dests = append(dests, subnetFragment{Subnet: ipNet.String()})
+					continue
+				}
+				found := false
+				for _, f := range fragments {
+					if strings.EqualFold(f.Name, item) {
+						dests = append(dests, f)
+						found = true
+					}
+				}
+				if !found {
+					return nil, fmt.Errorf("transit subnet %s: destination %q is neither a subnet of the plan nor a CIDR", t.Name, item)
+				}
+			}
+		}
+
+		for _, d := range dests {
+			_, ipNet, err := net.ParseCIDR(d.Subnet)
+			if err != nil {
+				return nil, fmt.Errorf("subnet %s: %v", d.Name, err)
+			}
+			routes = append(routes, staticRoute{dest: ipNet, name: d.Name, nextHop: nextHop, transit: t.Name})
+		}
+	}
+	return routes, nil
+}
+
+// ExportRoutes writes the static routes of every transit subnet (see
+// transitRoutes) in the syntax of format: "linux" (ip route), "windows"
+// (PowerShell New-NetRoute), "ios", "eos" or "junos". An empty format
+// selects windows for .ps1 files and linux otherwise.
+func ExportRoutes(results []SubnetResult, path, format string) error {
+	if format == "" {
+		format = "linux"
+		if strings.EqualFold(filepath.Ext(path), ".ps1") {
+			format = "windows"
+		}
+	}
+	routes, err := transitRoutes(results)
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 {
+		return fmt.Errorf("no subnet is tagged %q", transitTag)
+	}
+
+	var sb strings.Builder
+	switch format {
+	case "linux":
+		sb.WriteString("#!/bin/sh\n")
+		for _, r := range routes {
+			sb.WriteString(fmt.Sprintf("# %s via %s\n", r.label(), r.transit))
+			sb.WriteString(fmt.Sprintf("ip route replace %s via %s\n", r.dest, r.nextHop))
+		}
+	case "windows":
+		for _, r := range routes {
+			sb.WriteString(fmt.Sprintf("# %s via %s\n", r.label(), r.transit))
+			sb.WriteString(fmt.Sprintf("New-NetRoute -DestinationPrefix '%s' -NextHop '%s' -PolicyStore PersistentStore\n", r.dest, r.nextHop))
+		}
+	case "ios":
+		for _, r := range routes {
+			sb.WriteString(fmt.Sprintf("ip route %s %s %s%s\n", r.dest.IP, net.IP(r.dest.Mask), r.nextHop, r.nameClause()))
+		}
+	case "eos":
+		for _, r := range routes {
+			sb.WriteString(fmt.Sprintf("ip route %s %s%s\n", r.dest, r.nextHop, r.nameClause()))
+		}
+	case "junos":
+		for _, r := range routes {
+			sb.WriteString(fmt.Sprintf("set routing-options static route %s next-hop %s\n", r.dest, r.nextHop))
+		}
+	default:
+		return fmt.Errorf("unknown route format %q (use linux, windows, ios, eos or junos)", format)
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
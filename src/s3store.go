@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Store keeps versions as objects <plan>/000001.json in an S3 bucket, or
+// a bucket of an S3-compatible service such as MinIO. Requests are signed
+// with AWS Signature Version 4 from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and optional AWS_SESSION_TOKEN environment
+// variables, for the region in AWS_REGION or AWS_DEFAULT_REGION (default
+// us-east-1).
+type s3Store struct {
+	bucket    *url.URL // virtual-hosted (https://bucket.s3.region.amazonaws.com) or path-style (http://host:9000/bucket)
+	accessKey string
+	secretKey string
+	token     string
+	region    string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// newS3Store returns a store for the bucket URL, with credentials from the
+// environment.
+func newS3Store(bucket *url.URL) (*s3Store, error) {
+	s := &s3Store{
+		bucket:    bucket,
+		accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		token:     os.Getenv("AWS_SESSION_TOKEN"),
+		region:    os.Getenv("AWS_REGION"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("s3 store needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+// objectURL returns the URL of an object, or of the bucket for key "".
+func (s *s3Store) objectURL(key string, query url.Values) *url.URL {
+	u := *s.bucket
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	u.RawPath = ""
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+	return &u
+}
+
+func (s *s3Store) do(method string, u *url.URL, body []byte, header map[string]string) ([]byte, int, error) {
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	s.sign(req, body, s.now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
+
+// sign adds the Signature Version 4 headers to req. Only the host and
+// x-amz-* headers are signed.
+func (s *s3Store) sign(req *http.Request, body []byte, at time.Time) {
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate := at.Format("20060102T150405Z")
+	date := at.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if s.token != "" {
+		req.Header.Set("x-amz-security-token", s.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := sortedKeys(headers)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{req.Method, path, canonicalQuery(req.URL.Query()), canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s.accessKey, scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, toSign))))
+}
+
+// canonicalQuery is the query string of a Signature Version 4 request:
+// sorted, with spaces written as %20.
+func canonicalQuery(query url.Values) string {
+	var parts []string
+	for k, values := range query {
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	sort.Strings(parts)
+	return strings.ReplaceAll(strings.Join(parts, "&"), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func (s *s3Store) Save(v planVersion) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// If-None-Match: * keeps a saved version from being overwritten
+	_, status, err := s.do(http.MethodPut, s.objectURL(v.Plan+"/"+versionName(v.Version), nil), data, map[string]string{
+		"Content-Type":  "application/json",
+		"If-None-Match": "*",
+	})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("s3: saving %s version %d: HTTP %d", v.Plan, v.Version, status)
+	}
+	return nil
+}
+
+func (s *s3Store) Versions(plan string) ([]int, error) {
+	keys, err := s.list(plan + "/")
+	if err != nil {
+		return nil, fmt.Errorf("s3: listing %s: %v", plan, err)
+	}
+	var versions []int
+	for _, key := range keys {
+		if n, ok := parseVersionName(strings.TrimPrefix(key, plan+"/")); ok {
+			versions = append(versions, n)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+func (s *s3Store) Plans() ([]string, error) {
+	keys, err := s.list("")
+	if err != nil {
+		return nil, fmt.Errorf("s3: listing plans: %v", err)
+	}
+	var plans []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		plan, _, ok := strings.Cut(key, "/")
+		if ok && !seen[plan] && planNamePattern.MatchString(plan) {
+			seen[plan] = true
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return plans, nil
+}
+
+// list returns the keys of all objects starting with prefix, following the
+// continuation tokens of large buckets.
+func (s *s3Store) list(prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		data, status, err := s.do(http.MethodGet, s.objectURL("", query), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d", status)
+		}
+		var list struct {
+			Keys      []string `xml:"Contents>Key"`
+			Truncated bool     `xml:"IsTruncated"`
+			NextToken string   `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		keys = append(keys, list.Keys...)
+		if !list.Truncated || list.NextToken == "" {
+			return keys, nil
+		}
+		token = list.NextToken
+	}
+}
+
+func (s *s3Store) Load(plan string, version int) (planVersion, error) {
+	var v planVersion
+	data, status, err := s.do(http.MethodGet, s.objectURL(plan+"/"+versionName(version), nil), nil, nil)
+	if err != nil {
+		return v, err
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return v, errVersionNotFound
+	default:
+		return v, fmt.Errorf("s3: loading %s version %d: HTTP %d", plan, version, status)
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // -tz must work on hosts without a zoneinfo database (Windows)
+)
+
+// parsePlanDate accepts a date (2006-01-02), interpreted as midnight in loc,
+// or a full RFC 3339 timestamp.
+func parsePlanDate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC 3339)", value)
+}
+
+// parseAsOf resolves the -as-of and -tz flags. "now" and "today" use the
+// current time in the given zone.
+func parseAsOf(value, zone string) (time.Time, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -tz %q: %v", zone, err)
+	}
+	switch value {
+	case "now":
+		return time.Now().In(loc), nil
+	case "today":
+		y, m, d := time.Now().In(loc).Date()
+		return time.Date(y, m, d, 23, 59, 59, 0, loc), nil
+	}
+	asOf, err := parsePlanDate(value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -as-of: %v", err)
+	}
+	if len(value) == len("2006-01-02") {
+		// A date means "by the end of that day"
+		asOf = asOf.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return asOf, nil
+}
+
+// FilterAsOf drops rows of subnets whose plannedFor activation is after asOf.
+// Planning happens before filtering, so addresses do not move between phases.
+func FilterAsOf(results []SubnetResult, asOf time.Time) []SubnetResult {
+	var out []SubnetResult
+	for _, r := range results {
+		if r.PlannedFor != "" {
+			activation, err := parsePlanDate(r.PlannedFor, asOf.Location())
+			if err == nil && activation.After(asOf) {
+				continue
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// configSchemaJSON is the JSON Schema of the config format, also written by
+// -schema for editors and CI.
+//
+//go:embed config.schema.json
+var configSchemaJSON []byte
+
+// configSchema is the parsed configSchemaJSON.
+var configSchema = mustParseSchema(configSchemaJSON)
+
+// maxSchemaProblems caps how many schema violations are reported at once.
+const maxSchemaProblems = 20
+
+// jsonSchema is the subset of JSON Schema used by config.schema.json.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+	Type                 schemaTypes            `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            int                    `json:"minLength"`
+	Pattern              string                 `json:"pattern"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	PatternProperties    map[string]*jsonSchema `json:"patternProperties"`
+	AdditionalProperties *additionalProperties  `json:"additionalProperties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	AnyOf                []*jsonSchema          `json:"anyOf"`
+}
+
+// schemaTypes is a "type" keyword, either one type name or a list.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additionalProperties is false (deny) or a schema for the extra values.
+type additionalProperties struct {
+	deny   bool
+	schema *jsonSchema
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	var allow bool
+	if err := json.Unmarshal(data, &allow); err == nil {
+		a.deny = !allow
+		return nil
+	}
+	return json.Unmarshal(data, &a.schema)
+}
+
+func mustParseSchema(data []byte) *jsonSchema {
+	var s jsonSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		panic(fmt.Sprintf("invalid embedded config schema: %v", err))
+	}
+	return &s
+}
+
+// jsonNode is a decoded JSON value that remembers where it starts in the
+// input, so violations can be reported by line and column.
+type jsonNode struct {
+	kind   string // object, array, string, number, boolean, null
+	offset int64
+	keys   []string
+	keyOff []int64
+	values []*jsonNode // object values (parallel to keys) or array items
+	str    string
+	num    json.Number
+	b      bool
+}
+
+type nodeParser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// parseJSONNode decodes data into a tree of positioned nodes.
+func parseJSONNode(data []byte) (*jsonNode, error) {
+	p := &nodeParser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	p.dec.UseNumber()
+	node, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, off, err := p.next(); err != io.EOF {
+		line, col := lineCol(data, off)
+		return nil, fmt.Errorf("line %d, column %d: unexpected data after the config", line, col)
+	}
+	return node, nil
+}
+
+// next reads a token and returns the offset of its first byte.
+func (p *nodeParser) next() (json.Token, int64, error) {
+	off := p.dec.InputOffset()
+	for off < int64(len(p.data)) && strings.IndexByte(" \t\r\n,:", p.data[off]) >= 0 {
+		off++
+	}
+	tok, err := p.dec.Token()
+	return tok, off, err
+}
+
+func (p *nodeParser) value() (*jsonNode, error) {
+	tok, off, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	n := &jsonNode{offset: off}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			n.kind = "object"
+			for p.dec.More() {
+				key, keyOff, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+				n.keyOff = append(n.keyOff, keyOff)
+				n.values = append(n.values, v)
+			}
+		} else {
+			n.kind = "array"
+			for p.dec.More() {
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.values = append(n.values, v)
+			}
+		}
+		if _, _, err := p.next(); err != nil { // closing delimiter
+			return nil, err
+		}
+	case string:
+		n.kind, n.str = "string", t
+	case json.Number:
+		n.kind, n.num = "number", t
+	case bool:
+		n.kind, n.b = "boolean", t
+	default:
+		n.kind = "null"
+	}
+	return n, nil
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(data []byte, offset int64) (int, int) {
+	offset = max(0, min(offset, int64(len(data))))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, col
+}
+
+// schemaProblem is one schema violation.
+type schemaProblem struct {
+	offset  int64
+	path    string
+	message string
+	unknown bool // an unknown field, reported as a warning
+}
+
+// SchemaError lists where a config does not match the config schema.
+type SchemaError struct {
+	Problems []string // "line L, column C: path: message"
+	total    int
+}
+
+func (e *SchemaError) Error() string {
+	msg := "config does not match the schema:\n  " + strings.Join(e.Problems, "\n  ")
+	if more := e.total - len(e.Problems); more > 0 {
+		msg += fmt.Sprintf("\n  ... and %d more", more)
+	}
+	return msg
+}
+
+// validateConfigSchema checks data against the embedded config schema.
+// Syntax errors and violations are reported with their line and column.
+// Unknown fields are not errors: they are ignored by the planner and
+// returned as warnings, which -strict turns into errors.
+func validateConfigSchema(data []byte) (unknown []string, err error) {
+	root, err := parseJSONNode(data)
+	if err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			// Offset counts the offending byte
+			line, col := lineCol(data, syntax.Offset-1)
+			return nil, fmt.Errorf("error parsing config file: line %d, column %d: %v", line, col, err)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			line, col := lineCol(data, int64(len(data)))
+			return nil, fmt.Errorf("error parsing config file: line %d, column %d: unexpected end of input", line, col)
+		}
+		return nil, fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	var problems []schemaProblem
+	for _, p := range validateNode(configSchema, root, "") {
+		if p.unknown {
+			unknown = append(unknown, schemaLocation(data, p)+": "+p.message)
+		} else {
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) == 0 {
+		return unknown, nil
+	}
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].offset < problems[j].offset })
+	schemaErr := &SchemaError{total: len(problems)}
+	for i, p := range problems {
+		if i == maxSchemaProblems {
+			break
+		}
+		schemaErr.Problems = append(schemaErr.Problems, schemaLocation(data, p)+": "+p.message)
+	}
+	return unknown, schemaErr
+}
+
+// schemaLocation returns "line L, column C: path" for p.
+func schemaLocation(data []byte, p schemaProblem) string {
+	line, col := lineCol(data, p.offset)
+	where := fmt.Sprintf("line %d, column %d", line, col)
+	if p.path != "" {
+		where += ": " + p.path
+	}
+	return where
+}
+
+// validateNode returns the violations of n against s; path is n's location
+// in the config, such as "networks[0].subnets[2].vlan".
+func validateNode(s *jsonSchema, n *jsonNode, path string) []schemaProblem {
+	problem := func(format string, args ...interface{}) []schemaProblem {
+		return []schemaProblem{{offset: n.offset, path: path, message: fmt.Sprintf(format, args...)}}
+	}
+	if s.Ref != "" {
+		ref := resolveSchema(s)
+		if ref == s {
+			return problem("schema reference %s not found", s.Ref)
+		}
+		if problems := validateNode(ref, n, path); len(problems) > 0 {
+			return problems
+		}
+	}
+
+	// anyOf reports the alternative of the right type that came closest to
+	// matching: fewest problems with n's own keys first (an unknown or
+	// missing top-level field usually means the wrong alternative), then
+	// fewest problems overall
+	if len(s.AnyOf) > 0 {
+		var best []schemaProblem
+		bestOwn := -1
+		var types []string
+		for _, alt := range s.AnyOf {
+			for _, t := range resolveSchema(alt).Type {
+				if !slices.Contains(types, t) {
+					types = append(types, t)
+				}
+			}
+			if altTypes := resolveSchema(alt).Type; len(altTypes) > 0 && !nodeHasType(n, altTypes) {
+				continue
+			}
+			problems := validateNode(alt, n, path)
+			if len(problems) == 0 {
+				return nil
+			}
+			own := 0
+			for _, p := range problems {
+				if p.path == path || !strings.ContainsAny(strings.TrimPrefix(p.path, path+"."), ".[") {
+					own++
+				}
+			}
+			if bestOwn < 0 || own < bestOwn || (own == bestOwn && len(problems) < len(best)) {
+				best, bestOwn = problems, own
+			}
+		}
+		if bestOwn < 0 {
+			return problem("expected %s, got %s", strings.Join(types, " or "), n.kind)
+		}
+		return best
+	}
+
+	if len(s.Type) > 0 && !nodeHasType(n, s.Type) {
+		msg := fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, " or "), n.kind)
+		if n.kind == "string" {
+			msg += fmt.Sprintf(" %q", n.str)
+			if _, err := strconv.Atoi(n.str); err == nil && s.Type[0] == "integer" {
+				msg += " (remove the quotes)"
+			}
+		}
+		return problem("%s", msg)
+	}
+
+	var problems []schemaProblem
+	if len(s.Enum) > 0 {
+		ok := false
+		var allowed []string
+		for _, e := range s.Enum {
+			allowed = append(allowed, fmt.Sprint(e))
+			if str, isStr := e.(string); isStr && n.kind == "string" && n.str == str {
+				ok = true
+			}
+		}
+		if !ok {
+			problems = append(problems, problem("must be one of %s", strings.Join(allowed, ", "))...)
+		}
+	}
+
+	switch n.kind {
+	case "number":
+		v, _ := n.num.Float64()
+		if s.Minimum != nil && v < *s.Minimum {
+			problems = append(problems, problem("%s is below the minimum of %v", n.num, *s.Minimum)...)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			problems = append(problems, problem("%s is above the maximum of %v", n.num, *s.Maximum)...)
+		}
+	case "string":
+		if len(n.str) < s.MinLength {
+			problems = append(problems, problem("must not be empty")...)
+		}
+		if s.Pattern != "" && !schemaRegexp(s.Pattern).MatchString(n.str) {
+			problems = append(problems, problem("%q is not in the expected format", n.str)...)
+		}
+	case "array":
+		if s.Items != nil {
+			for i, item := range n.values {
+				problems = append(problems, validateNode(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "object":
+		problems = append(problems, validateObject(s, n, path)...)
+	}
+	return problems
+}
+
+// resolveSchema follows a "#/$defs/..." reference; s is returned unchanged
+// if it has none or the definition does not exist.
+func resolveSchema(s *jsonSchema) *jsonSchema {
+	if s.Ref == "" {
+		return s
+	}
+	if ref := configSchema.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]; ref != nil {
+		return ref
+	}
+	return s
+}
+
+// validateObject checks properties and required keys. Property names match
+// case-insensitively, like the planner's JSON decoding.
+func validateObject(s *jsonSchema, n *jsonNode, path string) []schemaProblem {
+	var problems []schemaProblem
+	for i, key := range n.keys {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		if prop := schemaProperty(s, key); prop != nil {
+			problems = append(problems, validateNode(prop, n.values[i], keyPath)...)
+			continue
+		}
+		matched := false
+		for pattern, prop := range s.PatternProperties {
+			if schemaRegexp(pattern).MatchString(key) {
+				matched = true
+				problems = append(problems, validateNode(prop, n.values[i], keyPath)...)
+			}
+		}
+		switch {
+		case matched || s.AdditionalProperties == nil:
+		case s.AdditionalProperties.deny:
+			problems = append(problems, schemaProblem{offset: n.keyOff[i], path: keyPath, message: "unknown field " + strconv.Quote(key), unknown: true})
+		case s.AdditionalProperties.schema != nil:
+			problems = append(problems, validateNode(s.AdditionalProperties.schema, n.values[i], keyPath)...)
+		}
+	}
+	for _, req := range s.Required {
+		found := false
+		for _, key := range n.keys {
+			if strings.EqualFold(key, req) {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, schemaProblem{offset: n.offset, path: path, message: "missing required field " + strconv.Quote(req)})
+		}
+	}
+	return problems
+}
+
+// schemaPatterns caches compiled schema patterns; the server validates
+// configs concurrently.
+var schemaPatterns sync.Map
+
+func schemaRegexp(pattern string) *regexp.Regexp {
+	if re, ok := schemaPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	schemaPatterns.Store(pattern, re)
+	return re
+}
+
+func schemaProperty(s *jsonSchema, key string) *jsonSchema {
+	if prop, ok := s.Properties[key]; ok {
+		return prop
+	}
+	for name, prop := range s.Properties {
+		if strings.EqualFold(name, key) {
+			return prop
+		}
+	}
+	return nil
+}
+
+func nodeHasType(n *jsonNode, types []string) bool {
+	for _, t := range types {
+		switch {
+		case t == n.kind:
+			return true
+		case t == "integer" && n.kind == "number":
+			if !strings.ContainsAny(n.num.String(), ".eE") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// searchHit is one matching row and the file it came from.
+type searchHit struct {
+	File string `json:"file"`
+	SubnetResult
+}
+
+// runSearch implements the "search" subcommand.
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	kind := fs.String("type", "auto", "Query type: name, vlan, ip or auto (IP if it parses as one, VLAN if numeric, else name)")
+	asJSON := fs.Bool("json", false, "Print matches as JSON")
+	env := fs.String("env", "", "Environment to plan from configs with \"environments\" (e.g. prod)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner search [-type auto|name|vlan|ip] [-json] [-env name] <query> <plan-or-config.json|dir>...\n\n")
+		fmt.Fprintf(os.Stderr, "Searches -exportjson plans and planner configs (directories are searched for *.json).\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+
+	hits, err := searchPlans(fs.Arg(0), *kind, *env, fs.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "search: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		data, _ := json.MarshalIndent(hits, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		printSearchHits(os.Stdout, hits)
+	}
+	if len(hits) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// searchPlans loads every plan or config under paths, planning environment
+// env of configs with environments, and returns the rows matching query.
+func searchPlans(query, kind, env string, paths []string) ([]searchHit, error) {
+	match, err := searchMatcher(query, kind)
+	if err != nil {
+		return nil, err
+	}
+	files, err := searchFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []searchHit
+	for _, file := range files {
+		results, err := loadSearchable(file, env)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping %s: %v\n", file, err)
+			continue
+		}
+		for _, r := range results {
+			if match(r) {
+				hits = append(hits, searchHit{File: file, SubnetResult: r})
+			}
+		}
+	}
+	return hits, nil
+}
+
+// searchMatcher builds the row predicate for a query.
+func searchMatcher(query, kind string) (func(SubnetResult) bool, error) {
+	if kind == "auto" {
+		if ip := net.ParseIP(query); ip != nil && ip.To4() != nil {
+			kind = "ip"
+		} else if _, err := strconv.Atoi(query); err == nil {
+			kind = "vlan"
+		} else {
+			kind = "name"
+		}
+	}
+
+	switch kind {
+	case "ip":
+		ip := net.ParseIP(query)
+		if ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %q", query)
+		}
+		n := ipToUint32(ip)
+		return func(r SubnetResult) bool {
+			start, end, err := parseIPSpan(r.IP)
+			return err == nil && start <= n && n <= end
+		}, nil
+	case "vlan":
+		vlan, err := strconv.Atoi(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VLAN %q", query)
+		}
+		return func(r SubnetResult) bool {
+			return r.Category == "Network" && r.VLAN == vlan
+		}, nil
+	case "name":
+		q := strings.ToLower(query)
+		return func(r SubnetResult) bool {
+			if r.Category == "Network" {
+				return strings.Contains(strings.ToLower(r.Name), q)
+			}
+			return isAssignedCategory(r.Category) && strings.Contains(strings.ToLower(r.Label), q)
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid -type %q (use auto, name, vlan or ip)", kind)
+}
+
+// searchFiles expands directories into the *.json files they contain.
+func searchFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// loadSearchable reads an -exportjson plan, or plans environment env of a
+// config file.
+func loadSearchable(path, env string) ([]SubnetResult, error) {
+	// A config array also decodes as rows, but without any subnet column
+	if results, err := loadPlanFile(path); err == nil && len(results) > 0 && results[0].Subnet != "" {
+		return results, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfigFile(path, data, env)
+	if err != nil {
+		return nil, fmt.Errorf("neither a plan nor a config: %v", err)
+	}
+	return PlanSubnets(cfg.Networks)
+}
+
+func printSearchHits(w io.Writer, hits []searchHit) {
+	if len(hits) == 0 {
+		fmt.Fprintln(w, "No matches.")
+		return
+	}
+	fmt.Fprintf(w, "%-30s %-18s %-18s %-20s %-6s %-20s %-31s %s\n", "File", "Network", "Subnet", "Name", "VLAN", "Label", "IP", "Category")
+	for _, h := range hits {
+		vlan := "-"
+		if h.VLAN > 0 {
+			vlan = strconv.Itoa(h.VLAN)
+		}
+		fmt.Fprintf(w, "%-30s %-18s %-18s %-20s %-6s %-20s %-31s %s\n",
+			truncate(h.File, 30), h.Parent, h.Subnet, truncate(h.Name, 20), vlan, truncate(h.Label, 20), h.IP, h.Category)
+	}
+	fmt.Fprintf(w, "\n%d match(es)\n", len(hits))
+}
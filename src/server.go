@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRequestBytes bounds the size of a config accepted by the REST API.
+const maxRequestBytes = 10 << 20
+
+// runServe implements the "serve" subcommand.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", ":8080", "Address to listen on")
+	metricsConfig := fs.String("input", "", "Config to plan on every GET /metrics scrape (metrics are disabled without it)")
+	metricsEnv := fs.String("env", "", "Environment to plan from an -input config with \"environments\" (e.g. prod)")
+	storeSpec := fs.String("store", "", "Keep the version history of plans posted to /plans/{name}: a directory, azblob:<container URL with SAS token> or s3:<bucket URL>")
+	revalidate := fs.Duration("revalidate", 0, "Re-validate every stored plan at this interval, e.g. 1h (needs -store; 0 disables)")
+	staleAfter := fs.Duration("stale-after", 0, "Flag plans whose latest version is older than this, e.g. 720h (0 disables)")
+	live := liveFlag{}
+	fs.Var(live, "live", "Compare a stored plan with a live import during re-validation, as plan=file (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner serve [-listen :8080] [-input config.json [-env name]]\n\n")
+		fmt.Fprintf(os.Stderr, "Endpoints:\n")
+		fmt.Fprintf(os.Stderr, "  POST /plan     plan a JSON config (same format as -input) and return results\n")
+		fmt.Fprintf(os.Stderr, "  POST /view     plan a JSON config and return its view model\n")
+		fmt.Fprintf(os.Stderr, "  POST /plans/{name}                       plan a config and store it as the next version (needs -store)\n")
+		fmt.Fprintf(os.Stderr, "  GET  /plans/{name}                       list stored versions\n")
+		fmt.Fprintf(os.Stderr, "  GET  /plans/{name}/versions/{v}          fetch a version (number or latest)\n")
+		fmt.Fprintf(os.Stderr, "  GET  /plans/{name}/versions/{v}/diff     changes from the previous version (or ?from=N)\n")
+		fmt.Fprintf(os.Stderr, "  POST /plans/{name}/versions/{v}/whatif   check a batch of hypothetical subnets against a version's free space\n")
+		fmt.Fprintf(os.Stderr, "  GET  /validation                         latest re-validation of every stored plan (needs -revalidate)\n")
+		fmt.Fprintf(os.Stderr, "  POST /validation                         re-validate all stored plans now\n")
+		fmt.Fprintf(os.Stderr, "  GET  /plans/{name}/validation            latest re-validation of one plan\n")
+		fmt.Fprintf(os.Stderr, "  GET  /metrics  Prometheus utilization gauges for the -input config and re-validation gauges\n")
+		fmt.Fprintf(os.Stderr, "  GET  /health   liveness check\n")
+		fmt.Fprintf(os.Stderr, "  GET  /version  planner version\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	store, err := openStore(*storeSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return exitUsage
+	}
+	var validator *revalidator
+	if *revalidate > 0 {
+		if store == nil {
+			fmt.Fprintf(os.Stderr, "serve: -revalidate needs -store\n")
+			return exitUsage
+		}
+		validator = newRevalidator(store, live, *staleAfter)
+		validator.start(*revalidate)
+	} else if len(live) > 0 || *staleAfter > 0 {
+		fmt.Fprintf(os.Stderr, "serve: -live and -stale-after need -revalidate\n")
+		return exitUsage
+	}
+
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           newServer(*metricsConfig, *metricsEnv, store, validator),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+	log.Printf("IPSubnetPlanner %s listening on %s", version, *listen)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Printf("server error: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// newServer returns the HTTP handler for the REST API. metricsConfig is the
+// config file planned for /metrics, with its environment metricsEnv; it is
+// re-read on every scrape so edits show up without a restart. store keeps the history of /plans and
+// validator re-checks it (nil disables either).
+func newServer(metricsConfig, metricsEnv string, store planStore, validator *revalidator) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /plan", handlePlan)
+	mux.HandleFunc("POST /view", handleView)
+	h := &planHistory{store: store}
+	mux.HandleFunc("POST /plans/{name}", h.handleSave)
+	mux.HandleFunc("GET /plans/{name}", h.handleList)
+	mux.HandleFunc("GET /plans/{name}/versions/{version}", h.handleVersion)
+	mux.HandleFunc("GET /plans/{name}/versions/{version}/diff", h.handleDiff)
+	mux.HandleFunc("POST /plans/{name}/versions/{version}/whatif", h.handleWhatIf)
+	mux.HandleFunc("GET /plans/{name}/validation", h.handleValidation(validator))
+	mux.HandleFunc("GET /validation", func(w http.ResponseWriter, r *http.Request) {
+		handleValidation(w, validator, false)
+	})
+	mux.HandleFunc("POST /validation", func(w http.ResponseWriter, r *http.Request) {
+		handleValidation(w, validator, true)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		handleMetrics(w, metricsConfig, metricsEnv, validator)
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"version": version})
+	})
+	return mux
+}
+
+// requestNetworks decodes a posted config, selecting the environment given
+// by the request's "env" query parameter.
+func requestNetworks(r *http.Request, data []byte) ([]Network, error) {
+	cfg, err := loadConfig(data, r.URL.Query().Get("env"))
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Networks, nil
+}
+
+func handlePlan(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("error reading request body: %v", err))
+		return
+	}
+	networks, err := requestNetworks(r, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("planning error: %v", err))
+		return
+	}
+	if results == nil {
+		results = []SubnetResult{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// handleView plans the posted config and returns its view model.
+func handleView(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("error reading request body: %v", err))
+		return
+	}
+	networks, err := requestNetworks(r, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("planning error: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, BuildView(results))
+}
+
+// planHistory serves the stored versions of named plans.
+type planHistory struct {
+	store planStore
+	mu    sync.Mutex // serializes version numbering
+}
+
+// plan checks that storage is enabled and returns the plan name of the
+// request, or writes an error.
+func (h *planHistory) plan(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if h.store == nil {
+		writeError(w, http.StatusNotFound, "plan storage is disabled; start the server with -store <dir>")
+		return "", false
+	}
+	name := r.PathValue("name")
+	if !planNamePattern.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid plan name %q (use letters, digits, '.', '_' and '-')", name))
+		return "", false
+	}
+	return name, true
+}
+
+// version resolves a version path value: a number or "latest".
+func (h *planHistory) version(w http.ResponseWriter, name, value string) (int, bool) {
+	if value == "latest" {
+		versions, err := h.store.Versions(name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return 0, false
+		}
+		if len(versions) == 0 {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("plan %s has no versions", name))
+			return 0, false
+		}
+		return versions[len(versions)-1], true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid version %q (use a number or latest)", value))
+		return 0, false
+	}
+	return n, true
+}
+
+// load fetches a version, or writes an error.
+func (h *planHistory) load(w http.ResponseWriter, name string, version int) (planVersion, bool) {
+	v, err := h.store.Load(name, version)
+	if errors.Is(err, errVersionNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("plan %s has no version %d", name, version))
+		return v, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return v, false
+	}
+	return v, true
+}
+
+// handleSave plans the posted config and stores it as the plan's next
+// version.
+func (h *planHistory) handleSave(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.plan(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("error reading request body: %v", err))
+		return
+	}
+	networks, err := requestNetworks(r, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("planning error: %v", err))
+		return
+	}
+	if results == nil {
+		results = []SubnetResult{}
+	}
+	config, env := json.RawMessage(configText(data)), r.URL.Query().Get("env")
+	if !json.Valid(config) {
+		// JSONC and other accepted inputs are stored in canonical form, with
+		// the environment's networks already selected
+		config, _ = json.Marshal(networks)
+		env = ""
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	versions, err := h.store.Versions(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	v := planVersion{Plan: name, Version: len(versions) + 1, Created: time.Now().UTC(), Env: env, Config: config, Results: results}
+	if n := len(versions); n > 0 {
+		v.Version = versions[n-1] + 1
+	}
+	if err := h.store.Save(v); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("error storing plan: %v", err))
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/plans/%s/versions/%d", name, v.Version))
+	writeJSON(w, http.StatusCreated, v)
+}
+
+// handleList lists the stored versions of a plan.
+func (h *planHistory) handleList(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.plan(w, r)
+	if !ok {
+		return
+	}
+	versions, err := h.store.Versions(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(versions) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("plan %s has no versions", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"plan": name, "versions": versions, "latest": versions[len(versions)-1]})
+}
+
+// handleVersion returns one stored version.
+func (h *planHistory) handleVersion(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.plan(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.version(w, name, r.PathValue("version"))
+	if !ok {
+		return
+	}
+	if v, ok := h.load(w, name, version); ok {
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// handleDiff compares a version with the one before it, or with ?from=N.
+func (h *planHistory) handleDiff(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.plan(w, r)
+	if !ok {
+		return
+	}
+	to, ok := h.version(w, name, r.PathValue("version"))
+	if !ok {
+		return
+	}
+	from := to - 1
+	if f := r.URL.Query().Get("from"); f != "" {
+		if from, ok = h.version(w, name, f); !ok {
+			return
+		}
+	}
+	current, ok := h.load(w, name, to)
+	if !ok {
+		return
+	}
+	var previous planVersion
+	if from > 0 {
+		if previous, ok = h.load(w, name, from); !ok {
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"plan": name, "from": from, "to": to, "changes": DiffPlans(previous.Results, current.Results)})
+}
+
+// handleValidation returns the latest re-validation of all stored plans,
+// after checking them again if now is set.
+func handleValidation(w http.ResponseWriter, v *revalidator, now bool) {
+	if v == nil {
+		writeError(w, http.StatusNotFound, "re-validation is disabled; start the server with -store <dir> -revalidate <interval>")
+		return
+	}
+	if now {
+		if err := v.run(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"plans": v.results()})
+}
+
+// handleValidation returns the latest re-validation of one plan.
+func (h *planHistory) handleValidation(v *revalidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, ok := h.plan(w, r)
+		if !ok {
+			return
+		}
+		if v == nil {
+			writeError(w, http.StatusNotFound, "re-validation is disabled; start the server with -revalidate <interval>")
+			return
+		}
+		c, ok := v.result(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("plan %s has not been validated yet", name))
+			return
+		}
+		writeJSON(w, http.StatusOK, c)
+	}
+}
+
+// handleWhatIf checks a batch of hypothetical subnets, posted as
+// {"requests": [...], "candidates": N}, against a stored version.
+func (h *planHistory) handleWhatIf(w http.ResponseWriter, r *http.Request) {
+	name, ok := h.plan(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.version(w, name, r.PathValue("version"))
+	if !ok {
+		return
+	}
+	var body struct {
+		Requests   []WhatIfRequest `json:"requests"`
+		Candidates int             `json:"candidates"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid what-if request: %v", err))
+		return
+	}
+	if len(body.Requests) == 0 {
+		writeError(w, http.StatusBadRequest, "what-if request lists no requests")
+		return
+	}
+	v, ok := h.load(w, name, version)
+	if !ok {
+		return
+	}
+	results := WhatIf(v.Results, body.Requests, body.Candidates)
+	feasible := true
+	for _, res := range results {
+		feasible = feasible && res.Feasible
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"plan": name, "version": version, "feasible": feasible, "results": results})
+}
+
+func handleMetrics(w http.ResponseWriter, configPath, env string, validator *revalidator) {
+	if configPath == "" {
+		if validator == nil {
+			writeError(w, http.StatusNotFound, "metrics are disabled; start the server with -input <config.json> or -revalidate <interval>")
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		validator.writeMetrics(w)
+		return
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("error reading config file: %v", err))
+		return
+	}
+	cfg, err := loadConfigFile(configPath, data, env)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results, err := PlanSubnets(cfg.Networks)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("planning error: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WriteMetrics(w, results)
+	if validator != nil {
+		validator.writeMetrics(w)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// SitePlan rolls one subnet template out to many sites, such as branch
+// offices: every site gets a parent network of the same size, carved from
+// the supernet in site order.
+type SitePlan struct {
+	Supernet         string             `json:"supernet"`
+	Prefix           int                `json:"prefix"` // size of each site's parent network
+	Gateway          string             `json:"gateway,omitempty"`
+	Redundancy       *Redundancy        `json:"redundancy,omitempty"`
+	RedundantGateway *RedundantGateway  `json:"redundantGateway,omitempty"`
+	Variables        map[string]float64 `json:"variables,omitempty"` // defaults for the sites' host count expressions
+	Subnets          []Subnet           `json:"subnets"`
+	Sites            []Site             `json:"sites"`
+}
+
+// Site is one site of a SitePlan. Network pins the site's parent network,
+// e.g. for a site that already exists; the others take the lowest free
+// blocks of the supernet.
+type Site struct {
+	Name      string             `json:"name"`
+	Network   string             `json:"network,omitempty"`
+	Variables map[string]float64 `json:"variables,omitempty"` // win over the plan's variables
+}
+
+// siteNetworks turns every site plan into one parent network per site, with
+// the template subnets named "<site>-<subnet>" and tagged with the site.
+func siteNetworks(plans []SitePlan) ([]Network, error) {
+	var networks []Network
+	for _, plan := range plans {
+		_, super, err := net.ParseCIDR(plan.Supernet)
+		if err != nil {
+			return nil, fmt.Errorf("site plan: invalid supernet %q", plan.Supernet)
+		}
+		superPrefix, _ := super.Mask.Size()
+		if plan.Prefix < superPrefix || plan.Prefix > 30 {
+			return nil, fmt.Errorf("site plan %s: site prefix /%d must be between /%d and /30", super, plan.Prefix, superPrefix)
+		}
+		base := ipToUint32(super.IP)
+		size := uint64(1) << (32 - plan.Prefix)
+		blocks := uint64(1) << (plan.Prefix - superPrefix)
+
+		used := make(map[uint64]string)
+		names := make(map[string]bool)
+		for _, site := range plan.Sites {
+			if site.Name == "" {
+				return nil, fmt.Errorf("site plan %s: every site needs a name", super)
+			}
+			if names[site.Name] {
+				return nil, fmt.Errorf("site plan %s: site %s is listed more than once", super, site.Name)
+			}
+			names[site.Name] = true
+			if site.Network == "" {
+				continue
+			}
+			ip, block, err := net.ParseCIDR(site.Network)
+			if err != nil {
+				return nil, fmt.Errorf("site %s: invalid network %q", site.Name, site.Network)
+			}
+			if ones, _ := block.Mask.Size(); ones != plan.Prefix || !ip.Equal(block.IP) || !super.Contains(ip) {
+				return nil, fmt.Errorf("site %s: network %s is not a /%d block of %s", site.Name, site.Network, plan.Prefix, super)
+			}
+			index := (uint64(ipToUint32(ip)) - uint64(base)) / size
+			if other, ok := used[index]; ok {
+				return nil, fmt.Errorf("site %s: network %s is already used by site %s", site.Name, site.Network, other)
+			}
+			used[index] = site.Name
+		}
+
+		var next uint64
+		for _, site := range plan.Sites {
+			network := site.Network
+			if network == "" {
+				for used[next] != "" {
+					next++
+				}
+				if next >= blocks {
+					return nil, fmt.Errorf("site plan %s: no /%d left for site %s (room for %d sites)", super, plan.Prefix, site.Name, blocks)
+				}
+				used[next] = site.Name
+				network = fmt.Sprintf("%s/%d", uint32ToIP(base+uint32(next*size)), plan.Prefix)
+			}
+			networks = append(networks, siteNetwork(plan, site, network))
+		}
+	}
+	return networks, nil
+}
+
+// siteNetwork is the parent network of one site, with its own copy of the
+// template subnets.
+func siteNetwork(plan SitePlan, site Site, network string) Network {
+	n := Network{Network: network, Gateway: plan.Gateway, Redundancy: plan.Redundancy, RedundantGateway: plan.RedundantGateway}
+	if len(plan.Variables)+len(site.Variables) > 0 {
+		n.Variables = make(map[string]float64)
+		for k, v := range plan.Variables {
+			n.Variables[k] = v
+		}
+		for k, v := range site.Variables {
+			n.Variables[k] = v
+		}
+	}
+	for _, s := range plan.Subnets {
+		s.Name = site.Name + "-" + s.Name
+		tags := map[string]string{"site": site.Name}
+		for k, v := range s.Tags {
+			tags[k] = v
+		}
+		s.Tags = tags
+		s.IPAssignments = append([]IPAssignment(nil), s.IPAssignments...)
+		n.Subnets = append(n.Subnets, s)
+	}
+	return n
+}
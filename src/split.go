@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/bits"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxSplitChildren limits how many children one split may create, so a
+// typo such as -into /32 on a /8 does not plan millions of subnets.
+const maxSplitChildren = 4096
+
+// splitNetwork builds a network that divides cidr into equal children,
+// either count of them or children of the given prefix (exactly one of the
+// two is non-zero). Children are named name-1, name-2, ... in address order.
+func splitNetwork(cidr string, count, prefix int, name string) (Network, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return Network{}, fmt.Errorf("invalid CIDR '%s': %v", cidr, err)
+	}
+	if ipNet.IP.To4() == nil {
+		return Network{}, fmt.Errorf("invalid CIDR '%s': only IPv4 is supported", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+	if count > 0 {
+		if count&(count-1) != 0 {
+			return Network{}, fmt.Errorf("cannot split %s into %d equal parts: the count must be a power of two", ipNet, count)
+		}
+		prefix = ones + bits.TrailingZeros(uint(count))
+	}
+	if prefix < ones || prefix > 32 {
+		return Network{}, fmt.Errorf("cannot split %s into /%d subnets: the prefix must be between /%d and /32", ipNet, prefix, ones)
+	}
+
+	total := 1 << (prefix - ones)
+	if total > maxSplitChildren {
+		return Network{}, fmt.Errorf("cannot split %s into /%d subnets: that makes %d children, more than the limit of %d", ipNet, prefix, total, maxSplitChildren)
+	}
+
+	n := Network{Network: ipNet.String()}
+	for i := 1; i <= total; i++ {
+		n.Subnets = append(n.Subnets, Subnet{Name: fmt.Sprintf("%s-%d", name, i), CIDR: prefix})
+	}
+	return n, nil
+}
+
+// runSplit implements the "split" subcommand.
+func runSplit(args []string) int {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	into := fs.String("into", "", "Prefix of the children (e.g. /26)")
+	count := fs.Int("count", 0, "Number of equal children (a power of two)")
+	name := fs.String("name", "Subnet", "Name prefix of the children")
+	exportJSON := fs.String("exportjson", "", "Export the children to this JSON file")
+	exportCSV := fs.String("exportcsv", "", "Export the children to this CSV file")
+	exportMD := fs.String("exportmd", "", "Export the children to this Markdown file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner split CIDR (-into /N | -count N) [-name prefix] [-exportjson file] [-exportcsv file] [-exportmd file]\n\n")
+		fmt.Fprintf(os.Stderr, "Divides a CIDR into equal children.\n\n")
+		fs.PrintDefaults()
+	}
+	// Allow flags after the CIDR as well as before it
+	_ = fs.Parse(args)
+	var cidrs []string
+	for fs.NArg() > 0 {
+		cidrs = append(cidrs, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(cidrs) != 1 || (*into == "") == (*count == 0) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	prefix := 0
+	if *into != "" {
+		p, err := strconv.Atoi(strings.TrimPrefix(*into, "/"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "split: invalid -into %q (use a prefix such as /26)\n", *into)
+			return exitUsage
+		}
+		prefix = p
+	} else if *count < 0 {
+		fmt.Fprintf(os.Stderr, "split: invalid -count %d\n", *count)
+		return exitUsage
+	}
+
+	n, err := splitNetwork(cidrs[0], *count, prefix, *name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "split: %v\n", err)
+		return exitUsage
+	}
+	results, err := PlanSubnets([]Network{n})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "planning error: %v\n", err)
+		return planningExitCode(err)
+	}
+
+	PrintTable(results)
+	tasks := []exportTask{
+		{label: "JSON", path: *exportJSON, write: ExportJSON},
+		{label: "CSV", path: *exportCSV, write: ExportCSV},
+		{label: "Markdown", path: *exportMD, write: ExportMarkdown},
+	}
+	if errs := runExports(results, tasks, exportOptions{}); len(errs) > 0 {
+		return exitExportFailure
+	}
+	return 0
+}
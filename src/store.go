@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// planVersion is one stored version of a named plan: the submitted config
+// and the results planned from it.
+type planVersion struct {
+	Plan    string          `json:"plan"`
+	Version int             `json:"version"`
+	Created time.Time       `json:"created"`
+	Env     string          `json:"env,omitempty"` // environment selected from the config's "environments"
+	Config  json.RawMessage `json:"config"`
+	Results []SubnetResult  `json:"results"`
+}
+
+// planStore keeps the version history of named plans. Versions are
+// numbered from 1 and never change once saved. Other backends plug in by
+// implementing this interface and adding a scheme to openStore.
+type planStore interface {
+	// Save stores v under v.Plan and v.Version.
+	Save(v planVersion) error
+	// Versions lists the stored versions of plan in ascending order; an
+	// unknown plan has none.
+	Versions(plan string) ([]int, error)
+	// Load returns one version, or errVersionNotFound.
+	Load(plan string, version int) (planVersion, error)
+	// Plans lists the names of all stored plans, sorted.
+	Plans() ([]string, error)
+}
+
+var errVersionNotFound = errors.New("version not found")
+
+// planNamePattern restricts plan names to what every backend can use as a
+// path or blob name.
+var planNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// openStore opens the storage given to "serve -store": a directory (or
+// "dir:<path>"), "azblob:<container URL with SAS token>" for Azure Blob
+// Storage, or "s3:<bucket URL>" for S3 and S3-compatible services. An empty
+// spec disables storage.
+func openStore(spec string) (planStore, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	scheme, rest, ok := strings.Cut(spec, ":")
+	if !ok || len(scheme) == 1 { // no scheme, or a Windows drive letter
+		scheme, rest = "dir", spec
+	}
+	switch strings.ToLower(scheme) {
+	case "dir", "file":
+		if rest == "" {
+			return nil, fmt.Errorf("%s store needs a path, such as %s:/var/lib/ipsubnetplanner/plans", scheme, scheme)
+		}
+		if err := os.MkdirAll(rest, 0755); err != nil {
+			return nil, fmt.Errorf("plan store %s: %v", rest, err)
+		}
+		return dirStore{root: rest}, nil
+	case "azblob":
+		u, err := url.Parse(rest)
+		if err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+			return nil, fmt.Errorf("azblob store needs a container URL such as azblob:https://account.blob.core.windows.net/plans?<SAS token>")
+		}
+		return &blobStore{container: u, client: &http.Client{Timeout: 30 * time.Second}}, nil
+	case "s3":
+		u, err := url.Parse(rest)
+		if err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" || u.RawQuery != "" {
+			return nil, fmt.Errorf("s3 store needs a bucket URL such as s3:https://bucket.s3.eu-west-1.amazonaws.com or s3:http://minio:9000/bucket")
+		}
+		return newS3Store(u)
+	case "sqlite":
+		// SQLite needs cgo or a driver dependency, and the planner has neither
+		return nil, fmt.Errorf("sqlite plan storage is not built into this binary (use a directory, azblob:<container URL> or s3:<bucket URL>)")
+	}
+	return nil, fmt.Errorf("unknown plan store %q (use a directory, azblob:<container URL> or s3:<bucket URL>)", spec)
+}
+
+// versionName is the file or blob name of a version, padded so that names
+// sort in version order.
+func versionName(version int) string {
+	return fmt.Sprintf("%06d.json", version)
+}
+
+// parseVersionName reverses versionName.
+func parseVersionName(name string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+	return n, err == nil && n > 0 && strings.HasSuffix(name, ".json")
+}
+
+// dirStore keeps each plan in a directory of version files:
+// <root>/<plan>/000001.json, 000002.json, ...
+type dirStore struct {
+	root string
+}
+
+func (s dirStore) Save(v planVersion) error {
+	dir := filepath.Join(s.root, v.Plan)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, versionName(v.Version))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s dirStore) Versions(plan string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, plan))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for _, e := range entries {
+		if n, ok := parseVersionName(e.Name()); ok && !e.IsDir() {
+			versions = append(versions, n)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+func (s dirStore) Plans() ([]string, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, err
+	}
+	var plans []string
+	for _, e := range entries {
+		if e.IsDir() && planNamePattern.MatchString(e.Name()) {
+			plans = append(plans, e.Name())
+		}
+	}
+	return plans, nil
+}
+
+func (s dirStore) Load(plan string, version int) (planVersion, error) {
+	var v planVersion
+	data, err := os.ReadFile(filepath.Join(s.root, plan, versionName(version)))
+	if os.IsNotExist(err) {
+		return v, errVersionNotFound
+	}
+	if err != nil {
+		return v, err
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+// blobStore keeps versions as block blobs <plan>/000001.json in an Azure
+// Blob Storage container, authorized by the SAS token in the container URL.
+type blobStore struct {
+	container *url.URL
+	client    *http.Client
+}
+
+// blobURL returns the URL of a blob, or of the container for name "", with
+// extra query parameters added to the SAS token.
+func (s *blobStore) blobURL(name string, query url.Values) string {
+	u := *s.container
+	if name != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + name
+	}
+	q := u.Query()
+	for k, v := range query {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (s *blobStore) do(method, target string, body []byte, header map[string]string) ([]byte, int, error) {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("x-ms-version", "2021-08-06")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.StatusCode, err
+}
+
+func (s *blobStore) Save(v planVersion) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// If-None-Match: * keeps a saved version from being overwritten
+	_, status, err := s.do(http.MethodPut, s.blobURL(v.Plan+"/"+versionName(v.Version), nil), data, map[string]string{
+		"x-ms-blob-type": "BlockBlob",
+		"Content-Type":   "application/json",
+		"If-None-Match":  "*",
+	})
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		return fmt.Errorf("azblob: saving %s version %d: HTTP %d", v.Plan, v.Version, status)
+	}
+	return nil
+}
+
+func (s *blobStore) Versions(plan string) ([]int, error) {
+	names, err := s.list(plan + "/")
+	if err != nil {
+		return nil, fmt.Errorf("azblob: listing %s: %v", plan, err)
+	}
+	var versions []int
+	for _, name := range names {
+		if n, ok := parseVersionName(strings.TrimPrefix(name, plan+"/")); ok {
+			versions = append(versions, n)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+func (s *blobStore) Plans() ([]string, error) {
+	names, err := s.list("")
+	if err != nil {
+		return nil, fmt.Errorf("azblob: listing plans: %v", err)
+	}
+	var plans []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		plan, _, ok := strings.Cut(name, "/")
+		if ok && !seen[plan] && planNamePattern.MatchString(plan) {
+			seen[plan] = true
+			plans = append(plans, plan)
+		}
+	}
+	sort.Strings(plans)
+	return plans, nil
+}
+
+// list returns the names of all blobs starting with prefix, following the
+// continuation markers of large containers.
+func (s *blobStore) list(prefix string) ([]string, error) {
+	var names []string
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		data, status, err := s.do(http.MethodGet, s.blobURL("", query), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d", status)
+		}
+		var list struct {
+			Names      []string `xml:"Blobs>Blob>Name"`
+			NextMarker string   `xml:"NextMarker"`
+		}
+		if err := xml.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		names = append(names, list.Names...)
+		if list.NextMarker == "" {
+			return names, nil
+		}
+		marker = list.NextMarker
+	}
+}
+
+func (s *blobStore) Load(plan string, version int) (planVersion, error) {
+	var v planVersion
+	data, status, err := s.do(http.MethodGet, s.blobURL(plan+"/"+versionName(version), nil), nil, nil)
+	if err != nil {
+		return v, err
+	}
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return v, errVersionNotFound
+	default:
+		return v, fmt.Errorf("azblob: loading %s version %d: HTTP %d", plan, version, status)
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// rowWriter receives planned rows one at a time and finishes the output on
+// Close, so an export can be written while the plan is generated.
+type rowWriter interface {
+	Write(SubnetResult) error
+	Close() error
+}
+
+// jsonStream writes rows as the same indented JSON array as ExportJSON.
+type jsonStream struct {
+	w    *bufio.Writer
+	rows int
+}
+
+func newJSONStream(w io.Writer) *jsonStream {
+	return &jsonStream{w: bufio.NewWriter(w)}
+}
+
+func (s *jsonStream) Write(r SubnetResult) error {
+	data, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	sep := ",\n  "
+	if s.rows == 0 {
+		sep = "[\n  "
+	}
+	s.rows++
+	s.w.WriteString(sep)
+	_, err = s.w.Write(data)
+	return err
+}
+
+func (s *jsonStream) Close() error {
+	if s.rows == 0 {
+		s.w.WriteString("[]")
+	} else {
+		s.w.WriteString("\n]")
+	}
+	return s.w.Flush()
+}
+
+// csvStream writes rows in the layout of ExportCSV. The optional columns
+// must be known before the first row, so they are passed in rather than
+// derived from the rows (see streamColumns). When cols is set, only those
+// columns are written, as with -columns.
+type csvStream struct {
+	w      *csv.Writer
+	extra  []string
+	cols   []outputColumn
+	header bool
+}
+
+func newCSVStream(w io.Writer, extra []string) *csvStream {
+	return &csvStream{w: csv.NewWriter(w), extra: extra}
+}
+
+func (s *csvStream) Write(r SubnetResult) error {
+	if !s.header {
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	}
+	row := append(csvRow(r), optionalCells(r, s.extra)...)
+	if s.cols != nil {
+		row = columnCells(r, s.cols)
+	}
+	if err := s.w.Write(row); err != nil {
+		return fmt.Errorf("failed to write CSV row: %v", err)
+	}
+	return nil
+}
+
+func (s *csvStream) writeHeader() error {
+	s.header = true
+	header := append(append([]string{}, csvHeader...), s.extra...)
+	if s.cols != nil {
+		header = header[:0]
+		for _, col := range s.cols {
+			header = append(header, col.header)
+		}
+	}
+	if err := s.w.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %v", err)
+	}
+	return nil
+}
+
+func (s *csvStream) Close() error {
+	if !s.header {
+		if err := s.writeHeader(); err != nil {
+			return err
+		}
+	}
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// streamColumns predicts the optional CSV columns of planning networks with
+// p from the config alone, as hasStatus and the like decide them from the
+// rows: Status when a subnet is decommissioned and not reclaimed, FQDN when
+// a network names its hosts, and Description and Tags when any subnet or
+// assignment has them.
+func streamColumns(p Planner, networks []Network) []string {
+	var status, fqdn, description, tags bool
+	for _, n := range networks {
+		fqdn = fqdn || n.NameTemplate != "" || n.DNSSuffix != ""
+		for _, s := range n.Subnets {
+			if s.Decommissioned && !status {
+				until, err := p.quarantineUntil(s)
+				status = err != nil || until.After(p.now()) || !p.Reclaim
+			}
+			description = description || s.Description != ""
+			tags = tags || len(s.Tags) > 0
+			for _, a := range s.IPAssignments {
+				description = description || a.Description != ""
+				tags = tags || len(a.Tags) > 0
+			}
+		}
+	}
+	var cols []string
+	for _, c := range []struct {
+		name string
+		on   bool
+	}{{"Status", status}, {"FQDN", fqdn}, {"Description", description}, {"Tags", tags}} {
+		if c.on {
+			cols = append(cols, c.name)
+		}
+	}
+	return cols
+}
+
+// streamPlan plans networks and writes every row that passes match (nil
+// keeps all) to each writer as soon as its network is planned, then closes
+// the writers. It returns the number of rows written.
+func streamPlan(p Planner, networks []Network, match rowFilter, writers ...rowWriter) (int, error) {
+	rows := 0
+	err := p.Each(networks, func(r SubnetResult) error {
+		if match != nil && !match(r) {
+			return nil
+		}
+		rows++
+		for _, w := range writers {
+			if err := w.Write(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for _, w := range writers {
+		if cerr := w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return rows, err
+}
+
+// streamExports plans networks straight into the JSON and CSV exports at
+// jsonPath and csvPath (either may be empty, or stdoutPath), checking each
+// file with opts.guard first. cols selects the CSV columns (nil for all).
+// Files are written under a temporary name next to the target and renamed
+// once planning succeeded, so a failed run leaves the previous exports
+// intact; opts.lock and opts.retries apply to the rename. A target whose
+// directory is not writable is written to opts.fallback instead, which is
+// reported in fallbacks.
+func streamExports(p Planner, networks []Network, match rowFilter, jsonPath, csvPath string, cols []outputColumn, opts exportOptions) (fallbacks []error, err error) {
+	var writers []rowWriter
+	var files []*os.File
+	var targets []string
+	var done []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	for _, target := range []struct {
+		label, path string
+		open        func(io.Writer) rowWriter
+	}{
+		{"JSON", jsonPath, func(w io.Writer) rowWriter { return newJSONStream(w) }},
+		{"CSV", csvPath, func(w io.Writer) rowWriter {
+			s := newCSVStream(w, streamColumns(p, networks))
+			s.cols = cols
+			return s
+		}},
+	} {
+		if target.path == "" {
+			continue
+		}
+		if target.path == stdoutPath {
+			writers = append(writers, target.open(streamOutput))
+			continue
+		}
+		path := target.path
+		f, err := createStreamTemp(path, opts.guard)
+		if err != nil && opts.fallback != "" && opts.fallback != "none" && unwritableTarget(path, err) {
+			dir, dirErr := resolveFallbackDir(opts.fallback)
+			alt := fallbackPath(dir, path)
+			if dirErr == nil {
+				f, dirErr = createStreamTemp(alt, opts.guard)
+			}
+			if dirErr != nil {
+				return nil, fmt.Errorf("%s export to %s: %v (fallback to %s failed: %v)", target.label, path, err, alt, dirErr)
+			}
+			fmt.Fprintf(os.Stderr, "warning: %s is not writable (%v); writing the %s export to %s instead\n", path, err, target.label, alt)
+			fallbacks = append(fallbacks, fmt.Errorf("%s export to %s: %w (written to %s instead)", target.label, path, err, alt))
+			path, err = alt, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s export to %s: %v", target.label, path, err)
+		}
+		files = append(files, f)
+		targets = append(targets, path)
+		writers = append(writers, target.open(f))
+		done = append(done, fmt.Sprintf("✓ %s: %s", target.label, path))
+	}
+
+	rows, err := streamPlan(p, networks, match, writers...)
+	if err != nil {
+		return nil, err
+	}
+	if jsonPath == stdoutPath {
+		fmt.Fprintln(streamOutput) // as ExportJSON does
+	}
+	for i, f := range files {
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(f.Name(), 0644); err != nil {
+			return nil, err
+		}
+		target, temp := targets[i], f.Name()
+		rename := func() error { return os.Rename(temp, target) }
+		if opts.lock {
+			unlocked := rename
+			rename = func() error { return lockedWrite(target, opts.force, unlocked) }
+		}
+		if err := exportWithRetry(rename, opts.retries); err != nil {
+			return nil, fmt.Errorf("export to %s: %v", target, err)
+		}
+	}
+	files = nil
+	fmt.Printf("Streamed %d rows\n", rows)
+	for _, line := range done {
+		fmt.Println(line)
+	}
+	return fallbacks, nil
+}
+
+// createStreamTemp checks path with guard and creates the temporary file
+// a streamed export to path is written to.
+func createStreamTemp(path string, guard *exportGuard) (*os.File, error) {
+	if guard != nil {
+		if err := guard.check(path); err != nil {
+			return nil, err
+		}
+	}
+	ensureDir(path)
+	return os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+)
+
+// cidrBlock is an aligned power-of-two address block.
+type cidrBlock struct {
+	start  uint32
+	prefix int
+}
+
+func (b cidrBlock) end() uint32 {
+	return b.start + uint32(uint64(1)<<(32-b.prefix)-1)
+}
+
+// SummarizeRoutes computes, for each parent network, the minimal set of
+// aggregates that exactly covers its allocated subnets (free space is never
+// included). The aggregates are returned as "Summary" rows so they flow
+// through the console table and every exporter.
+func SummarizeRoutes(results []SubnetResult) []SubnetResult {
+	blocks := make(map[string][]cidrBlock)
+	var parents []string
+	seen := make(map[string]bool)
+
+	for _, r := range results {
+		if isFreeSpaceRow(r) || r.Category == "Summary" || seen[r.Parent+" "+r.Subnet] {
+			continue
+		}
+		seen[r.Parent+" "+r.Subnet] = true
+		_, ipNet, err := net.ParseCIDR(r.Subnet)
+		if err != nil {
+			continue
+		}
+		prefix, _ := ipNet.Mask.Size()
+		if _, ok := blocks[r.Parent]; !ok {
+			parents = append(parents, r.Parent)
+		}
+		blocks[r.Parent] = append(blocks[r.Parent], cidrBlock{start: ipToUint32(ipNet.IP), prefix: prefix})
+	}
+
+	var summary []SubnetResult
+	for _, parent := range parents {
+		for _, b := range aggregateBlocks(blocks[parent]) {
+			mask := net.CIDRMask(b.prefix, 32)
+			summary = append(summary, SubnetResult{
+				Subnet:   fmt.Sprintf("%s/%d", uint32ToIP(b.start), b.prefix),
+				Name:     "Summary",
+				Label:    "Summary Route",
+				IP:       fmt.Sprintf("%s - %s", uint32ToIP(b.start), uint32ToIP(b.end())),
+				TotalIPs: int(uint64(b.end()) - uint64(b.start) + 1),
+				Prefix:   b.prefix,
+				Mask:     fmt.Sprintf("%d.%d.%d.%d", mask[0], mask[1], mask[2], mask[3]),
+				Category: "Summary",
+				Parent:   parent,
+			})
+		}
+	}
+	return summary
+}
+
+// aggregateBlocks merges blocks into the smallest equivalent list of
+// aligned CIDR blocks: contained blocks are dropped and adjacent sibling
+// blocks are joined until nothing changes.
+func aggregateBlocks(blocks []cidrBlock) []cidrBlock {
+	list := append([]cidrBlock(nil), blocks...)
+	for {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].start != list[j].start {
+				return list[i].start < list[j].start
+			}
+			return list[i].prefix < list[j].prefix
+		})
+
+		var merged []cidrBlock
+		changed := false
+		for _, b := range list {
+			if n := len(merged); n > 0 {
+				last := merged[n-1]
+				if b.start >= last.start && b.end() <= last.end() {
+					changed = true // already covered
+					continue
+				}
+				if last.prefix == b.prefix && last.prefix > 0 && last.end()+1 == b.start &&
+					uint64(last.start)%(uint64(1)<<(33-last.prefix)) == 0 {
+					merged[n-1] = cidrBlock{start: last.start, prefix: last.prefix - 1}
+					changed = true
+					continue
+				}
+			}
+			merged = append(merged, b)
+		}
+		list = merged
+		if !changed {
+			return list
+		}
+	}
+}
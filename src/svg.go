@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net"
+	"os"
+	"strings"
+)
+
+const (
+	svgWidth     = 1000
+	svgMargin    = 20
+	svgBarHeight = 40
+	svgRowHeight = 14
+	svgBlockGap  = 30
+)
+
+// svgColors are the fills per category. Subnets use "Subnet" (or
+// "Decommissioned"); the detail strip below uses the row categories.
+var svgColors = map[string]string{
+	"Subnet":         "#4e79a7",
+	"Decommissioned": "#f28e2b",
+	"Free":           "#d9d9d9",
+	"Network":        "#59a14f",
+	"Broadcast":      "#59a14f",
+	"Assignment":     "#e15759",
+	"Reserved":       "#b07aa1",
+	"Virtual IP":     "#ff9da7",
+	"Router":         "#9c755f",
+	"Unused":         "#a0cbe8",
+	"Available":      "#a0cbe8",
+}
+
+// svgLegend is the order categories are listed in the legend.
+var svgLegend = []struct{ key, label string }{
+	{"Subnet", "Subnet"},
+	{"Decommissioned", "Decommissioned"},
+	{"Free", "Free space"},
+	{"Assignment", "Assigned"},
+	{"Reserved", "Reserved"},
+	{"Virtual IP", "Virtual IP"},
+	{"Router", "Router"},
+	{"Network", "Network/broadcast"},
+	{"Available", "Unassigned"},
+}
+
+// svgSegment is a colored address range in a bar.
+type svgSegment struct {
+	start, end uint64 // inclusive start, exclusive end
+	color      string
+	label      string
+	tooltip    string
+}
+
+// ExportSVG draws one proportional bar per parent network: the top bar shows
+// every subnet and the free space by position and size, the strip below it
+// what the addresses inside the subnets are used for.
+func ExportSVG(results []SubnetResult, path string) error {
+	return os.WriteFile(path, []byte(renderSVG(results)), 0644)
+}
+
+// renderSVG returns the allocation map drawn by ExportSVG.
+func renderSVG(results []SubnetResult) string {
+	var parents []string
+	rows := make(map[string][]SubnetResult)
+	for _, r := range results {
+		if r.Category == "Summary" {
+			continue
+		}
+		if _, ok := rows[r.Parent]; !ok {
+			parents = append(parents, r.Parent)
+		}
+		rows[r.Parent] = append(rows[r.Parent], r)
+	}
+
+	blockHeight := 20 + svgBarHeight + svgRowHeight + svgBlockGap
+	height := svgMargin*2 + len(parents)*blockHeight + 30
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"sans-serif\" font-size=\"11\">\n", svgWidth, height))
+	sb.WriteString("<rect width=\"100%\" height=\"100%\" fill=\"white\"/>\n")
+
+	y := svgMargin
+	for _, parent := range parents {
+		_, ipNet, err := net.ParseCIDR(parent)
+		if err != nil {
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		base := uint64(ipToUint32(ipNet.IP))
+		size := uint64(1) << (32 - ones)
+		scale := float64(svgWidth-2*svgMargin) / float64(size)
+
+		subnets, detail := svgSegments(rows[parent])
+		sb.WriteString(fmt.Sprintf("<text x=\"%d\" y=\"%d\" font-size=\"13\" font-weight=\"bold\">%s</text>\n", svgMargin, y+14, html.EscapeString(parent)))
+		y += 20
+		writeSVGSegments(&sb, subnets, base, scale, y, svgBarHeight)
+		writeSVGSegments(&sb, detail, base, scale, y+svgBarHeight, svgRowHeight)
+		y += svgBarHeight + svgRowHeight + svgBlockGap
+	}
+
+	x := svgMargin
+	for _, l := range svgLegend {
+		sb.WriteString(fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"12\" height=\"12\" fill=\"%s\"/>", x, y, svgColors[l.key]))
+		sb.WriteString(fmt.Sprintf("<text x=\"%d\" y=\"%d\">%s</text>\n", x+16, y+10, l.label))
+		x += 24 + 7*len(l.label)
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// svgSegments splits a parent network's rows into subnet blocks and the
+// per-address detail strip.
+func svgSegments(rows []SubnetResult) (subnets, detail []svgSegment) {
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		_, block, err := net.ParseCIDR(r.Subnet)
+		if err != nil {
+			continue
+		}
+		ones, _ := block.Mask.Size()
+		start := uint64(ipToUint32(block.IP))
+		end := start + uint64(1)<<(32-ones)
+
+		if isFreeSpaceRow(r) {
+			subnets = append(subnets, svgSegment{start, end, svgColors["Free"], "", fmt.Sprintf("Free: %s", r.Subnet)})
+			continue
+		}
+		if !seen[r.Subnet+"|"+r.Name] {
+			seen[r.Subnet+"|"+r.Name] = true
+			color := svgColors["Subnet"]
+			if r.Status != "" {
+				color = svgColors["Decommissioned"]
+			}
+			subnets = append(subnets, svgSegment{start, end, color, r.Name, fmt.Sprintf("%s: %s", r.Name, r.Subnet)})
+		}
+		if first, last, err := parseIPSpan(r.IP); err == nil {
+			detail = append(detail, svgSegment{uint64(first), uint64(last) + 1, svgColors[r.Category], "", fmt.Sprintf("%s %s: %s", r.Name, r.Label, r.IP)})
+		}
+	}
+	return subnets, detail
+}
+
+func writeSVGSegments(sb *strings.Builder, segments []svgSegment, base uint64, scale float64, y, height int) {
+	for _, s := range segments {
+		x := float64(svgMargin) + float64(s.start-base)*scale
+		w := float64(s.end-s.start) * scale
+		sb.WriteString(fmt.Sprintf("<rect x=\"%.2f\" y=\"%d\" width=\"%.2f\" height=\"%d\" fill=\"%s\" stroke=\"white\" stroke-width=\"0.5\"><title>%s</title></rect>\n",
+			x, y, w, height, s.color, html.EscapeString(s.tooltip)))
+		// Only label blocks wide enough to hold the text
+		if s.label != "" && w >= float64(7*len(s.label)+8) {
+			sb.WriteString(fmt.Sprintf("<text x=\"%.2f\" y=\"%d\" fill=\"white\">%s</text>\n", x+4, y+height/2+4, html.EscapeString(s.label)))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var switchInvalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// switchVLANName converts a subnet name into a VLAN name accepted by IOS and
+// EOS (no spaces, at most 32 characters).
+func switchVLANName(name string) string {
+	n := strings.Trim(switchInvalidNameChars.ReplaceAllString(strings.TrimSpace(name), "_"), "_")
+	if len(n) > 32 {
+		n = n[:32]
+	}
+	return n
+}
+
+// switchVLAN is one VLAN and, if the subnet has a Gateway assignment, its SVI
+// address.
+type switchVLAN struct {
+	id      int
+	name    string
+	gateway string
+	prefix  int
+}
+
+// switchVLANs collects the VLANs of the plan in order; a VLAN used by more
+// than one subnet keeps the first subnet's name.
+func switchVLANs(results []SubnetResult) []*switchVLAN {
+	var vlans []*switchVLAN
+	byID := make(map[int]*switchVLAN)
+	owner := make(map[int]string)
+	for _, r := range results {
+		if r.VLAN <= 0 || isFreeSpaceRow(r) || r.Category == "Summary" {
+			continue
+		}
+		v, ok := byID[r.VLAN]
+		if !ok {
+			v = &switchVLAN{id: r.VLAN, name: switchVLANName(r.Name), prefix: r.Prefix}
+			byID[r.VLAN] = v
+			owner[r.VLAN] = r.Subnet
+			vlans = append(vlans, v)
+		}
+		if isAssignedCategory(r.Category) && strings.EqualFold(r.Label, "Gateway") && owner[r.VLAN] == r.Subnet && v.gateway == "" {
+			v.gateway = r.IP
+		}
+	}
+	return vlans
+}
+
+// ExportSwitch writes VLAN definitions and SVI stubs for every VLAN in the
+// plan in the Cisco IOS ("ios") or Arista EOS ("eos") dialect. SVIs are only
+// written for subnets with a Gateway assignment.
+func ExportSwitch(results []SubnetResult, path, dialect string) error {
+	var indent string
+	switch dialect {
+	case "ios":
+		indent = " "
+	case "eos":
+		indent = "   "
+	default:
+		return fmt.Errorf("unknown switch dialect %q (use ios or eos)", dialect)
+	}
+
+	vlans := switchVLANs(results)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("! VLAN configuration (%s) generated by IPSubnetPlanner\n!\n", strings.ToUpper(dialect)))
+	for _, v := range vlans {
+		sb.WriteString(fmt.Sprintf("vlan %d\n%sname %s\n!\n", v.id, indent, v.name))
+	}
+	for _, v := range vlans {
+		if v.gateway == "" {
+			continue
+		}
+		address := fmt.Sprintf("%s/%d", v.gateway, v.prefix)
+		if dialect == "ios" {
+			mask := net.CIDRMask(v.prefix, 32)
+			address = fmt.Sprintf("%s %d.%d.%d.%d", v.gateway, mask[0], mask[1], mask[2], mask[3])
+		}
+		sb.WriteString(fmt.Sprintf("interface Vlan%d\n", v.id))
+		sb.WriteString(fmt.Sprintf("%sdescription %s\n", indent, v.name))
+		sb.WriteString(fmt.Sprintf("%sip address %s\n", indent, address))
+		sb.WriteString(fmt.Sprintf("%sno shutdown\n!\n", indent))
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ticketRow is one line of a ticket table: what changed and its before and
+// after state.
+type ticketRow struct {
+	item, change, before, after string
+}
+
+// ticketSections collects the diff into the rows shared by every ticket format.
+func ticketSections(d PlanDiff) (subnets, assignments []ticketRow, rollback []string) {
+	for _, c := range d.Added {
+		subnets = append(subnets, ticketRow{c.Name, "Added", "", c.NewSubnet})
+		rollback = append(rollback, fmt.Sprintf("Remove subnet %s (%s)", c.Name, c.NewSubnet))
+	}
+	for _, c := range d.Removed {
+		subnets = append(subnets, ticketRow{c.Name, "Removed", c.OldSubnet, ""})
+		rollback = append(rollback, fmt.Sprintf("Restore subnet %s at %s", c.Name, c.OldSubnet))
+	}
+	for _, c := range d.Resized {
+		subnets = append(subnets, ticketRow{c.Name, "Resized", c.OldSubnet, c.NewSubnet})
+		rollback = append(rollback, fmt.Sprintf("Resize subnet %s back from %s to %s", c.Name, c.NewSubnet, c.OldSubnet))
+	}
+	for _, c := range d.Moved {
+		subnets = append(subnets, ticketRow{c.Name, "Moved", c.OldSubnet, c.NewSubnet})
+		rollback = append(rollback, fmt.Sprintf("Move subnet %s back from %s to %s", c.Name, c.NewSubnet, c.OldSubnet))
+	}
+	for _, c := range d.Assignments {
+		item := c.Subnet + " / " + c.Label
+		switch {
+		case c.OldIP == "":
+			assignments = append(assignments, ticketRow{item, "Added", "", c.NewIP})
+			rollback = append(rollback, fmt.Sprintf("Release %s (%s)", item, c.NewIP))
+		case c.NewIP == "":
+			assignments = append(assignments, ticketRow{item, "Removed", c.OldIP, ""})
+			rollback = append(rollback, fmt.Sprintf("Re-assign %s to %s", item, c.OldIP))
+		default:
+			assignments = append(assignments, ticketRow{item, "Changed", c.OldIP, c.NewIP})
+			rollback = append(rollback, fmt.Sprintf("Re-assign %s from %s back to %s", item, c.NewIP, c.OldIP))
+		}
+	}
+	return subnets, assignments, rollback
+}
+
+// ticketSummary is the one-line summary used as the ticket title.
+func ticketSummary(d PlanDiff) string {
+	return fmt.Sprintf("IP plan change: %d added, %d removed, %d resized, %d moved subnet(s); %d IP assignment change(s)",
+		len(d.Added), len(d.Removed), len(d.Resized), len(d.Moved), len(d.Assignments))
+}
+
+// ExportTicket writes a change ticket body for the diff between the previous
+// plan file and the current plan, in Jira wiki markup ("jira") or
+// ServiceNow-friendly HTML ("servicenow"). An empty format picks servicenow
+// for .html/.htm files and jira otherwise.
+func ExportTicket(d PlanDiff, previousPlan, path, format string) error {
+	if format == "" {
+		format = "jira"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+			format = "servicenow"
+		}
+	}
+	subnets, assignments, rollback := ticketSections(d)
+
+	var body string
+	switch format {
+	case "jira":
+		body = jiraTicket(d, previousPlan, subnets, assignments, rollback)
+	case "servicenow":
+		body = serviceNowTicket(d, previousPlan, subnets, assignments, rollback)
+	default:
+		return fmt.Errorf("unknown ticket format %q (use jira or servicenow)", format)
+	}
+	return os.WriteFile(path, []byte(body), 0644)
+}
+
+func jiraTicket(d PlanDiff, previousPlan string, subnets, assignments []ticketRow, rollback []string) string {
+	cell := func(s string) string {
+		if s == "" {
+			return " "
+		}
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+	table := func(sb *strings.Builder, header string, rows []ticketRow) {
+		sb.WriteString(fmt.Sprintf("||%s||Change||Before||After||\n", header))
+		for _, r := range rows {
+			sb.WriteString(fmt.Sprintf("|%s|%s|%s|%s|\n", cell(r.item), r.change, cell(r.before), cell(r.after)))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("h2. Summary\n")
+	sb.WriteString(ticketSummary(d) + "\n\n")
+	if !d.HasChanges() {
+		sb.WriteString("No changes compared with " + previousPlan + ".\n")
+		return sb.String()
+	}
+	if len(subnets) > 0 {
+		sb.WriteString("h2. Affected Subnets\n")
+		table(&sb, "Subnet", subnets)
+		sb.WriteString("\n")
+	}
+	if len(assignments) > 0 {
+		sb.WriteString("h2. IP Assignment Changes\n")
+		table(&sb, "Assignment", assignments)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("h2. Rollback Plan\n")
+	sb.WriteString(fmt.Sprintf("Re-apply the previous plan ({{%s}}). In detail:\n", previousPlan))
+	for _, step := range rollback {
+		sb.WriteString("# " + step + "\n")
+	}
+	return sb.String()
+}
+
+func serviceNowTicket(d PlanDiff, previousPlan string, subnets, assignments []ticketRow, rollback []string) string {
+	e := html.EscapeString
+	table := func(sb *strings.Builder, header string, rows []ticketRow) {
+		sb.WriteString("<table border=\"1\" cellpadding=\"4\">\n")
+		sb.WriteString(fmt.Sprintf("<tr><th>%s</th><th>Change</th><th>Before</th><th>After</th></tr>\n", header))
+		for _, r := range rows {
+			sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n", e(r.item), r.change, e(r.before), e(r.after)))
+		}
+		sb.WriteString("</table>\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h2>Summary</h2>\n")
+	sb.WriteString("<p>" + e(ticketSummary(d)) + "</p>\n")
+	if !d.HasChanges() {
+		sb.WriteString("<p>No changes compared with " + e(previousPlan) + ".</p>\n")
+		return sb.String()
+	}
+	if len(subnets) > 0 {
+		sb.WriteString("<h2>Affected Subnets</h2>\n")
+		table(&sb, "Subnet", subnets)
+	}
+	if len(assignments) > 0 {
+		sb.WriteString("<h2>IP Assignment Changes</h2>\n")
+		table(&sb, "Assignment", assignments)
+	}
+	sb.WriteString("<h2>Rollback Plan</h2>\n")
+	sb.WriteString("<p>Re-apply the previous plan (<code>" + e(previousPlan) + "</code>). In detail:</p>\n<ol>\n")
+	for _, step := range rollback {
+		sb.WriteString("<li>" + e(step) + "</li>\n")
+	}
+	sb.WriteString("</ol>\n")
+	return sb.String()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// topologyKeyWords select the assignments shown in a topology diagram; an
+// assignment is shown if its name contains one of them (case-insensitive).
+var topologyKeyWords = []string{"gateway", "router", "firewall", "vip"}
+
+// topologyNetwork is a parent network with its subnets.
+type topologyNetwork struct {
+	cidr    string
+	subnets []*topologySubnet
+}
+
+// topologySubnet is a subnet with its key assignments.
+type topologySubnet struct {
+	name, cidr, status string
+	vlan               int
+	keys               []string // "<name> <ip>"
+}
+
+// buildTopology groups the plan into parent networks and subnets, skipping
+// free space and summaries.
+func buildTopology(results []SubnetResult) []*topologyNetwork {
+	var networks []*topologyNetwork
+	byParent := make(map[string]*topologyNetwork)
+	bySubnet := make(map[string]*topologySubnet)
+	for _, r := range results {
+		if r.Category == "Summary" || isFreeSpaceRow(r) {
+			continue
+		}
+		n, ok := byParent[r.Parent]
+		if !ok {
+			n = &topologyNetwork{cidr: r.Parent}
+			byParent[r.Parent] = n
+			networks = append(networks, n)
+		}
+		key := r.Parent + "|" + r.Subnet + "|" + r.Name
+		s, ok := bySubnet[key]
+		if !ok {
+			s = &topologySubnet{name: r.Name, cidr: r.Subnet, status: r.Status, vlan: r.VLAN}
+			bySubnet[key] = s
+			n.subnets = append(n.subnets, s)
+		}
+		if isAssignedCategory(r.Category) && !strings.Contains(r.IP, "-") && isTopologyKey(r.Label) {
+			s.keys = append(s.keys, r.Label+" "+r.IP)
+		}
+	}
+	return networks
+}
+
+func isTopologyKey(label string) bool {
+	l := strings.ToLower(label)
+	for _, w := range topologyKeyWords {
+		if strings.Contains(l, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// lines is the text shown in a subnet's node, one entry per line.
+func (s *topologySubnet) lines() []string {
+	lines := []string{s.name, s.cidr}
+	if s.vlan > 0 {
+		lines = append(lines, fmt.Sprintf("VLAN %d", s.vlan))
+	}
+	if s.status != "" {
+		lines = append(lines, s.status)
+	}
+	return lines
+}
+
+// ExportTopology writes a diagram of parent networks, their subnets, and the
+// gateway/router assignments of each subnet. format is "mermaid" (a
+// flowchart for wikis and Markdown) or "dot" (Graphviz); empty selects dot
+// for .dot/.gv files and mermaid otherwise.
+func ExportTopology(results []SubnetResult, path, format string) error {
+	if format == "" {
+		format = "mermaid"
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".dot" || ext == ".gv" {
+			format = "dot"
+		}
+	}
+	networks := buildTopology(results)
+
+	var sb strings.Builder
+	switch format {
+	case "mermaid":
+		writeTopologyMermaid(&sb, networks)
+	case "dot":
+		writeTopologyDOT(&sb, networks)
+	default:
+		return fmt.Errorf("unknown topology format %q (use mermaid or dot)", format)
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+func mermaidText(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+func writeTopologyMermaid(sb *strings.Builder, networks []*topologyNetwork) {
+	sb.WriteString("graph LR\n")
+	for i, n := range networks {
+		sb.WriteString(fmt.Sprintf("  n%d[\"%s\"]\n", i, mermaidText(n.cidr)))
+		for j, s := range n.subnets {
+			var lines []string
+			for _, l := range s.lines() {
+				lines = append(lines, mermaidText(l))
+			}
+			id := fmt.Sprintf("n%d_s%d", i, j)
+			sb.WriteString(fmt.Sprintf("  n%d --> %s[\"%s\"]\n", i, id, strings.Join(lines, "<br/>")))
+			for k, key := range s.keys {
+				sb.WriteString(fmt.Sprintf("  %s --- %s_a%d([\"%s\"])\n", id, id, k, mermaidText(key)))
+			}
+		}
+	}
+}
+
+func dotText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+func writeTopologyDOT(sb *strings.Builder, networks []*topologyNetwork) {
+	sb.WriteString("digraph topology {\n")
+	sb.WriteString("  rankdir=LR;\n  node [shape=box, fontname=\"sans-serif\"];\n")
+	for i, n := range networks {
+		sb.WriteString(fmt.Sprintf("  n%d [label=\"%s\", style=bold];\n", i, dotText(n.cidr)))
+		for j, s := range n.subnets {
+			var lines []string
+			for _, l := range s.lines() {
+				lines = append(lines, dotText(l))
+			}
+			id := fmt.Sprintf("n%d_s%d", i, j)
+			sb.WriteString(fmt.Sprintf("  %s [label=\"%s\"];\n", id, strings.Join(lines, `\n`)))
+			sb.WriteString(fmt.Sprintf("  n%d -> %s;\n", i, id))
+			for k, key := range s.keys {
+				sb.WriteString(fmt.Sprintf("  %s_a%d [label=\"%s\", shape=ellipse];\n", id, k, dotText(key)))
+				sb.WriteString(fmt.Sprintf("  %s -> %s_a%d [arrowhead=none];\n", id, id, k))
+			}
+		}
+	}
+	sb.WriteString("}\n")
+}
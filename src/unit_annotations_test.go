@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportMarkdownAnnotated(t *testing.T) {
+	dir := t.TempDir()
+	sidecar := filepath.Join(dir, "review.json")
+	content := `{
+		"Web": {"note": "Reviewed | approved with conditions", "approval": "CAB-2024-031", "reviewer": "netops"},
+		"Legacy": {"note": "Gone"}
+	}`
+	if err := os.WriteFile(sidecar, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := loadAnnotations(sidecar)
+	if err != nil {
+		t.Fatalf("loadAnnotations: %v", err)
+	}
+
+	results := []SubnetResult{
+		{Name: "Web", Subnet: "10.0.0.0/26", Category: "Network"},
+		{Name: "Web", Subnet: "10.0.0.0/26", Category: "Broadcast"},
+		{Name: "DB", Subnet: "10.0.0.64/26", Category: "Network"},
+	}
+	if stale := staleAnnotations(annotations, results); !reflect.DeepEqual(stale, []string{"Legacy"}) {
+		t.Errorf("staleAnnotations = %v, want [Legacy]", stale)
+	}
+
+	path := filepath.Join(dir, "plan.md")
+	if err := ExportMarkdownAnnotated(results, annotations, path); err != nil {
+		t.Fatalf("ExportMarkdownAnnotated: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	md := string(data)
+	want := "## Review Notes\n\n| Subnet | Note | Approval | Reviewer |\n|--------|------|----------|----------|\n| Web | Reviewed \\| approved with conditions | CAB-2024-031 | netops |\n"
+	if !strings.HasSuffix(md, want) {
+		t.Errorf("expected review notes section, got:\n%s", md)
+	}
+	if !strings.HasPrefix(md, "# Subnet Plan") {
+		t.Errorf("plan table should come first:\n%s", md)
+	}
+
+	if err := os.WriteFile(sidecar, []byte(`["Web"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadAnnotations(sidecar); err == nil {
+		t.Error("expected an error for a malformed sidecar")
+	}
+}
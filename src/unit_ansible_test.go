@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnsibleGroupName(t *testing.T) {
+	tests := map[string]string{
+		"Guest-WiFi":  "guest_wifi",
+		"App Service": "app_service",
+		"10G Storage": "subnet_10g_storage",
+		"---":         "subnet_",
+	}
+	for in, want := range tests {
+		if got := ansibleGroupName(in); got != want {
+			t.Errorf("ansibleGroupName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportAnsible(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Rack-1", CIDR: 27, VLAN: 101, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "TOR1", Position: -2}, {Name: "Pool", Position: 10, Count: 5}}},
+			{Name: "Rack-2", CIDR: 27, VLAN: 102, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+			{Name: "Spare", CIDR: 28},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "inventory.yml")
+	if err := ExportAnsible(results, path); err != nil {
+		t.Fatalf("ExportAnsible() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+
+	for _, want := range []string{
+		"    rack_1:\n      vars:\n        subnet: \"10.0.0.0/27\"",
+		"        vlan: 101\n        gateway: \"10.0.0.1\"\n      hosts:\n",
+		"        \"Rack-1-Gateway\":\n          ansible_host: \"10.0.0.1\"\n",
+		"        \"TOR1\":\n          ansible_host: \"10.0.0.29\"\n",
+		"        \"Rack-2-Gateway\":\n          ansible_host: \"10.0.0.33\"\n",
+		"    spare:\n      vars:\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("inventory missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Pool") {
+		t.Errorf("address blocks should not become hosts:\n%s", out)
+	}
+}
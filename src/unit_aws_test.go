@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportAWS(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/16", Subnets: []Subnet{
+		{Name: "App A", CIDR: 24, Tags: map[string]string{"az": "0", "env": "prod"}},
+		{Name: "App B", CIDR: 24, Tags: map[string]string{"az": "use1-az2"}},
+		{Name: "Data", CIDR: 24, Tags: map[string]string{"az": "us-east-1c"}},
+	}}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	tf := filepath.Join(dir, "vpc.tf")
+	if err := ExportAWS(results, tf, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(tf)
+	for _, want := range []string{
+		`data "aws_availability_zones" "available"`,
+		`resource "aws_vpc" "vpc_10_0_0_0_16"`,
+		`resource "aws_subnet" "subnet_app_a"`,
+		"vpc_id     = aws_vpc.vpc_10_0_0_0_16.id",
+		"availability_zone = data.aws_availability_zones.available.names[0]",
+		`availability_zone_id = "use1-az2"`,
+		`availability_zone = "us-east-1c"`,
+		`"env" = "prod"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Terraform is missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), `"az" =`) {
+		t.Errorf("the az tag should not become an AWS tag:\n%s", data)
+	}
+
+	cfn := filepath.Join(dir, "vpc.json")
+	if err := ExportAWS(results, cfn, ""); err != nil {
+		t.Fatal(err)
+	}
+	var template struct {
+		Resources map[string]cfnResource
+	}
+	data, _ = os.ReadFile(cfn)
+	if err := json.Unmarshal(data, &template); err != nil {
+		t.Fatalf("CloudFormation template is not JSON: %v", err)
+	}
+	if template.Resources["VPC0"].Type != "AWS::EC2::VPC" || len(template.Resources) != 4 {
+		t.Errorf("unexpected resources: %v", template.Resources)
+	}
+	zone, _ := json.Marshal(template.Resources["SubnetAppA"].Properties["AvailabilityZone"])
+	if string(zone) != `{"Fn::Select":[0,{"Fn::GetAZs":""}]}` {
+		t.Errorf("AvailabilityZone of App A = %s", zone)
+	}
+
+	// Generated IDs never collide with names that look like them
+	dupes, err := PlanSubnets([]Network{{Network: "10.0.0.0/16", Subnets: []Subnet{
+		{Name: "Web", CIDR: 24}, {Name: "Web", CIDR: 24}, {Name: "Web 2", CIDR: 24}, {Name: "Web_1", CIDR: 24}, {Name: "Web1", CIDR: 24},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vpcs, err := buildAWSVPCs(dupes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tfIDs, cfnIDs := map[string]bool{}, map[string]bool{}
+	for _, s := range vpcs[0].subnets {
+		if tfIDs[s.id] || cfnIDs[s.cfnID] {
+			t.Errorf("duplicate ID %s / %s", s.id, s.cfnID)
+		}
+		tfIDs[s.id], cfnIDs[s.cfnID] = true, true
+	}
+	if err := ExportAWS(dupes, cfn, "cloudformation"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(cfn)
+	template.Resources = nil
+	if err := json.Unmarshal(data, &template); err != nil || len(template.Resources) != 6 {
+		t.Errorf("expected 6 resources, got %d (%v)", len(template.Resources), err)
+	}
+
+	if err := ExportAWS(results, tf, "pulumi"); err == nil || !strings.Contains(err.Error(), "unknown AWS format") {
+		t.Errorf("expected an unknown format error, got %v", err)
+	}
+	small, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Tiny", CIDR: 29}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportAWS(small, tf, ""); err == nil || !strings.Contains(err.Error(), "/28") {
+		t.Errorf("expected a subnet size error, got %v", err)
+	}
+}
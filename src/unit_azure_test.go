@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAzureSubnetName(t *testing.T) {
+	tests := map[string]string{
+		"Web Servers":       "Web-Servers",
+		"GatewaySubnet":     "GatewaySubnet",
+		"_app tier (prod).": "app-tier-prod",
+		"!!!":               "subnet",
+	}
+	for in, want := range tests {
+		if got := azureSubnetName(in); got != want {
+			t.Errorf("azureSubnetName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExportBicep(t *testing.T) {
+	networks := []Network{{
+		Network: "10.20.0.0/24",
+		Subnets: []Subnet{
+			{Name: "App Service", CIDR: 26, Delegations: []string{"Microsoft.Web/serverFarms"}},
+			{Name: "GatewaySubnet", CIDR: 27},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "vnet.bicep")
+	if err := ExportBicep(results, networks, path); err != nil {
+		t.Fatalf("ExportBicep() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	for _, want := range []string{
+		"resource vnet0 'Microsoft.Network/virtualNetworks@",
+		"name: 'vnet-10-20-0-0-24'",
+		"'10.20.0.0/24'",
+		"name: 'App-Service'",
+		"addressPrefix: '10.20.0.0/26'",
+		"serviceName: 'Microsoft.Web/serverFarms'",
+		"name: 'GatewaySubnet'",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Bicep output missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestExportBicep_RejectsTinySubnets(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "P2P", CIDR: 31}}}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+	if err := ExportBicep(results, networks, filepath.Join(t.TempDir(), "x.bicep")); err == nil {
+		t.Error("expected error for subnet smaller than /29")
+	}
+}
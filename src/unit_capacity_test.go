@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckCapacity(t *testing.T) {
+	networks := []Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "A", CIDR: 25}}},                                           // 50%
+		{Network: "10.0.1.0/24", Subnets: []Subnet{{Name: "B", CIDR: 25}, {Name: "C", CIDR: 26}}},                    // 75%
+		{Network: "10.0.2.0/24", CapacityError: 70, Subnets: []Subnet{{Name: "D", CIDR: 25}, {Name: "E", CIDR: 26}}}, // 75%, stricter
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := CheckCapacity(networks, results, 60, 95)
+	want := []string{"ok", "warning", "error"}
+	for i, s := range statuses {
+		if s.Level != want[i] {
+			t.Errorf("%s at %.1f%%: level %s, want %s", s.Network, s.Utilization, s.Level, want[i])
+		}
+	}
+	if statuses[1].Used != 192 || statuses[1].Total != 256 {
+		t.Errorf("unexpected usage for %s: %d/%d", statuses[1].Network, statuses[1].Used, statuses[1].Total)
+	}
+	if capacityLevel(statuses) != "error" || capacityLevel(statuses[:2]) != "warning" {
+		t.Error("capacityLevel should report the most severe level")
+	}
+	if got := CheckCapacity(networks, results, 0, 0); capacityLevel(got) != "error" {
+		t.Error("per-network threshold should apply without global thresholds")
+	}
+}
+
+func TestPostCapacityWebhook(t *testing.T) {
+	var got capacityWebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	statuses := []CapacityStatus{
+		{Network: "10.0.0.0/24", Level: "ok"},
+		{Network: "10.0.1.0/24", Level: "warning", Utilization: 85, WarnAt: 80},
+	}
+	if err := postCapacityWebhook(srv.URL, statuses); err != nil {
+		t.Fatalf("postCapacityWebhook: %v", err)
+	}
+	if got.Level != "warning" || len(got.Networks) != 1 || got.Networks[0].Network != "10.0.1.0/24" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	if err := postCapacityWebhook(failing.URL, statuses); err == nil {
+		t.Error("expected an error for a failing webhook")
+	}
+}
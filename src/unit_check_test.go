@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		code   int
+		want   string // substring of the first problem
+	}{
+		{"valid", `{"network": "10.0.0.0/24", "subnets": [{"name": "A", "vlan": 10, "hosts": 20}]}`, 0, ""},
+		{"schema", `{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": "26"}]}`, exitConfigError, "cidr"},
+		{"duplicate name", `{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": 26}, {"name": "A", "cidr": 26}]}`, exitValidationError, `subnet name "A"`},
+		{"duplicate VLAN", `{"network": "10.0.0.0/24", "subnets": [{"name": "A", "vlan": 5, "cidr": 26}, {"name": "B", "vlan": 5, "cidr": 26}]}`, exitValidationError, "VLAN 5 is used by A and B"},
+		{"overlap", `[{"network": "10.0.0.0/16", "subnets": [{"name": "A", "cidr": 24}]}, {"network": "10.0.4.0/24", "subnets": [{"name": "B", "cidr": 26}]}]`, exitValidationError, "10.0.4.0/24 overlaps 10.0.0.0/16"},
+		{"capacity", `{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": 24}, {"name": "B", "cidr": 26}]}`, exitCapacityExceeded, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := checkConfig("plan.json", []byte(tt.config), "")
+			if res.Code != tt.code {
+				t.Fatalf("code = %d, want %d (problems %v)", res.Code, tt.code, res.Problems)
+			}
+			if tt.code != 0 && len(res.Problems) == 0 {
+				t.Fatal("no problems reported")
+			}
+			if tt.want != "" && !strings.Contains(res.Problems[0], tt.want) {
+				t.Errorf("problem = %q, want it to mention %q", res.Problems[0], tt.want)
+			}
+		})
+	}
+}
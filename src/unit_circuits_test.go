@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCircuitPlanAndSheet(t *testing.T) {
+	cfg, err := loadConfig([]byte(`{"circuits": [
+		{"name": "HQ", "id": "ACME-1", "provider": "Acme", "block": "203.0.113.8"},
+		{"name": "Branch", "block": "198.51.100.4/30"}
+	]}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Networks) != 2 || cfg.Networks[0].Network != "203.0.113.8/29" {
+		t.Fatalf("circuit networks = %+v, want a /29 by default", cfg.Networks)
+	}
+	results, err := PlanSubnets(cfg.Networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "circuits.csv")
+	if err := ExportCircuits(cfg.Circuits, results, path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"Circuit,CircuitID,Provider,Block,Mask,VLAN,PE,CE,Usable,Broadcast",
+		"HQ,ACME-1,Acme,203.0.113.8/29,255.255.255.248,,203.0.113.9,203.0.113.10,203.0.113.11 - 203.0.113.14,203.0.113.15",
+		"Branch,,,198.51.100.4/30,255.255.255.252,,198.51.100.5,198.51.100.6,,198.51.100.7",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("sheet:\n%s", data)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBlockValidation(t *testing.T) {
+	for _, block := range []string{"", "203.0.113.9/29", "203.0.113.8/31", "bogus"} {
+		if _, err := circuitBlock(Circuit{Name: "c", Block: block}); err == nil {
+			t.Errorf("expected an error for block %q", block)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig_Templates(t *testing.T) {
+	data := []byte(`{
+		"templates": {
+			"torPair": [
+				{"Name": "Gateway", "Position": 1},
+				{"Name": "TOR1", "Position": -2},
+				{"Name": "TOR2", "Position": -3}
+			]
+		},
+		"networks": [{
+			"network": "10.0.0.0/24",
+			"subnets": [
+				{"name": "Rack1", "cidr": 27, "template": "torPair"},
+				{"name": "Rack2", "cidr": 27, "template": "torPair", "IPAssignments": [{"Name": "Gateway", "Position": 30}]}
+			]
+		}]
+	}`)
+	cfg, err := loadConfig(data, "")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	rack1, rack2 := cfg.Networks[0].Subnets[0], cfg.Networks[0].Subnets[1]
+	if len(rack1.IPAssignments) != 3 || rack1.Template != "" {
+		t.Errorf("Rack1 not expanded: %+v", rack1)
+	}
+	if len(rack2.IPAssignments) != 3 {
+		t.Fatalf("Rack2 should have 3 assignments, got %+v", rack2.IPAssignments)
+	}
+	for _, a := range rack2.IPAssignments {
+		if a.Name == "Gateway" && a.Position != 30 {
+			t.Errorf("subnet assignment should override template, got Gateway@%d", a.Position)
+		}
+	}
+	if _, err := PlanSubnets(cfg.Networks); err != nil {
+		t.Errorf("expanded config should plan: %v", err)
+	}
+}
+
+func TestParseConfig_UnknownTemplate(t *testing.T) {
+	for _, data := range []string{
+		`{"templates": {}, "networks": [{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": 26, "template": "nope"}]}]}`,
+		`{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": 26, "template": "nope"}]}`,
+	} {
+		if _, err := loadConfig([]byte(data), ""); err == nil || !strings.Contains(err.Error(), `unknown template "nope"`) {
+			t.Errorf("expected unknown template error, got %v", err)
+		}
+	}
+}
+
+func TestValidateConfigSchema(t *testing.T) {
+	data := []byte(`{"network": "10.0.0.0/24",
+ "subnets": [
+  {"name": "a", "vlan": "100", "hostz": 5},
+  {"Name": "b", "cidr": 40, "IPAssignments": [{"name": "gw", "position": 1.5}], "_note": "ok"}
+ ]
+}`)
+	unknown, err := validateConfigSchema(data)
+	schemaErr, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	want := []string{
+		`line 3, column 25: subnets[0].vlan: expected integer, got string "100" (remove the quotes)`,
+		`line 4, column 25: subnets[1].cidr: 40 is above the maximum of 32`,
+		`line 4, column 74: subnets[1].IPAssignments[0].position: expected integer or string, got number`,
+	}
+	if strings.Join(schemaErr.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(schemaErr.Problems, "\n"), strings.Join(want, "\n"))
+	}
+	if len(unknown) != 1 || unknown[0] != `line 3, column 32: subnets[0].hostz: unknown field "hostz"` {
+		t.Errorf("unknown fields = %q", unknown)
+	}
+
+	// The wrapped form is chosen when its keys match better
+	_, err = validateConfigSchema([]byte(`{"networks": [], "outputOrder": "size"}`))
+	if err == nil || !strings.Contains(err.Error(), "outputOrder: must be one of input, address, name, vlan") {
+		t.Errorf("wrapped config: err = %v", err)
+	}
+
+	_, err = validateConfigSchema([]byte("{\n  \"network\": \"10.0.0.0/24\",\n  \"subnets\": [}"))
+	if err == nil || !strings.Contains(err.Error(), "line 3, column 15") {
+		t.Errorf("syntax error: err = %v", err)
+	}
+
+	for _, path := range []string{"../examples/simple.json", "../examples/advanced.json", "../examples/multi-network.json", "../examples/multi-network2.json"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if unknown, err := validateConfigSchema(data); err != nil || len(unknown) > 0 {
+			t.Errorf("%s: %v %q", path, err, unknown)
+		}
+	}
+}
+
+func TestNormalizeParents(t *testing.T) {
+	networks := []Network{{Network: "192.168.1.10/24"}, {Network: "10.0.0.0/16"}, {Network: "bad"}}
+	warnings := normalizeParents(networks)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "192.168.1.10/24 has host bits set (network address 192.168.1.0/24)") {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if networks[0].Network != "192.168.1.0/24" || networks[1].Network != "10.0.0.0/16" || networks[2].Network != "bad" {
+		t.Errorf("networks = %+v", networks)
+	}
+}
+
+func TestOutputFlags(t *testing.T) {
+	values, err := outputFlags([]Output{
+		{Format: "JSON", Path: "out/plan.json"},
+		{Format: "dns", Path: "/srv/zones/db.corp", Options: map[string]string{"domain": "corp.example.com"}},
+		{Format: "switch", Path: "sw.cfg", Options: map[string]string{"dialect": "ios"}},
+	}, "configs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"exportjson":   filepath.Join("configs", "out", "plan.json"),
+		"exportdns":    "/srv/zones/db.corp",
+		"dnsdomain":    "corp.example.com",
+		"switchfile":   filepath.Join("configs", "sw.cfg"),
+		"exportswitch": "ios",
+	}
+	if len(values) != len(want) {
+		t.Errorf("values = %v", values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s = %q, want %q", k, values[k], v)
+		}
+	}
+
+	for _, tc := range []struct {
+		outputs []Output
+		want    string
+	}{
+		{[]Output{{Format: "xlsx", Path: "a"}}, "unknown format"},
+		{[]Output{{Format: "csv", Path: "a"}, {Format: "csv", Path: "b"}}, "more than once"},
+		{[]Output{{Format: "csv"}}, "needs a \"path\""},
+		{[]Output{{Format: "switch", Path: "a"}}, "dialect"},
+		{[]Output{{Format: "csv", Path: "a", Options: map[string]string{"delimiter": ";"}}}, "unknown option"},
+	} {
+		if _, err := outputFlags(tc.outputs, "."); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: err = %v, want %q", tc.outputs, err, tc.want)
+		}
+	}
+}
+
+func TestLoadConfigJSONC(t *testing.T) {
+	jsonc := "\xEF\xBB\xBF" + `{
+  // Site A
+  "network": "10.0.0.0/24", /* parent */
+  "subnets": [
+    {"name": "Web // frontend", "hosts": 50, "description": "Caf` + "\xE9" + `"},
+    {"name": "App", "cidr": 28,}, // trailing comma
+  ],
+}`
+	// The description is Windows-1252, so the file is not valid UTF-8
+	cfg, err := loadConfig([]byte(jsonc), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subnets := cfg.Networks[0].Subnets
+	if len(subnets) != 2 || subnets[0].Name != "Web // frontend" || subnets[0].Description != "Café" || subnets[1].CIDR != 28 {
+		t.Errorf("subnets = %+v", subnets)
+	}
+
+	out, stripped := stripJSONC([]byte("{\"a\": 1, /* x */\n}"))
+	if !stripped || string(out) != "{\"a\": 1         \n}" {
+		t.Errorf("stripJSONC = %q, %v; positions must be kept", out, stripped)
+	}
+
+	utf16 := []byte{0xFF, 0xFE}
+	for _, r := range `[{"network": "10.0.0.0/24", "subnets": []}]` {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	if cfg, err := loadConfig(utf16, ""); err != nil || cfg.Networks[0].Network != "10.0.0.0/24" {
+		t.Errorf("UTF-16 config = %+v, %v", cfg, err)
+	}
+}
+
+func TestParseCSVConfig(t *testing.T) {
+	csvData := "Network,Name,VLAN,Hosts/CIDR,Assignments,Description\n" +
+		"10.0.0.0/24,Web,100,50,Gateway=1; Pool=10-20 dhcp; Last=-1,Front end\n" +
+		",App,110,/27,,see http://wiki/app\n" +
+		"\n" +
+		"10.1.0.0/24,DB,,/28,,\n"
+	cfg, err := loadConfigFile("plan.CSV", []byte(csvData), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Networks) != 2 || len(cfg.Networks[0].Subnets) != 2 || cfg.Networks[1].Network != "10.1.0.0/24" {
+		t.Fatalf("networks = %+v", cfg.Networks)
+	}
+	web, app := cfg.Networks[0].Subnets[0], cfg.Networks[0].Subnets[1]
+	if web.Hosts != 50 || web.VLAN != 100 || web.Description != "Front end" || app.CIDR != 27 || app.Description != "see http://wiki/app" {
+		t.Errorf("subnets = %+v, %+v", web, app)
+	}
+	want := []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "Pool", Position: 10, EndPosition: 20, DHCP: true}, {Name: "Last", Position: -1}}
+	if !reflect.DeepEqual(web.IPAssignments, want) {
+		t.Errorf("assignments = %+v, want %+v", web.IPAssignments, want)
+	}
+
+	for _, bad := range []string{
+		"Network,VLAN\n10.0.0.0/24,1\n",
+		"Name,Hosts\nWeb,10\n",
+		"Network,Name,Hosts\n10.0.0.0/24,Web,many\n",
+		"Network,Name,Assignments\n10.0.0.0/24,Web,Gateway\n",
+	} {
+		if _, err := parseCSVConfig([]byte(bad)); err == nil {
+			t.Errorf("parseCSVConfig(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	networks := []Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{
+			{Name: "Users", VLAN: 10, Hosts: 100, Description: "Staff", IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1},
+				{Name: "Pool", Position: 10, EndPosition: 90, DHCP: true},
+				{Name: "Hold", Position: 100, Count: 4, Reserved: true},
+			}},
+			{Name: "Servers", CIDR: 28, Tags: map[string]string{"env": "prod"}},
+		}},
+		{Network: "10.1.0.0/25", Subnets: []Subnet{{Name: "Lab", CIDR: 26}}},
+	}
+	plan, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(plan)
+	if !isPlanJSON(data) {
+		t.Fatal("exported plan not recognized as a plan")
+	}
+	cfg, err := loadConfigFile("plan.json", data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Networks[0].Subnets[0].Address != "10.0.0.0/25" {
+		t.Errorf("subnet not pinned to its address: %+v", cfg.Networks[0].Subnets[0])
+	}
+	replanned, err := PlanSubnets(cfg.Networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan, replanned) {
+		t.Errorf("replanning the imported plan changed it:\n%+v\nwant\n%+v", replanned, plan)
+	}
+
+	if isPlanJSON([]byte(`[{"network": "10.0.0.0/24", "subnets": []}]`)) {
+		t.Error("config array recognized as a plan")
+	}
+}
+
+func TestHostExpressions(t *testing.T) {
+	vars := map[string]float64{"nodes": 12, "racks": 3}
+	for expr, want := range map[string]float64{
+		"nodes*3+10":              46,
+		"(nodes + 2) * racks":     42,
+		"-2 + nodes / 5":          0.4,
+		"max(nodes, 20) % 7":      6,
+		"ceil(nodes / 5) * 10":    30,
+		"min(racks, 2, floor(9))": 2,
+	} {
+		got, err := evalExpr(expr, vars)
+		if err != nil || math.Abs(got-want) > 1e-9 {
+			t.Errorf("evalExpr(%q) = %v, %v; want %v", expr, got, err, want)
+		}
+	}
+	for _, expr := range []string{"nodes *", "pods*2", "nodes/0", "sqrt(4)", "(nodes", "nodes 3"} {
+		if _, err := evalExpr(expr, vars); err == nil {
+			t.Errorf("evalExpr(%q) succeeded, want error", expr)
+		}
+	}
+
+	cfg, err := loadConfig([]byte(`{"variables": {"nodes": 10, "vms": 4}, "networks": [
+		{"network": "10.0.0.0/22", "variables": {"nodes": 20}, "subnets": [
+			{"name": "Nodes", "hosts": "nodes*vms+10"},
+			{"name": "Mgmt", "hosts": "8"}
+		]}]}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := cfg.Networks[0].Subnets[0]; s.HostsExpr != "nodes*vms+10" || s.Hosts != 0 {
+		t.Errorf("loaded subnet = %+v, want an unresolved expression", s)
+	}
+	if s := cfg.Networks[0].Subnets[1]; s.Hosts != 8 || s.HostsExpr != "" {
+		t.Errorf("numeric string hosts = %+v, want 8", s)
+	}
+	resolved, err := resolveHosts(cfg.Networks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resolved[0].Subnets[0].Hosts; got != 90 {
+		t.Errorf("network variables: hosts = %d, want 90", got)
+	}
+	if cfg.Networks[0].Subnets[0].HostsExpr == "" {
+		t.Error("resolveHosts modified its input")
+	}
+	resolved, _ = resolveHosts(cfg.Networks, map[string]float64{"vms": 2.5})
+	if got := resolved[0].Subnets[0].Hosts; got != 60 {
+		t.Errorf("-var override: hosts = %d, want 60", got)
+	}
+	if _, err := (Planner{}).Plan([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "A", HostsExpr: "pods"}}}}); err == nil || !strings.Contains(err.Error(), `unknown variable "pods"`) {
+		t.Errorf("Plan() error = %v, want unknown variable", err)
+	}
+
+	// Expressions survive re-encoding, e.g. by fmt
+	data, _ := json.Marshal(cfg.Networks[0].Subnets[0])
+	if !strings.Contains(string(data), `"hosts":"nodes*vms+10"`) {
+		t.Errorf("encoded subnet = %s", data)
+	}
+	networks, err := parseCSVConfig([]byte("Network,Name,Hosts\n10.0.0.0/24,Web,=nodes*2\n"))
+	if err != nil || networks[0].Subnets[0].HostsExpr != "nodes*2" {
+		t.Errorf("CSV formula = %+v, %v", networks, err)
+	}
+}
+
+func TestLoadConfigEnvironments(t *testing.T) {
+	data := []byte(`{
+		"templates": {"std": [{"name": "Gateway", "position": 1}]},
+		"networks": [{"network": "10.255.0.0/24", "subnets": [{"name": "Shared", "cidr": 26}]}],
+		"environments": {
+			"prod": [{"network": "10.1.0.0/24", "subnets": [{"name": "Web", "cidr": 26, "template": "std"}]}],
+			"dr": [{"network": "10.2.0.0/24", "subnets": [{"name": "Web", "cidr": 26, "template": "std"}]}]
+		}
+	}`)
+	cfg, err := loadConfig(data, "dr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Networks) != 2 || cfg.Networks[1].Network != "10.2.0.0/24" || len(cfg.Networks[1].Subnets[0].IPAssignments) != 1 {
+		t.Errorf("dr networks = %+v, want the shared network and dr's with the template applied", cfg.Networks)
+	}
+
+	for env, want := range map[string]string{
+		"":     "select one with -env",
+		"test": `unknown environment "test" (the config has dr, prod)`,
+	} {
+		if _, err := loadConfig(data, env); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("-env %q: error = %v, want %q", env, err, want)
+		}
+	}
+	if _, err := loadConfig([]byte(`{"network": "10.0.0.0/24", "subnets": []}`), "prod"); err == nil {
+		t.Error("expected an error for -env with a config without environments")
+	}
+
+	res := checkEnvironments("plan.json", data, "")
+	if res.Code != 0 || len(res.Problems) != 0 {
+		t.Errorf("check of every environment = %+v", res)
+	}
+}
+
+func TestSubstituteVars(t *testing.T) {
+	varsFile := filepath.Join(t.TempDir(), "site.vars")
+	if err := os.WriteFile(varsFile, []byte("# site\nSITE=east\nSITE_PREFIX=\"10.30\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vars := &varFlag{file: varsFile}
+	if err := vars.Set("SITE_PREFIX=10.20"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SITE_OWNER", `ops "east"`)
+
+	data := []byte(`{"network": "${SITE_PREFIX}.0.0/16", // ${NOT_A_REFERENCE}
+		"subnets": [{"name": "${SITE}-Web", "hosts": "${HOSTS:-50}", "vlan": "${VLAN:-12}",
+		"owner": "${SITE_OWNER}", "description": "$${KEPT}", "${KEY}": 1}]}`)
+	out, err := substituteVars("site.json", data, vars)
+	if err != nil {
+		t.Fatalf("substituteVars: %v", err)
+	}
+	if !strings.Contains(string(out), `"${KEY}"`) {
+		t.Errorf("object keys should not be substituted: %s", out)
+	}
+	out = bytes.Replace(out, []byte(`, "${KEY}": 1`), nil, 1)
+	cfg, err := loadConfig(out, "")
+	if err != nil {
+		t.Fatalf("loadConfig(%s): %v", out, err)
+	}
+	networks := cfg.Networks
+	s := networks[0].Subnets[0]
+	if networks[0].Network != "10.20.0.0/16" || s.Name != "east-Web" || s.Hosts != 50 || s.VLAN != 12 || s.Owner != `ops "east"` || s.Description != "${KEPT}" {
+		t.Errorf("substituted config = %s", out)
+	}
+
+	if _, err := substituteVars("site.json", []byte(`{"network": "${MISSING_B}/${MISSING_A}"}`), vars); err == nil || !strings.Contains(err.Error(), "MISSING_A, MISSING_B") {
+		t.Errorf("expected undefined variables error, got %v", err)
+	}
+
+	// Without -var, -var-file or "variables", ${...} is ordinary text
+	plain := []byte(`{"network": "10.0.0.0/24", "subnets": [{"name": "${literal}", "hosts": 10}]}`)
+	if out, err := substituteVars("site.json", plain, &varFlag{}); err != nil || !bytes.Equal(out, plain) {
+		t.Errorf("substituteVars() without variables = %s, %v", out, err)
+	}
+	declared := []byte(`{"variables": {"HOSTS": 20}, "networks": [{"network": "10.0.0.0/24", "subnets": [{"name": "Web", "hosts": "${HOSTS}"}]}]}`)
+	if out, err := substituteVars("site.json", declared, &varFlag{}); err != nil || !strings.Contains(string(out), `"hosts": 20`) {
+		t.Errorf("substituteVars() with declared variables = %s, %v", out, err)
+	}
+	// Text variables supply references and are left out of host count expressions
+	text := []byte(`{"variables": {"SITE": "east", "nodes": 4}, "network": "10.0.0.0/24", "subnets": [{"name": "${SITE}-users", "hosts": "nodes*2"}]}`)
+	out, err = substituteVars("site.json", text, &varFlag{})
+	if err != nil {
+		t.Fatalf("substituteVars() with a text variable: %v", err)
+	}
+	cfg, err = loadConfig(out, "")
+	if err != nil {
+		t.Fatalf("loadConfig() with a text variable: %v", err)
+	}
+	if n := cfg.Networks[0]; n.Subnets[0].Name != "east-users" || len(n.Variables) != 1 || n.Variables["nodes"] != 4 {
+		t.Errorf("network with a text variable = %+v", n)
+	}
+	if _, err := loadConfig([]byte(`{"variables": {"SITE": true}, "networks": []}`), ""); err == nil || !strings.Contains(err.Error(), "expected number or string") {
+		t.Errorf("expected a schema error for a boolean variable, got %v", err)
+	}
+
+	csvVars := &varFlag{}
+	if err := csvVars.Set(`SITE=east, "1"`); err != nil {
+		t.Fatal(err)
+	}
+	out, err = substituteVars("site.csv", []byte("Network,Name,Hosts\n10.0.0.0/24,${SITE}-Web,10\n"), csvVars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if networks, err := parseCSVConfig(out); err != nil || networks[0].Subnets[0].Name != `east, "1"-Web` {
+		t.Errorf("substituted CSV config = %s (%v)", out, err)
+	}
+}
+
+func TestLoadConfig_UnknownFields(t *testing.T) {
+	data := []byte(`{"network": "10.0.0.0/24", "subnets": [{"name": "a", "hosts": 5, "hostz": 9,
+  "IPAssignments": [{"Name": "gw", "Position": 1, "Postion": 2}]}]}`)
+	cfg, err := loadConfig(data, "")
+	if err != nil {
+		t.Fatalf("unknown fields should not be errors: %v", err)
+	}
+	if s := cfg.Networks[0].Subnets[0]; s.Hosts != 5 || s.IPAssignments[0].Position != 1 {
+		t.Errorf("known fields lost: %+v", s)
+	}
+	if len(cfg.Warnings) != 2 || !strings.Contains(cfg.Warnings[0], `"hostz"`) || !strings.Contains(cfg.Warnings[1], `"Postion"`) {
+		t.Errorf("warnings = %q", cfg.Warnings)
+	}
+
+	res := checkConfig("cfg.json", data, "")
+	if len(res.Problems) != 0 || len(res.Warnings) == 0 {
+		t.Errorf("check should warn about unknown fields: %+v", res)
+	}
+}
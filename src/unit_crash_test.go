@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteDiagnosticBundle(t *testing.T) {
+	crashConfig = []Network{{
+		Network:      "10.0.0.0/24",
+		NameTemplate: "{{.Subnet}}.corp.contoso.com",
+		DNSSuffix:    "contoso.com",
+		Subnets: []Subnet{{
+			Name:          "Payroll",
+			Hosts:         10,
+			Delegations:   []string{"Microsoft.Web/serverFarms"},
+			Fabrics:       []string{"redmond-a"},
+			Template:      "finance-template",
+			IPAssignments: []IPAssignment{{Name: "ceo-laptop", Position: 5}},
+		}},
+	}}
+	defer func() { crashConfig = nil }()
+
+	path, err := writeDiagnosticBundle(t.TempDir(), "boom", []byte("goroutine 1 [running]"))
+	if err != nil {
+		t.Fatalf("writeDiagnosticBundle: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Payroll", "ceo-laptop", "serverFarms", "contoso", "redmond", "finance"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("bundle leaks %q", secret)
+		}
+	}
+	var bundle diagnosticBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("bundle is not JSON: %v", err)
+	}
+	if bundle.Panic != "boom" || bundle.Version != version || bundle.Stack == "" {
+		t.Errorf("unexpected bundle header: %+v", bundle)
+	}
+	s := bundle.Config[0].Subnets[0]
+	if s.Name != "subnet-1-1" || s.Hosts != 10 || s.IPAssignments[0].Position != 5 {
+		t.Errorf("sanitized subnet lost planning data: %+v", s)
+	}
+}
+
+func TestFlagNames(t *testing.T) {
+	got := flagNames([]string{"-input", "secret/plan.json", "--exportcsv=out.csv", "-lock"})
+	want := "input,exportcsv,lock"
+	if strings.Join(got, ",") != want {
+		t.Errorf("flagNames = %v, want %s", got, want)
+	}
+}
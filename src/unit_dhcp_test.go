@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func dhcpTestResults(t *testing.T) []SubnetResult {
+	t.Helper()
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{{
+			Name: "Users",
+			VLAN: 20,
+			CIDR: 24,
+			IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1},
+				{Name: "Pool", Position: 50, EndPosition: 200, DHCP: true},
+				{Name: "Printer", Position: 60},
+			},
+		}},
+	}})
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+	return results
+}
+
+func TestExportDHCP_PowerShell(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dhcp.ps1")
+	if err := ExportDHCP(dhcpTestResults(t), path, ""); err != nil {
+		t.Fatalf("ExportDHCP() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+
+	for _, want := range []string{
+		`Add-DhcpServerv4Scope -Name "Users" -StartRange 10.0.0.50 -EndRange 10.0.0.200 -SubnetMask 255.255.255.0`,
+		"Add-DhcpServerv4ExclusionRange -ScopeId 10.0.0.0 -StartRange 10.0.0.60 -EndRange 10.0.0.60",
+		"Set-DhcpServerv4OptionValue -ScopeId 10.0.0.0 -Router 10.0.0.1",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("PowerShell output missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestExportDHCP_ISC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dhcpd.conf")
+	if err := ExportDHCP(dhcpTestResults(t), path, ""); err != nil {
+		t.Fatalf("ExportDHCP() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+
+	for _, want := range []string{
+		"subnet 10.0.0.0 netmask 255.255.255.0 {",
+		"option routers 10.0.0.1;",
+		"range 10.0.0.50 10.0.0.59;",
+		"range 10.0.0.61 10.0.0.200;",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("dhcpd.conf output missing %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestExportDHCP_UnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dhcp.txt")
+	if err := ExportDHCP(dhcpTestResults(t), path, "kea"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffPlans(t *testing.T) {
+	previous, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "LB", Position: 10}}},
+			{Name: "App", CIDR: 27},
+			{Name: "Legacy", CIDR: 28},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+	current, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "LB", Position: 11}, {Name: "DNS", Position: 2}}},
+			{Name: "App", CIDR: 26},
+			{Name: "DB", CIDR: 27},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+
+	d := DiffPlans(previous, current)
+
+	if len(d.Added) != 1 || d.Added[0].Name != "DB" {
+		t.Errorf("Added = %+v, want DB", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "Legacy" {
+		t.Errorf("Removed = %+v, want Legacy", d.Removed)
+	}
+	if len(d.Resized) != 1 || d.Resized[0].Name != "App" || d.Resized[0].OldSubnet != "10.0.0.64/27" {
+		t.Errorf("Resized = %+v, want App from 10.0.0.64/27", d.Resized)
+	}
+	if len(d.Moved) != 0 {
+		t.Errorf("Moved = %+v, want none", d.Moved)
+	}
+
+	changes := make(map[string]AssignmentChange)
+	for _, c := range d.Assignments {
+		changes[c.Label] = c
+	}
+	if c, ok := changes["LB"]; !ok || c.OldIP != "10.0.0.10" || c.NewIP != "10.0.0.11" {
+		t.Errorf("LB change = %+v, want 10.0.0.10 -> 10.0.0.11", c)
+	}
+	if c, ok := changes["DNS"]; !ok || c.OldIP != "" || c.NewIP != "10.0.0.2" {
+		t.Errorf("DNS change = %+v, want added at 10.0.0.2", c)
+	}
+	if _, ok := changes["Gateway"]; ok {
+		t.Error("unchanged Gateway should not be reported")
+	}
+}
+
+func TestDiffPlans_MovedSubnet(t *testing.T) {
+	previous := []SubnetResult{{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Category: "Network"}}
+	current := []SubnetResult{{Name: "Web", Subnet: "10.0.0.64/26", Prefix: 26, Category: "Network"}}
+
+	d := DiffPlans(previous, current)
+	if len(d.Moved) != 1 || d.Moved[0].NewSubnet != "10.0.0.64/26" {
+		t.Errorf("Moved = %+v, want Web moved to 10.0.0.64/26", d.Moved)
+	}
+	if DiffPlans(current, current).HasChanges() {
+		t.Error("identical plans should have no changes")
+	}
+}
+
+func TestDiffPlans_SameNameInTwoParents(t *testing.T) {
+	plan := func(siteB string) []SubnetResult {
+		results, err := PlanSubnets([]Network{
+			{Network: "10.1.0.0/24", Subnets: []Subnet{{Name: "Mgmt", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}}},
+			{Network: "10.2.0.0/24", Subnets: []Subnet{{Name: "Mgmt", CIDR: 26, Address: siteB, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return results
+	}
+	previous, current := plan(""), plan("10.2.0.128/26")
+
+	d := DiffPlans(previous, current)
+	if len(d.Moved) != 1 || d.Moved[0].OldSubnet != "10.2.0.0/26" || d.Moved[0].NewSubnet != "10.2.0.128/26" {
+		t.Errorf("Moved = %+v, want only the second Mgmt moved", d.Moved)
+	}
+	if len(d.Assignments) != 1 || d.Assignments[0].NewIP != "10.2.0.129" {
+		t.Errorf("Assignments = %+v, want only the second Mgmt's gateway", d.Assignments)
+	}
+	for _, r := range DiffRows(previous, current) {
+		if r.Parent != "10.2.0.0/24" {
+			t.Errorf("unchanged subnet reported: %+v", r)
+		}
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	previous := []SubnetResult{
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "Network", IP: "10.0.0.0", Category: "Network", TotalIPs: 1},
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "Gateway", IP: "10.0.0.1", Category: "Assignment", TotalIPs: 1},
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "LB", IP: "10.0.0.10", Category: "Assignment", TotalIPs: 1},
+	}
+	current := []SubnetResult{
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "Network", IP: "10.0.0.0", Category: "Network", TotalIPs: 1},
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "Gateway", IP: "10.0.0.2", Category: "Assignment", TotalIPs: 1},
+		{Name: "Web", Subnet: "10.0.0.0/26", Prefix: 26, Label: "DNS", IP: "10.0.0.3", Category: "Assignment", TotalIPs: 1},
+	}
+
+	rows := DiffRows(previous, current)
+	got := make(map[string]string)
+	for _, r := range rows {
+		got[r.Label] = r.Change
+	}
+	want := map[string]string{"Gateway": "changed", "DNS": "added", "LB": "removed"}
+	if len(got) != len(want) {
+		t.Errorf("DiffRows() returned %d rows, want %d: %+v", len(got), len(want), rows)
+	}
+	for label, change := range want {
+		if got[label] != change {
+			t.Errorf("%s change = %q, want %q", label, got[label], change)
+		}
+	}
+}
+
+func TestExportCSV_ChangeColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "changes.csv")
+	rows := []SubnetResult{{Name: "Web", Subnet: "10.0.0.0/26", Label: "DNS", Category: "Assignment", Change: "added"}}
+	if err := ExportCSV(rows, path); err != nil {
+		t.Fatalf("ExportCSV() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.HasSuffix(lines[0], ",Change") || !strings.HasSuffix(lines[1], ",added") {
+		t.Errorf("expected trailing Change column, got:\n%s", data)
+	}
+}
+
+func TestExportTicket(t *testing.T) {
+	d := PlanDiff{
+		Added:       []SubnetChange{{Name: "IoT", NewSubnet: "10.0.0.128/26"}},
+		Moved:       []SubnetChange{{Name: "Web", OldSubnet: "10.0.0.0/26", NewSubnet: "10.0.0.64/26"}},
+		Assignments: []AssignmentChange{{Subnet: "Web", Label: "Gateway", OldIP: "10.0.0.1", NewIP: "10.0.0.65"}},
+	}
+	dir := t.TempDir()
+
+	jira := filepath.Join(dir, "ticket.txt")
+	if err := ExportTicket(d, "old.json", jira, ""); err != nil {
+		t.Fatalf("ExportTicket(jira) error = %v", err)
+	}
+	data, _ := os.ReadFile(jira)
+	for _, want := range []string{
+		"h2. Summary\nIP plan change: 1 added, 0 removed, 0 resized, 1 moved subnet(s); 1 IP assignment change(s)",
+		"||Subnet||Change||Before||After||\n|IoT|Added| |10.0.0.128/26|\n|Web|Moved|10.0.0.0/26|10.0.0.64/26|",
+		"|Web / Gateway|Changed|10.0.0.1|10.0.0.65|",
+		"# Remove subnet IoT (10.0.0.128/26)\n# Move subnet Web back from 10.0.0.64/26 to 10.0.0.0/26\n# Re-assign Web / Gateway from 10.0.0.65 back to 10.0.0.1",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Jira ticket missing %q:\n%s", want, data)
+		}
+	}
+
+	snow := filepath.Join(dir, "ticket.html")
+	if err := ExportTicket(d, "old<1>.json", snow, ""); err != nil {
+		t.Fatalf("ExportTicket(servicenow) error = %v", err)
+	}
+	data, _ = os.ReadFile(snow)
+	for _, want := range []string{"<h2>Affected Subnets</h2>", "<tr><td>Web</td><td>Moved</td><td>10.0.0.0/26</td><td>10.0.0.64/26</td></tr>", "<code>old&lt;1&gt;.json</code>", "<li>Remove subnet IoT (10.0.0.128/26)</li>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("ServiceNow ticket missing %q:\n%s", want, data)
+		}
+	}
+
+	if err := ExportTicket(d, "old.json", jira, "remedy"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
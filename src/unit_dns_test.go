@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReverseZoneFor(t *testing.T) {
+	ip := func(s string) uint32 { n, _, _ := parseIPSpan(s); return n }
+	tests := []struct {
+		ip          string
+		prefix      int
+		zone, owner string
+	}{
+		{"10.1.2.3", 24, "2.1.10.in-addr.arpa", "3"},
+		{"10.1.6.3", 22, "6.1.10.in-addr.arpa", "3"},
+		{"10.1.6.3", 16, "1.10.in-addr.arpa", "3.6"},
+		{"10.1.2.70", 26, "64-26.2.1.10.in-addr.arpa", "70"},
+		{"10.1.2.129", 25, "128-25.2.1.10.in-addr.arpa", "129"},
+	}
+	for _, tt := range tests {
+		zone, owner, _ := reverseZoneFor(ip(tt.ip), tt.prefix)
+		if zone != tt.zone || owner != tt.owner {
+			t.Errorf("reverseZoneFor(%s/%d) = %s %s, want %s %s", tt.ip, tt.prefix, zone, owner, tt.zone, tt.owner)
+		}
+	}
+}
+
+func TestExportDNS(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/23",
+		Subnets: []Subnet{
+			{Name: "Servers", CIDR: 24, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "Mail Server", Position: 25}, {Name: "Pool", Position: 100, Count: 10}}},
+			{Name: "Mgmt", CIDR: 27, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.corp.example.com")
+	if err := ExportDNS(results, path, "corp.example.com.", ""); err != nil {
+		t.Fatalf("ExportDNS() error = %v", err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("missing zone file %s: %v", name, err)
+		}
+		return string(data)
+	}
+	forward := read("db.corp.example.com")
+	for _, want := range []string{"$ORIGIN corp.example.com.", "servers-gateway ", "IN A     10.0.0.1\n", "mail-server ", "IN A     10.0.0.25\n", "mgmt-gateway "} {
+		if !strings.Contains(forward, want) {
+			t.Errorf("forward zone missing %q:\n%s", want, forward)
+		}
+	}
+	if strings.Contains(forward, "pool") {
+		t.Errorf("address blocks should not get records:\n%s", forward)
+	}
+	if r := read("db.0.0.10.in-addr.arpa"); !strings.Contains(r, "IN PTR   mail-server.corp.example.com.") {
+		t.Errorf("classful reverse zone missing PTR:\n%s", r)
+	}
+	if r := read("db.0-27.1.0.10.in-addr.arpa"); !strings.Contains(r, "IN PTR   mgmt-gateway.corp.example.com.") || !strings.Contains(r, "RFC 2317") {
+		t.Errorf("classless reverse zone missing PTR:\n%s", r)
+	}
+
+	if err := ExportDNS(results, path, "", ""); err == nil {
+		t.Error("expected an error without a domain")
+	}
+}
+
+func TestExportDNS_PowerShell(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{{Name: "Mgmt", CIDR: 27, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "VMM", Position: 10}}}},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dns.ps1")
+	if err := ExportDNS(results, path, "corp.example.com", ""); err != nil {
+		t.Fatalf("ExportDNS() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{
+		`$ZoneName = "corp.example.com"`,
+		`Add-DnsServerResourceRecordA -ZoneName $ZoneName -Name "vmm" -IPv4Address 10.0.0.10`,
+		`Add-DnsServerPrimaryZone -NetworkId "10.0.0.0/24"`,
+		`Add-DnsServerResourceRecordPtr -ZoneName "0.0.10.in-addr.arpa" -Name "1" -PtrDomainName "gateway.corp.example.com"`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("PowerShell export missing %q:\n%s", want, data)
+		}
+	}
+	if err := ExportDNS(results, path, "corp.example.com", "tinydns"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPlanner_Hostnames(t *testing.T) {
+	network := Network{
+		Network:      "10.0.0.0/24",
+		NameTemplate: "{{.Label}}{{.Index}}.{{.Subnet}}",
+		DNSSuffix:    "corp.example.com.",
+		Subnets: []Subnet{{Name: "Web Tier", CIDR: 28, IPAssignments: []IPAssignment{
+			{Name: "Gateway", Position: 1},
+			{Name: "LB_VIP", Position: 4},
+			{Name: "Pool", Position: 8, Count: 4},
+		}}},
+	}
+	results, err := PlanSubnets([]Network{network})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fqdns := make(map[string]string)
+	for _, r := range results {
+		if r.FQDN != "" {
+			fqdns[r.Label] = r.FQDN
+		}
+	}
+	want := map[string]string{"Gateway": "gateway1.web-tier.corp.example.com", "LB_VIP": "lb-vip2.web-tier.corp.example.com"}
+	if len(fqdns) != len(want) || fqdns["Gateway"] != want["Gateway"] || fqdns["LB_VIP"] != want["LB_VIP"] {
+		t.Errorf("FQDNs = %v, want %v", fqdns, want)
+	}
+
+	records := dnsRecords(results, "corp.example.com")
+	if len(records) != 2 || records[0].host != "gateway1.web-tier" {
+		t.Errorf("DNS records = %+v", records)
+	}
+
+	network.DNSSuffix = strings.Repeat("a.", 120) + "example.com"
+	if _, err := PlanSubnets([]Network{network}); err == nil || !strings.Contains(err.Error(), "longer than 253 characters") {
+		t.Errorf("FQDN over 253 characters: err = %v", err)
+	}
+	network.DNSSuffix = strings.Repeat("a", 64) + ".example.com"
+	if _, err := PlanSubnets([]Network{network}); err == nil || !strings.Contains(err.Error(), "label longer than 63") {
+		t.Errorf("suffix label over 63 characters: err = %v", err)
+	}
+
+	network.NameTemplate = "{{.Nope}}"
+	if _, err := PlanSubnets([]Network{network}); err == nil || !strings.Contains(err.Error(), "nameTemplate") {
+		t.Errorf("unknown template field: err = %v", err)
+	}
+}
+
+func TestDNSNameIssues(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Servers", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Web Server", Position: 10}, {Name: "db01", Position: 11}, {Name: "DB01", Position: 12}}},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixes, conflicts := dnsNameIssues(dnsRecords(results, "corp.example.com"), "corp.example.com")
+	if len(fixes) != 1 || !strings.Contains(fixes[0], `"Web Server" is not a valid DNS label; published as "web-server"`) {
+		t.Errorf("fixes = %q", fixes)
+	}
+	if len(conflicts) != 1 || !strings.Contains(conflicts[0], "servers-db01 is used by") || !strings.Contains(conflicts[0], "10.0.0.12") {
+		t.Errorf("conflicts = %q", conflicts)
+	}
+
+	long := strings.Repeat("x.", 125) + "example.com"
+	if _, conflicts := dnsNameIssues([]dnsRecord{{host: "web", label: "web", subnet: "Servers"}}, long); len(conflicts) != 1 || !strings.Contains(conflicts[0], "longer than 253 characters") {
+		t.Errorf("conflicts for a long FQDN = %q", conflicts)
+	}
+
+	path := filepath.Join(t.TempDir(), "db.corp.example.com")
+	if err := ExportDNS(results, path, "corp.example.com", ""); err == nil || !strings.Contains(err.Error(), "not unique") {
+		t.Errorf("ExportDNS() error = %v, want a uniqueness error", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("no zone file should be written when names clash")
+	}
+}
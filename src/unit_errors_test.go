@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestPlanningExitCode(t *testing.T) {
+	_, err := PlanSubnets([]Network{{Network: "10.0.0.0/26", Subnets: []Subnet{{Name: "Big", CIDR: 25}}}})
+	if err == nil {
+		t.Fatal("expected an error for a prefix larger than the parent")
+	}
+	if code := planningExitCode(err); code != exitValidationError {
+		t.Errorf("invalid prefix: exit code %d, want %d", code, exitValidationError)
+	}
+
+	_, err = PlanSubnets([]Network{{Network: "10.0.0.0/26", Subnets: []Subnet{{Name: "A", CIDR: 26}, {Name: "B", CIDR: 27}}}})
+	if err == nil {
+		t.Fatal("expected an error when subnets do not fit")
+	}
+	if code := planningExitCode(err); code != exitCapacityExceeded {
+		t.Errorf("no space: exit code %d, want %d", code, exitCapacityExceeded)
+	}
+	if err.Error() != "error planning network 10.0.0.0/26: subnet B (/27) does not fit in the remaining space of 10.0.0.0/26" {
+		t.Errorf("unexpected message %q", err)
+	}
+}
+
+func TestErrorFormatArg(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "text"},
+		{[]string{"-input", "c.json", "-errors", "json"}, "json"},
+		{[]string{"--errors=json"}, "json"},
+		{[]string{"-input", "errors", "json"}, "text"},
+	}
+	for _, tt := range tests {
+		if got := errorFormatArg(tt.args); got != tt.want {
+			t.Errorf("errorFormatArg(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestErrorCodesNamed(t *testing.T) {
+	for _, code := range []int{exitFailure, exitUsage, exitExportFailure, exitLintScore, exitCapacityThreshold,
+		exitConfigError, exitValidationError, exitCapacityExceeded, exitPanic} {
+		if errorCodes[code] == "" {
+			t.Errorf("exit code %d has no name", code)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestExportBehavior verifies default markdown export and opt-in JSON/CSV.
@@ -45,3 +48,213 @@ func TestExportBehavior(t *testing.T) {
 		t.Errorf("expected csv file created: %v", err)
 	}
 }
+
+func TestParseExportSet(t *testing.T) {
+	formats, dir, all, err := parseExportSet("all:out/")
+	if err != nil || !all || dir != "out/" || len(formats) != len(fileExportFlags) {
+		t.Errorf("all:out/ = %v, %q, %v, %v", formats, dir, all, err)
+	}
+	for _, f := range formats {
+		if _, ok := exportSuffixes[f]; !ok {
+			t.Errorf("format %s (from fileExportFlags) has no -export suffix", f)
+		}
+	}
+
+	formats, dir, all, err = parseExportSet("json, CSV,json")
+	if err != nil || all || dir != "" || strings.Join(formats, ",") != "json,csv" {
+		t.Errorf("json, CSV,json = %v, %q, %v, %v", formats, dir, all, err)
+	}
+	paths := exportSetPaths(formats, "out", "site")
+	if paths["exportjson"] != filepath.Join("out", "site.json") || paths["exportcsv"] != filepath.Join("out", "site.csv") || len(paths) != 2 {
+		t.Errorf("paths = %v", paths)
+	}
+
+	if _, _, _, err := parseExportSet("json,xlsx:out"); err == nil || !strings.Contains(err.Error(), `"xlsx"`) {
+		t.Errorf("unknown format: err = %v", err)
+	}
+}
+
+func TestExportGuard(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "config.json")
+	old := filepath.Join(dir, "old.md")
+	newer := filepath.Join(dir, "newer.md")
+	for _, p := range []string{input, old, newer} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := time.Now().Add(-30 * 24 * time.Hour)
+	_ = os.Chtimes(input, base, base)
+	_ = os.Chtimes(old, base, base)
+
+	g := exportGuard{input: input, newerDays: 7}
+	if err := g.check(input); err == nil || !strings.Contains(err.Error(), "input config") {
+		t.Errorf("overwriting the input: err = %v", err)
+	}
+	if err := g.check(old); err != nil {
+		t.Errorf("old target: %v", err)
+	}
+	if err := g.check(filepath.Join(dir, "new.md")); err != nil {
+		t.Errorf("new target: %v", err)
+	}
+	if err := g.check(newer); err == nil || !strings.Contains(err.Error(), "-force") {
+		t.Errorf("newer target without confirmation: err = %v", err)
+	}
+	g.confirm = func(string) bool { return true }
+	if err := g.check(newer); err != nil {
+		t.Errorf("newer target with confirmation: %v", err)
+	}
+	g.confirm = nil
+	g.force = true
+	if err := g.check(newer); err != nil {
+		t.Errorf("newer target with -force: %v", err)
+	}
+	g.force = false
+	g.newerDays = 0
+	if err := g.check(newer); err != nil {
+		t.Errorf("the newer-days check should be off by default: %v", err)
+	}
+
+	// Redirecting stdin from the null device is not a terminal
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	if isTerminal(null) {
+		t.Errorf("%s should not count as a terminal", os.DevNull)
+	}
+
+	if filepath.Separator == '/' {
+		if err := g.check("/plan.md"); err == nil {
+			t.Error("export into / was allowed")
+		}
+		if err := g.check("/etc/plan.md"); err == nil {
+			t.Error("export into /etc was allowed")
+		}
+		g.unsafe = true
+		if err := g.check("/etc/plan.md"); err != nil {
+			t.Errorf("-unsafe-exports: %v", err)
+		}
+	}
+}
+
+func TestExportToStdout(t *testing.T) {
+	results, err := planSingleNetwork(Network{Network: "10.0.0.0/29", Subnets: []Subnet{{Name: "Lab", CIDR: 29}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	saved := streamOutput
+	streamOutput = &sb
+	defer func() { streamOutput = saved }()
+
+	if err := ExportJSON(results, stdoutPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportCSV(results, stdoutPath); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	if !strings.HasPrefix(out, "[") || !strings.Contains(out, "\"name\": \"Lab\"") || !strings.Contains(out, "Subnet,Name,Vlan") {
+		t.Errorf("stdout exports = %q", out)
+	}
+	if _, err := os.Stat(stdoutPath); err == nil {
+		t.Error("export to - created a file named -")
+	}
+}
+
+func TestExportFragments(t *testing.T) {
+	results, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Web", VLAN: 100, CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+		{Name: "App", CIDR: 27},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	previous, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Old", CIDR: 26}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportFragments(previous, dir, "json"); err != nil {
+		t.Fatal(err)
+	}
+	stale := filepath.Join(dir, "10-0-0-0-24", "old.json")
+	// Files the tool did not write survive, even next to fragments
+	keep := []string{filepath.Join(dir, "config.json"), filepath.Join(dir, "10-0-0-0-24", "notes.json")}
+	for _, path := range keep {
+		_ = os.WriteFile(path, []byte("{}"), 0644)
+	}
+
+	if err := ExportFragments(results, dir, "json"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "10-0-0-0-24", "web.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f subnetFragment
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Subnet != "10.0.0.0/26" || f.VLAN != 100 || f.Gateway != "10.0.0.1" || len(f.Rows) == 0 {
+		t.Errorf("web fragment = %+v", f)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "10-0-0-0-24", "app.json")); err != nil {
+		t.Errorf("app fragment: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale fragment was not removed")
+	}
+	for _, path := range keep {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s was removed although the export did not write it", path)
+		}
+	}
+
+	if err := ExportFragments(results, dir, "yaml"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, "10-0-0-0-24", "web.yaml"))
+	if !strings.Contains(string(data), "gateway: \"10.0.0.1\"") {
+		t.Errorf("web.yaml:\n%s", data)
+	}
+}
+
+func TestExportDir(t *testing.T) {
+	results, err := PlanSubnets([]Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Web", CIDR: 26}, {Name: "App", CIDR: 27}}},
+		{Network: "10.1.0.0/16", Subnets: []Subnet{{Name: "DB", CIDR: 24}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := ExportDir(results, dir, "json, md"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"10.0.0.0-24.json", "10.0.0.0-24.md", "10.1.0.0-16.json", "10.1.0.0-16.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	rows, err := loadPlanFile(filepath.Join(dir, "10.1.0.0-16.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if r.Parent != "10.1.0.0/16" {
+			t.Errorf("10.1.0.0-16.json has a row of %s", r.Parent)
+		}
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "index.md"))
+	if !strings.Contains(string(index), "| 10.0.0.0/24 | 2 | [json](10.0.0.0-24.json) [md](10.0.0.0-24.md) |") {
+		t.Errorf("index.md:\n%s", index)
+	}
+
+	if err := ExportDir(results, dir, "json,bicep"); err == nil {
+		t.Error("unsupported format accepted")
+	}
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"encoding/csv"
 	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestExportJSON(t *testing.T) {
@@ -297,17 +304,50 @@ func TestExportMarkdown_MultipleSubnets(t *testing.T) {
 		}
 	}
 
-	// Count the number of data rows (should be 3)
-	lines := strings.Split(content, "\n")
-	dataRows := 0
-	for _, line := range lines {
-		if strings.HasPrefix(line, "| ") && !strings.Contains(line, "Name | VLAN") && !strings.Contains(line, "---") {
-			dataRows++
+	// One overview row and one section per subnet; the overview table is
+	// the block right under the title
+	_, rest, found := strings.Cut(content, "# Subnet Plan\n\n")
+	if !found {
+		t.Fatalf("Markdown has no \"# Subnet Plan\" heading:\n%s", content)
+	}
+	table, _, _ := strings.Cut(rest, "\n\n")
+	overview := strings.Split(table, "\n")
+	if len(overview) != 5 {
+		t.Errorf("Expected 3 data rows in the overview table, got %d:\n%s", len(overview)-2, content)
+	}
+	for _, want := range []string{
+		"| DMZ | 10 | 192.168.1.0/26 |  | 192.168.1.1 – 192.168.1.62 |  | 62 | 64 |",
+		"## LAN (VLAN 20) — 192.168.1.64/26\n",
+		"## MGMT (VLAN 30) — 192.168.1.128/27\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, content)
 		}
 	}
+}
 
-	if dataRows != 3 {
-		t.Errorf("Expected 3 data rows in Markdown table, got %d", dataRows)
+func TestExportMarkdown_AssignmentSections(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", VLAN: 100, CIDR: 27, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "LB", Position: 5}}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := renderMarkdown(results, nil)
+	for _, want := range []string{
+		"| Web | 100 | 10.0.0.0/27 | 255.255.255.224 | 10.0.0.1 – 10.0.0.30 | 10.0.0.1 | 30 | 32 |\n",
+		"\n## 10.0.0.0/24\n\n### Web (VLAN 100) — 10.0.0.0/27\n\n| IP | Label | Category | Total IPs |\n",
+		"| 10.0.0.0 | Network | Network | 1 |\n| 10.0.0.1 | Gateway | Assignment | 1 |\n",
+		"| 10.0.0.5 | LB | Assignment | 1 |\n",
+		"| 10.0.0.31 | Broadcast | Broadcast | 1 |\n",
+		"\n### Free Space\n\n| Subnet | Range | Total IPs |\n|--------|-------|-----------|\n| 10.0.0.32/27 | 10.0.0.32 – 10.0.0.63 | 32 |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown missing %q:\n%s", want, md)
+		}
 	}
 }
 
@@ -365,3 +405,763 @@ func BenchmarkExportCSV(b *testing.B) {
 		ExportCSV(testResults, testFile)
 	}
 }
+
+func TestExportWithRetry_TransientThenSuccess(t *testing.T) {
+	defer func(d time.Duration) { exportRetryDelay = d }(exportRetryDelay)
+	exportRetryDelay = time.Millisecond
+
+	attempts := 0
+	err := exportWithRetry(func() error {
+		attempts++
+		if attempts < 3 {
+			return &os.PathError{Op: "write", Path: "plan.csv", Err: syscall.EAGAIN}
+		}
+		return nil
+	}, 2)
+	if err != nil {
+		t.Fatalf("exportWithRetry() error = %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestExportWithRetry_PermanentErrorNotRetried(t *testing.T) {
+	attempts := 0
+	err := exportWithRetry(func() error {
+		attempts++
+		return &os.PathError{Op: "open", Path: "plan.csv", Err: syscall.ENOENT}
+	}, 5)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("permanent error should not be retried, got %d attempts", attempts)
+	}
+}
+
+func TestRunExports_AggregatesFailures(t *testing.T) {
+	results := []SubnetResult{{Name: "Test", Subnet: "192.168.1.0/24"}}
+	dir := t.TempDir()
+	good := filepath.Join(dir, "plan.json")
+	// A regular file used as a parent directory makes both writes fail even as root.
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	tasks := []exportTask{
+		{label: "CSV", path: filepath.Join(blocker, "a.csv"), write: ExportCSV},
+		{label: "JSON", path: good, write: ExportJSON},
+		{label: "Markdown", path: filepath.Join(blocker, "b.md"), write: ExportMarkdown},
+		{label: "Skipped", path: "", write: ExportJSON},
+	}
+
+	errs := runExports(results, tasks, exportOptions{})
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 aggregated errors, got %d: %v", len(errs), errs)
+	}
+	if _, err := os.Stat(good); err != nil {
+		t.Errorf("successful export should still be written after an earlier failure: %v", err)
+	}
+}
+
+func TestRunExports_FallbackDir(t *testing.T) {
+	results := []SubnetResult{{Name: "Test", Subnet: "192.168.1.0/24"}}
+	dir := t.TempDir()
+	readOnly := filepath.Join(dir, "ro", "plan.json")
+	fallback := filepath.Join(dir, "fallback")
+	// Simulate a read-only mount for the original target only
+	write := func(results []SubnetResult, path string) error {
+		if path == readOnly {
+			return &fs.PathError{Op: "open", Path: path, Err: syscall.EROFS}
+		}
+		return ExportJSON(results, path)
+	}
+	tasks := []exportTask{{label: "JSON", path: readOnly, write: write}}
+
+	// The fallback is still reported, so -export-errors fail exits non-zero
+	alt := fallbackPath(fallback, readOnly)
+	if errs := runExports(results, tasks, exportOptions{fallback: fallback}); len(errs) != 1 || !strings.Contains(errs[0].Error(), alt) {
+		t.Fatalf("expected the export to fall back to %s, got %v", alt, errs)
+	}
+	if _, err := os.Stat(alt); err != nil {
+		t.Errorf("export should be written to the fallback directory: %v", err)
+	}
+
+	// Fallback paths keep the target's directories
+	if a, b := fallbackPath(fallback, filepath.Join("a", "plan.json")), fallbackPath(fallback, filepath.Join("b", "plan.json")); a == b || !strings.HasPrefix(a, fallback) {
+		t.Errorf("fallback paths %s and %s", a, b)
+	}
+
+	// The fallback directory goes through the export guard
+	guard := &exportGuard{}
+	if errs := runExports(results, tasks, exportOptions{fallback: "/etc", guard: guard}); len(errs) != 1 || !strings.Contains(errs[0].Error(), "system directory") {
+		t.Errorf("expected the guard to refuse the fallback target, got %v", errs)
+	}
+
+	for _, none := range []string{"", "none"} {
+		if errs := runExports(results, tasks, exportOptions{fallback: none}); len(errs) != 1 || strings.Contains(errs[0].Error(), "instead") {
+			t.Errorf("without a fallback directory the export should fail, got %v", errs)
+		}
+	}
+}
+
+func TestFormatIPs(t *testing.T) {
+	results := []SubnetResult{
+		{Subnet: "10.60.48.128/25", IP: "10.60.48.130 - 10.60.48.140", Mask: "255.255.255.128", Label: "Pool", Category: "Unused"},
+		{Subnet: "192.168.1.0/24", IP: "192.168.1.1", Mask: "255.255.255.0", Label: "Gateway", Category: "Assignment"},
+	}
+	padded := formatIPs(results, "padded")
+	if got := padded[0].Subnet + " " + padded[0].IP; got != "010.060.048.128/25 010.060.048.130 - 010.060.048.140" {
+		t.Errorf("padded = %q", got)
+	}
+	if results[0].Subnet != "10.60.48.128/25" {
+		t.Errorf("formatIPs should not modify its input, got %q", results[0].Subnet)
+	}
+	if got := unpadOctets(padded[1].IP); got != "192.168.1.1" {
+		t.Errorf("unpadOctets = %q", got)
+	}
+	fixed := formatIPs(results, "fixed")
+	if len(fixed[0].Subnet) != len(fixed[1].Subnet) || len(fixed[0].IP) != len(fixed[1].IP) {
+		t.Errorf("fixed columns differ in width: %q %q, %q %q", fixed[0].Subnet, fixed[1].Subnet, fixed[0].IP, fixed[1].IP)
+	}
+
+	// Appending padded rows updates the plain rows of an existing file
+	path := filepath.Join(t.TempDir(), "plan.csv")
+	if err := ExportCSV(results, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportCSVAppend(padded, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("expected the header and 2 merged rows, got %d lines:\n%s", lines, data)
+	}
+}
+
+func TestPrintTable_Window(t *testing.T) {
+	var results []SubnetResult
+	for i := 0; i < 10; i++ {
+		results = append(results, SubnetResult{Name: fmt.Sprintf("row%d", i), Subnet: "10.0.0.0/24", IP: fmt.Sprintf("10.0.0.%d", i), Category: "Assignment"})
+	}
+	for _, tt := range []struct {
+		window rowWindow
+		shown  []int
+		marker string
+	}{
+		{rowWindow{}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ""},
+		{rowWindow{head: 2}, []int{0, 1}, "... 8 rows not shown"},
+		{rowWindow{tail: 3}, []int{7, 8, 9}, "... 7 rows not shown"},
+		{rowWindow{head: 1, tail: 1}, []int{0, 9}, "... 8 rows not shown"},
+		{rowWindow{head: 6, tail: 6}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, ""},
+	} {
+		var sb strings.Builder
+		printTable(&sb, results, tableStyle{window: tt.window})
+		out := sb.String()
+		if !strings.Contains(out, "Generated 10 subnet entries") {
+			t.Errorf("%+v: the header should count every row:\n%s", tt.window, out)
+		}
+		var shown []int
+		for i := range results {
+			if strings.Contains(out, fmt.Sprintf("row%d ", i)) {
+				shown = append(shown, i)
+			}
+		}
+		if fmt.Sprint(shown) != fmt.Sprint(tt.shown) {
+			t.Errorf("%+v: shown rows %v, want %v", tt.window, shown, tt.shown)
+		}
+		if tt.marker != "" && !strings.Contains(out, tt.marker) || tt.marker == "" && strings.Contains(out, "not shown") {
+			t.Errorf("%+v: expected marker %q:\n%s", tt.window, tt.marker, out)
+		}
+	}
+}
+
+func TestExportCSVAppend_MergesAndPreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "living.csv")
+	existing := "Subnet,Name,Vlan,Label,IP,TotalIPs,Prefix,Mask,Category,Comment\n" +
+		"10.0.0.0/28,Mgmt,10,Gateway,10.0.0.1,1,/28,255.255.255.240,Assignment,core switch\n" +
+		"10.0.9.0/28,Old,90,Gateway,10.0.9.1,1,/28,255.255.255.240,Circuit,keep me\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []SubnetResult{
+		{Subnet: "10.0.0.0/28", Name: "Mgmt", VLAN: 11, Label: "Gateway", IP: "10.0.0.1", TotalIPs: 1, Prefix: 28, Mask: "255.255.255.240", Category: "Assignment"},
+		{Subnet: "10.0.0.0/28", Name: "Mgmt", VLAN: 11, Label: "DNS", IP: "10.0.0.2", TotalIPs: 1, Prefix: 28, Mask: "255.255.255.240", Category: "Assignment"},
+	}
+	if err := ExportCSVAppend(results, path); err != nil {
+		t.Fatalf("ExportCSVAppend() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("expected header + 3 rows, got %d: %v", len(records), records)
+	}
+	if records[0][9] != "Comment" {
+		t.Errorf("extra column header lost: %v", records[0])
+	}
+	if records[1][2] != "11" || records[1][9] != "core switch" {
+		t.Errorf("updated row should change VLAN and keep comment: %v", records[1])
+	}
+	if records[2][1] != "Old" || records[2][9] != "keep me" {
+		t.Errorf("unrelated existing row should be preserved: %v", records[2])
+	}
+	if records[3][3] != "DNS" || records[3][9] != "" {
+		t.Errorf("new row should be appended with empty comment: %v", records[3])
+	}
+}
+
+func TestExportCSVAppend_MovedAssignment(t *testing.T) {
+	plan := func(position int) []SubnetResult {
+		results, err := PlanSubnets([]Network{{
+			Network: "10.0.0.0/24",
+			Subnets: []Subnet{{Name: "Mgmt", CIDR: 28, IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1},
+				{Name: "Switch", Position: position, Description: "core"},
+			}}},
+		}})
+		if err != nil {
+			t.Fatalf("PlanSubnets() error = %v", err)
+		}
+		return results
+	}
+	path := filepath.Join(t.TempDir(), "living.csv")
+	if err := ExportCSV(plan(5), path); err != nil {
+		t.Fatal(err)
+	}
+	// A hand-added row survives the merge
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(file, "10.0.0.0/28,Mgmt,0,Console,10.0.0.14,1,/28,255.255.255.240,Manual,,")
+	file.Close()
+
+	if err := ExportCSVAppend(plan(9), path); err != nil {
+		t.Fatalf("ExportCSVAppend() error = %v", err)
+	}
+	var want bytes.Buffer
+	if err := writeCSV(&want, plan(9)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// New rows are appended, so compare the merged rows regardless of order
+	got := strings.Split(strings.TrimSpace(string(data)), "\n")
+	manual := "10.0.0.0/28,Mgmt,0,Console,10.0.0.14,1,/28,255.255.255.240,Manual,,"
+	if !strings.Contains(string(data), manual+"\n") {
+		t.Errorf("hand-added row should be kept:\n%s", data)
+	}
+	var rows []string
+	for _, line := range got {
+		if line != manual {
+			rows = append(rows, line)
+		}
+	}
+	got = rows
+	expected := strings.Split(strings.TrimSpace(want.String()), "\n")
+	if got[0] != expected[0] {
+		t.Errorf("header = %q, want %q", got[0], expected[0])
+	}
+	sort.Strings(got)
+	sort.Strings(expected)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("merged file should hold exactly the new plan's rows, no stale Unused rows:\n%s", data)
+	}
+}
+
+func TestUsageStats_AnonymousAndLocal(t *testing.T) {
+	networks := []Network{{
+		Network: "10.99.0.0/24",
+		Subnets: []Subnet{{Name: "SecretProject", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	stats := collectUsageStats(networks, results, time.Now())
+	if err := appendUsageStats(path, stats); err != nil {
+		t.Fatalf("appendUsageStats() error = %v", err)
+	}
+	if err := appendUsageStats(path, stats); err != nil {
+		t.Fatalf("appendUsageStats() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	if lines := strings.Count(content, "\n"); lines != 2 {
+		t.Errorf("expected 2 appended records, got %d", lines)
+	}
+	for _, secret := range []string{"10.99.0.0", "SecretProject", "Gateway"} {
+		if strings.Contains(content, secret) {
+			t.Errorf("usage stats must not contain plan data %q", secret)
+		}
+	}
+	if stats.Networks != 1 || stats.Subnets != 1 || stats.Assignments != 1 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+
+	t.Setenv("IPSUBNETPLANNER_USAGE_STATS", "")
+	if usageStatsPath("") != "" {
+		t.Error("usage stats must be disabled by default")
+	}
+}
+
+func TestExportDecommissionedStatus(t *testing.T) {
+	dir := t.TempDir()
+	rows := []SubnetResult{
+		{Name: "Legacy", Subnet: "10.0.0.0/26", Label: "Network", Category: "Network", Status: "Decommissioned"},
+		{Name: "Web", Subnet: "10.0.0.64/26", Label: "Network", Category: "Network"},
+	}
+
+	csvPath := filepath.Join(dir, "plan.csv")
+	if err := ExportCSV(rows, csvPath); err != nil {
+		t.Fatalf("ExportCSV() error = %v", err)
+	}
+	data, _ := os.ReadFile(csvPath)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if !strings.HasSuffix(lines[0], ",Category,Status") || !strings.HasSuffix(lines[1], ",Decommissioned") || !strings.HasSuffix(lines[2], ",Network,") {
+		t.Errorf("expected trailing Status column, got:\n%s", data)
+	}
+
+	mdPath := filepath.Join(dir, "plan.md")
+	if err := ExportMarkdown(rows, mdPath); err != nil {
+		t.Fatalf("ExportMarkdown() error = %v", err)
+	}
+	md, _ := os.ReadFile(mdPath)
+	if !strings.Contains(string(md), "| ~~Legacy~~ (decommissioned) |") || !strings.Contains(string(md), "| Web |") {
+		t.Errorf("expected decommissioned subnet to be struck through, got:\n%s", md)
+	}
+}
+
+func TestExportMetadataColumns(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{
+				Name: "Web", CIDR: 28, Description: "Public web tier",
+				Tags:          map[string]string{"owner": "web-team", "env": "prod"},
+				IPAssignments: []IPAssignment{{Name: "LB", Position: 1, Description: "Load balancer VIP", Tags: map[string]string{"ticket": "CHG-1"}}},
+			},
+			{Name: "Spare", CIDR: 28},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Label == "LB" && (r.Description != "Load balancer VIP" || r.Tags["ticket"] != "CHG-1") {
+			t.Errorf("assignment row lost its metadata: %+v", r)
+		}
+		if r.Name == "Web" && r.Category == "Network" && r.Tags["env"] != "prod" {
+			t.Errorf("subnet row lost its tags: %+v", r)
+		}
+	}
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "plan.csv")
+	if err := ExportCSV(results, csvPath); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(csvPath)
+	csvText := string(data)
+	if !strings.HasPrefix(csvText, "Subnet,Name,Vlan,Label,IP,TotalIPs,Prefix,Mask,Category,Description,Tags\n") {
+		t.Errorf("unexpected CSV header:\n%s", csvText)
+	}
+	if !strings.Contains(csvText, ",Public web tier,env=prod; owner=web-team\n") {
+		t.Errorf("CSV missing subnet metadata:\n%s", csvText)
+	}
+
+	mdPath := filepath.Join(dir, "plan.md")
+	if err := ExportMarkdown(results, mdPath); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(mdPath)
+	if !strings.Contains(string(data), "| Total IPs | Description | Tags |") || !strings.Contains(string(data), "| Load balancer VIP | ticket=CHG-1 |") {
+		t.Errorf("Markdown missing metadata columns:\n%s", data)
+	}
+}
+
+func TestCollapseUnused(t *testing.T) {
+	results, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Srv", CIDR: 26, IPAssignments: []IPAssignment{{Name: "a", Position: 5}, {Name: "b", Position: 10}}},
+		{Name: "One", CIDR: 27, IPAssignments: []IPAssignment{{Name: "x", Position: 1}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	collapsed := CollapseUnused(results)
+	var unused []SubnetResult
+	for _, r := range collapsed {
+		if r.Category == "Unused" {
+			unused = append(unused, r)
+		}
+	}
+	if len(unused) != 2 {
+		t.Fatalf("unused rows = %+v, want one per subnet", unused)
+	}
+	srv := unused[0]
+	if srv.Label != "Unused (3 ranges)" || srv.IP != "10.0.0.1 - 10.0.0.4, 10.0.0.6 - 10.0.0.9, 10.0.0.11 - 10.0.0.62" || srv.TotalIPs != 60 {
+		t.Errorf("collapsed row = %+v", srv)
+	}
+	if unused[1].Label != "Unused Range" {
+		t.Errorf("a single Unused row should stay as is: %+v", unused[1])
+	}
+	if len(collapsed) != len(results)-2 {
+		t.Errorf("collapsed %d rows to %d, want %d", len(results), len(collapsed), len(results)-2)
+	}
+}
+
+func TestRenderCopy(t *testing.T) {
+	results, err := planSingleNetwork(Network{Network: "10.0.0.0/29", Subnets: []Subnet{{Name: "Lab", CIDR: 29}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for format, want := range map[string]string{
+		"table":    "Generated 3 subnet entries",
+		"markdown": "# Subnet Plan",
+		"CSV":      "Subnet,Name,Vlan",
+	} {
+		text, err := renderCopy(results, format)
+		if err != nil || !strings.Contains(text, want) {
+			t.Errorf("renderCopy(%s) = %q, %v; want it to contain %q", format, text, err, want)
+		}
+	}
+	if _, err := renderCopy(results, "html"); err == nil {
+		t.Error("renderCopy(html) succeeded, want error")
+	}
+}
+
+func TestStreamPlan(t *testing.T) {
+	networks := []Network{
+		{Network: "10.0.0.0/16", Subnets: []Subnet{{Name: "Big", CIDR: 16, IPAssignments: []IPAssignment{
+			{Name: "Gateway", Position: 1},
+			{Name: "Block", Position: 100, EndPosition: 200, Description: "lab"},
+			{Name: "Inside", Position: 150},
+		}}}},
+		{Network: "10.1.0.0/24", Subnets: []Subnet{{Name: "Web", Hosts: 50, Decommissioned: true}}},
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A planned subnet gets no Status column, streamed or not
+	active := []Network{{Network: "10.2.0.0/24", Subnets: []Subnet{{Name: "Next", Hosts: 10, PlannedFor: "2000-01-01"}}}}
+	activeResults, err := PlanSubnets(active)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasStatus(activeResults) || len(streamColumns(Planner{}, active)) != 0 {
+		t.Errorf("streamColumns() = %v, hasStatus() = %v; want no Status column", streamColumns(Planner{}, active), hasStatus(activeResults))
+	}
+	reclaimed := []Network{{Network: "10.3.0.0/24", Subnets: []Subnet{{Name: "Old", Hosts: 10, Decommissioned: true}}}}
+	if cols := streamColumns(Planner{Reclaim: true}, reclaimed); len(cols) != 0 {
+		t.Errorf("streamColumns() of a reclaimed subnet = %v", cols)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	jsonPath, csvPath := filepath.Join(dir, "plan.json"), filepath.Join(dir, "plan.csv")
+	if err := ExportJSON(results, jsonPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportCSV(results, csvPath); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonOut, csvOut strings.Builder
+	rows, err := streamPlan(Planner{}, networks, nil, newJSONStream(&jsonOut), newCSVStream(&csvOut, streamColumns(Planner{}, networks)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != len(results) {
+		t.Errorf("streamPlan() wrote %d rows, want %d", rows, len(results))
+	}
+	if want, _ := os.ReadFile(jsonPath); jsonOut.String() != string(want) {
+		t.Errorf("streamed JSON differs from ExportJSON:\n%s\nwant:\n%s", jsonOut.String(), want)
+	}
+	if want, _ := os.ReadFile(csvPath); csvOut.String() != string(want) {
+		t.Errorf("streamed CSV differs from ExportCSV:\n%s\nwant:\n%s", csvOut.String(), want)
+	}
+
+	// A filter drops rows before they reach the writers
+	var filtered strings.Builder
+	match, _ := compileFilter("category == Broadcast")
+	if rows, err := streamPlan(Planner{}, networks, match, newJSONStream(&filtered)); err != nil || rows != 2 {
+		t.Errorf("streamPlan() with filter = %d rows, %v; want 2 rows", rows, err)
+	}
+	var empty strings.Builder
+	match, _ = compileFilter("category == Nothing")
+	streamPlan(Planner{}, networks, match, newJSONStream(&empty))
+	if empty.String() != "[]" {
+		t.Errorf("empty stream = %q, want []", empty.String())
+	}
+}
+
+func TestExportColumns(t *testing.T) {
+	results := []SubnetResult{
+		{Name: "Servers", VLAN: 100, Subnet: "10.0.0.0/26", Prefix: 26, IP: "10.0.0.0", Category: "Network"},
+		{Name: "Servers", VLAN: 100, Subnet: "10.0.0.0/26", Prefix: 26, IP: "10.0.0.1", Label: "Gateway", Category: "Assignment"},
+		{Name: "Spare", Subnet: "10.0.0.64/26", Prefix: 26, IP: "10.0.0.65 - 10.0.0.126", Category: "Available"},
+	}
+	cols, err := parseColumns("subnet, Name,VLAN,ip,Label")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.csv")
+	if err := ExportCSVColumns(results, path, cols); err != nil {
+		t.Fatalf("ExportCSVColumns() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Subnet,Name,Vlan,IP,Label\n10.0.0.0/26,Servers,100,10.0.0.0,\n10.0.0.0/26,Servers,100,10.0.0.1,Gateway\n10.0.0.64/26,Spare,0,10.0.0.65 - 10.0.0.126,\n"
+	if string(data) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", data, want)
+	}
+
+	var table strings.Builder
+	PrintColumnsTo(&table, results, cols)
+	out := table.String()
+	for _, want := range []string{"Subnet               Name                      Vlan   IP              Label\n", "10.0.0.0/26          Servers                   100    10.0.0.1        Gateway\n", "-      10.0.0.65 - ... Available Range\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+
+	var streamed strings.Builder
+	s := newCSVStream(&streamed, nil)
+	s.cols = cols
+	for _, r := range results {
+		if err := s.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if streamed.String() != want {
+		t.Errorf("streamed CSV =\n%s\nwant\n%s", streamed.String(), want)
+	}
+
+	for _, spec := range []string{"Subnet,Color", " , "} {
+		if _, err := parseColumns(spec); err == nil {
+			t.Errorf("parseColumns(%q) expected an error", spec)
+		}
+	}
+}
+
+func TestBuildView(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Servers", VLAN: 100, CIDR: 26, IPAssignments: []IPAssignment{
+				{Name: "Pool", Position: 10, Count: 10},
+				{Name: "Gateway", Position: 1},
+				{Name: "Spare", Position: 5, Reserved: true},
+			}},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view := BuildView(results)
+	if len(view.Networks) != 1 {
+		t.Fatalf("networks = %+v", view.Networks)
+	}
+	n := view.Networks[0]
+	if n.TotalIPs != 256 || n.AllocatedIPs != 64 || n.UtilizationText != "25.0%" || n.Display.Summary != "1 subnet, 64 of 256 addresses allocated" {
+		t.Errorf("network = %+v", n)
+	}
+	if len(n.FreeBlocks) != 2 || n.FreeBlocks[0].CIDR != "10.0.0.64/26" || n.FreeBlocks[0].Size != 64 || n.FreeBlocks[0].Range != "10.0.0.64 – 10.0.0.127" {
+		t.Errorf("free blocks = %+v", n.FreeBlocks)
+	}
+
+	s := n.Subnets[0]
+	if s.Gateway != "10.0.0.1" || s.UsableHosts != 62 || s.AssignedIPs != 11 || s.UtilizationText != "17.7%" {
+		t.Errorf("subnet = %+v", s)
+	}
+	want := SubnetDisplay{Title: "Servers (VLAN 100)", Range: "10.0.0.1 – 10.0.0.62", VLAN: "100", Usage: "11 of 62 hosts assigned"}
+	if s.Display != want {
+		t.Errorf("subnet display = %+v, want %+v", s.Display, want)
+	}
+	var order []string
+	for _, a := range s.Assignments {
+		order = append(order, a.Display)
+	}
+	if got := strings.Join(order, "; "); got != "Gateway 10.0.0.1; Spare 10.0.0.5; Pool 10.0.0.10 – 10.0.0.19 (10)" {
+		t.Errorf("assignments = %s", got)
+	}
+}
+
+func TestPrintGroupedTo(t *testing.T) {
+	results, err := PlanSubnets([]Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Web", VLAN: 100, CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}}},
+		{Network: "10.1.0.0/25", Subnets: []Subnet{{Name: "Lab", CIDR: 25}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	PrintGroupedTo(&sb, results)
+	out := sb.String()
+	for _, want := range []string{
+		"\n10.0.0.0/24\n\n  Web  10.0.0.0/26  mask 255.255.255.192  VLAN 100  62 usable\n",
+		"    Gateway              10.0.0.1                        1          Assignment\n",
+		"  Free space\n    10.0.0.64/26         10.0.0.64 - 10.0.0.127          64\n",
+		"  Total: 1 subnet, 1 assignment, 64 of 256 addresses allocated (25.0%)\n",
+		"  Lab  10.1.0.0/25  mask 255.255.255.128  no VLAN  126 usable\n",
+		"\nTotal: 2 networks, 2 subnets, 1 assignment, 192 of 384 addresses allocated (50.0%)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("grouped output missing %q:\n%s", want, out)
+		}
+	}
+
+	// Rows sorted by category still print one block per subnet
+	results, err = PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Web", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+		{Name: "DB", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Category < results[j].Category })
+	sb.Reset()
+	PrintGroupedTo(&sb, results)
+	out = sb.String()
+	if n := strings.Count(out, "  Web  10.0.0.0/26"); n != 1 || !strings.Contains(out, "  Total: 2 subnets, 2 assignments") {
+		t.Errorf("category-sorted grouped output has %d Web headers:\n%s", n, out)
+	}
+}
+
+func TestPrintTableStyle(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{{Name: "A very long subnet name for the core servers", CIDR: 26,
+			IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plain strings.Builder
+	PrintTableTo(&plain, results)
+	if strings.Contains(plain.String(), "\x1b[") || !strings.Contains(plain.String(), "A very long subnet nam... ") {
+		t.Errorf("plain table should be uncolored and truncated to 25 characters:\n%s", plain.String())
+	}
+
+	var wide strings.Builder
+	printTable(&wide, results, tableStyle{color: true, width: 200})
+	out := wide.String()
+	if !strings.Contains(out, "A very long subnet name for the core servers ") {
+		t.Errorf("wide table truncated the name:\n%s", out)
+	}
+	for _, want := range []string{ansiGreen + "10.0.0.0/26", ansiGray + "10.0.0.64/26"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("colored table missing %q:\n%q", want, out)
+		}
+	}
+	if rowColor(SubnetResult{Category: "Assignment", Status: "Decommissioned"}) != ansiRed {
+		t.Error("decommissioned rows should be red")
+	}
+
+	if name, label, ip := (tableStyle{width: 80}).columnWidths(results, 66); name != 25 || label != 20 || ip != 15 {
+		t.Errorf("narrow terminal widths = %d, %d, %d, want the defaults", name, label, ip)
+	}
+}
+
+func TestStreamExports_KeepsPreviousFilesOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "plan.csv")
+	_ = os.WriteFile(csvPath, []byte("previous export\n"), 0644)
+
+	tooBig := []Network{{Network: "10.0.0.0/28", Subnets: []Subnet{{Name: "Web", CIDR: 24}}}}
+	if _, err := streamExports(Planner{}, tooBig, nil, "", csvPath, nil, exportOptions{}); err == nil {
+		t.Fatal("streamExports() succeeded for a plan that does not fit")
+	}
+	if data, _ := os.ReadFile(csvPath); string(data) != "previous export\n" {
+		t.Errorf("failed run changed the export to %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	fits := []Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Web", CIDR: 26}}}}
+	if _, err := streamExports(Planner{}, fits, nil, "", csvPath, nil, exportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(csvPath); !strings.Contains(string(data), "10.0.0.0/26") {
+		t.Errorf("export was not replaced:\n%s", data)
+	}
+}
+
+func TestStreamExports_VarsEnvAndLock(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "s.csv")
+	vars := &varFlag{}
+	set := flag.NewFlagSet("ipsubnetplanner", flag.ContinueOnError)
+	set.Bool("stream", false, "")
+	set.String("exportcsv", "", "")
+	set.String("env", "", "")
+	set.Var(vars, "var", "")
+	set.Bool("lock", false, "")
+	set.Int("export-retries", 2, "")
+	set.String("exporthtml", "", "")
+	if err := set.Parse([]string{"-stream", "-exportcsv", csvPath, "-var", "SITE=east", "-env", "prod", "-lock", "-export-retries", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkStreamFlags(set, false, ""); err != nil {
+		t.Fatalf("checkStreamFlags() = %v", err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	config := []byte(`{"environments": {"prod": [{"network": "10.1.0.0/24", "subnets": [{"name": "${SITE}-users", "cidr": 26}]}]}}`)
+	data, err := substituteVars(path, config, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(data, set.Lookup("env").Value.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := streamExports(Planner{}, cfg.Networks, nil, "", csvPath, nil, exportOptions{retries: 1, lock: true}); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := os.ReadFile(csvPath); !strings.Contains(string(out), "10.1.0.0/26,east-users") {
+		t.Errorf("streamed export of the prod environment:\n%s", out)
+	}
+	if _, err := os.Stat(exportStatePath(csvPath)); err != nil {
+		t.Errorf("-lock should record the export state: %v", err)
+	}
+
+	if err := set.Parse([]string{"-exporthtml", "x.html"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkStreamFlags(set, false, ""); err == nil || !strings.Contains(err.Error(), "-exporthtml") {
+		t.Errorf("checkStreamFlags() with -exporthtml = %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileFilter(t *testing.T) {
+	rows := []SubnetResult{
+		{Name: "Web", VLAN: 100, Label: "Gateway", Category: "Assignment", Prefix: 26, Tags: map[string]string{"env": "prod"}},
+		{Name: "Web", VLAN: 100, Label: "Unused Range", Category: "Unused", Prefix: 26},
+		{Name: "App", VLAN: 110, Label: "App Server 1", Category: "Assignment", Prefix: 27},
+		{Name: "DB", VLAN: 120, Label: "Gateway", Category: "Assignment", Prefix: 28, Tags: map[string]string{"env": "dev"}},
+	}
+	tests := []struct {
+		expr string
+		want int // number of matching rows
+	}{
+		{"category==Assignment && vlan in (100,110)", 2},
+		{"category == 'assignment'", 3},
+		{"vlan >= 110", 2},
+		{"prefix<27 || name==DB", 3},
+		{"!(label ~ gate)", 2},
+		{`label == "App Server 1"`, 1},
+		{"tag.env == prod", 1},
+		{"category != Unused && (vlan == 100 || vlan == 120)", 2},
+	}
+	for _, tc := range tests {
+		f, err := compileFilter(tc.expr)
+		if err != nil {
+			t.Errorf("compileFilter(%q): %v", tc.expr, err)
+			continue
+		}
+		if got := len(FilterResults(rows, f)); got != tc.want {
+			t.Errorf("%q matched %d rows, want %d", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, expr := range []string{"", "vlan = 1", "owner == x", "vlan ==", "(vlan == 1", "vlan in 1", "name == 'x", "vlan == 1 extra"} {
+		if _, err := compileFilter(expr); err == nil {
+			t.Errorf("compileFilter(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestCategoryFilter(t *testing.T) {
+	results, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Web", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "Spare", Position: 5, Reserved: true}}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := func(f rowFilter) map[string]int {
+		got := make(map[string]int)
+		for _, r := range FilterResults(results, f) {
+			got[r.Category]++
+		}
+		return got
+	}
+
+	f, err := categoryFilter("network, ASSIGNMENT,Broadcast", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(f); len(got) != 3 || got["Network"] != 1 || got["Assignment"] != 1 || got["Broadcast"] != 1 {
+		t.Errorf("include = %v", got)
+	}
+	f, _ = categoryFilter("", "Unused,Available")
+	if got := count(f); got["Unused"] != 0 || got["Available"] != 0 || got["Reserved"] != 1 || got["Network"] != 1 {
+		t.Errorf("exclude = %v", got)
+	}
+	f, _ = categoryFilter("Network,Assignment,Reserved", "Reserved")
+	if got := count(f); got["Reserved"] != 0 || got["Assignment"] != 1 {
+		t.Errorf("include and exclude = %v", got)
+	}
+	if f, err := categoryFilter("", ""); f != nil || err != nil {
+		t.Errorf("empty lists = %v, %v; want no filter", f != nil, err)
+	}
+	if _, err := categoryFilter("Gateway", ""); err == nil || !strings.Contains(err.Error(), "include-categories") {
+		t.Errorf("unknown category error = %v", err)
+	}
+
+	// Combined with -filter, both must match
+	expr, _ := compileFilter("ip ~ 10.0.0.1")
+	if got := count(andFilters(expr, nil, f)); got["Assignment"] != 1 || len(got) != 1 {
+		t.Errorf("andFilters = %v", got)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitCapacity(t *testing.T) {
+	results, err := PlanSubnets([]Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Web", CIDR: 26}, {Name: "App", CIDR: 28}}},
+		{Network: "10.1.0.0/28", Subnets: []Subnet{{Name: "Full", CIDR: 28}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := FitCapacity(results, 27)
+	if len(reports) != 2 {
+		t.Fatalf("reports = %+v", reports)
+	}
+	// Free: .80/28, .96/27, .128/25 -> 176 addresses; /27s fit in the /27 and the /25
+	if r := reports[0]; r.Free != 176 || r.Largest != 25 || r.Fits != 5 {
+		t.Errorf("10.0.0.0/24 = %+v, want 176 free, largest /25, 5 x /27", r)
+	}
+	if r := reports[1]; r.Free != 0 || r.Largest != 0 || r.Fits != 0 {
+		t.Errorf("full network = %+v", r)
+	}
+
+	var sb strings.Builder
+	PrintFitCapacity(&sb, reports, 27)
+	for _, want := range []string{"largest free block /25, 5 x /27 fit", "largest free block none (full)", "Total: 5 x /27 fit"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, sb.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatConfig(t *testing.T) {
+	in := []byte(`{"subnets": [
+		{"hosts": 10, "name": "web", "vlan": 20},
+		{"name": "App", "cidr": 24, "vlan": 10},
+		{"name": "db", "cidr": 28, "address": "10.1.0.77/28"}
+	], "network": "10.1.0.5/16"}`)
+
+	out, warnings, err := formatConfig(in, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("warnings = %v, want the network and the db address", warnings)
+	}
+	want := `{
+  "network": "10.1.0.0/16",
+  "subnets": [
+    {
+      "name": "App",
+      "vlan": 10,
+      "cidr": 24
+    },
+    {
+      "name": "db",
+      "cidr": 28,
+      "address": "10.1.0.64/28"
+    },
+    {
+      "name": "web",
+      "vlan": 20,
+      "hosts": 10
+    }
+  ]
+}
+`
+	if string(out) != want {
+		t.Errorf("formatted config:\n%s\nwant:\n%s", out, want)
+	}
+
+	// Formatting is idempotent
+	again, warnings, err := formatConfig(out, "name")
+	if err != nil || len(warnings) != 0 || string(again) != string(out) {
+		t.Errorf("second pass changed the config (warnings %v, err %v):\n%s", warnings, err, again)
+	}
+
+	sized, _, err := formatConfig(in, "size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i, j := strings.Index(string(sized), `"App"`), strings.Index(string(sized), `"db"`); i > j {
+		t.Errorf("size order should put the /24 first:\n%s", sized)
+	}
+}
+
+func TestFormatConfig_Errors(t *testing.T) {
+	if _, _, err := formatConfig([]byte(`{"network": "10.0.0.0/24", "subnets": [{"name": "a", "hosts": 5, "vlna": 3}]}`), ""); err == nil || !strings.Contains(err.Error(), "vlna") {
+		t.Errorf("unknown field: err = %v", err)
+	}
+	if _, _, err := formatConfig([]byte(`[{"network": "10.0.0.0/24", "subnets": []}]`), "color"); err == nil {
+		t.Error("expected an error for an unknown sort key")
+	}
+}
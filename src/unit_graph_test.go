@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestBuildGraph(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", VLAN: 10, CIDR: 26, IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1},
+				{Name: "fw-web", Position: 2, Tags: map[string]string{"device": "fw1"}},
+				{Name: "Pool", Position: 10, Count: 5},
+			}},
+			{Name: "DB", CIDR: 27, IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1},
+				{Name: "fw-db", Position: 2, Tags: map[string]string{"device": "fw1"}},
+			}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := BuildGraph(results)
+
+	count := map[string]int{}
+	for _, n := range g.Nodes {
+		count[n.Type]++
+	}
+	// One network, two subnets, two gateways and the shared firewall; the
+	// pool is not a device
+	if count["Network"] != 1 || count["Subnet"] != 2 || count["Device"] != 3 {
+		t.Errorf("node types = %v", count)
+	}
+	var firewall []string
+	for _, e := range g.Edges {
+		if e.Source == "device:fw1" {
+			firewall = append(firewall, e.Target+" "+e.Properties["ip"])
+		}
+	}
+	if strings.Join(firewall, ",") != "subnet:10.0.0.0/26 10.0.0.2,subnet:10.0.0.64/27 10.0.0.66" {
+		t.Errorf("firewall edges = %v", firewall)
+	}
+
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Label string `xml:"label,attr"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal([]byte(renderGraphML(g)), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v", err)
+	}
+	if len(doc.Nodes) != len(g.Nodes) || len(doc.Edges) != len(g.Edges) || doc.Edges[0].Label != "CONTAINS" {
+		t.Errorf("GraphML has %d nodes and %d edges, want %d and %d", len(doc.Nodes), len(doc.Edges), len(g.Nodes), len(g.Edges))
+	}
+}
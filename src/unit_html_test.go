@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportHTML(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Web & API", VLAN: 10, CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+		{Name: "Old", CIDR: 27, Decommissioned: true},
+	}}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.html")
+	if err := ExportHTML(results, path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	report := string(data)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		"<svg xmlns=\"http://www.w3.org/2000/svg\"",
+		"<b>2</b>Subnets",
+		"<b>256</b>Addresses",
+		"<td>10.0.0.0/24</td><td class=\"num\">256</td>",
+		"Web &amp; API (VLAN 10) — 10.0.0.0/26",
+		"<td>10.0.0.1</td><td>Gateway</td>",
+		"<span class=\"status\">Old</span>",
+		"<h2>Free Space</h2>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q", want)
+		}
+	}
+	// Self-contained: no links to stylesheets, scripts or images
+	for _, asset := range []string{"<link", "<script", "src=", "href="} {
+		if strings.Contains(report, asset) {
+			t.Errorf("report references an external asset (%s)", asset)
+		}
+	}
+}
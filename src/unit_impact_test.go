@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildImpactReport(t *testing.T) {
+	core := map[string]string{"device": "core1"}
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Office", Hosts: 50, VLAN: 20, Criticality: "low", IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1, Tags: core}}},
+			{Name: "Payments", Hosts: 20, VLAN: 10, Criticality: "critical", IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1, Tags: core}}},
+			{Name: "Lab", Hosts: 10, VLAN: 20},
+		},
+	}, {
+		Network: "10.1.0.0/24",
+		Subnets: []Subnet{{Name: "Voice", CIDR: 26, Criticality: "high"}},
+	}})
+	if err != nil {
+		t.Fatalf("PlanSubnets: %v", err)
+	}
+	report := BuildImpactReport(results)
+
+	var order []string
+	for _, s := range report.Subnets {
+		order = append(order, s.Name+" "+s.Criticality+" "+strings.Join(s.Dependencies, ","))
+	}
+	want := []string{
+		"Payments critical parent 10.0.0.0/24,gateway core1",
+		"Voice high ",
+		"Office low parent 10.0.0.0/24,vlan 20,gateway core1",
+		"Lab  parent 10.0.0.0/24,vlan 20",
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("subnets = %q, want %q", order, want)
+	}
+
+	var deps []string
+	for _, d := range report.Dependencies {
+		deps = append(deps, d.Key+" "+d.Criticality+" "+strings.Join(d.Subnets, ","))
+	}
+	want = []string{
+		"gateway core1 critical Office,Payments",
+		"parent 10.0.0.0/24 critical Office,Payments,Lab",
+		"vlan 20 low Office,Lab",
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("dependencies = %q, want %q", deps, want)
+	}
+
+	if _, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "X", CIDR: 28, Criticality: "urgent"}}}}); err == nil || !strings.Contains(err.Error(), "invalid criticality") {
+		t.Errorf("expected invalid criticality error, got %v", err)
+	}
+}
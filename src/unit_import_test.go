@@ -0,0 +1,85 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestImportSolarWinds(t *testing.T) {
+	data := []byte("Address,CIDR,FriendlyName,VLAN,Comments,GroupType\n" +
+		"10.0.0.0,16,Campus,,,Supernet\n" +
+		"10.0.1.0,255.255.255.0,Users,10,Floor 1 // see http://wiki,Subnet\n" +
+		",,Branch Offices,,,Group\n" +
+		"192.168.5.0,/28,,,,Subnet\n")
+	networks, err := importSolarWinds(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Network{
+		{Network: "10.0.0.0/16", Subnets: []Subnet{{Name: "Users", VLAN: 10, CIDR: 24, Address: "10.0.1.0", Description: "Floor 1 // see http://wiki"}}},
+		{Network: "192.168.5.0/28", Subnets: []Subnet{{Name: "192.168.5.0/28", CIDR: 28, Address: "192.168.5.0"}}},
+	}
+	if !reflect.DeepEqual(networks, want) {
+		t.Errorf("importSolarWinds() = %+v, want %+v", networks, want)
+	}
+	if _, err := PlanSubnets(networks); err != nil {
+		t.Errorf("imported config does not plan: %v", err)
+	}
+	if _, err := importSolarWinds([]byte("Name,VLAN\nA,1\n")); err == nil {
+		t.Error("expected an error without an Address column")
+	}
+}
+
+func TestImportIPCalc(t *testing.T) {
+	data := []byte("#!/bin/sh\n" +
+		"ipcalc 10.0.0.0/24 -s 50 20 -b\n" +
+		"/usr/bin/ipcalc 10.0.1.0 255.255.255.0 /26 # four /26s, /* none spare\n" +
+		"10.0.0.0/24 --split 10\n")
+	networks, err := importIPCalc(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Subnet-1", Hosts: 50}, {Name: "Subnet-2", Hosts: 20}, {Name: "Subnet-3", Hosts: 10}}},
+		{Network: "10.0.1.0/24", Subnets: []Subnet{{Name: "Subnet-1", CIDR: 26}, {Name: "Subnet-2", CIDR: 26}, {Name: "Subnet-3", CIDR: 26}, {Name: "Subnet-4", CIDR: 26}}},
+	}
+	if !reflect.DeepEqual(networks, want) {
+		t.Errorf("importIPCalc() = %+v, want %+v", networks, want)
+	}
+	if _, err := importIPCalc([]byte("ipcalc 10.0.0.0/24 -s many\n")); err == nil {
+		t.Error("expected an error for an invalid host count")
+	}
+}
+
+func TestImportXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>Network</t></si><si><t>Name</t></si><si><t>Hosts/CIDR</t></si>` +
+			`<si><t>10.0.0.0/24</t></si><si><r><t>We</t></r><r><t>b</t></r></si><si><t>/26</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>Subnet Template</t></is></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c><c r="C2" t="s"><v>2</v></c><c r="D2" t="inlineStr"><is><t>VLAN</t></is></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>3</v></c><c r="B3" t="s"><v>4</v></c><c r="C3" t="s"><v>5</v></c><c r="D3"><v>100</v></c></row>` +
+			`<row r="4"><c r="B4" t="inlineStr"><is><t>App</t></is></c><c r="C4"><v>20</v></c></row>` +
+			`</sheetData></worksheet>`,
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	cfg, err := loadConfigFile("template.xlsx", buf.Bytes(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "Web", VLAN: 100, CIDR: 26}, {Name: "App", Hosts: 20}}}}
+	if !reflect.DeepEqual(cfg.Networks, want) {
+		t.Errorf("loadConfigFile(.xlsx) = %+v, want %+v", cfg.Networks, want)
+	}
+	if _, err := importXLSX([]byte("not a zip")); err == nil {
+		t.Error("expected an error for a non-workbook")
+	}
+}
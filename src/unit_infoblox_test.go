@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeWAPI implements the Infoblox WAPI calls used by the push, keeping
+// objects in memory by type.
+type fakeWAPI struct {
+	objects map[string][]map[string]interface{}
+	posts   int
+}
+
+func (f *fakeWAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, password, _ := r.BasicAuth(); user != "admin" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"Error": "AdmConProtoError: Authorization failed", "text": "Authorization failed"}`))
+		return
+	}
+	object := strings.TrimPrefix(r.URL.Path, "/wapi/v2.12/")
+	if r.Method == http.MethodPost {
+		var fields map[string]interface{}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &fields)
+		f.objects[object] = append(f.objects[object], fields)
+		f.posts++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%q", fmt.Sprintf("%s/ZG5z%d:%v", object, f.posts, fields["network"]))
+		return
+	}
+	query := r.URL.Query()
+	found := []map[string]string{}
+	if object == "ipv4address" {
+		ip := query.Get("ip_address")
+		status := "UNUSED"
+		for _, kind := range []string{"fixedaddress", "record:host"} {
+			for _, o := range f.objects[kind] {
+				if o["ipv4addr"] == ip || strings.Contains(fmt.Sprint(o["ipv4addrs"]), ip+"]") {
+					status = "USED"
+				}
+			}
+		}
+		found = append(found, map[string]string{"status": status})
+	} else {
+		for _, o := range f.objects[object] {
+			match := true
+			for k := range query {
+				if !strings.HasPrefix(k, "_") && fmt.Sprint(o[k]) != query.Get(k) {
+					match = false
+				}
+			}
+			if match {
+				found = append(found, map[string]string{"_ref": object})
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(found)
+}
+
+func TestPushInfoblox(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/24", Gateway: "first", DNSSuffix: "corp.example", Subnets: []Subnet{
+		{Name: "Users", VLAN: 10, CIDR: 26, IPAssignments: []IPAssignment{
+			{Name: "DNS", Position: 2},
+			{Name: "Pool", Position: 10, EndPosition: 50, DHCP: true},
+			{Name: "Printers", Position: 51, Count: 5},
+			{Name: "Spare", Position: 60, Reserved: true},
+		}},
+	}}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run without an Infoblox creates everything, but nothing is sent
+	var out strings.Builder
+	sum, err := pushInfoblox(nil, results, true, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != (pushSummary{Created: 6, Skipped: 1}) {
+		t.Errorf("dry run summary = %+v\n%s", sum, out.String())
+	}
+	if !strings.Contains(out.String(), "would create network container 10.0.0.0/24") {
+		t.Errorf("dry run output:\n%s", out.String())
+	}
+
+	fake := &fakeWAPI{objects: map[string][]map[string]interface{}{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client := &infobloxClient{base: srv.URL + "/wapi/v2.12", user: "admin", password: "secret", view: "default", client: srv.Client()}
+	if sum, err = pushInfoblox(client, results, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if sum != (pushSummary{Created: 6, Skipped: 1}) {
+		t.Errorf("push summary = %+v", sum)
+	}
+	counts := map[string]int{}
+	for kind, objects := range fake.objects {
+		counts[kind] = len(objects)
+		for _, o := range objects {
+			if o["network_view"] != "default" {
+				t.Errorf("%s created outside the network view: %v", kind, o)
+			}
+		}
+	}
+	want := map[string]int{"networkcontainer": 1, "network": 1, "range": 1, "record:host": 2, "fixedaddress": 1}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Errorf("created objects = %v, want %v", counts, want)
+	}
+	if r := fake.objects["range"][0]; r["start_addr"] != "10.0.0.10" || r["end_addr"] != "10.0.0.50" {
+		t.Errorf("DHCP range = %v", r)
+	}
+
+	// Pushing again finds everything in place
+	posts := fake.posts
+	if sum, err = pushInfoblox(client, results, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if sum.Created != 0 || sum.Existing != 6 || fake.posts != posts {
+		t.Errorf("second push = %+v with %d new objects", sum, fake.posts-posts)
+	}
+
+	client.password = "wrong"
+	if _, err := pushInfoblox(client, results, false, io.Discard); err == nil || !strings.Contains(err.Error(), "Authorization failed") {
+		t.Errorf("expected an authorization error, got %v", err)
+	}
+}
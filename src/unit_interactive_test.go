@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunInteractive(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "cfg.json")
+	script := strings.Join([]string{
+		"add web /25 10",
+		"add db 50",
+		"resize db 5000", // does not fit: reverted
+		"net add 10.1.0.0/24",
+		"move db 2",
+		"save " + cfg,
+		"quit",
+	}, "\n")
+
+	var out bytes.Buffer
+	networks := []Network{{Network: "10.0.0.0/24"}}
+	if err := runInteractive(networks, strings.NewReader(script), &out); err != nil {
+		t.Fatalf("runInteractive: %v", err)
+	}
+	if !strings.Contains(out.String(), "change reverted") {
+		t.Errorf("expected oversized resize to be reverted, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "*2 10.1.0.0/24") || !strings.Contains(out.String(), " 25%") {
+		t.Errorf("expected utilization bars for both networks, got:\n%s", out.String())
+	}
+
+	data, err := os.ReadFile(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := loadConfig(data, "")
+	if err != nil {
+		t.Fatalf("saved config does not parse: %v", err)
+	}
+	saved := parsed.Networks
+	if len(saved) != 2 || len(saved[0].Subnets) != 1 || saved[1].Subnets[0].Name != "db" || saved[1].Subnets[0].Hosts != 50 {
+		t.Errorf("unexpected saved config: %+v", saved)
+	}
+}
+
+func TestRunInteractive_Reorder(t *testing.T) {
+	var out bytes.Buffer
+	s := &interactiveSession{networks: []Network{{Network: "10.0.0.0/24"}}, out: &out}
+	for _, cmd := range []string{"add a /26", "add b /26", "reorder b 1"} {
+		if err := s.execute(strings.Fields(cmd)); err != nil {
+			t.Fatalf("%s: %v", cmd, err)
+		}
+	}
+	if err := s.execute(strings.Fields("reorder b 3")); err == nil {
+		t.Error("expected an out-of-range position to be rejected")
+	}
+	for _, r := range s.results {
+		if r.Name == "b" && r.Category == "Network" && r.Subnet != "10.0.0.0/26" {
+			t.Errorf("b placed at %s after moving it first, want 10.0.0.0/26", r.Subnet)
+		}
+	}
+}
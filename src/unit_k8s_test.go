@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportK8s(t *testing.T) {
+	results, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Web Tier", VLAN: 100, CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "Pool", Position: 10, Count: 5}}},
+		{Name: "App", CIDR: 27},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "subnets.yaml")
+	if err := ExportK8s(results, path, "", "network", true); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	if n := strings.Count(out, "\nkind: Subnet\n"); n != 2 {
+		t.Errorf("got %d Subnet resources, want 2:\n%s", n, out)
+	}
+	for _, want := range []string{
+		"kind: CustomResourceDefinition",
+		"name: subnets.ipsubnetplanner.microsoft.com",
+		"apiVersion: " + defaultK8sAPIVersion,
+		"  name: web-tier\n  namespace: network\n",
+		"  cidr: \"10.0.0.0/26\"\n",
+		"  vlan: 100\n  gateway: \"10.0.0.1\"\n",
+		"    - name: \"Gateway\"\n      ip: \"10.0.0.1\"\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\"Pool\"") {
+		t.Error("address blocks should not be listed as assignments")
+	}
+
+	if err := ExportK8s(results, path, "noversion", "", false); err == nil {
+		t.Error("invalid apiVersion accepted")
+	}
+}
+
+func TestExportWhereabouts(t *testing.T) {
+	results, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Pods", VLAN: 200, CIDR: 26, Tags: map[string]string{"multus": "eth1"}, IPAssignments: []IPAssignment{
+			{Name: "Gateway", Position: 1},
+			{Name: "Static", Position: 10, Count: 5},
+			{Name: "Lease", Position: 20, EndPosition: 30, DHCP: true},
+		}},
+		{Name: "Hosts", CIDR: 27},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "nad.yaml")
+	if err := ExportWhereabouts(results, path, "vlan", "pods"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	out := string(data)
+	if n := strings.Count(out, "kind: NetworkAttachmentDefinition"); n != 1 {
+		t.Errorf("got %d attachments, want 1:\n%s", n, out)
+	}
+	var config string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out[strings.Index(out, "config: ")+len("config: "):])), &config); err != nil {
+		t.Fatalf("config is not a YAML string: %v\n%s", err, out)
+	}
+	var cfg multusConfig
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		t.Fatalf("config is not JSON: %v\n%s", err, out)
+	}
+	if cfg.Master != "eth1" || cfg.VLANID != 200 || cfg.IPAM.Range != "10.0.0.0/26" || cfg.IPAM.Gateway != "10.0.0.1" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	want := []string{"10.0.0.1/32", "10.0.0.10/31", "10.0.0.12/31", "10.0.0.14/32"}
+	if !reflect.DeepEqual(cfg.IPAM.Exclude, want) {
+		t.Errorf("exclude = %v, want %v", cfg.IPAM.Exclude, want)
+	}
+	if !strings.Contains(out, "  name: pods\n  namespace: pods\n") {
+		t.Errorf("unexpected metadata:\n%s", out)
+	}
+
+	if err := ExportWhereabouts(results[len(results)-1:], path, "", ""); err == nil {
+		t.Error("expected an error without tagged subnets")
+	}
+}
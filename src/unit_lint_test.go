@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintPlan(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "web-tier", Hosts: 60, VLAN: 10, Description: "Front end", Owner: "web-team"},
+			{Name: "db-tier", Hosts: 30, VLAN: 10},
+			{Name: "Mgmt_Net", CIDR: 28},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := LintPlan(networks, results, 0)
+
+	if r.NamingStyle != "kebab-case" || r.Naming < 0.66 || r.Naming > 0.67 {
+		t.Errorf("naming = %.2f (%s), want 2/3 kebab-case", r.Naming, r.NamingStyle)
+	}
+	if r.Documentation < 0.33 || r.Documentation > 0.34 {
+		t.Errorf("documentation = %.2f, want 1/3", r.Documentation)
+	}
+	// 90 requested hosts in 62+30 usable addresses
+	if r.Efficiency < 0.97 || r.Efficiency > 0.98 {
+		t.Errorf("efficiency = %.2f, want 90/92", r.Efficiency)
+	}
+	want := []string{"VLAN 10 is shared", "no VLAN while others", "web-tier: 60 hosts leave less than 10% headroom", "db-tier: 30 hosts leave"}
+	joined := strings.Join(r.Warnings, "\n")
+	for _, w := range want {
+		if !strings.Contains(joined, w) {
+			t.Errorf("missing warning %q in:\n%s", w, joined)
+		}
+	}
+	if r.Score < 0 || r.Score > 100 {
+		t.Errorf("score %d out of range", r.Score)
+	}
+
+	// -growth gives headroom, except to a subnet that sets growth 0
+	networks[0].Subnets[1].Growth = intPtr(0)
+	joined = strings.Join(lintWarnings(networks, results, 30), "\n")
+	if strings.Contains(joined, "web-tier: 60 hosts") || !strings.Contains(joined, "db-tier: 30 hosts") {
+		t.Errorf("headroom warnings with -growth 30:\n%s", joined)
+	}
+}
+
+func TestLintPlan_CleanPlanScoresFull(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{{Name: "Web", CIDR: 26, VLAN: 10, Description: "Web servers", Owner: "Ops"}},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := LintPlan(networks, results, 0); r.Score != 100 || len(r.Warnings) != 0 {
+		t.Errorf("expected a clean plan to score 100, got %+v", r)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockedWrite_DetectsExternalEdits(t *testing.T) {
+	results := []SubnetResult{{Name: "Users", Subnet: "192.168.1.0/26"}}
+	path := filepath.Join(t.TempDir(), "plan.csv")
+	write := func() error { return ExportCSV(results, path) }
+
+	// First generation records state; regenerating an untouched file is fine.
+	if err := lockedWrite(path, false, write); err != nil {
+		t.Fatalf("first lockedWrite() error = %v", err)
+	}
+	if err := lockedWrite(path, false, write); err != nil {
+		t.Fatalf("regenerating unmodified export failed: %v", err)
+	}
+
+	// A colleague edits the file by hand.
+	if err := os.WriteFile(path, []byte("edited by hand\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := lockedWrite(path, false, write)
+	if err == nil || !strings.Contains(err.Error(), "modified since") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "edited by hand\n" {
+		t.Error("conflicting export must not be overwritten")
+	}
+
+	if err := lockedWrite(path, true, write); err != nil {
+		t.Fatalf("forced lockedWrite() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file should be removed after export")
+	}
+}
+
+func TestLockedWrite_RespectsExistingLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.md")
+	if err := os.WriteFile(path+".lock", []byte("pid 1 on other-host"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	called := false
+	err := lockedWrite(path, false, func() error { called = true; return nil })
+	if err == nil || !strings.Contains(err.Error(), "other-host") {
+		t.Fatalf("expected lock error naming the owner, got %v", err)
+	}
+	if called {
+		t.Error("export must not run while another planner holds the lock")
+	}
+}
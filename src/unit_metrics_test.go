@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Mgmt", VLAN: 10, CIDR: 28, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "Pool", Position: 5, Count: 4}}},
+			{Name: "Servers", CIDR: 26},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	WriteMetrics(&sb, results)
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE ipsubnetplanner_network_total_addresses gauge",
+		`ipsubnetplanner_network_total_addresses{network="10.0.0.0/24"} 256`,
+		`ipsubnetplanner_network_allocated_addresses{network="10.0.0.0/24"} 80`,
+		`ipsubnetplanner_network_assigned_addresses{network="10.0.0.0/24"} 5`,
+		`ipsubnetplanner_network_free_addresses{network="10.0.0.0/24"} 176`,
+		`ipsubnetplanner_subnet_total_addresses{network="10.0.0.0/24",subnet="10.0.0.64/28",name="Mgmt",vlan="10"} 16`,
+		`ipsubnetplanner_subnet_assigned_addresses{network="10.0.0.0/24",subnet="10.0.0.64/28",name="Mgmt",vlan="10"} 5`,
+		`ipsubnetplanner_subnet_free_addresses{network="10.0.0.0/24",subnet="10.0.0.64/28",name="Mgmt",vlan="10"} 9`,
+		`ipsubnetplanner_subnet_free_addresses{network="10.0.0.0/24",subnet="10.0.0.0/26",name="Servers",vlan="0"} 62`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("metrics missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricLabelEscaping(t *testing.T) {
+	if got := metricLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("metricLabel = %s", got)
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	srv := httptest.NewServer(newServer("", "", nil, nil))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status without -input = %d, want 404", resp.StatusCode)
+	}
+
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{"network": "10.0.0.0/24", "subnets": [{"name": "A", "cidr": 25}]}`), 0644)
+	srv = httptest.NewServer(newServer(config, "", nil, nil))
+	defer srv.Close()
+	resp, err = http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("content type = %q", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), `ipsubnetplanner_network_free_addresses{network="10.0.0.0/24"} 128`) {
+		t.Errorf("unexpected metrics:\n%s", body)
+	}
+}
+
+func TestServerMetrics_Environment(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{"environments": {"prod": [{"network": "10.1.0.0/24", "subnets": [{"name": "A", "cidr": 25}]}]}}`), 0644)
+	srv := httptest.NewServer(newServer(config, "prod", nil, nil))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `ipsubnetplanner_network_free_addresses{network="10.1.0.0/24"} 128`) {
+		t.Errorf("status %d, metrics of the prod environment expected:\n%s", resp.StatusCode, body)
+	}
+}
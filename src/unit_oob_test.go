@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckOOB(t *testing.T) {
+	cfg, err := loadConfig([]byte(`{
+		"oob": {"subnets": ["BMC"], "name": "{name}-bmc", "map": {"sw-core": "sw-core-mgmt"}},
+		"networks": [{"network": "10.0.0.0/24", "subnets": [
+			{"name": "Compute", "cidr": 26, "IPAssignments": [
+				{"Name": "Gateway", "Position": 1}, {"Name": "node1", "Position": 2},
+				{"Name": "node2", "Position": 3}, {"Name": "sw-core", "Position": 4},
+				{"Name": "Pool", "Position": 10, "Count": 5}]},
+			{"name": "BMC", "cidr": 27, "IPAssignments": [
+				{"Name": "NODE1-BMC", "Position": 1}, {"Name": "sw-core-mgmt", "Position": 2}]}
+		]}]
+	}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := PlanSubnets(cfg.Networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, checked := CheckOOB(*cfg.OOB, results)
+	if checked != 3 {
+		t.Errorf("checked %d devices, want 3 (gateway and blocks are not devices)", checked)
+	}
+	if len(missing) != 1 || missing[0].Device != "node2" || missing[0].Expected != "node2-bmc" {
+		t.Errorf("missing = %+v, want node2", missing)
+	}
+
+	var sb strings.Builder
+	PrintOOBCheck(&sb, missing, checked, cfg.OOB.Subnets)
+	if !strings.Contains(sb.String(), "1 of 3 in-band device(s) have no OOB address") {
+		t.Errorf("unexpected report:\n%s", sb.String())
+	}
+
+	// Restricting the in-band subnets to one without devices checks nothing
+	if _, checked := CheckOOB(OOBCheck{Subnets: []string{"BMC"}, InBand: []string{"Storage"}}, results); checked != 0 {
+		t.Errorf("checked %d devices outside the in-band list", checked)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortResults(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Mgmt", CIDR: 28, VLAN: 30, IPAssignments: []IPAssignment{{Name: "VMM", Position: 10}, {Name: "Gateway", Position: 1}}},
+			{Name: "Web", CIDR: 26, VLAN: 20},
+			{Name: "App", CIDR: 26, VLAN: 10},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := func(rows []SubnetResult) []string {
+		var out []string
+		for i, r := range rows {
+			if i == 0 || rows[i-1].Subnet != r.Subnet {
+				out = append(out, r.Name+" "+r.Subnet)
+			}
+		}
+		return out
+	}
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"input", []string{"Mgmt 10.0.0.128/28", "Web 10.0.0.0/26", "App 10.0.0.64/26", "Available 10.0.0.144/28", "Available 10.0.0.160/27", "Available 10.0.0.192/26"}},
+		{"address", []string{"Web 10.0.0.0/26", "App 10.0.0.64/26", "Mgmt 10.0.0.128/28", "Available 10.0.0.144/28", "Available 10.0.0.160/27", "Available 10.0.0.192/26"}},
+		{"name", []string{"App 10.0.0.64/26", "Mgmt 10.0.0.128/28", "Web 10.0.0.0/26", "Available 10.0.0.144/28", "Available 10.0.0.160/27", "Available 10.0.0.192/26"}},
+		{"vlan", []string{"App 10.0.0.64/26", "Web 10.0.0.0/26", "Mgmt 10.0.0.128/28", "Available 10.0.0.144/28", "Available 10.0.0.160/27", "Available 10.0.0.192/26"}},
+	}
+	for _, tt := range tests {
+		sorted, err := SortResults(results, tt.order, networks)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.order, err)
+		}
+		if len(sorted) != len(results) {
+			t.Fatalf("%s: %d rows, want %d", tt.order, len(sorted), len(results))
+		}
+		if got := blocks(sorted); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s order:\n got  %v\n want %v", tt.order, got, tt.want)
+		}
+		// Rows inside a subnet are in address order
+		for i := 1; i < len(sorted); i++ {
+			if sorted[i].Subnet == sorted[i-1].Subnet {
+				a, _, _ := parseIPSpan(sorted[i-1].IP)
+				b, _, _ := parseIPSpan(sorted[i].IP)
+				if a > b {
+					t.Errorf("%s: %s listed before %s", tt.order, sorted[i-1].IP, sorted[i].IP)
+				}
+			}
+		}
+	}
+
+	// Several keys: VLAN first, then name
+	shared := []SubnetResult{
+		{Name: "B", VLAN: 10, Subnet: "10.0.0.0/30", IP: "10.0.0.0", Category: "Network"},
+		{Name: "C", VLAN: 5, Subnet: "10.0.0.4/30", IP: "10.0.0.4", Category: "Network"},
+		{Name: "A", VLAN: 10, Subnet: "10.0.0.8/30", IP: "10.0.0.8", Category: "Network"},
+	}
+	sorted, err := SortResults(shared, "vlan, name", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := blocks(sorted); !reflect.DeepEqual(got, []string{"C 10.0.0.4/30", "A 10.0.0.8/30", "B 10.0.0.0/30"}) {
+		t.Errorf("vlan,name order: %v", got)
+	}
+
+	// The category key orders rows within each subnet, which stays whole
+	sorted, err = SortResults(results, "vlan,category", networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := blocks(sorted), tests[3].want; !reflect.DeepEqual(got, want) {
+		t.Errorf("vlan,category order:\n got  %v\n want %v", got, want)
+	}
+	var rows []string
+	for _, r := range sorted {
+		if r.Name == "Mgmt" {
+			rows = append(rows, r.Category+" "+r.IP)
+		}
+	}
+	want := []string{"Network 10.0.0.128", "Assignment 10.0.0.129", "Assignment 10.0.0.138"}
+	if !reflect.DeepEqual(rows[:3], want) || rows[len(rows)-1] != "Broadcast 10.0.0.143" {
+		t.Errorf("vlan,category rows of Mgmt: %v", rows)
+	}
+
+	if _, err := SortResults(results, "size", networks); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakePHPIPAM implements the phpIPAM API calls used by the push, keeping
+// objects in memory.
+type fakePHPIPAM struct {
+	sections  map[string]string            // name -> id
+	subnets   map[string]map[string]string // "ip/mask" -> fields
+	addresses map[string]bool              // "subnetId ip"
+	posts     []string
+	nextID    int
+}
+
+func (f *fakePHPIPAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(code int, body map[string]interface{}) {
+		body["code"] = code
+		body["success"] = code < 300
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	}
+	if r.Header.Get("token") != "secret" {
+		reply(http.StatusUnauthorized, map[string]interface{}{"message": "Invalid token"})
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/planner/"), "/"), "/")
+	if r.Method == http.MethodPost {
+		var fields map[string]interface{}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &fields)
+		f.nextID++
+		id := fmt.Sprint(f.nextID)
+		switch parts[0] {
+		case "sections":
+			f.sections[fmt.Sprint(fields["name"])] = id
+		case "subnets":
+			f.subnets[fmt.Sprintf("%v/%v", fields["subnet"], fields["mask"])] = map[string]string{"id": id, "sectionId": fmt.Sprint(fields["sectionId"]), "masterSubnetId": fmt.Sprint(fields["masterSubnetId"])}
+		case "addresses":
+			f.addresses[fmt.Sprintf("%v %v", fields["subnetId"], fields["ip"])] = true
+		}
+		f.posts = append(f.posts, parts[0]+" "+string(data))
+		reply(http.StatusCreated, map[string]interface{}{"id": id})
+		return
+	}
+	switch {
+	case parts[0] == "sections" && f.sections[parts[1]] != "":
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]string{"id": f.sections[parts[1]]}})
+	case parts[0] == "subnets" && f.subnets[parts[2]+"/"+parts[3]] != nil:
+		reply(http.StatusOK, map[string]interface{}{"data": []map[string]string{f.subnets[parts[2]+"/"+parts[3]]}})
+	case parts[0] == "addresses" && f.addresses[parts[2]+" "+parts[1]]:
+		reply(http.StatusOK, map[string]interface{}{"data": map[string]string{"ip": parts[1]}})
+	default:
+		reply(http.StatusNotFound, map[string]interface{}{"message": "Not found"})
+	}
+}
+
+func TestPushPHPIPAM(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/24", Gateway: "first", Subnets: []Subnet{
+		{Name: "Users", VLAN: 10, CIDR: 26, IPAssignments: []IPAssignment{
+			{Name: "DNS", Position: 2},
+			{Name: "Pool", Position: 10, EndPosition: 50, DHCP: true},
+			{Name: "Spare", Position: 60, Reserved: true},
+		}},
+	}}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run without a phpIPAM creates everything, but nothing is sent
+	var out strings.Builder
+	sum, err := pushPHPIPAM(nil, results, "Lab", true, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != (pushSummary{Created: 6, Skipped: 1}) {
+		t.Errorf("dry run summary = %+v\n%s", sum, out.String())
+	}
+	if !strings.Contains(out.String(), "would create subnet 10.0.0.0/26 Users (VLAN 10)") {
+		t.Errorf("dry run output:\n%s", out.String())
+	}
+
+	fake := &fakePHPIPAM{sections: map[string]string{}, subnets: map[string]map[string]string{}, addresses: map[string]bool{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client := &phpipamClient{base: srv.URL + "/api/planner", token: "secret", client: srv.Client()}
+	if sum, err = pushPHPIPAM(client, results, "Lab", false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if sum != (pushSummary{Created: 6, Skipped: 1}) {
+		t.Errorf("push summary = %+v", sum)
+	}
+	users := fake.subnets["10.0.0.0/26"]
+	if users == nil || users["masterSubnetId"] != fake.subnets["10.0.0.0/24"]["id"] {
+		t.Errorf("Users should be created inside its parent network: %v", fake.subnets)
+	}
+	if !fake.addresses[users["id"]+" 10.0.0.60"] {
+		t.Errorf("reserved address missing: %v", fake.addresses)
+	}
+
+	// Pushing again finds everything in place
+	posts := len(fake.posts)
+	if sum, err = pushPHPIPAM(client, results, "Lab", false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if sum.Created != 0 || sum.Existing != 6 || len(fake.posts) != posts {
+		t.Errorf("second push = %+v with %d new requests", sum, len(fake.posts)-posts)
+	}
+
+	client.token = "wrong"
+	if _, err := pushPHPIPAM(client, results, "Other", false, io.Discard); err == nil || !strings.Contains(err.Error(), "Invalid token") {
+		t.Errorf("expected an authorization error, got %v", err)
+	}
+}
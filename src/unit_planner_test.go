@@ -1,8 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"net/netip"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCalculatePrefixFromHosts(t *testing.T) {
@@ -492,6 +499,194 @@ func TestPlanSingleNetwork_NegativePositions(t *testing.T) {
 	}
 }
 
+func TestPlanSingleNetwork_AssignmentBlocks(t *testing.T) {
+	network := Network{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{
+				Name: "Servers",
+				CIDR: 24,
+				IPAssignments: []IPAssignment{
+					{Name: "Gateway", Position: 1},
+					{Name: "K8s-Nodes", Position: 10, Count: 20},
+					{Name: "DHCP", Position: 50, EndPosition: 200},
+					{Name: "Tail", Position: -3, EndPosition: -1},
+				},
+			},
+		},
+	}
+
+	results, err := planSingleNetwork(network)
+	if err != nil {
+		t.Fatalf("planSingleNetwork() error = %v", err)
+	}
+
+	want := map[string]struct {
+		ip    string
+		total int
+	}{
+		"Gateway":   {"10.0.0.1", 1},
+		"K8s-Nodes": {"10.0.0.10 - 10.0.0.29", 20},
+		"DHCP":      {"10.0.0.50 - 10.0.0.200", 151},
+		"Tail":      {"10.0.0.252 - 10.0.0.254", 3},
+	}
+	unused := 0
+	for _, r := range results {
+		if w, ok := want[r.Label]; ok {
+			if r.IP != w.ip || r.TotalIPs != w.total || r.Category != "Assignment" {
+				t.Errorf("%s = %s (%d), want %s (%d)", r.Label, r.IP, r.TotalIPs, w.ip, w.total)
+			}
+			delete(want, r.Label)
+		}
+		if r.Category == "Unused" {
+			unused += r.TotalIPs
+		}
+	}
+	for label := range want {
+		t.Errorf("assignment %s not found", label)
+	}
+	// 254 usable - 1 - 20 - 151 - 3
+	if unused != 79 {
+		t.Errorf("unused addresses = %d, want 79", unused)
+	}
+}
+
+func TestPlanSingleNetwork_InvalidAssignmentBlocks(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment IPAssignment
+	}{
+		{"Block past end of subnet", IPAssignment{Name: "Pool", Position: 10, Count: 10}},
+		{"End before start", IPAssignment{Name: "Pool", Position: 10, EndPosition: 5}},
+		{"Count and EndPosition", IPAssignment{Name: "Pool", Position: 1, Count: 2, EndPosition: 3}},
+		{"Position outside subnet", IPAssignment{Name: "Far", Position: 40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network := Network{
+				Network: "10.0.0.0/24",
+				Subnets: []Subnet{{Name: "Small", CIDR: 28, IPAssignments: []IPAssignment{tt.assignment}}},
+			}
+			if _, err := planSingleNetwork(network); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestPlanEach(t *testing.T) {
+	networks := []Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "A", CIDR: 26}}},
+		{Network: "10.0.1.0/24", Subnets: []Subnet{{Name: "B", CIDR: 26}}},
+	}
+	want, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+
+	var got []SubnetResult
+	if err := PlanEach(networks, func(r SubnetResult) error {
+		got = append(got, r)
+		return nil
+	}); err != nil {
+		t.Fatalf("PlanEach() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PlanEach() yielded %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// A sink error stops iteration and is returned unchanged
+	stop := errors.New("sink full")
+	calls := 0
+	err = PlanEach(networks, func(SubnetResult) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("PlanEach() = %v after %d calls, want sink error after 1 call", err, calls)
+	}
+}
+
+func TestPlanner_Workers(t *testing.T) {
+	var networks []Network
+	for i := 0; i < 50; i++ {
+		networks = append(networks, Network{
+			Network: fmt.Sprintf("10.%d.0.0/24", i),
+			Subnets: []Subnet{{Name: "A", Hosts: 60}, {Name: "B", CIDR: 27, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}},
+		})
+	}
+	want, err := Planner{Workers: 1}.Plan(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Planner{Workers: 8}.Plan(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("concurrent plan differs from serial plan")
+	}
+
+	// The reported error is the first failing network in input order
+	networks[30].Subnets = append(networks[30].Subnets, Subnet{Name: "Huge", CIDR: 8})
+	networks[10].Network = "10.10.0.0/33"
+	for i := 0; i < 10; i++ {
+		_, err := Planner{Workers: 8}.Plan(networks)
+		if err == nil || !strings.Contains(err.Error(), "10.10.0.0/33") {
+			t.Fatalf("Plan() error = %v, want the error of network 10", err)
+		}
+	}
+}
+
+func TestPlanner_Constraint(t *testing.T) {
+	// Avoid any block whose third octet is 1
+	planner := Planner{Constraint: func(candidate netip.Prefix, subnet Subnet) bool {
+		return candidate.Addr().As4()[2] != 1
+	}}
+	results, err := planner.Plan([]Network{{
+		Network: "10.0.0.0/22",
+		Subnets: []Subnet{{Name: "A", CIDR: 24}, {Name: "B", CIDR: 24}, {Name: "C", CIDR: 25}},
+	}})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	placed := make(map[string]string)
+	availableInSkipped := false
+	for _, r := range results {
+		if r.Category == "Network" {
+			placed[r.Name] = r.Subnet
+		}
+		if r.Name == "Available" && r.Subnet == "10.0.1.0/24" {
+			availableInSkipped = true
+		}
+	}
+	want := map[string]string{"A": "10.0.0.0/24", "B": "10.0.2.0/24", "C": "10.0.3.0/25"}
+	for name, subnet := range want {
+		if placed[name] != subnet {
+			t.Errorf("%s placed at %s, want %s", name, placed[name], subnet)
+		}
+	}
+	if !availableInSkipped {
+		t.Error("block rejected by the constraint should be reported as Available")
+	}
+}
+
+func TestPlanSingleNetwork_ExceedsParent(t *testing.T) {
+	network := Network{
+		Network: "192.168.1.0/24",
+		Subnets: []Subnet{{Name: "Big", CIDR: 24}, {Name: "Extra", CIDR: 28}},
+	}
+	if _, err := planSingleNetwork(network); err == nil {
+		t.Error("expected error when subnets exceed the parent network")
+	}
+}
+
 // Benchmark tests
 func BenchmarkCalculatePrefixFromHosts(b *testing.B) {
 	for i := 0; i < b.N; i++ {
@@ -547,3 +742,725 @@ func BenchmarkProcessIPAssignments(b *testing.B) {
 		processIPAssignments(subnet, "192.168.1.0/24", 24)
 	}
 }
+
+func BenchmarkProcessIPAssignments_Slash8(b *testing.B) {
+	subnet := Subnet{Name: "Huge"}
+	for i := 1; i <= 10; i++ {
+		subnet.IPAssignments = append(subnet.IPAssignments, IPAssignment{Name: fmt.Sprintf("Host%d", i), Position: i * 100000})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processIPAssignments(subnet, "10.0.0.0/8", 8)
+	}
+}
+
+func TestProcessIPAssignments_UnusedGaps(t *testing.T) {
+	subnet := Subnet{Name: "Huge", IPAssignments: []IPAssignment{
+		{Name: "Block", Position: 100, EndPosition: 200},
+		{Name: "Inside", Position: 150},
+		{Name: "Gateway", Position: 1},
+		{Name: "Last", Position: -1},
+	}}
+	results := processIPAssignments(subnet, "10.0.0.0/8", 8)
+	var unused []string
+	for _, r := range results {
+		if r.Category == "Unused" {
+			unused = append(unused, fmt.Sprintf("%s/%d", r.IP, r.TotalIPs))
+		}
+	}
+	want := []string{"10.0.0.2 - 10.0.0.99/98", "10.0.0.201 - 10.255.255.253/16777013"}
+	if !reflect.DeepEqual(unused, want) {
+		t.Errorf("unused ranges = %v, want %v", unused, want)
+	}
+}
+
+func TestPlanSubnets_GatewayConvention(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Gateway: "last",
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26},
+			{Name: "DB", CIDR: 27, Gateway: "offset:5"},
+			{Name: "Mgmt", CIDR: 28, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 2}}},
+			{Name: "Lab", CIDR: 28, Gateway: "none"},
+			{Name: "Link", CIDR: 31},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets: %v", err)
+	}
+	gateways := map[string]string{}
+	for _, r := range results {
+		if r.Label == "Gateway" {
+			gateways[r.Name] = r.IP
+		}
+	}
+	want := map[string]string{"Web": "10.0.0.62", "DB": "10.0.0.69", "Mgmt": "10.0.0.98"}
+	if !reflect.DeepEqual(gateways, want) {
+		t.Errorf("gateways = %v, want %v", gateways, want)
+	}
+
+	networks[0].Gateway = "middle"
+	if _, err := PlanSubnets(networks); err == nil || !strings.Contains(err.Error(), "invalid gateway") {
+		t.Errorf("expected invalid gateway error, got %v", err)
+	}
+}
+
+func TestPlanSubnets_Redundancy(t *testing.T) {
+	networks := []Network{{
+		Network:    "10.0.0.0/24",
+		Redundancy: &Redundancy{Protocol: "hsrp"},
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26},
+			{Name: "DB", CIDR: 27, Gateway: "last", Redundancy: &Redundancy{Protocol: "GLBP", Routers: 3}},
+			{Name: "Lab", CIDR: 28, Redundancy: &Redundancy{Protocol: "none"}},
+			{Name: "Link", CIDR: 30, P2P: true},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets: %v", err)
+	}
+	got := map[string][]string{}
+	for _, r := range results {
+		if r.Category == "Virtual IP" || r.Category == "Router" {
+			got[r.Name] = append(got[r.Name], r.Label+" "+r.IP+" "+r.Category+" "+r.Tags["protocol"])
+		}
+	}
+	want := map[string][]string{
+		"Web": {"Gateway 10.0.0.1 Virtual IP hsrp", "Router1 10.0.0.2 Router hsrp", "Router2 10.0.0.3 Router hsrp"},
+		"DB":  {"Router3 10.0.0.91 Router glbp", "Router2 10.0.0.92 Router glbp", "Router1 10.0.0.93 Router glbp", "Gateway 10.0.0.94 Virtual IP glbp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redundancy rows = %v, want %v", got, want)
+	}
+
+	// Each added assignment has its own tags
+	subnet, err := applyRedundancy(Subnet{Name: "X"}, &Redundancy{Protocol: "vrrp"}, nil, "", 28)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subnet.IPAssignments[1].Tags["rack"] = "r1"
+	if _, ok := subnet.IPAssignments[0].Tags["rack"]; ok {
+		t.Error("redundancy assignments share one tags map")
+	}
+
+	// The plan converts back to a config that reproduces it
+	again, err := PlanSubnets(planToConfig(results))
+	if err != nil {
+		t.Fatalf("PlanSubnets(planToConfig): %v", err)
+	}
+	if d := DiffPlans(results, again); d.HasChanges() {
+		t.Errorf("round trip changed the plan: %+v", d)
+	}
+
+	for _, tc := range []struct {
+		subnet Subnet
+		err    string
+	}{
+		{Subnet{Name: "X", CIDR: 28, Redundancy: &Redundancy{Protocol: "carp"}}, "invalid redundancy protocol"},
+		{Subnet{Name: "X", CIDR: 28, Redundancy: &Redundancy{Protocol: "glbp", Routers: 5}}, "GLBP needs 2 to 4 routers"},
+		{Subnet{Name: "X", CIDR: 31, Redundancy: &Redundancy{Protocol: "vrrp"}}, "too small"},
+		{Subnet{Name: "X", CIDR: 28, Redundancy: &Redundancy{Protocol: "vrrp"}, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}, "conflicts"},
+	} {
+		_, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{tc.subnet}}})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%+v: expected %q error, got %v", tc.subnet.Redundancy, tc.err, err)
+		}
+	}
+}
+
+func TestPlanToConfig_Status(t *testing.T) {
+	p := Planner{QuarantineDays: 30, Now: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)}
+	results, err := p.Plan([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Old", CIDR: 26, Decommissioned: true, DecommissionedOn: "2026-03-01"},
+			{Name: "Gone", CIDR: 26, Decommissioned: true},
+			{Name: "Next", CIDR: 26, PlannedFor: "2026-06-01"},
+			{Name: "Live", CIDR: 26},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range results {
+		if results[i].Name == "Live" {
+			results[i].Status = "Imported" // not a planner status
+		}
+	}
+
+	got := map[string]Subnet{}
+	for _, s := range planToConfig(results)[0].Subnets {
+		got[s.Name] = s
+	}
+	if s := got["Old"]; !s.Decommissioned || s.DecommissionedOn != "2026-03-01" {
+		t.Errorf("quarantined subnet = %+v, want decommissioned on 2026-03-01", s)
+	}
+	if s := got["Gone"]; !s.Decommissioned || s.DecommissionedOn != "" {
+		t.Errorf("decommissioned subnet = %+v", s)
+	}
+	if s := got["Next"]; s.Decommissioned || s.PlannedFor != "2026-06-01" {
+		t.Errorf("planned subnet = %+v, want planned and not decommissioned", s)
+	}
+	if got["Live"].Decommissioned {
+		t.Error("an unknown status should not decommission a subnet")
+	}
+}
+
+func TestPlanSubnets_RedundantGateway(t *testing.T) {
+	data := []byte(`{"network": "10.0.0.0/24", "redundantGateway": true, "subnets": [
+		{"name": "Web", "cidr": 26},
+		{"name": "DB", "cidr": 27, "gateway": "last"},
+		{"name": "App", "cidr": 27, "redundantGateway": {"vip": 10, "routerA": "last-1", "routerB": "last"}},
+		{"name": "Lab", "cidr": 28, "redundantGateway": false},
+		{"name": "Link", "cidr": 30, "p2p": true}
+	]}`)
+	var network Network
+	if err := json.Unmarshal(data, &network); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	results, err := PlanSubnets([]Network{network})
+	if err != nil {
+		t.Fatalf("PlanSubnets: %v", err)
+	}
+	got := map[string][]string{}
+	for _, r := range results {
+		if r.Category == "Virtual IP" || r.Category == "Router" {
+			got[r.Name] = append(got[r.Name], r.Label+" "+r.IP+" "+r.Category)
+		}
+	}
+	want := map[string][]string{
+		"Web": {"Gateway 10.0.0.1 Virtual IP", "Router-A 10.0.0.2 Router", "Router-B 10.0.0.3 Router"},
+		"DB":  {"Router-B 10.0.0.92 Router", "Router-A 10.0.0.93 Router", "Gateway 10.0.0.94 Virtual IP"},
+		"App": {"Gateway 10.0.0.106 Virtual IP", "Router-A 10.0.0.125 Router", "Router-B 10.0.0.126 Router"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redundant gateway rows = %v, want %v", got, want)
+	}
+
+	// The plan converts back to a config that reproduces it
+	again, err := PlanSubnets(planToConfig(results))
+	if err != nil {
+		t.Fatalf("PlanSubnets(planToConfig): %v", err)
+	}
+	if d := DiffPlans(results, again); d.HasChanges() {
+		t.Errorf("round trip changed the plan: %+v", d)
+	}
+
+	for _, tc := range []struct {
+		subnet Subnet
+		err    string
+	}{
+		{Subnet{Name: "X", CIDR: 31, RedundantGateway: &RedundantGateway{Enabled: true}}, "too small"},
+		{Subnet{Name: "X", CIDR: 28, RedundantGateway: &RedundantGateway{Enabled: true}, Redundancy: &Redundancy{Protocol: "vrrp"}}, "set only one"},
+		{Subnet{Name: "X", CIDR: 28, RedundantGateway: &RedundantGateway{Enabled: true}, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}}, "conflicts"},
+	} {
+		_, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{tc.subnet}}})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected %q error, got %v", tc.subnet.Name, tc.err, err)
+		}
+	}
+
+	// A subnet's own redundancy wins over the network's triplet, which
+	// conflicts with the network's redundancy
+	gateway := &RedundantGateway{Enabled: true}
+	results, err = PlanSubnets([]Network{{Network: "10.0.0.0/24", RedundantGateway: gateway, Subnets: []Subnet{
+		{Name: "Web", CIDR: 26, Redundancy: &Redundancy{Protocol: "vrrp", Routers: 3}},
+	}}})
+	if err != nil || len(results) < 5 || results[4].Label != "Router3" || results[4].Tags["protocol"] != "vrrp" {
+		t.Errorf("subnet redundancy under a network triplet = %+v, %v", results, err)
+	}
+	_, err = PlanSubnets([]Network{{Network: "10.0.0.0/24", RedundantGateway: gateway, Redundancy: &Redundancy{Protocol: "hsrp"}, Subnets: []Subnet{{Name: "Web", CIDR: 26}}}})
+	if err == nil || !strings.Contains(err.Error(), "set only one") {
+		t.Errorf("expected a conflict between the network's redundancy and triplet, got %v", err)
+	}
+}
+
+func TestPlanSubnets_PositionExpressions(t *testing.T) {
+	data := []byte(`{"network": "10.0.0.0/24", "gateway": "first", "variables": {"pool": 20}, "subnets": [
+		{"name": "Web", "cidr": 26, "IPAssignments": [
+			{"Name": "Spare", "Position": "dhcp_pool.end+1"},
+			{"Name": "DHCP Pool", "Position": "lb+10", "EndPosition": "dhcp_pool+pool-1"},
+			{"Name": "LB", "Position": "gateway+1"},
+			{"Name": "TOR", "Position": "last-1"},
+			{"Name": "Fixed", "Position": "5"}]}]}`)
+	cfg, err := loadConfig(data, "")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	networks := cfg.Networks
+	if out, _ := json.Marshal(networks[0].Subnets[0].IPAssignments[1]); !strings.Contains(string(out), `"Position":"lb+10","EndPosition":"dhcp_pool+pool-1"`) {
+		t.Errorf("expressions are not written back: %s", out)
+	}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatalf("PlanSubnets: %v", err)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		if r.Category == "Assignment" {
+			got[r.Label] = r.IP
+		}
+	}
+	want := map[string]string{"Gateway": "10.0.0.1", "LB": "10.0.0.2", "DHCP Pool": "10.0.0.12 - 10.0.0.31", "Spare": "10.0.0.32", "TOR": "10.0.0.61", "Fixed": "10.0.0.5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("assignments = %v, want %v", got, want)
+	}
+
+	for expr, msg := range map[string]string{"b+1": "unknown variable", "first-2": "evaluates to -1", "last/4": "evaluates to 15.5"} {
+		subnet := Subnet{Name: "X", CIDR: 26, IPAssignments: []IPAssignment{{Name: "A", PositionExpr: expr}, {Name: "B", PositionExpr: "a+1"}}}
+		_, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{subnet}}})
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: expected %q error, got %v", expr, msg, err)
+		}
+	}
+}
+
+func TestPlanner_Decommissioned(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/25",
+		Subnets: []Subnet{
+			{Name: "Old", CIDR: 26, Decommissioned: true},
+			{Name: "New", CIDR: 26},
+		},
+	}}
+
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		want := ""
+		if r.Name == "Old" {
+			want = "Decommissioned"
+		}
+		if r.Status != want {
+			t.Errorf("%s %s: status %q, want %q", r.Name, r.Label, r.Status, want)
+		}
+	}
+
+	// Quarantined space cannot take another /26; reclaiming frees it
+	networks[0].Subnets = append(networks[0].Subnets, Subnet{Name: "Extra", CIDR: 26})
+	if _, err := PlanSubnets(networks); err == nil {
+		t.Error("expected decommissioned space to stay allocated")
+	}
+	results, err = Planner{Reclaim: true}.Plan(networks)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	for _, r := range results {
+		if r.Name == "Old" {
+			t.Errorf("reclaimed subnet still in output: %+v", r)
+		}
+	}
+}
+
+func TestPlanner_QuarantinePeriod(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/25",
+		Subnets: []Subnet{
+			{Name: "Old", CIDR: 26, Decommissioned: true, DecommissionedOn: "2025-01-01"},
+			{Name: "New", CIDR: 26},
+		},
+	}}
+	during := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	after := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	results, err := Planner{Reclaim: true, QuarantineDays: 30, Now: during}.Plan(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range results {
+		if r.Name == "Old" {
+			found = true
+			if r.Status != "Quarantined until 2025-01-31" {
+				t.Errorf("status = %q, want quarantine annotation", r.Status)
+			}
+		}
+	}
+	if !found {
+		t.Error("subnet in quarantine must not be reclaimed")
+	}
+
+	results, err = Planner{Reclaim: true, QuarantineDays: 30, Now: after}.Plan(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Name == "Old" {
+			t.Errorf("subnet past quarantine should be reclaimed: %+v", r)
+		}
+	}
+
+	networks[0].Subnets[0].DecommissionedOn = "last year"
+	if _, err := PlanSubnets(networks); err == nil {
+		t.Error("expected an error for an invalid decommissionedOn date")
+	}
+}
+
+func TestPlanner_PinnedAddress(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Pinned", CIDR: 26, Address: "10.0.0.128"},
+			{Name: "Big", CIDR: 25},
+			{Name: "Small", CIDR: 26},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"Pinned": "10.0.0.128/26", "Big": "10.0.0.0/25", "Small": "10.0.0.192/26"}
+	for _, r := range results {
+		if w, ok := want[r.Name]; ok && r.Subnet != w {
+			t.Errorf("%s = %s, want %s", r.Name, r.Subnet, w)
+		}
+	}
+
+	for _, address := range []string{"10.0.0.96", "10.0.1.0", "10.0.0.128/25", "bogus"} {
+		networks[0].Subnets[0].Address = address
+		if _, err := PlanSubnets(networks); err == nil {
+			t.Errorf("expected an error for address %q", address)
+		}
+	}
+}
+
+func TestApplyBaseline(t *testing.T) {
+	baseline, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Servers", CIDR: 26},
+			{Name: "Legacy", CIDR: 26},
+			{Name: "Clients", CIDR: 26},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Legacy is gone, Clients keeps its size, Servers grows, Lab is new
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Lab", CIDR: 27},
+			{Name: "Clients", CIDR: 26},
+			{Name: "Servers", CIDR: 25},
+		},
+	}}
+	pinned, reclaimable, warnings := ApplyBaseline(networks, baseline)
+	if networks[0].Subnets[1].Address != "" {
+		t.Error("ApplyBaseline must not modify its input")
+	}
+	if len(reclaimable) != 1 || reclaimable[0].Name != "Legacy" {
+		t.Errorf("reclaimable = %+v, want Legacy", reclaimable)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Servers") {
+		t.Errorf("warnings = %v, want one for the resized Servers subnet", warnings)
+	}
+
+	results, err := PlanSubnets(pinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, r := range results {
+		if r.Category == "Network" {
+			got[r.Name] = r.Subnet
+		}
+	}
+	if got["Clients"] != "10.0.0.128/26" {
+		t.Errorf("Clients moved to %s, want its baseline address 10.0.0.128/26", got["Clients"])
+	}
+	if got["Servers"] != "10.0.0.0/25" || got["Lab"] != "10.0.0.192/27" {
+		t.Errorf("new allocations = %v", got)
+	}
+}
+
+func TestApplyBaseline_PointToPointLinks(t *testing.T) {
+	networks := []Network{{Network: "10.0.0.0/24", P2PLinks: 2, Subnets: []Subnet{{Name: "Servers", CIDR: 26}}}}
+	baseline, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, reclaimable, _ := ApplyBaseline(networks, baseline)
+	if len(reclaimable) != 0 {
+		t.Errorf("generated links reported as reclaimable: %+v", reclaimable)
+	}
+	addresses := make(map[string]string)
+	for _, s := range pinned[0].Subnets {
+		addresses[s.Name] = s.Address
+	}
+	if addresses["p2p-1"] == "" || addresses["p2p-2"] == "" || len(addresses) != 3 {
+		t.Errorf("pinned subnets = %v, want both links pinned", addresses)
+	}
+}
+
+func TestApplyBaseline_Pools(t *testing.T) {
+	networks := []Network{{Pools: []string{"10.0.0.0/25", "10.0.4.0/25"}, Subnets: []Subnet{
+		{Name: "Web", CIDR: 26}, {Name: "App", CIDR: 26}, {Name: "DB", CIDR: 26},
+	}}}
+	baseline, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned, _, warnings := ApplyBaseline(networks, baseline)
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+	for _, s := range pinned[0].Subnets {
+		if s.Address == "" {
+			t.Errorf("subnet %s of a pool-only network was not pinned", s.Name)
+		}
+	}
+	results, err := PlanSubnets(pinned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Subnet != baseline[i].Subnet || r.Name != baseline[i].Name {
+			t.Fatalf("row %d = %s %s, want %s %s", i, r.Name, r.Subnet, baseline[i].Name, baseline[i].Subnet)
+		}
+	}
+}
+
+func TestPlanner_PointToPointLinks(t *testing.T) {
+	networks := []Network{{
+		Network:  "10.0.0.0/28",
+		Gateway:  "first",
+		P2PLinks: 2,
+		Subnets:  []Subnet{{Name: "wan-a", P2P: true, Hosts: 2}},
+	}}
+	prefixes := func(p Planner) map[string]int {
+		results, err := p.Plan(networks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]int)
+		for _, r := range results {
+			if r.Category == "Assignment" {
+				t.Errorf("point-to-point link got an assignment: %+v", r)
+			}
+			if r.Name != "Available" {
+				got[r.Name] = r.Prefix
+			}
+		}
+		return got
+	}
+
+	got := prefixes(Planner{})
+	for _, name := range []string{"wan-a", "p2p-1", "p2p-2"} {
+		if got[name] != 31 {
+			t.Errorf("%s = /%d, want /31", name, got[name])
+		}
+	}
+	got = prefixes(Planner{LegacyP2P: true})
+	for _, name := range []string{"wan-a", "p2p-1", "p2p-2"} {
+		if got[name] != 30 {
+			t.Errorf("legacy %s = /%d, want /30", name, got[name])
+		}
+	}
+
+	networks[0].Subnets[0].Hosts = 3
+	if _, err := PlanSubnets(networks); err == nil {
+		t.Error("expected an error for a point-to-point link with 3 hosts")
+	}
+}
+
+func TestPlanner_FabricPairs(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Mgmt", CIDR: 26},
+			{Name: "Storage", VLAN: 711, CIDR: 27, Fabrics: []string{"A", "B"},
+				IPAssignments: []IPAssignment{{Name: "Node1", Position: 1}, {Name: "Node2", Position: 2}}},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type row struct {
+		subnet string
+		vlan   int
+	}
+	got := map[string]row{}
+	ips := map[string]string{}
+	for _, r := range results {
+		if r.Category == "Network" {
+			got[r.Name] = row{r.Subnet, r.VLAN}
+			if strings.HasPrefix(r.Name, "Storage") && r.Tags["fabric"] == "" {
+				t.Errorf("%s has no fabric tag", r.Name)
+			}
+		}
+		if r.Label == "Node2" {
+			ips[r.Name] = r.IP
+		}
+	}
+	// The pair is allocated as one /26 block, right after the /26 Mgmt subnet
+	if got["Storage-A"] != (row{"10.0.0.64/27", 711}) || got["Storage-B"] != (row{"10.0.0.96/27", 712}) {
+		t.Errorf("fabric subnets = %+v", got)
+	}
+	if ips["Storage-A"] != "10.0.0.66" || ips["Storage-B"] != "10.0.0.98" {
+		t.Errorf("assignments not mirrored: %v", ips)
+	}
+
+	networks[0].Subnets[1].Fabrics = []string{"A", "B", "C"}
+	if _, err := PlanSubnets(networks); err == nil {
+		t.Error("expected an error for three fabrics")
+	}
+	networks[0].Subnets[1].Fabrics = []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	if _, err := PlanSubnets(networks); err == nil {
+		t.Error("expected an error for eight fabrics")
+	}
+}
+
+func TestPlanner_Growth(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/22",
+		Subnets: []Subnet{
+			{Name: "Web", Hosts: 100, Growth: intPtr(50)},  // 150 hosts -> /24
+			{Name: "App", Hosts: 100},                      // global growth
+			{Name: "Exact", Hosts: 100, Growth: intPtr(0)}, // opts out of global growth
+			{Name: "Fixed", CIDR: 28},
+		},
+	}}
+	prefixes := func(p Planner) map[string]int {
+		results, err := p.Plan(networks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := map[string]int{}
+		for _, r := range results {
+			out[r.Name] = r.Prefix
+		}
+		return out
+	}
+
+	got := prefixes(Planner{})
+	if got["Web"] != 24 || got["App"] != 25 || got["Fixed"] != 28 {
+		t.Errorf("without global growth: %v", got)
+	}
+	got = prefixes(Planner{Growth: 30}) // 130 hosts -> /24; Web keeps its own 50%
+	if got["Web"] != 24 || got["App"] != 24 || got["Exact"] != 25 || got["Fixed"] != 28 {
+		t.Errorf("with -growth 30: %v", got)
+	}
+	if grownHosts(101, 50) != 152 {
+		t.Errorf("grownHosts(101, 50) = %d, want 152 (rounded up)", grownHosts(101, 50))
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestPlanner_PoolSpillover(t *testing.T) {
+	networks := []Network{{
+		Pools:   []string{"10.0.0.0/25", "10.0.4.0/24"},
+		Gateway: "first",
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26},
+			{Name: "Pinned", CIDR: 28, Address: "10.0.4.0/28"},
+			{Name: "App", CIDR: 26},  // fills the first pool
+			{Name: "Mgmt", CIDR: 27}, // spills over
+			{Name: "Big", CIDR: 24},  // fits no pool next to Pinned
+		},
+	}}
+	_, err := Planner{}.Plan(networks)
+	var noSpace *NoSpaceError
+	if !errors.As(err, &noSpace) || noSpace.Subnet != "Big" {
+		t.Fatalf("err = %v, want no space for Big", err)
+	}
+
+	networks[0].Subnets = networks[0].Subnets[:4]
+	results, err := Planner{}.Plan(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		if r.Category != "Available" && r.Category != "Unused" && r.Label != "Gateway" {
+			got[r.Name] = r.Subnet + " in " + r.Parent
+		}
+	}
+	want := map[string]string{
+		"Web":    "10.0.0.0/26 in 10.0.0.0/25",
+		"App":    "10.0.0.64/26 in 10.0.0.0/25",
+		"Pinned": "10.0.4.0/28 in 10.0.4.0/24",
+		"Mgmt":   "10.0.4.32/27 in 10.0.4.0/24",
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s = %q, want %q", name, got[name], w)
+		}
+	}
+
+	split, err := Planner{}.SplitPools(networks)
+	if err != nil || len(split) != 2 || split[1].Gateway != "first" || len(split[1].Subnets) != 2 || split[1].Subnets[0].Name != "Pinned" {
+		t.Errorf("SplitPools = %+v, %v", split, err)
+	}
+}
+
+func TestPlanner_ReservedAddresses(t *testing.T) {
+	results, err := planSingleNetwork(Network{Network: "10.0.0.0/28", Subnets: []Subnet{{Name: "Users", CIDR: 28, IPAssignments: []IPAssignment{
+		{Name: "Gateway", Position: 1},
+		{Name: "Pool", Position: 2, EndPosition: 14, DHCP: true},
+		{Name: "Future VIPs", Position: 4, Count: 3, Reserved: true},
+	}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reserved []SubnetResult
+	for _, r := range results {
+		if r.Category == "Reserved" {
+			reserved = append(reserved, r)
+		}
+	}
+	if len(reserved) != 1 || reserved[0].Label != "Future VIPs" || reserved[0].IP != "10.0.0.4 - 10.0.0.6" || reserved[0].DHCP {
+		t.Fatalf("reserved rows = %+v", reserved)
+	}
+
+	// Reserved addresses are carved out of the DHCP pool like statics
+	scopes, err := buildDHCPScopes(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 1 || len(scopes[0].pools) != 2 || scopes[0].pools[0].end != ipToUint32(net.ParseIP("10.0.0.3")) {
+		t.Errorf("DHCP pools = %+v", scopes)
+	}
+}
+
+func TestProposeSplits(t *testing.T) {
+	networks := []Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "A", CIDR: 25}, {Name: "B", CIDR: 25}, {Name: "C", CIDR: 26}, {Name: "D", CIDR: 27}}},
+		{Network: "10.1.0.0/24", Subnets: []Subnet{{Name: "E", CIDR: 26}}},
+	}
+	// 10.0.0.0/23 overlaps the config's own parent and is skipped
+	proposals, err := Planner{}.ProposeSplits(networks, []string{"10.0.0.0/23", "10.9.0.0/26", "10.9.1.0/24", "10.9.2.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 1 {
+		t.Fatalf("got %d proposals, want 1 for the network that does not fit", len(proposals))
+	}
+	var got []string
+	for _, p := range proposals[0].Parents {
+		got = append(got, fmt.Sprintf("%s %v %v", p.CIDR, p.Candidate, p.Subnets))
+	}
+	want := []string{"10.0.0.0/24 false [A B]", "10.9.0.0/26 true [C]", "10.9.1.0/24 true [D]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parents = %q, want %q", got, want)
+	}
+	if pools := proposals[0].Config.Pools; !reflect.DeepEqual(pools, []string{"10.9.0.0/26", "10.9.1.0/24"}) {
+		t.Errorf("proposed pools = %v", pools)
+	}
+	if _, err := PlanSubnets([]Network{proposals[0].Config}); err != nil {
+		t.Errorf("proposed config does not plan: %v", err)
+	}
+
+	if _, err := (Planner{}).ProposeSplits(networks[:1], []string{"10.9.0.0/28"}); err == nil {
+		t.Error("expected an error when the candidates are too small")
+	}
+}
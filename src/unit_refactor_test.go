@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRefactorConfig(t *testing.T) {
+	data := []byte(`{
+		"oob": {"subnets": ["prod-BMC"], "inband": ["prod-Compute"]},
+		"networks": [{"network": "10.0.0.0/24", "subnets": [
+			{"name": "prod-Compute", "vlan": 100, "hosts": 20},
+			{"name": "prod-BMC", "vlan": 101, "hosts": 20},
+			{"name": "Storage", "vlan": 120, "hosts": 50}
+		]}]
+	}`)
+	cfg, shape, err := decodeConfigRaw(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape != shapeWrapped {
+		t.Fatalf("shape = %v, want wrapped", shape)
+	}
+
+	changed, renamed, err := refactorConfig(&cfg, "prefix", []string{"prod-", "prd-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 || renamed["prod-BMC"] != "prd-BMC" {
+		t.Errorf("prefix: changed %d, renamed %v", changed, renamed)
+	}
+	if cfg.OOB.Subnets[0] != "prd-BMC" || cfg.OOB.InBand[0] != "prd-Compute" {
+		t.Errorf("OOB references not renamed: %+v", cfg.OOB)
+	}
+
+	if changed, _, err = refactorConfig(&cfg, "vlan", []string{"Storage", "121"}); err != nil || changed != 1 {
+		t.Errorf("vlan: changed %d, err %v", changed, err)
+	}
+	if cfg.Networks[0].Subnets[2].VLAN != 121 {
+		t.Errorf("VLAN = %d, want 121", cfg.Networks[0].Subnets[2].VLAN)
+	}
+	if changed, _, err = refactorConfig(&cfg, "vlan", []string{"Storage", "121"}); err != nil || changed != 0 {
+		t.Errorf("vlan to the current VLAN: changed %d, err %v", changed, err)
+	}
+
+	for _, tc := range []struct {
+		op   string
+		args []string
+		want string
+	}{
+		{"rename", []string{"Missing", "X"}, "no subnet matches"},
+		{"vlan", []string{"Storage", "5000"}, "invalid VLAN"},
+		{"vlan", []string{"Missing", "200"}, "no subnet matches"},
+		{"move", []string{"a", "b"}, "unknown operation"},
+		{"rename", []string{"Storage"}, "two arguments"},
+	} {
+		if _, _, err := refactorConfig(&cfg, tc.op, tc.args); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s %v: err = %v, want %q", tc.op, tc.args, err, tc.want)
+		}
+	}
+}
+
+func TestEncodeConfig_KeepsShape(t *testing.T) {
+	for _, in := range []string{
+		`{"network": "10.0.0.0/24", "subnets": [{"name": "A", "hosts": 10}]}`,
+		`[{"network": "10.0.0.0/24", "subnets": [{"name": "A", "hosts": 10}]}]`,
+	} {
+		cfg, shape, err := decodeConfigRaw([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := refactorConfig(&cfg, "rename", []string{"A", "B"}); err != nil {
+			t.Fatal(err)
+		}
+		out, err := encodeConfig(cfg, shape)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out[0] != in[0] || !strings.Contains(string(out), `"name": "B"`) {
+			t.Errorf("encoded config lost its shape or rename:\n%s", out)
+		}
+		if _, err := loadConfig(out, ""); err != nil {
+			t.Errorf("encoded config does not load: %v", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportRoutes(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Transit", CIDR: 29, Tags: map[string]string{"transit": "true"}, IPAssignments: []IPAssignment{
+				{Name: "host", Position: 2}, {Name: "Firewall", Position: 1}}},
+			{Name: "Web", CIDR: 26},
+			{Name: "App", CIDR: 27},
+		},
+	}, {
+		Network: "10.1.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Uplink", CIDR: 30, Tags: map[string]string{"transit": "Web, 0.0.0.0/0"}, IPAssignments: []IPAssignment{{Name: "Router", Position: 1}}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	tests := []struct {
+		format, file string
+		want         []string
+	}{
+		{"", "routes.sh", []string{"ip route replace 10.0.0.0/26 via 10.0.0.97\n", "ip route replace 10.0.0.64/27 via 10.0.0.97\n", "ip route replace 0.0.0.0/0 via 10.1.0.1\n"}},
+		{"", "routes.ps1", []string{"New-NetRoute -DestinationPrefix '10.0.0.64/27' -NextHop '10.0.0.97' -PolicyStore PersistentStore\n"}},
+		{"ios", "routes.cfg", []string{"ip route 10.0.0.0 255.255.255.192 10.0.0.97 name Web\n", "ip route 10.0.0.0 255.255.255.192 10.1.0.1 name Web\n", "ip route 0.0.0.0 0.0.0.0 10.1.0.1\n"}},
+		{"eos", "routes.cfg", []string{"ip route 10.0.0.64/27 10.0.0.97 name App\n"}},
+		{"junos", "routes.set", []string{"set routing-options static route 0.0.0.0/0 next-hop 10.1.0.1\n"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := ExportRoutes(results, path, tt.format); err != nil {
+			t.Fatalf("ExportRoutes(%q) error = %v", tt.format, err)
+		}
+		data, _ := os.ReadFile(path)
+		out := string(data)
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s output missing %q:\n%s", tt.file, want, out)
+			}
+		}
+		if strings.Contains(out, "10.0.0.96/29") {
+			t.Errorf("%s routes a transit subnet:\n%s", tt.file, out)
+		}
+	}
+
+	if err := ExportRoutes(results, filepath.Join(dir, "x"), "bird"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	bad, _ := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{
+		{Name: "Transit", CIDR: 29, Tags: map[string]string{"transit": "Nowhere"}, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+	}}})
+	if err := ExportRoutes(bad, filepath.Join(dir, "x"), ""); err == nil || !strings.Contains(err.Error(), "Nowhere") {
+		t.Errorf("ExportRoutes() error = %v, want unknown destination", err)
+	}
+}
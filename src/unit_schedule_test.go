@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterAsOf(t *testing.T) {
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Phase1", CIDR: 26},
+			{Name: "Phase2", CIDR: 25, PlannedFor: "2025-07-01"},
+			{Name: "Phase3", CIDR: 26, PlannedFor: "2026-01-01T00:00:00Z"},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(rows []SubnetResult) map[string]string {
+		m := map[string]string{}
+		for _, r := range rows {
+			if r.Category == "Network" {
+				m[r.Name] = r.Subnet
+			}
+		}
+		return m
+	}
+	all := names(results)
+
+	tests := []struct {
+		asOf string
+		want []string
+	}{
+		{"2025-06-30", []string{"Phase1"}},
+		{"2025-07-01", []string{"Phase1", "Phase2"}},
+		{"2026-01-01", []string{"Phase1", "Phase2", "Phase3"}},
+	}
+	for _, tt := range tests {
+		at, err := parseAsOf(tt.asOf, "UTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := names(FilterAsOf(results, at))
+		if len(got) != len(tt.want) {
+			t.Errorf("as of %s: got %v, want %v", tt.asOf, got, tt.want)
+		}
+		for _, name := range tt.want {
+			if got[name] != all[name] {
+				t.Errorf("as of %s: %s at %q, want stable address %q", tt.asOf, name, got[name], all[name])
+			}
+		}
+	}
+}
+
+func TestParseAsOf_TimeZone(t *testing.T) {
+	// The end of 2025-06-30 in Tokyo is still the 30th in UTC
+	at, err := parseAsOf("2025-06-30", "Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := at.UTC().Format(time.RFC3339); got != "2025-06-30T14:59:59Z" {
+		t.Errorf("as-of in UTC = %s", got)
+	}
+	if _, err := parseAsOf("2025-06-30", "Mars/Olympus"); err == nil {
+		t.Error("expected an error for an unknown time zone")
+	}
+	if _, err := PlanSubnets([]Network{{Network: "10.0.0.0/24", Subnets: []Subnet{{Name: "A", CIDR: 26, PlannedFor: "next week"}}}}); err == nil {
+		t.Error("expected an error for an invalid plannedFor date")
+	}
+}
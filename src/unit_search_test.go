@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchPlans(t *testing.T) {
+	dir := t.TempDir()
+	networks := []Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", CIDR: 26, VLAN: 10, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}, {Name: "LB", Position: 5}}},
+			{Name: "DB", CIDR: 27, VLAN: 20},
+		},
+	}}
+	results, err := PlanSubnets(networks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportJSON(results, filepath.Join(dir, "plan.json")); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"network": "172.16.0.0/24", "subnets": [{"name": "web-backup", "cidr": 28, "vlan": 10}]}`
+	if err := os.MkdirAll(filepath.Join(dir, "configs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "configs", "site.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query, kind string
+		want        []string // Subnet|Label of each hit
+	}{
+		{"web", "auto", []string{"172.16.0.0/28|Network", "10.0.0.0/26|Network"}},
+		{"10", "auto", []string{"172.16.0.0/28|Network", "10.0.0.0/26|Network"}},
+		{"10.0.0.5", "auto", []string{"10.0.0.0/26|LB"}},
+		{"10.0.0.70", "ip", []string{"10.0.0.64/27|Available Range"}},
+		{"gateway", "name", []string{"10.0.0.0/26|Gateway"}},
+	}
+	for _, tt := range tests {
+		hits, err := searchPlans(tt.query, tt.kind, "", []string{dir})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.Subnet+"|"+h.Label)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := searchPlans("x", "mac", "", []string{dir}); err == nil {
+		t.Error("expected an error for an unknown -type")
+	}
+}
+
+func TestSearchPlans_Environment(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"environments": {"prod": [{"network": "10.1.0.0/24", "subnets": [{"name": "users", "cidr": 26}]}], "dev": [{"network": "10.2.0.0/24", "subnets": [{"name": "users", "cidr": 26}]}]}}`
+	if err := os.WriteFile(filepath.Join(dir, "e.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hits, err := searchPlans("users", "name", "dev", []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Subnet != "10.2.0.0/26" {
+		t.Errorf("expected the dev subnet, got %+v", hits)
+	}
+	if _, err := loadSearchable(filepath.Join(dir, "e.json"), ""); err == nil || !strings.Contains(err.Error(), "-env") {
+		t.Errorf("without -env the error should ask for one, got %v", err)
+	}
+}
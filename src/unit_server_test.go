@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_Plan(t *testing.T) {
+	srv := httptest.NewServer(newServer("", "", nil, nil))
+	defer srv.Close()
+
+	body := `{"network": "192.168.1.0/24", "subnets": [{"name": "Users", "hosts": 50, "vlan": 10}]}`
+	resp, err := http.Post(srv.URL+"/plan", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /plan: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var results []SubnetResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(results) == 0 || results[0].Name != "Users" || results[0].Subnet != "192.168.1.0/26" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestServer_PlanEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	store, err := openStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newServer("", "", store, nil))
+	defer srv.Close()
+
+	body := `{"environments": {"prod": [{"network": "10.1.0.0/24", "subnets": [{"name": "Web", "cidr": 26}]}],
+		"dr": [{"network": "10.2.0.0/24", "subnets": [{"name": "Web", "cidr": 26}]}]}}`
+	for path, want := range map[string]int{"/plan?env=dr": http.StatusOK, "/plan": http.StatusBadRequest, "/plans/hq?env=dr": http.StatusCreated} {
+		resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("POST %s: status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	// The stored version keeps its environment, so it can be planned again
+	v, err := store.Load("hq", 1)
+	if err != nil || v.Env != "dr" || len(v.Results) == 0 || v.Results[0].Subnet != "10.2.0.0/26" {
+		t.Fatalf("stored version = %+v, %v", v, err)
+	}
+	if c := newRevalidator(store, nil, 0).check("hq"); c.Error != "" || c.Drifted {
+		t.Errorf("re-validation of the stored environment = %+v", c)
+	}
+}
+
+func TestServer_PlanErrors(t *testing.T) {
+	srv := httptest.NewServer(newServer("", "", nil, nil))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"Malformed JSON", `{"network":`, http.StatusBadRequest},
+		{"Planning error", `{"network": "192.168.1.0/29", "subnets": [{"name": "Big", "hosts": 100}]}`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/plan", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST /plan: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestServer_HealthAndVersion(t *testing.T) {
+	srv := httptest.NewServer(newServer("", "", nil, nil))
+	defer srv.Close()
+
+	for _, path := range []string{"/health", "/version"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s status = %d, want 200", path, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/plan")
+	if err != nil {
+		t.Fatalf("GET /plan: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /plan status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestServer_View(t *testing.T) {
+	srv := httptest.NewServer(newServer("", "", nil, nil))
+	defer srv.Close()
+
+	body := `{"network": "192.168.1.0/24", "subnets": [{"name": "Users", "hosts": 50, "vlan": 10}]}`
+	resp, err := http.Post(srv.URL+"/view", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /view: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var view PlanView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(view.Networks) != 1 || len(view.Networks[0].Subnets) != 1 || view.Networks[0].Subnets[0].Display.Title != "Users (VLAN 10)" {
+		t.Errorf("unexpected view: %+v", view)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSiteNetworks(t *testing.T) {
+	data := []byte(`{"sites": [{"supernet": "10.0.0.0/14", "prefix": 16, "gateway": "first",
+		"variables": {"users": 100},
+		"subnets": [{"name": "Users", "hosts": "users", "vlan": 10, "tags": {"role": "users"}}, {"name": "Mgmt", "cidr": 28}],
+		"sites": [{"name": "Berlin"}, {"name": "Paris", "network": "10.0.0.0/16", "variables": {"users": 300}}, {"name": "Rome"}]}]}`)
+	cfg, err := loadConfig(data, "")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	var parents []string
+	for _, n := range cfg.Networks {
+		parents = append(parents, n.Network+" "+n.Subnets[0].Name)
+	}
+	want := []string{"10.1.0.0/16 Berlin-Users", "10.0.0.0/16 Paris-Users", "10.2.0.0/16 Rome-Users"}
+	if !reflect.DeepEqual(parents, want) {
+		t.Errorf("site networks = %v, want %v", parents, want)
+	}
+	networks, err := resolveHosts(cfg.Networks, nil)
+	if err != nil {
+		t.Fatalf("resolveHosts: %v", err)
+	}
+	if berlin, paris := networks[0].Subnets[0], networks[1].Subnets[0]; berlin.Hosts != 100 || paris.Hosts != 300 {
+		t.Errorf("hosts = %d and %d, want 100 and 300", berlin.Hosts, paris.Hosts)
+	}
+	if tags := networks[2].Subnets[0].Tags; tags["site"] != "Rome" || tags["role"] != "users" {
+		t.Errorf("Rome tags = %v", tags)
+	}
+	if _, err := PlanSubnets(networks); err != nil {
+		t.Errorf("PlanSubnets: %v", err)
+	}
+
+	for _, tc := range []struct {
+		sites, err string
+	}{
+		{`[{"name": "A"}, {"name": "B"}, {"name": "C"}, {"name": "D"}, {"name": "E"}]`, "no /16 left for site E (room for 4 sites)"},
+		{`[{"name": "A", "network": "10.8.0.0/16"}]`, "is not a /16 block of 10.0.0.0/14"},
+		{`[{"name": "A", "network": "10.1.0.0/16"}, {"name": "B", "network": "10.1.0.0/16"}]`, "already used by site A"},
+		{`[{"name": "A"}, {"name": "A"}]`, "listed more than once"},
+	} {
+		data := `{"sites": [{"supernet": "10.0.0.0/14", "prefix": 16, "subnets": [{"name": "Web", "cidr": 24}], "sites": ` + tc.sites + `}]}`
+		if _, err := loadConfig([]byte(data), ""); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected %q error, got %v", tc.sites, tc.err, err)
+		}
+	}
+}
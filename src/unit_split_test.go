@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestSplitNetwork(t *testing.T) {
+	n, err := splitNetwork("10.0.0.5/24", 0, 26, "Part")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Network != "10.0.0.0/24" || len(n.Subnets) != 4 || n.Subnets[3].Name != "Part-4" || n.Subnets[0].CIDR != 26 {
+		t.Fatalf("split into /26 = %+v", n)
+	}
+	results, err := PlanSubnets([]Network{n})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[len(results)-1].Subnet; got != "10.0.0.192/26" {
+		t.Errorf("last child = %s, want 10.0.0.192/26", got)
+	}
+
+	n, err = splitNetwork("10.0.0.0/22", 8, 0, "Subnet")
+	if err != nil || len(n.Subnets) != 8 || n.Subnets[0].CIDR != 25 {
+		t.Errorf("split into 8 = %+v, %v", n, err)
+	}
+
+	for _, tc := range []struct {
+		cidr          string
+		count, prefix int
+	}{
+		{"10.0.0.0/24", 3, 0},
+		{"10.0.0.0/24", 0, 23},
+		{"10.0.0.0/24", 0, 33},
+		{"10.0.0.0/31", 4, 0},
+		{"10.0.0.0/8", 0, 32},
+		{"10.0.0.0/16", 8192, 0},
+		{"bogus", 2, 0},
+	} {
+		if _, err := splitNetwork(tc.cidr, tc.count, tc.prefix, "Subnet"); err == nil {
+			t.Errorf("splitNetwork(%s, %d, %d) succeeded, want error", tc.cidr, tc.count, tc.prefix)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestAggregateBlocks(t *testing.T) {
+	block := func(ip string, prefix int) cidrBlock {
+		return cidrBlock{start: ipToUint32(net.ParseIP(ip)), prefix: prefix}
+	}
+	tests := []struct {
+		name string
+		in   []cidrBlock
+		want []string
+	}{
+		{"Adjacent siblings", []cidrBlock{block("10.0.0.0", 25), block("10.0.0.128", 25)}, []string{"10.0.0.0/24"}},
+		{"Non-sibling neighbours stay apart", []cidrBlock{block("10.0.0.128", 25), block("10.0.1.0", 25)}, []string{"10.0.0.128/25", "10.0.1.0/25"}},
+		{"Cascade", []cidrBlock{block("10.0.0.0", 26), block("10.0.0.64", 26), block("10.0.0.128", 25)}, []string{"10.0.0.0/24"}},
+		{"Contained", []cidrBlock{block("10.0.0.0", 24), block("10.0.0.64", 26)}, []string{"10.0.0.0/24"}},
+		{"Whole space", []cidrBlock{block("0.0.0.0", 1), block("128.0.0.0", 1)}, []string{"0.0.0.0/0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := aggregateBlocks(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("aggregateBlocks() = %v, want %v", got, tt.want)
+			}
+			for i, b := range got {
+				if s := fmt.Sprintf("%s/%d", uint32ToIP(b.start), b.prefix); s != tt.want[i] {
+					t.Errorf("block %d = %s, want %s", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeRoutes(t *testing.T) {
+	results, err := PlanSubnets([]Network{
+		{Network: "10.1.0.0/16", Subnets: []Subnet{{Name: "A", CIDR: 24}, {Name: "B", CIDR: 24}, {Name: "C", CIDR: 23}}},
+		{Network: "10.2.0.0/24", Subnets: []Subnet{{Name: "D", CIDR: 26}}},
+	})
+	if err != nil {
+		t.Fatalf("PlanSubnets() error = %v", err)
+	}
+
+	summary := SummarizeRoutes(results)
+	want := map[string]string{"10.1.0.0/22": "10.1.0.0/16", "10.2.0.0/26": "10.2.0.0/24"}
+	if len(summary) != len(want) {
+		t.Fatalf("SummarizeRoutes() = %+v, want %v", summary, want)
+	}
+	for _, s := range summary {
+		if want[s.Subnet] != s.Parent || s.Category != "Summary" {
+			t.Errorf("unexpected summary row %+v", s)
+		}
+	}
+}
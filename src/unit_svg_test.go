@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSVG(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web & App", CIDR: 25, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+			{Name: "Old", CIDR: 26, Decommissioned: true},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "plan.svg")
+	if err := ExportSVG(results, path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	svg := string(data)
+
+	// Must be well-formed XML, with names escaped
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err.Error() != "EOF" {
+				t.Fatalf("invalid SVG: %v\n%s", err, svg)
+			}
+			break
+		}
+	}
+	for _, want := range []string{
+		`<rect x="20.00" y="40" width="480.00" height="40" fill="#4e79a7"`, // the /25 fills half the bar
+		`<title>Web &amp; App: 10.0.0.0/25</title>`,
+		`fill="#f28e2b"`, // decommissioned
+		`<title>Free: 10.0.0.192/26</title>`,
+		`<title>Web &amp; App Gateway: 10.0.0.1</title>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG missing %q", want)
+		}
+	}
+}
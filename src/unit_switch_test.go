@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportSwitch(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Gateway: "first",
+		Subnets: []Subnet{
+			{Name: "Management Net", VLAN: 110, CIDR: 28},
+			{Name: "Storage", VLAN: 711, CIDR: 27, Gateway: "none"},
+			{Name: "Untagged", CIDR: 28},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	ios := filepath.Join(dir, "switch.ios")
+	if err := ExportSwitch(results, ios, "ios"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(ios)
+	for _, want := range []string{
+		"vlan 110\n name Management_Net\n!\n",
+		"vlan 711\n name Storage\n!\n",
+		"interface Vlan110\n description Management_Net\n ip address 10.0.0.33 255.255.255.240\n no shutdown\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("IOS config missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "interface Vlan711") || strings.Contains(string(data), "vlan 0") {
+		t.Errorf("unexpected SVI or VLAN 0:\n%s", data)
+	}
+
+	eos := filepath.Join(dir, "switch.eos")
+	if err := ExportSwitch(results, eos, "eos"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(eos)
+	if !strings.Contains(string(data), "interface Vlan110\n   description Management_Net\n   ip address 10.0.0.33/28\n") {
+		t.Errorf("EOS config:\n%s", data)
+	}
+
+	if err := ExportSwitch(results, eos, "junos"); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
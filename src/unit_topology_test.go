@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportTopology(t *testing.T) {
+	results, err := PlanSubnets([]Network{{
+		Network: "10.0.0.0/24",
+		Subnets: []Subnet{
+			{Name: "Web", VLAN: 100, CIDR: 25, IPAssignments: []IPAssignment{
+				{Name: "Gateway", Position: 1}, {Name: "web1", Position: 2}, {Name: "Router-B", Position: 3}}},
+			{Name: `Lab "2"`, CIDR: 26},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	mmd := filepath.Join(dir, "topology.mmd")
+	if err := ExportTopology(results, mmd, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(mmd)
+	out := string(data)
+	for _, want := range []string{
+		"graph LR\n",
+		`n0["10.0.0.0/24"]`,
+		`n0 --> n0_s0["Web<br/>10.0.0.0/25<br/>VLAN 100"]`,
+		`n0_s0 --- n0_s0_a0(["Gateway 10.0.0.1"])`,
+		`n0_s0_a1(["Router-B 10.0.0.3"])`,
+		`Lab #quot;2#quot;`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("mermaid output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "web1") || strings.Contains(out, "Available") {
+		t.Errorf("mermaid output should only show key assignments:\n%s", out)
+	}
+
+	dot := filepath.Join(dir, "topology.dot")
+	if err := ExportTopology(results, dot, ""); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(dot)
+	out = string(data)
+	for _, want := range []string{
+		"digraph topology {",
+		`n0_s0 [label="Web\n10.0.0.0/25\nVLAN 100"];`,
+		"n0 -> n0_s0;",
+		`label="Lab \"2\"\n10.0.0.128/26"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dot output missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := ExportTopology(results, mmd, "visio"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterAndGroupPlan(t *testing.T) {
+	results, err := PlanSubnets([]Network{
+		{Network: "10.0.0.0/24", Subnets: []Subnet{
+			{Name: "Web", CIDR: 26, IPAssignments: []IPAssignment{{Name: "Gateway", Position: 1}}},
+			{Name: "App", CIDR: 27},
+		}},
+		{Network: "10.1.0.0/24", Subnets: []Subnet{{Name: "DB", CIDR: 28}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := filterPlan(results, viewFilter{search: "10.0.0.1", kind: "auto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rows {
+		if r.Subnet != "10.0.0.0/26" {
+			t.Errorf("search for 10.0.0.1 kept %s %s", r.Subnet, r.Label)
+		}
+	}
+	if len(rows) < 3 {
+		t.Errorf("search kept %d rows, want the whole Web subnet", len(rows))
+	}
+
+	rows, _ = filterPlan(results, viewFilter{network: "10.1.0.0/24", categories: []string{"network", "BROADCAST"}})
+	if len(rows) != 2 || rows[0].Category != "Network" || rows[1].Category != "Broadcast" {
+		t.Errorf("network + category filter = %+v", rows)
+	}
+
+	order, groups, err := groupPlan(results, "network")
+	if err != nil || len(order) != 2 || order[0] != "10.0.0.0/24" || len(groups[order[1]]) == 0 {
+		t.Errorf("groupPlan(network) = %v, %v", order, err)
+	}
+	if _, _, err := groupPlan(results, "owner"); err == nil {
+		t.Error("groupPlan(owner) succeeded, want error")
+	}
+
+	var sb strings.Builder
+	if err := printPlanView(&sb, results, "category"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "== Assignment ==") {
+		t.Errorf("grouped view has no Assignment heading:\n%s", sb.String())
+	}
+}
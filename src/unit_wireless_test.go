@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestWirelessNetworks(t *testing.T) {
+	networks, err := wirelessNetworks([]WirelessPlan{{
+		Network: "10.60.0.0/21",
+		Vendor:  "Aruba",
+		Growth:  25,
+		VLAN:    300,
+		Buildings: []Building{
+			{Name: "HQ", APs: 240},
+			{Name: "Warehouse", APs: 30, Growth: 50},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subnets := networks[0].Subnets
+	want := []struct {
+		name        string
+		vlan, hosts int
+	}{
+		{"HQ-AP-1", 300, 153}, // 300 APs after growth, split at 250 per subnet
+		{"HQ-AP-2", 301, 153},
+		{"Warehouse-AP", 302, 48},
+	}
+	if len(subnets) != len(want) {
+		t.Fatalf("subnets = %+v", subnets)
+	}
+	for i, w := range want {
+		s := subnets[i]
+		if s.Name != w.name || s.VLAN != w.vlan || s.Hosts != w.hosts {
+			t.Errorf("subnet %d = %s vlan %d hosts %d, want %+v", i, s.Name, s.VLAN, s.Hosts, w)
+		}
+		if s.Tags["building"] == "" {
+			t.Errorf("subnet %s has no building tag", s.Name)
+		}
+	}
+	if _, err := PlanSubnets(networks); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := wirelessNetworks([]WirelessPlan{{Network: "10.0.0.0/24", Vendor: "acme"}}); err == nil {
+		t.Error("expected an error for an unknown vendor")
+	}
+	if _, err := wirelessNetworks([]WirelessPlan{{Network: "10.0.0.0/24", Buildings: []Building{{Name: "B"}}}}); err == nil {
+		t.Error("expected an error for a building without APs")
+	}
+}
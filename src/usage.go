@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// usageStats is one anonymous usage record. It deliberately holds only
+// counts and flag names: no CIDRs, subnet names, file paths, or flag values.
+type usageStats struct {
+	Time        string   `json:"time"`
+	Version     string   `json:"version"`
+	OS          string   `json:"os"`
+	Arch        string   `json:"arch"`
+	Flags       []string `json:"flags"`
+	Networks    int      `json:"networks"`
+	Subnets     int      `json:"subnets"`
+	Assignments int      `json:"assignments"`
+	Rows        int      `json:"rows"`
+	DurationMs  int64    `json:"durationMs"`
+}
+
+// usageStatsPath returns where opt-in usage stats should be written, or ""
+// when disabled. Stats are off unless -usage-stats or the
+// IPSUBNETPLANNER_USAGE_STATS environment variable names a file; the tool
+// never sends them anywhere, so sharing the file is always a manual step.
+func usageStatsPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("IPSUBNETPLANNER_USAGE_STATS")
+}
+
+// collectUsageStats builds an anonymous record for this run.
+func collectUsageStats(networks []Network, results []SubnetResult, started time.Time) usageStats {
+	stats := usageStats{
+		Time:       started.UTC().Format(time.RFC3339),
+		Version:    version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Flags:      []string{},
+		Networks:   len(networks),
+		Rows:       len(results),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	flag.Visit(func(f *flag.Flag) { stats.Flags = append(stats.Flags, f.Name) })
+	sort.Strings(stats.Flags)
+	for _, n := range networks {
+		stats.Subnets += len(n.Subnets)
+		for _, s := range n.Subnets {
+			stats.Assignments += len(s.IPAssignments)
+		}
+	}
+	return stats
+}
+
+// appendUsageStats appends stats as one JSON line to path.
+func appendUsageStats(path string, stats usageStats) error {
+	line, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage stats file: %v", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
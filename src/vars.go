@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// varReference matches $${NAME} (an escaped reference), ${NAME} and
+// ${NAME:-default}.
+var varReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// readVarFile reads a vars file: a JSON object of strings, numbers and
+// booleans, or NAME=value lines with # comments.
+func readVarFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	text := configText(data)
+	if bytes.HasPrefix(bytes.TrimSpace(text), []byte("{")) {
+		var raw map[string]interface{}
+		if err := json.Unmarshal(text, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for k, v := range raw {
+			switch v := v.(type) {
+			case string:
+				vars[k] = v
+			case float64:
+				vars[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				vars[k] = strconv.FormatBool(v)
+			default:
+				return nil, fmt.Errorf("%s: %s must be a string, number or boolean", path, k)
+			}
+		}
+		return vars, nil
+	}
+	for i, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: use NAME=value", path, i+1)
+		}
+		vars[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return vars, nil
+}
+
+// substituteVars replaces the ${NAME} references in the string values of a
+// JSON config, or the cells of a CSV config, with -var values, then the
+// -var-file's, then environment variables, then the config's top-level
+// "variables", and otherwise the ${NAME:-default}. $${NAME} stays as the
+// literal ${NAME}. A string that is only a reference to a number or boolean
+// becomes that value, so "vlan": "${VLAN}" works. Configs are only
+// substituted when -var or -var-file is given or they declare "variables";
+// others are returned unchanged. Unresolved references are an error.
+func substituteVars(path string, data []byte, vars *varFlag) ([]byte, error) {
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return data, nil
+	}
+	csvFile := strings.EqualFold(filepath.Ext(path), ".csv")
+	var text []byte
+	var declared map[string]string
+	if csvFile {
+		text = decodeText(data)
+	} else {
+		text = configText(data)
+		declared = declaredVars(text)
+	}
+	optIn := vars != nil && (len(vars.values) > 0 || vars.file != "")
+	if !optIn && declared == nil || !bytes.Contains(text, []byte("${")) {
+		return data, nil
+	}
+	fileVars := map[string]string{}
+	if vars != nil && vars.file != "" {
+		var err error
+		if fileVars, err = readVarFile(vars.file); err != nil {
+			return nil, fmt.Errorf("error reading -var-file: %v", err)
+		}
+	}
+	lookup := func(name string) (string, bool) {
+		if vars != nil {
+			if value, ok := vars.values[name]; ok {
+				return value, true
+			}
+		}
+		if value, ok := fileVars[name]; ok {
+			return value, true
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := declared[name]
+		return value, ok
+	}
+	missing := map[string]bool{}
+	expand := func(s string) string {
+		return varReference.ReplaceAllStringFunc(s, func(ref string) string {
+			if strings.HasPrefix(ref, "$$") {
+				return ref[1:]
+			}
+			m := varReference.FindStringSubmatch(ref)
+			value, ok := lookup(m[1])
+			if !ok && strings.Contains(ref, ":-") {
+				value, ok = m[2], true
+			}
+			if !ok {
+				missing[m[1]] = true
+				return ref
+			}
+			return value
+		})
+	}
+	var out []byte
+	var err error
+	if csvFile {
+		out, err = substituteCSV(text, expand)
+	} else {
+		out = substituteJSON(text, expand)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined config variables %s (set them with -var NAME=value, -var-file or the environment)", strings.Join(sortedKeys(missing), ", "))
+	}
+	return out, nil
+}
+
+// declaredVars returns the top-level "variables" of a JSON config as text,
+// or nil when it has none.
+func declaredVars(text []byte) map[string]string {
+	var cfg struct {
+		Variables map[string]json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(text, &cfg); err != nil || cfg.Variables == nil {
+		return nil
+	}
+	vars := make(map[string]string, len(cfg.Variables))
+	for k, raw := range cfg.Variables {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			vars[k] = s
+		} else {
+			vars[k] = string(bytes.TrimSpace(raw))
+		}
+	}
+	return vars
+}
+
+// substituteJSON expands the string values of JSON text; object keys are
+// left alone. Each value is decoded before expanding and encoded again
+// afterwards, so substituted text cannot change the JSON around it. A value
+// that is only a reference, of a key the schema types as a number or
+// boolean, is written as the bare value.
+func substituteJSON(text []byte, expand func(string) string) []byte {
+	var out bytes.Buffer
+	last, key, keyEnd := 0, "", -1
+	for i := 0; i < len(text); i++ {
+		if text[i] != '"' {
+			continue
+		}
+		end := skipString(text, i)
+		if end >= len(text) {
+			break
+		}
+		literal := text[i : end+1]
+		start := i
+		i = end
+		var s string
+		if json.Unmarshal(literal, &s) != nil {
+			continue
+		}
+		if next := bytes.TrimLeft(text[end+1:], " \t\r\n"); len(next) > 0 && next[0] == ':' {
+			key, keyEnd = s, len(text)-len(next)+1
+			continue
+		}
+		if !strings.Contains(s, "${") {
+			continue
+		}
+		value := expand(s)
+		var encoded []byte
+		if loc := varReference.FindStringIndex(s); loc != nil && loc[0] == 0 && loc[1] == len(s) && !strings.HasPrefix(s, "$$") &&
+			keyEnd >= 0 && len(bytes.TrimSpace(text[keyEnd:start])) == 0 && scalarKeys[key] && isJSONScalar(value) {
+			encoded = []byte(value)
+		} else {
+			encoded, _ = json.Marshal(value)
+		}
+		out.Write(text[last:start])
+		out.Write(encoded)
+		last = end + 1
+	}
+	out.Write(text[last:])
+	return out.Bytes()
+}
+
+// scalarKeys are the config keys whose schema allows a number or boolean
+// wherever they appear.
+var scalarKeys = schemaScalarKeys(configSchema)
+
+func schemaScalarKeys(root *jsonSchema) map[string]bool {
+	scalar, text := map[string]bool{}, map[string]bool{}
+	resolve := func(s *jsonSchema) *jsonSchema {
+		if def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]; ok && s.Ref != "" {
+			return def
+		}
+		return s
+	}
+	allowsScalar := func(s *jsonSchema) bool {
+		for _, alt := range append([]*jsonSchema{s}, s.AnyOf...) {
+			for _, t := range resolve(alt).Type {
+				if t == "integer" || t == "number" || t == "boolean" {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	seen := map[*jsonSchema]bool{}
+	var walk func(s *jsonSchema)
+	walk = func(s *jsonSchema) {
+		if s == nil || seen[s] {
+			return
+		}
+		seen[s] = true
+		for name, prop := range s.Properties {
+			prop = resolve(prop)
+			if allowsScalar(prop) {
+				scalar[name] = true
+			} else {
+				text[name] = true
+			}
+			walk(prop)
+		}
+		for _, d := range s.Defs {
+			walk(d)
+		}
+		for _, p := range s.PatternProperties {
+			walk(p)
+		}
+		for _, alt := range s.AnyOf {
+			walk(alt)
+		}
+		walk(s.Items)
+		if s.AdditionalProperties != nil {
+			walk(s.AdditionalProperties.schema)
+		}
+	}
+	walk(root)
+	for name := range text {
+		delete(scalar, name)
+	}
+	return scalar
+}
+
+// isJSONScalar reports whether s is a JSON number or boolean.
+func isJSONScalar(s string) bool {
+	return s == "true" || s == "false" || s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+// substituteCSV expands every cell of CSV text.
+func substituteCSV(text []byte, expand func(string) string) ([]byte, error) {
+	r := csv.NewReader(bytes.NewReader(text))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CSV config: %v", err)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = expand(cell)
+		}
+	}
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
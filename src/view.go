@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// viewFilter selects the rows of a plan shown by the "view" subcommand.
+type viewFilter struct {
+	categories []string // keep only these categories (case-insensitive)
+	network    string   // keep only rows of this parent network
+	search     string   // keep only subnets with a row matching this query
+	kind       string   // search query type, as for the search subcommand
+}
+
+// filterPlan returns the rows of results that pass f. A search keeps every
+// row of the subnets it matches, so a match is shown with its context.
+func filterPlan(results []SubnetResult, f viewFilter) ([]SubnetResult, error) {
+	var matched map[string]bool
+	if f.search != "" {
+		match, err := searchMatcher(f.search, f.kind)
+		if err != nil {
+			return nil, err
+		}
+		matched = make(map[string]bool)
+		for _, r := range results {
+			if match(r) {
+				matched[r.Parent+" "+r.Subnet] = true
+			}
+		}
+	}
+
+	var out []SubnetResult
+	for _, r := range results {
+		if f.network != "" && r.Parent != f.network {
+			continue
+		}
+		if len(f.categories) > 0 && !containsFold(f.categories, r.Category) {
+			continue
+		}
+		if matched != nil && !matched[r.Parent+" "+r.Subnet] {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupPlan splits results by network, subnet or category, keeping the
+// first-seen order of the groups.
+func groupPlan(results []SubnetResult, by string) ([]string, map[string][]SubnetResult, error) {
+	key := func(r SubnetResult) string { return "" }
+	switch by {
+	case "", "none":
+	case "network":
+		key = func(r SubnetResult) string { return r.Parent }
+	case "subnet":
+		key = func(r SubnetResult) string { return r.Subnet + " " + r.Name }
+	case "category":
+		key = func(r SubnetResult) string { return r.Category }
+	default:
+		return nil, nil, fmt.Errorf("invalid -group %q (use network, subnet or category)", by)
+	}
+	var order []string
+	groups := make(map[string][]SubnetResult)
+	for _, r := range results {
+		k := key(r)
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+	return order, groups, nil
+}
+
+// printPlanView prints the console table once per group.
+func printPlanView(w io.Writer, results []SubnetResult, group string) error {
+	order, groups, err := groupPlan(results, group)
+	if err != nil {
+		return err
+	}
+	if len(order) == 0 {
+		PrintTableTo(w, nil)
+	}
+	for _, k := range order {
+		if k != "" {
+			fmt.Fprintf(w, "\n== %s ==\n", k)
+		}
+		PrintTableTo(w, groups[k])
+	}
+	return nil
+}
+
+// runView implements the "view" subcommand.
+func runView(args []string) int {
+	fs := flag.NewFlagSet("view", flag.ExitOnError)
+	group := fs.String("group", "", "Group rows by network, subnet or category")
+	category := fs.String("category", "", "Show only these categories (comma-separated, e.g. Assignment,Unused)")
+	network := fs.String("network", "", "Show only rows of this parent network")
+	search := fs.String("search", "", "Show only subnets with a row matching this name, VLAN or IP")
+	kind := fs.String("type", "auto", "Search query type: name, vlan, ip or auto")
+	filterExpr := fs.String("filter", "", "Show only rows matching an expression, e.g. 'category==Assignment && vlan in (100,110)'")
+	collapse := fs.Bool("collapse-unused", false, "Merge each subnet's Unused rows into one row")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: ipsubnetplanner view [-group network|subnet|category] [-category list] [-network CIDR] [-search query] [-filter expr] plan.json\n\n")
+		fmt.Fprintf(os.Stderr, "Shows an -exportjson plan as the console table, without replanning.\n\n")
+		fs.PrintDefaults()
+	}
+	// Allow flags after the plan file as well as before it
+	_ = fs.Parse(args)
+	var files []string
+	for fs.NArg() > 0 {
+		files = append(files, fs.Arg(0))
+		_ = fs.Parse(fs.Args()[1:])
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	results, err := loadPlanFile(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfigError
+	}
+	f := viewFilter{network: *network, search: *search, kind: *kind}
+	if *category != "" {
+		for _, c := range strings.Split(*category, ",") {
+			f.categories = append(f.categories, strings.TrimSpace(c))
+		}
+	}
+	results, err = filterPlan(results, f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "view: %v\n", err)
+		return exitUsage
+	}
+	if *filterExpr != "" {
+		match, err := compileFilter(*filterExpr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "view: %v\n", err)
+			return exitUsage
+		}
+		results = FilterResults(results, match)
+	}
+	if *collapse {
+		results = CollapseUnused(results)
+	}
+	if err := printPlanView(os.Stdout, results, *group); err != nil {
+		fmt.Fprintf(os.Stderr, "view: %v\n", err)
+		return exitUsage
+	}
+	return 0
+}
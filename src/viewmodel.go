@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PlanView is the plan prepared for display: per network and subnet
+// totals, utilization and ready-made display strings, so web front-ends
+// can render it without reimplementing planner logic.
+type PlanView struct {
+	Networks []NetworkView `json:"networks"`
+}
+
+// NetworkView is one parent network of a PlanView.
+type NetworkView struct {
+	CIDR            string          `json:"cidr"`
+	TotalIPs        uint64          `json:"totalIPs"`
+	AllocatedIPs    uint64          `json:"allocatedIPs"`
+	FreeIPs         uint64          `json:"freeIPs"`
+	Utilization     float64         `json:"utilization"` // percent of addresses in subnets
+	UtilizationText string          `json:"utilizationText"`
+	Subnets         []SubnetView    `json:"subnets"`
+	FreeBlocks      []FreeBlockView `json:"freeBlocks,omitempty"`
+	Display         NetworkDisplay  `json:"display"`
+}
+
+// NetworkDisplay holds the display strings of a NetworkView.
+type NetworkDisplay struct {
+	Title   string `json:"title"`   // "10.0.0.0/16"
+	Summary string `json:"summary"` // "4 subnets, 1,024 of 65,536 addresses allocated"
+}
+
+// SubnetView is one planned subnet of a NetworkView.
+type SubnetView struct {
+	Name            string            `json:"name"`
+	VLAN            int               `json:"vlan,omitempty"`
+	CIDR            string            `json:"cidr"`
+	Prefix          int               `json:"prefix"`
+	Mask            string            `json:"mask"`
+	Gateway         string            `json:"gateway,omitempty"`
+	FirstHost       string            `json:"firstHost,omitempty"`
+	LastHost        string            `json:"lastHost,omitempty"`
+	UsableHosts     int               `json:"usableHosts"`
+	AssignedIPs     uint64            `json:"assignedIPs"`
+	FreeIPs         uint64            `json:"freeIPs"`
+	Utilization     float64           `json:"utilization"` // percent of usable hosts assigned
+	UtilizationText string            `json:"utilizationText"`
+	Status          string            `json:"status,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	Assignments     []AssignmentView  `json:"assignments"`
+	Display         SubnetDisplay     `json:"display"`
+}
+
+// SubnetDisplay holds the display strings of a SubnetView.
+type SubnetDisplay struct {
+	Title string `json:"title"` // "Servers (VLAN 100)"
+	Range string `json:"range"` // "10.0.0.1 – 10.0.0.62"
+	VLAN  string `json:"vlan"`  // "100", or "-" without a VLAN
+	Usage string `json:"usage"` // "12 of 62 hosts assigned"
+}
+
+// AssignmentView is a named address or block of a subnet, in address order.
+type AssignmentView struct {
+	Name        string `json:"name"`
+	IP          string `json:"ip"`
+	Count       int    `json:"count"`
+	Category    string `json:"category"` // Assignment, Reserved, Virtual IP or Router
+	DHCP        bool   `json:"dhcp,omitempty"`
+	FQDN        string `json:"fqdn,omitempty"`
+	Description string `json:"description,omitempty"`
+	Display     string `json:"display"` // "Gateway 10.0.0.1", "Pool 10.0.0.10 – 10.0.0.19 (10)"
+}
+
+// FreeBlockView is an unallocated block of a parent network.
+type FreeBlockView struct {
+	CIDR    string `json:"cidr"`
+	Range   string `json:"range"`
+	Size    uint64 `json:"size"`
+	Display string `json:"display"` // "10.0.4.0/22 (1,024 addresses)"
+}
+
+// ExportView writes the plan's view model as JSON.
+func ExportView(results []SubnetResult, path string) error {
+	data, err := json.MarshalIndent(BuildView(results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal view model: %v", err)
+	}
+	if path == stdoutPath {
+		_, err := streamOutput.Write(append(data, '\n'))
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// BuildView derives the view model from planned results. Network
+// utilization counts the addresses inside subnets, as capacity checks do;
+// subnet utilization counts assigned addresses against usable hosts.
+func BuildView(results []SubnetResult) PlanView {
+	parents, byNetwork, keys, bySubnet := collectUsage(results)
+	fragments, _ := subnetFragments(results)
+
+	view := PlanView{Networks: []NetworkView{}}
+	index := make(map[string]int)
+	for _, parent := range parents {
+		u := byNetwork[parent]
+		index[parent] = len(view.Networks)
+		view.Networks = append(view.Networks, NetworkView{
+			CIDR:            parent,
+			TotalIPs:        u.total,
+			AllocatedIPs:    u.allocated(),
+			FreeIPs:         u.free,
+			Utilization:     percent(u.allocated(), u.total),
+			UtilizationText: percentText(u.allocated(), u.total),
+			Subnets:         []SubnetView{},
+		})
+	}
+
+	usage := make(map[string]*addressUsage)
+	for _, k := range keys {
+		usage[k.network+" "+k.subnet] = bySubnet[k]
+	}
+	for _, f := range fragments {
+		n := &view.Networks[index[f.Parent]]
+		n.Subnets = append(n.Subnets, subnetView(f, usage[f.Parent+" "+f.Subnet]))
+	}
+
+	for _, r := range results {
+		if !isFreeSpaceRow(r) {
+			continue
+		}
+		size := uint64(1) << (32 - r.Prefix)
+		block := FreeBlockView{
+			CIDR:    r.Subnet,
+			Size:    size,
+			Display: fmt.Sprintf("%s (%s addresses)", r.Subnet, groupDigits(size)),
+		}
+		if first, last := blockRange(r.Subnet); first != "" {
+			block.Range = first + " – " + last
+		}
+		n := &view.Networks[index[r.Parent]]
+		n.FreeBlocks = append(n.FreeBlocks, block)
+	}
+
+	for i := range view.Networks {
+		n := &view.Networks[i]
+		n.Display = NetworkDisplay{
+			Title:   n.CIDR,
+			Summary: fmt.Sprintf("%s, %s of %s addresses allocated", plural(len(n.Subnets), "subnet"), groupDigits(n.AllocatedIPs), groupDigits(n.TotalIPs)),
+		}
+	}
+	return view
+}
+
+// subnetView builds the view of one subnet from its fragment.
+func subnetView(f subnetFragment, u *addressUsage) SubnetView {
+	if u == nil {
+		u = &addressUsage{}
+	}
+	s := SubnetView{
+		Name: f.Name, VLAN: f.VLAN, CIDR: f.Subnet, Prefix: f.Prefix, Mask: f.Mask, Gateway: f.Gateway,
+		Description: f.Description, Tags: f.Tags,
+		UsableHosts: usableHostCount(f.Prefix),
+		AssignedIPs: u.assigned,
+		FreeIPs:     u.free,
+		Assignments: []AssignmentView{},
+	}
+	s.Utilization = percent(s.AssignedIPs, uint64(s.UsableHosts))
+	s.UtilizationText = percentText(s.AssignedIPs, uint64(s.UsableHosts))
+
+	s.FirstHost, s.LastHost = hostRange(f.Subnet)
+	for _, r := range f.Rows {
+		if r.Status != "" {
+			s.Status = r.Status
+		}
+		if !isAssignedCategory(r.Category) && r.Category != "Reserved" {
+			continue
+		}
+		a := AssignmentView{Name: r.Label, IP: r.IP, Count: r.TotalIPs, Category: r.Category, DHCP: r.DHCP, FQDN: r.FQDN, Description: r.Description}
+		a.Display = a.Name + " " + displayRange(r.IP)
+		if a.Count > 1 {
+			a.Display += fmt.Sprintf(" (%d)", a.Count)
+		}
+		s.Assignments = append(s.Assignments, a)
+	}
+	sort.SliceStable(s.Assignments, func(i, j int) bool {
+		a, _, _ := parseIPSpan(s.Assignments[i].IP)
+		b, _, _ := parseIPSpan(s.Assignments[j].IP)
+		return a < b
+	})
+
+	s.Display = SubnetDisplay{
+		Title: s.Name,
+		VLAN:  "-",
+		Usage: fmt.Sprintf("%d of %s hosts assigned", s.AssignedIPs, groupDigits(uint64(s.UsableHosts))),
+	}
+	if s.VLAN > 0 {
+		s.Display.Title = fmt.Sprintf("%s (VLAN %d)", s.Name, s.VLAN)
+		s.Display.VLAN = fmt.Sprint(s.VLAN)
+	}
+	if s.FirstHost != "" {
+		s.Display.Range = s.FirstHost + " – " + s.LastHost
+	}
+	return s
+}
+
+// displayRange renders "a - b" address ranges with an en dash.
+func displayRange(ip string) string {
+	return strings.Replace(ip, " - ", " – ", 1)
+}
+
+func percent(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(whole)
+}
+
+// percentText formats a share with one decimal, such as "62.5%".
+func percentText(part, whole uint64) string {
+	return fmt.Sprintf("%.1f%%", percent(part, whole))
+}
+
+// groupDigits formats n with thousands separators, such as "65,536".
+func groupDigits(n uint64) string {
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// WhatIfRequest is a hypothetical subnet: Hosts or CIDR gives its size and
+// Network optionally restricts it to one parent network of the plan.
+type WhatIfRequest struct {
+	Name    string `json:"name"`
+	Hosts   int    `json:"hosts,omitempty"`
+	CIDR    int    `json:"cidr,omitempty"`
+	Network string `json:"network,omitempty"`
+}
+
+// WhatIfResult answers one WhatIfRequest.
+type WhatIfResult struct {
+	Name       string   `json:"name"`
+	Prefix     int      `json:"prefix,omitempty"`
+	Feasible   bool     `json:"feasible"`
+	Placement  string   `json:"placement,omitempty"` // the first candidate, held for later requests of the batch
+	Candidates []string `json:"candidates,omitempty"`
+	Reason     string   `json:"reason,omitempty"`
+}
+
+// defaultWhatIfCandidates is the number of candidate placements returned
+// per request when the batch does not ask for a number.
+const defaultWhatIfCandidates = 3
+
+// maxWhatIfCandidates caps the candidates per request, since each one is
+// searched for and returned.
+const maxWhatIfCandidates = 100
+
+// WhatIf checks a batch of hypothetical subnets against the free space of
+// planned results. Requests are answered in order, and each feasible
+// request holds its placement, so the batch as a whole fits when every
+// request is feasible. Up to candidates placements (at most
+// maxWhatIfCandidates) are listed per request, first fit first, across
+// parent networks in plan order.
+func WhatIf(results []SubnetResult, requests []WhatIfRequest, candidates int) []WhatIfResult {
+	if candidates < 1 {
+		candidates = defaultWhatIfCandidates
+	}
+	candidates = min(candidates, maxWhatIfCandidates)
+	parents, _, _, _ := collectUsage(results)
+	allocators := make(map[string]*allocator)
+	for _, parent := range parents {
+		_, ipNet, err := net.ParseCIDR(parent)
+		if err != nil {
+			continue
+		}
+		ones, _ := ipNet.Mask.Size()
+		allocators[parent] = newAllocator(ipToUint32(ipNet.IP), ones)
+	}
+	// Everything but the free space rows is taken
+	free := make(map[string][]span)
+	for _, r := range results {
+		if !isFreeSpaceRow(r) {
+			continue
+		}
+		if _, block, err := net.ParseCIDR(r.Subnet); err == nil {
+			start := uint64(ipToUint32(block.IP))
+			free[r.Parent] = append(free[r.Parent], span{start, start + uint64(1)<<(32-r.Prefix)})
+		}
+	}
+	for parent, a := range allocators {
+		taken := &allocator{parent: a.parent}
+		for _, s := range free[parent] {
+			taken.reserve(s)
+		}
+		for _, gap := range taken.free() {
+			a.reserve(gap)
+		}
+	}
+
+	out := make([]WhatIfResult, 0, len(requests))
+	for _, req := range requests {
+		out = append(out, whatIf(req, parents, allocators, candidates))
+	}
+	return out
+}
+
+func whatIf(req WhatIfRequest, parents []string, allocators map[string]*allocator, candidates int) WhatIfResult {
+	res := WhatIfResult{Name: req.Name}
+	switch {
+	case req.Hosts > 0 && req.CIDR > 0:
+		res.Reason = "give hosts or cidr, not both"
+		return res
+	case req.Hosts > 0:
+		res.Prefix = calculatePrefixFromHosts(req.Hosts)
+	case req.CIDR > 0 && req.CIDR <= 32:
+		res.Prefix = req.CIDR
+	default:
+		res.Reason = "needs hosts or a cidr between 1 and 32"
+		return res
+	}
+	if req.Network != "" {
+		if _, ok := allocators[req.Network]; !ok {
+			res.Reason = fmt.Sprintf("network %s is not in the plan", req.Network)
+			return res
+		}
+		parents = []string{req.Network}
+	}
+
+	size := uint64(1) << (32 - res.Prefix)
+	var first *allocator
+	var firstStart uint64
+	for _, parent := range parents {
+		a := allocators[parent]
+		if a == nil || len(res.Candidates) == candidates {
+			continue
+		}
+		for _, start := range a.candidates(size, candidates-len(res.Candidates)) {
+			if first == nil {
+				first, firstStart = a, start
+			}
+			res.Candidates = append(res.Candidates, fmt.Sprintf("%s/%d", uint32ToIP(uint32(start)), res.Prefix))
+		}
+	}
+	if first == nil {
+		res.Reason = fmt.Sprintf("no free /%d block", res.Prefix)
+		if req.Network != "" {
+			res.Reason += " in " + req.Network
+		}
+		return res
+	}
+	first.reserve(span{firstStart, firstStart + size})
+	res.Feasible = true
+	res.Placement = res.Candidates[0]
+	return res
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// multusTag marks a subnet for container attachment; its value is the host
+// interface the CNI plugin attaches to ("true" leaves it to the plugin).
+const multusTag = "multus"
+
+// whereaboutsIPAM is the "ipam" section of a whereabouts network config.
+type whereaboutsIPAM struct {
+	Type    string   `json:"type"`
+	Range   string   `json:"range"`
+	Gateway string   `json:"gateway,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// multusConfig is the CNI config embedded in a NetworkAttachmentDefinition.
+type multusConfig struct {
+	CNIVersion string          `json:"cniVersion"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Master     string          `json:"master,omitempty"`
+	VLANID     int             `json:"vlanId,omitempty"`
+	IPAM       whereaboutsIPAM `json:"ipam"`
+}
+
+// ExportWhereabouts writes a Multus NetworkAttachmentDefinition with
+// whereabouts IPAM for every subnet tagged "multus". The range is the
+// subnet; static assignments and reserved addresses are excluded so pods
+// never receive them. cniType is the CNI plugin (macvlan if empty); the
+// vlan plugin also gets the subnet's VLAN ID.
+func ExportWhereabouts(results []SubnetResult, path, cniType, namespace string) error {
+	if cniType == "" {
+		cniType = "macvlan"
+	}
+	fragments, _ := subnetFragments(results)
+	var sb strings.Builder
+	names := make(map[string]int)
+	for _, f := range fragments {
+		master, ok := f.Tags[multusTag]
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(master, "true") {
+			master = ""
+		}
+		name := dnsLabel(f.Name)
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+
+		cfg := multusConfig{
+			CNIVersion: "0.3.1",
+			Name:       name,
+			Type:       cniType,
+			Master:     master,
+			IPAM:       whereaboutsIPAM{Type: "whereabouts", Range: f.Subnet, Gateway: f.Gateway},
+		}
+		if cniType == "vlan" {
+			cfg.VLANID = f.VLAN
+		}
+		for _, r := range f.Rows {
+			if (isAssignedCategory(r.Category) && !r.DHCP) || r.Category == "Reserved" {
+				start, end, err := parseIPSpan(r.IP)
+				if err != nil {
+					return fmt.Errorf("subnet %s: %v", f.Name, err)
+				}
+				for _, b := range rangeBlocks(start, end) {
+					cfg.IPAM.Exclude = append(cfg.IPAM.Exclude, fmt.Sprintf("%s/%d", uint32ToIP(b.start), b.prefix))
+				}
+			}
+		}
+		data, _ := json.Marshal(cfg)
+
+		sb.WriteString("---\n")
+		sb.WriteString("apiVersion: k8s.cni.cncf.io/v1\n")
+		sb.WriteString("kind: NetworkAttachmentDefinition\n")
+		sb.WriteString("metadata:\n")
+		sb.WriteString(fmt.Sprintf("  name: %s\n", name))
+		if namespace != "" {
+			sb.WriteString(fmt.Sprintf("  namespace: %s\n", namespace))
+		}
+		sb.WriteString("spec:\n")
+		sb.WriteString(fmt.Sprintf("  config: %s\n", yamlString(string(data))))
+	}
+	if sb.Len() == 0 {
+		return fmt.Errorf("no subnet is tagged %q for container attachment", multusTag)
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// rangeBlocks splits the inclusive range start-end into aligned CIDR blocks.
+func rangeBlocks(start, end uint32) []cidrBlock {
+	var blocks []cidrBlock
+	for cur := uint64(start); cur <= uint64(end); {
+		prefix := 32
+		for prefix > 0 {
+			size := uint64(1) << (33 - prefix)
+			if cur%size != 0 || cur+size-1 > uint64(end) {
+				break
+			}
+			prefix--
+		}
+		blocks = append(blocks, cidrBlock{start: uint32(cur), prefix: prefix})
+		cur += uint64(1) << (32 - prefix)
+	}
+	return blocks
+}
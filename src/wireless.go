@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// apVendorProfile holds sizing rules for AP management subnets.
+type apVendorProfile struct {
+	maxAPsPerSubnet int // keep AP broadcast/discovery domains manageable
+	reserved        int // gateway and redundant router addresses per subnet
+}
+
+// apVendorProfiles are the built-in sizing rules; apsPerSubnet overrides
+// the AP limit.
+var apVendorProfiles = map[string]apVendorProfile{
+	"generic": {maxAPsPerSubnet: 250, reserved: 3},
+	"cisco":   {maxAPsPerSubnet: 500, reserved: 3},
+	"aruba":   {maxAPsPerSubnet: 250, reserved: 3},
+	"mist":    {maxAPsPerSubnet: 250, reserved: 3},
+	"ruckus":  {maxAPsPerSubnet: 250, reserved: 3},
+}
+
+// WirelessPlan sizes AP management subnets per building inside one parent
+// network.
+type WirelessPlan struct {
+	Network      string     `json:"network"`
+	Vendor       string     `json:"vendor,omitempty"`
+	Growth       int        `json:"growth,omitempty"` // percent added to every AP count
+	APsPerSubnet int        `json:"apsPerSubnet,omitempty"`
+	VLAN         int        `json:"vlan,omitempty"` // first VLAN; the next subnets count up from it
+	Buildings    []Building `json:"buildings"`
+}
+
+// Building is a site with a number of access points.
+type Building struct {
+	Name   string `json:"name"`
+	APs    int    `json:"aps"`
+	Growth int    `json:"growth,omitempty"` // overrides the plan's growth
+}
+
+// wirelessNetworks turns every wireless plan into a parent network with one
+// AP subnet per building, split into several subnets when a building has
+// more APs (after growth) than one subnet should hold.
+func wirelessNetworks(plans []WirelessPlan) ([]Network, error) {
+	var networks []Network
+	for _, plan := range plans {
+		vendor := strings.ToLower(plan.Vendor)
+		if vendor == "" {
+			vendor = "generic"
+		}
+		profile, ok := apVendorProfiles[vendor]
+		if !ok {
+			return nil, fmt.Errorf("wireless plan %s: unknown vendor %q (use %s)", plan.Network, plan.Vendor, strings.Join(apVendors(), ", "))
+		}
+		if plan.APsPerSubnet > 0 {
+			profile.maxAPsPerSubnet = plan.APsPerSubnet
+		}
+
+		network := Network{Network: plan.Network, Gateway: "first"}
+		vlan := plan.VLAN
+		for _, b := range plan.Buildings {
+			if b.APs <= 0 {
+				return nil, fmt.Errorf("wireless plan %s: building %s needs a positive 'aps' count", plan.Network, b.Name)
+			}
+			growth := plan.Growth
+			if b.Growth > 0 {
+				growth = b.Growth
+			}
+			aps := grownHosts(b.APs, growth)
+			parts := (aps + profile.maxAPsPerSubnet - 1) / profile.maxAPsPerSubnet
+			for i := 0; i < parts; i++ {
+				share := aps / parts
+				if i < aps%parts {
+					share++
+				}
+				name := b.Name + "-AP"
+				if parts > 1 {
+					name += "-" + strconv.Itoa(i+1)
+				}
+				network.Subnets = append(network.Subnets, Subnet{
+					Name:        name,
+					VLAN:        vlan,
+					Hosts:       share + profile.reserved,
+					Description: fmt.Sprintf("AP management for %d APs (building: %d + %d%% growth)", share, b.APs, growth),
+					Tags:        map[string]string{"role": "wireless-ap", "building": b.Name},
+				})
+				if vlan > 0 {
+					vlan++
+				}
+			}
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func apVendors() []string {
+	var names []string
+	for name := range apVendorProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
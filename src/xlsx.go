@@ -0,0 +1,185 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxCell is a <c> element of a worksheet.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string `xml:",innerxml"`
+	} `xml:"is"`
+}
+
+// readXLSX returns the rows of the first worksheet of an Excel workbook as
+// text, with shared and inline strings resolved. Only cell values are read;
+// formulas are taken at their cached value.
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an Excel workbook: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("workbook has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		raw, err := read("xl/sharedStrings.xml")
+		if err != nil {
+			return nil, err
+		}
+		var sst struct {
+			Items []struct {
+				Text string `xml:",innerxml"`
+			} `xml:"si"`
+		}
+		if err := xml.Unmarshal(raw, &sst); err != nil {
+			return nil, fmt.Errorf("invalid shared strings: %v", err)
+		}
+		for _, si := range sst.Items {
+			shared = append(shared, xlsxText(si.Text))
+		}
+	}
+
+	sheet, err := firstSheetPath(read)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := read(sheet)
+	if err != nil {
+		return nil, err
+	}
+	var ws struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(raw, &ws); err != nil {
+		return nil, fmt.Errorf("invalid worksheet %s: %v", sheet, err)
+	}
+
+	var rows [][]string
+	for _, r := range ws.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil || n < 0 || n >= len(shared) {
+					return nil, fmt.Errorf("cell %s: invalid shared string %q", c.Ref, c.Value)
+				}
+				row[col] = shared[n]
+			case "inlineStr":
+				row[col] = xlsxText(c.Inline.Text)
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstSheetPath finds the part of the workbook's first sheet, falling back
+// to the conventional xl/worksheets/sheet1.xml.
+func firstSheetPath(read func(string) ([]byte, error)) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wb, err := read("xl/workbook.xml")
+	if err != nil {
+		return fallback, nil
+	}
+	rels, err := read("xl/_rels/workbook.xml.rels")
+	if err != nil {
+		return fallback, nil
+	}
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var relationships struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if xml.Unmarshal(wb, &workbook) != nil || xml.Unmarshal(rels, &relationships) != nil || len(workbook.Sheets) == 0 {
+		return fallback, nil
+	}
+	for _, rel := range relationships.Items {
+		if rel.ID == workbook.Sheets[0].ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+	return fallback, nil
+}
+
+// xlsxText joins the <t> runs of a shared or inline string.
+func xlsxText(inner string) string {
+	var sb strings.Builder
+	dec := xml.NewDecoder(strings.NewReader("<x>" + inner + "</x>"))
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			inText = t.Name.Local == "t"
+		case xml.EndElement:
+			inText = false
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// xlsxColumn returns the zero-based column of a cell reference such as "AB7".
+func xlsxColumn(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}